@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"project-management/internal/db"
+	"project-management/internal/repository"
+	"project-management/internal/service"
+	"project-management/internal/storage"
+)
+
+const usage = `usage:
+  %[1]s project export [-format json|zip] [-o file] <project id>
+  %[1]s project import [-owner email] [-title title] [-dry-run] <file>
+`
+
+var errUsage = errors.New("invalid arguments")
+
+// runCommand runs a command-line subcommand instead of the server and
+// returns the exit code.
+func runCommand(args []string) int {
+	if len(args) < 2 || args[0] != "project" {
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		return 2
+	}
+	var err error
+	switch args[1] {
+	case "export":
+		err = exportProject(args[2:])
+	case "import":
+		err = importProject(args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		return 2
+	}
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		return 2
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func bundleService() (service.BundleService, service.BundleRepository, error) {
+	database := db.MustOpen()
+	store, err := storage.FromEnv()
+	if err != nil {
+		return nil, nil, fmt.Errorf("blob store init failed: %w", err)
+	}
+	repo := repository.NewBundleRepository(database)
+	return service.NewBundleService(repo, repository.NewProjectRepository(database), store), repo, nil
+}
+
+func exportProject(args []string) error {
+	fs := flag.NewFlagSet("project export", flag.ExitOnError)
+	format := fs.String("format", "json", "json, or zip to include attachment contents")
+	out := fs.String("o", "", "output file (default project-<id>.<format>)")
+	fs.Parse(args)
+	if fs.NArg() != 1 || (*format != "json" && *format != "zip") {
+		return errUsage
+	}
+	id := fs.Arg(0)
+	if *out == "" {
+		*out = "project-" + id + "." + *format
+	}
+	bundles, _, err := bundleService()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	bundle, err := bundles.Export(ctx, id)
+	if err != nil {
+		return fmt.Errorf("export project %s: %w", id, err)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if *format == "zip" {
+		err = bundles.WriteZip(ctx, w, bundle)
+	} else {
+		err = writeJSON(w, bundle)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", *out, err)
+	}
+	fmt.Printf("exported project %s with %d tasks to %s\n", id, len(bundle.Tasks), *out)
+	return nil
+}
+
+func importProject(args []string) error {
+	fs := flag.NewFlagSet("project import", flag.ExitOnError)
+	owner := fs.String("owner", "", "email of the user who owns the project if the bundle's owner has no match")
+	title := fs.String("title", "", "title of the new project (default the bundle's)")
+	dryRun := fs.Bool("dry-run", false, "only report what would be imported")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	bundle, files, err := service.ReadBundle(f, info.Size())
+	if err != nil {
+		return err
+	}
+
+	bundles, repo, err := bundleService()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	input := service.BundleImportInput{Bundle: bundle, Files: files, Title: *title, DryRun: *dryRun}
+	if *owner != "" {
+		users, err := repo.UsersByEmail(ctx, []string{strings.ToLower(strings.TrimSpace(*owner))})
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("no user has the email %s", *owner)
+		}
+		input.OwnerID = &users[0].ID
+	}
+	report, err := bundles.Import(ctx, input)
+	if err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, report)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
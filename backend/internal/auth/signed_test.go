@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerifyPath(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	q, err := url.ParseQuery(SignPath("/api/attachments/4/download", now.Add(time.Minute)))
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	expires, sig := q.Get("expires"), q.Get("sig")
+
+	if !VerifyPath("/api/attachments/4/download", expires, sig, now) {
+		t.Fatal("expected valid signature")
+	}
+	if VerifyPath("/api/attachments/5/download", expires, sig, now) {
+		t.Fatal("signature must be bound to the path")
+	}
+	if VerifyPath("/api/attachments/4/download", expires, sig, now.Add(2*time.Minute)) {
+		t.Fatal("expired signature accepted")
+	}
+	if VerifyPath("/api/attachments/4/download", "bad", sig, now) {
+		t.Fatal("malformed expiry accepted")
+	}
+
+	t.Setenv("JWT_SECRET", "other-secret")
+	if VerifyPath("/api/attachments/4/download", expires, sig, now) {
+		t.Fatal("signature must depend on the secret")
+	}
+}
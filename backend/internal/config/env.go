@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 func GetEnvInt(key string, def int) int {
@@ -16,3 +17,25 @@ func GetEnvInt(key string, def int) int {
 	}
 	return n
 }
+
+// GetEnvBool parses a boolean such as true, false, 1 or 0, falling back to def
+// when the variable is unset or invalid.
+func GetEnvBool(key string, def bool) bool {
+	b, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// GetEnvList splits a comma-separated variable into its trimmed, non-empty
+// items.
+func GetEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
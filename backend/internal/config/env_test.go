@@ -2,6 +2,28 @@ package config
 
 import "testing"
 
+func TestGetEnvBool(t *testing.T) {
+	t.Setenv("TEST_BOOL", "true")
+	if !GetEnvBool("TEST_BOOL", false) {
+		t.Fatal("true read as false")
+	}
+	t.Setenv("TEST_BOOL", "maybe")
+	if GetEnvBool("TEST_BOOL", false) {
+		t.Fatal("invalid value did not fall back")
+	}
+}
+
+func TestGetEnvList(t *testing.T) {
+	t.Setenv("TEST_LIST", " a@example.com,, b@example.com ,")
+	if got := GetEnvList("TEST_LIST"); len(got) != 2 || got[0] != "a@example.com" || got[1] != "b@example.com" {
+		t.Fatalf("got = %q", got)
+	}
+	t.Setenv("TEST_LIST", "")
+	if got := GetEnvList("TEST_LIST"); len(got) != 0 {
+		t.Fatalf("got = %q", got)
+	}
+}
+
 func TestGetEnvInt(t *testing.T) {
 	t.Run("value exists", func(t *testing.T) {
 		t.Setenv("TEST_INT", "12")
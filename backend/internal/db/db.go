@@ -49,7 +49,10 @@ func defaultGetSQLDB(database *gorm.DB) (sqlDB, error) {
 }
 
 func defaultAutoMigrate(database *gorm.DB) error {
-	return database.AutoMigrate(&model.User{}, &model.Project{}, &model.Task{}, &model.Comment{})
+	if err := database.AutoMigrate(&model.User{}, &model.Project{}, &model.Task{}, &model.Label{}, &model.Comment{}, &model.CommentReaction{}, &model.CommentRevision{}, &model.Blob{}, &model.Attachment{}, &model.SavedView{}, &model.IdempotencyKey{}, &model.ProjectTemplate{}, &model.TaskWatcher{}, &model.CalendarToken{}, &model.ReminderPreference{}, &model.SentReminder{}, &model.Notification{}, &model.TimeEntry{}, &model.Sprint{}, &model.Milestone{}); err != nil {
+		return err
+	}
+	return MigrateSearch(database)
 }
 
 func parseDatabaseURL(rawURL string) (Config, error) {
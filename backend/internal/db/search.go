@@ -0,0 +1,32 @@
+package db
+
+import "gorm.io/gorm"
+
+// searchColumns lists the generated tsvector column of every searchable table.
+// The 'simple' configuration keeps words unstemmed so prefix queries behave the
+// same for every language the content is written in.
+var searchColumns = []struct {
+	table  string
+	vector string
+}{
+	{"projects", "setweight(to_tsvector('simple', coalesce(title, '')), 'A') || setweight(to_tsvector('simple', coalesce(description, '')), 'B')"},
+	{"tasks", "setweight(to_tsvector('simple', coalesce(title, '')), 'A') || setweight(to_tsvector('simple', coalesce(description, '')), 'B')"},
+	{"comments", "to_tsvector('simple', coalesce(text, ''))"},
+}
+
+// MigrateSearch adds the search_vector columns and their GIN indexes. It runs
+// after AutoMigrate and is safe to repeat.
+func MigrateSearch(database *gorm.DB) error {
+	for _, c := range searchColumns {
+		stmts := []string{
+			"ALTER TABLE " + c.table + " ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (" + c.vector + ") STORED",
+			"CREATE INDEX IF NOT EXISTS idx_" + c.table + "_search ON " + c.table + " USING GIN (search_vector)",
+		}
+		for _, stmt := range stmts {
+			if err := database.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
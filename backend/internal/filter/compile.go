@@ -0,0 +1,283 @@
+package filter
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Enum
+	Date
+)
+
+// Field describes a filterable field. Column is the SQL expression compared
+// against. When Exists is set the field is a to-many relation: Exists is a
+// correlated "SELECT 1 FROM ... WHERE ..." subquery and Column is evaluated
+// inside it, so "label = bug" becomes EXISTS (Exists AND Column = 'bug').
+type Field struct {
+	Type     Type
+	Column   string
+	Exists   string
+	Values   []string // allowed values of an Enum
+	Nullable bool     // accepts "= null" and "!= null"
+	Me       bool     // an Int field that accepts "me" for the current user
+}
+
+// Fields is the allowlist of fields an endpoint can be filtered by.
+type Fields map[string]Field
+
+// Env holds the values relative terms resolve to: "me" and "today".
+type Env struct {
+	UserID uint
+	Now    time.Time
+}
+
+// Condition is a parameterized SQL condition for gorm's Where.
+type Condition struct {
+	SQL  string
+	Args []any
+}
+
+// Compile validates node against the allowlist and builds the condition.
+func (f Fields) Compile(node Node, env Env) (Condition, error) {
+	c := &compiler{fields: f, env: env}
+	sql, err := c.compile(node)
+	if err != nil {
+		return Condition{}, err
+	}
+	return Condition{SQL: sql, Args: c.args}, nil
+}
+
+// Names returns the allowed field names in sorted order.
+func (f Fields) Names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type compiler struct {
+	fields Fields
+	env    Env
+	args   []any
+}
+
+func (c *compiler) compile(node Node) (string, error) {
+	switch n := node.(type) {
+	case *Binary:
+		left, err := c.compile(n.Left)
+		if err != nil {
+			return "", err
+		}
+		right, err := c.compile(n.Right)
+		if err != nil {
+			return "", err
+		}
+		return "(" + left + " " + strings.ToUpper(n.Op) + " " + right + ")", nil
+	case *Not:
+		inner, err := c.compile(n.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+	default:
+		return c.comparison(node.(*Comparison))
+	}
+}
+
+var opsByType = map[Type][]string{
+	String: {"=", "!=", "~", "in", "not in"},
+	Int:    {"=", "!=", "in", "not in"},
+	Enum:   {"=", "!=", "in", "not in"},
+	Date:   {"=", "!=", "<", "<=", ">", ">="},
+}
+
+func (c *compiler) comparison(cmp *Comparison) (string, error) {
+	field, ok := c.fields[cmp.Field]
+	if !ok {
+		return "", errorf(cmp.Pos, "unknown field %q; allowed fields are %s", cmp.Field, strings.Join(c.fields.Names(), ", "))
+	}
+	ops := opsByType[field.Type]
+	if !slices.Contains(ops, cmp.Op) {
+		return "", errorf(cmp.OpPos, "operator %q is not supported for %s; use one of %s", cmp.Op, cmp.Field, strings.Join(ops, ", "))
+	}
+
+	if len(cmp.Values) == 1 && strings.EqualFold(cmp.Values[0].Text, "null") && (cmp.Op == "=" || cmp.Op == "!=") {
+		if !field.Nullable {
+			return "", errorf(cmp.Values[0].Pos, "%s cannot be null", cmp.Field)
+		}
+		return c.null(field, cmp.Op == "="), nil
+	}
+
+	values := make([]any, len(cmp.Values))
+	for i, v := range cmp.Values {
+		value, err := c.value(field, cmp.Field, v)
+		if err != nil {
+			return "", err
+		}
+		values[i] = value
+	}
+
+	if field.Type == Date {
+		return c.date(field, cmp.Op, values[0].(time.Time)), nil
+	}
+	if field.Exists != "" {
+		return c.exists(field, cmp.Op, values), nil
+	}
+	switch cmp.Op {
+	case "in", "not in":
+		c.args = append(c.args, values)
+		if cmp.Op == "in" {
+			return field.Column + " IN ?", nil
+		}
+		if field.Nullable {
+			return "(" + field.Column + " IS NULL OR " + field.Column + " NOT IN ?)", nil
+		}
+		return field.Column + " NOT IN ?", nil
+	case "~":
+		c.args = append(c.args, "%"+escapeLike(values[0].(string))+"%")
+		return field.Column + " ILIKE ?", nil
+	case "!=":
+		c.args = append(c.args, values[0])
+		return field.Column + " IS DISTINCT FROM ?", nil
+	default:
+		c.args = append(c.args, values[0])
+		return field.Column + " = ?", nil
+	}
+}
+
+func (c *compiler) null(field Field, isNull bool) string {
+	if field.Exists != "" {
+		if isNull {
+			return "NOT EXISTS (" + field.Exists + ")"
+		}
+		return "EXISTS (" + field.Exists + ")"
+	}
+	if isNull {
+		return field.Column + " IS NULL"
+	}
+	return field.Column + " IS NOT NULL"
+}
+
+func (c *compiler) exists(field Field, op string, values []any) string {
+	cond := field.Column + " = ?"
+	if op == "in" || op == "not in" {
+		cond = field.Column + " IN ?"
+		c.args = append(c.args, values)
+	} else {
+		c.args = append(c.args, values[0])
+	}
+	sql := "EXISTS (" + field.Exists + " AND " + cond + ")"
+	if op == "!=" || op == "not in" {
+		return "NOT " + sql
+	}
+	return sql
+}
+
+// date compares whole UTC days: "due = 2026-11-01" matches any time that day.
+func (c *compiler) date(field Field, op string, day time.Time) string {
+	next := day.AddDate(0, 0, 1)
+	col := field.Column
+	switch op {
+	case "=":
+		c.args = append(c.args, day, next)
+		return "(" + col + " >= ? AND " + col + " < ?)"
+	case "!=":
+		c.args = append(c.args, day, next)
+		return "(" + col + " IS NULL OR " + col + " < ? OR " + col + " >= ?)"
+	case "<":
+		c.args = append(c.args, day)
+		return col + " < ?"
+	case "<=":
+		c.args = append(c.args, next)
+		return col + " < ?"
+	case ">":
+		c.args = append(c.args, next)
+		return col + " >= ?"
+	default:
+		c.args = append(c.args, day)
+		return col + " >= ?"
+	}
+}
+
+var relativeDay = regexp.MustCompile(`^today(?:([+-])(\d{1,4})([dw]))?$`)
+
+func (c *compiler) value(field Field, name string, v Value) (any, error) {
+	switch field.Type {
+	case Int:
+		if field.Me && strings.EqualFold(v.Text, "me") {
+			if c.env.UserID == 0 {
+				return nil, errorf(v.Pos, "\"me\" requires a signed-in user")
+			}
+			return c.env.UserID, nil
+		}
+		n, err := strconv.ParseUint(v.Text, 10, 32)
+		if err != nil {
+			if field.Me {
+				return nil, errorf(v.Pos, "%s expects a numeric ID or \"me\", got %q", name, v.Text)
+			}
+			return nil, errorf(v.Pos, "%s expects a numeric ID, got %q", name, v.Text)
+		}
+		return uint(n), nil
+	case Enum:
+		if !slices.Contains(field.Values, v.Text) {
+			return nil, errorf(v.Pos, "invalid %s %q; expected one of %s", name, v.Text, strings.Join(field.Values, ", "))
+		}
+		return v.Text, nil
+	case Date:
+		return c.day(name, v)
+	default:
+		return v.Text, nil
+	}
+}
+
+// day parses a date value for the field called name.
+func (c *compiler) day(name string, v Value) (time.Time, error) {
+	t, ok := ResolveDate(v.Text, c.env.Now)
+	if !ok {
+		return time.Time{}, errorf(v.Pos, "%s expects a date like 2026-11-01 or today+7d, got %q", name, v.Text)
+	}
+	return t, nil
+}
+
+// ResolveDate parses YYYY-MM-DD or a day relative to now: today, today+7d,
+// today-2w. The result is midnight UTC. A zero now means time.Now().
+func ResolveDate(text string, now time.Time) (time.Time, bool) {
+	if t, err := time.Parse("2006-01-02", text); err == nil {
+		return t, true
+	}
+	m := relativeDay.FindStringSubmatch(strings.ToLower(text))
+	if m == nil {
+		return time.Time{}, false
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if m[1] != "" {
+		n, _ := strconv.Atoi(m[2])
+		if m[3] == "w" {
+			n *= 7
+		}
+		if m[1] == "-" {
+			n = -n
+		}
+		day = day.AddDate(0, 0, n)
+	}
+	return day, true
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
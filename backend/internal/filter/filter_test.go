@@ -0,0 +1,98 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testFields = Fields{
+	"status":   {Type: Enum, Column: "status", Values: []string{"todo", "in_progress", "done"}},
+	"assignee": {Type: Int, Column: "assignee_id", Nullable: true, Me: true},
+	"due":      {Type: Date, Column: "due_date", Nullable: true},
+	"title":    {Type: String, Column: "title"},
+	"label":    {Type: String, Column: "l.name", Exists: "SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id WHERE tl.task_id = tasks.id", Nullable: true},
+}
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestCompile(t *testing.T) {
+	env := Env{UserID: 7, Now: time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		src  string
+		sql  string
+		args []any
+	}{
+		{
+			`status in (todo,in_progress) and (assignee = me or due < 2026-11-01) and label = bug`,
+			"((status IN ? AND (assignee_id = ? OR due_date < ?)) AND EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id WHERE tl.task_id = tasks.id AND l.name = ?))",
+			[]any{[]any{"todo", "in_progress"}, uint(7), day("2026-11-01"), "bug"},
+		},
+		{`assignee != 3`, "assignee_id IS DISTINCT FROM ?", []any{uint(3)}},
+		{`assignee = null`, "assignee_id IS NULL", nil},
+		{`NOT label = NULL`, "NOT (NOT EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id WHERE tl.task_id = tasks.id))", nil},
+		{`label not in (bug, "needs review")`, "NOT EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id WHERE tl.task_id = tasks.id AND l.name IN ?)", []any{[]any{"bug", "needs review"}}},
+		{`due = today`, "(due_date >= ? AND due_date < ?)", []any{day("2026-10-18"), day("2026-10-19")}},
+		{`due <= today+1w or due > today-2d`, "(due_date < ? OR due_date >= ?)", []any{day("2026-10-26"), day("2026-10-17")}},
+		{`title ~ "50%_off"`, "title ILIKE ?", []any{`%50\%\_off%`}},
+	}
+	for _, tt := range tests {
+		node, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.src, err)
+		}
+		cond, err := testFields.Compile(node, env)
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", tt.src, err)
+		}
+		if cond.SQL != tt.sql || !reflect.DeepEqual(cond.Args, tt.args) {
+			t.Fatalf("Compile(%q)\n sql = %s\nargs = %#v", tt.src, cond.SQL, cond.Args)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+	}{
+		{``, 1},
+		{`status = `, 10},
+		{`status todo`, 8},
+		{`status in (todo done)`, 17},
+		{`(status = todo`, 15},
+		{`status = todo and`, 18},
+		{`status = "todo`, 10},
+		{`status = todo #`, 15},
+		{`status ! todo`, 8},
+		{`owner = 1`, 1},
+		{`status < todo`, 8},
+		{`status = blocked`, 10},
+		{`assignee = you`, 12},
+		{`due >= next-week`, 8},
+		{`title = null`, 9},
+		{`status = todo or or`, 18},
+	}
+	for _, tt := range tests {
+		node, err := Parse(tt.src)
+		if err == nil {
+			_, err = testFields.Compile(node, Env{UserID: 1})
+		}
+		var ferr *Error
+		if !errors.As(err, &ferr) {
+			t.Fatalf("%q: err = %v, want *Error", tt.src, err)
+		}
+		if ferr.Pos != tt.pos {
+			t.Fatalf("%q: pos = %d, want %d (%v)", tt.src, ferr.Pos, tt.pos, ferr)
+		}
+	}
+
+	node, _ := Parse(`assignee = me`)
+	if _, err := testFields.Compile(node, Env{}); err == nil {
+		t.Fatal("me without a user must fail")
+	}
+}
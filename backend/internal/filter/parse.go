@@ -0,0 +1,319 @@
+// Package filter implements the ?filter= expression language used by list
+// endpoints, e.g.
+//
+//	status in (todo, in_progress) and (assignee = me or due < 2026-11-01) and label = bug
+//
+// Parse turns the text into an AST; Fields.Compile checks it against an
+// allowlist of fields and turns it into a parameterized SQL condition.
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Error reports a problem with a filter expression. Pos is the 1-based
+// character position the problem starts at.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("filter: %s at position %d", e.Msg, e.Pos) }
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Node is an expression: *Binary, *Not or *Comparison.
+type Node interface{ pos() int }
+
+// Binary joins two expressions with "and" or "or".
+type Binary struct {
+	Op    string
+	Left  Node
+	Right Node
+	Pos   int
+}
+
+type Not struct {
+	Expr Node
+	Pos  int
+}
+
+// Comparison is "field op value" or "field [not] in (values)".
+type Comparison struct {
+	Field  string
+	Op     string
+	Values []Value
+	Pos    int
+	OpPos  int
+}
+
+type Value struct {
+	Text string
+	Pos  int
+}
+
+func (n *Binary) pos() int     { return n.Pos }
+func (n *Not) pos() int        { return n.Pos }
+func (n *Comparison) pos() int { return n.Pos }
+
+// MaxLength bounds the size of an expression.
+const MaxLength = 2000
+
+// maxDepth bounds nesting of parentheses and "not".
+const maxDepth = 32
+
+// Parse parses a filter expression. Keywords (and, or, not, in) are case
+// insensitive; values are bare words or double-quoted strings.
+func Parse(src string) (Node, error) {
+	if len([]rune(src)) > MaxLength {
+		return nil, errorf(MaxLength+1, "expression is longer than %d characters", MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, errorf(1, "expression is empty")
+	}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, errorf(t.pos, "unexpected %s", t)
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keyword reports whether t is the given keyword.
+func (t token) keyword(k string) bool { return t.kind == tokWord && strings.EqualFold(t.text, k) }
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.:+", r)
+}
+
+func lex(src string) ([]token, error) {
+	runes := []rune(src)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", pos})
+			i++
+		case r == '=' || r == '~':
+			tokens = append(tokens, token{tokOp, string(r), pos})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{tokOp, string(runes[i : i+2]), pos})
+				i += 2
+			} else if r == '!' {
+				return nil, errorf(pos, "expected \"!=\"")
+			} else {
+				tokens = append(tokens, token{tokOp, string(r), pos})
+				i++
+			}
+		case r == '"':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, errorf(pos, "unterminated string")
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					b.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{tokString, b.String(), pos})
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, string(runes[start:i]), pos})
+		default:
+			return nil, errorf(pos, "unexpected character %q", r)
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(runes) + 1}), nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("or") {
+		op := p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: "or", Left: left, Right: right, Pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("and") {
+		op := p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: "and", Left: left, Right: right, Pos: op.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+	t := p.peek()
+	if depth >= maxDepth {
+		return nil, errorf(t.pos, "expression is nested too deeply")
+	}
+	switch {
+	case t.keyword("not"):
+		p.next()
+		expr, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Expr: expr, Pos: t.pos}, nil
+	case t.kind == tokLParen:
+		p.next()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorf(closing.pos, "expected \")\" but found %s", closing)
+		}
+		return expr, nil
+	case t.kind == tokWord && !isKeyword(t):
+		return p.parseComparison()
+	default:
+		return nil, errorf(t.pos, "expected a field name but found %s", t)
+	}
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	field := p.next()
+	cmp := &Comparison{Field: strings.ToLower(field.text), Pos: field.pos}
+
+	op := p.next()
+	cmp.OpPos = op.pos
+	switch {
+	case op.kind == tokOp:
+		cmp.Op = op.text
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		cmp.Values = []Value{v}
+		return cmp, nil
+	case op.keyword("in"):
+		cmp.Op = "in"
+	case op.keyword("not") && p.peek().keyword("in"):
+		p.next()
+		cmp.Op = "not in"
+	default:
+		return nil, errorf(op.pos, "expected an operator (=, !=, <, <=, >, >=, ~, in, not in) but found %s", op)
+	}
+
+	if open := p.next(); open.kind != tokLParen {
+		return nil, errorf(open.pos, "expected \"(\" but found %s", open)
+	}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		cmp.Values = append(cmp.Values, v)
+		sep := p.next()
+		if sep.kind == tokRParen {
+			return cmp, nil
+		}
+		if sep.kind != tokComma {
+			return nil, errorf(sep.pos, "expected \",\" or \")\" but found %s", sep)
+		}
+	}
+}
+
+func (p *parser) parseValue() (Value, error) {
+	t := p.next()
+	if t.kind == tokString || (t.kind == tokWord && !isKeyword(t)) {
+		return Value{Text: t.text, Pos: t.pos}, nil
+	}
+	return Value{}, errorf(t.pos, "expected a value but found %s", t)
+}
+
+func isKeyword(t token) bool {
+	return t.keyword("and") || t.keyword("or") || t.keyword("not") || t.keyword("in")
+}
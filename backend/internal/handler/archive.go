@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"errors"
+
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// projectArchived answers 409 PROJECT_ARCHIVED and returns true when err is a
+// write refused because the project is archived.
+func projectArchived(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrProjectArchived) {
+		return false
+	}
+	c.JSON(httpx.StatusFor(httpx.CodeProjectArchived), httpx.Err(httpx.CodeProjectArchived, err.Error()))
+	return true
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project-management/internal/auth"
+	"project-management/internal/config"
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+	"project-management/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead is the slack allowed on top of the file size limit for
+// multipart boundaries and headers.
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	service  service.AttachmentService
+	basePath string
+}
+
+func NewAttachmentHandler(service service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: service}
+}
+
+func (h *AttachmentHandler) Register(r *gin.RouterGroup) {
+	r.GET("/tasks/:id/attachments", h.ListTaskAttachments)
+	r.POST("/tasks/:id/attachments", h.UploadTaskAttachment)
+	r.GET("/comments/:id/attachments", h.ListCommentAttachments)
+	r.POST("/comments/:id/attachments", h.UploadCommentAttachment)
+	r.GET("/attachments/:id", h.Get)
+	r.DELETE("/attachments/:id", h.Delete)
+}
+
+// RegisterPublic mounts the download routes. They are authorized by the signed
+// query string in downloadUrl/thumbnailUrl instead of a bearer token so they
+// can be used directly in <img> and <a> tags.
+func (h *AttachmentHandler) RegisterPublic(r *gin.RouterGroup) {
+	h.basePath = r.BasePath()
+	if h.basePath == "/" {
+		h.basePath = ""
+	}
+	r.GET("/attachments/:id/download", h.Download)
+	r.GET("/attachments/:id/thumbnail", h.Thumbnail)
+}
+
+func (h *AttachmentHandler) ListTaskAttachments(c *gin.Context) {
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	items, total, err := h.service.ListForTask(c.Request.Context(), c.Param("id"), lp)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "task not found"))
+			return
+		}
+		listError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, listBody(c, lp, h.signAll(items), total))
+}
+
+func (h *AttachmentHandler) ListCommentAttachments(c *gin.Context) {
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	items, total, err := h.service.ListForComment(c.Request.Context(), c.Param("id"), lp)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "comment not found"))
+			return
+		}
+		listError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, listBody(c, lp, h.signAll(items), total))
+}
+
+func (h *AttachmentHandler) UploadTaskAttachment(c *gin.Context) {
+	h.upload(c, service.AttachmentUploadInput{TaskID: c.Param("id")}, "task not found")
+}
+
+func (h *AttachmentHandler) UploadCommentAttachment(c *gin.Context) {
+	h.upload(c, service.AttachmentUploadInput{CommentID: c.Param("id")}, "comment not found")
+}
+
+func (h *AttachmentHandler) upload(c *gin.Context, input service.AttachmentUploadInput, notFound string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxBytes()+multipartOverhead)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(httpx.StatusFor(httpx.CodePayloadTooLarge), httpx.Err(httpx.CodePayloadTooLarge, service.ErrAttachmentTooLarge.Error()))
+			return
+		}
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "multipart field \"file\" is required"))
+		return
+	}
+	defer file.Close()
+
+	input.UploadedBy = userID
+	input.FileName = header.Filename
+	input.Content = file
+	a, err := h.service.Upload(c.Request.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, notFound))
+		case errors.Is(err, service.ErrAttachmentTooLarge):
+			c.JSON(httpx.StatusFor(httpx.CodePayloadTooLarge), httpx.Err(httpx.CodePayloadTooLarge, err.Error()))
+		case errors.Is(err, service.ErrAttachmentType):
+			c.JSON(httpx.StatusFor(httpx.CodeUnsupportedMediaType), httpx.Err(httpx.CodeUnsupportedMediaType, err.Error()))
+		case errors.Is(err, service.ErrCommentDeleted):
+			c.JSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+		case projectArchived(c, err):
+		default:
+			c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, h.sign(a))
+}
+
+func (h *AttachmentHandler) Get(c *gin.Context) {
+	a, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "attachment not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, h.sign(a))
+}
+
+func (h *AttachmentHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "attachment not found"))
+			return
+		}
+		if projectArchived(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AttachmentHandler) Download(c *gin.Context) { h.serve(c, "download") }
+
+func (h *AttachmentHandler) Thumbnail(c *gin.Context) { h.serve(c, "thumbnail") }
+
+func (h *AttachmentHandler) serve(c *gin.Context, kind string) {
+	id := c.Param("id")
+	if !auth.VerifyPath(attachmentPath(id, kind), c.Query("expires"), c.Query("sig"), time.Now()) {
+		c.JSON(httpx.StatusFor(httpx.CodeForbidden), httpx.Err(httpx.CodeForbidden, "invalid or expired link"))
+		return
+	}
+
+	thumbnail := kind == "thumbnail"
+	a, body, err := h.service.Open(c.Request.Context(), id, thumbnail)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, storage.ErrNotFound) || errors.Is(err, service.ErrNoThumbnail) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "attachment not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	defer body.Close()
+
+	headers := map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=300",
+	}
+	if thumbnail {
+		headers["Content-Disposition"] = "inline"
+		c.DataFromReader(http.StatusOK, -1, "image/jpeg", body, headers)
+		return
+	}
+	headers["Content-Disposition"] = mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName})
+	c.DataFromReader(http.StatusOK, a.Size, a.MimeType, body, headers)
+}
+
+func (h *AttachmentHandler) sign(a model.Attachment) model.Attachment {
+	ttl := time.Duration(config.GetEnvInt("ATTACHMENT_URL_TTL_MINUTES", 15)) * time.Minute
+	expires := time.Now().Add(ttl)
+	id := strconv.FormatUint(uint64(a.ID), 10)
+
+	path := attachmentPath(id, "download")
+	a.DownloadURL = h.basePath + path + "?" + auth.SignPath(path, expires)
+	if a.HasThumbnail {
+		path = attachmentPath(id, "thumbnail")
+		a.ThumbnailURL = h.basePath + path + "?" + auth.SignPath(path, expires)
+	}
+	return a
+}
+
+func (h *AttachmentHandler) signAll(items []model.Attachment) []model.Attachment {
+	for i := range items {
+		items[i] = h.sign(items[i])
+	}
+	return items
+}
+
+func attachmentPath(id, kind string) string { return "/attachments/" + id + "/" + kind }
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockAttachmentService struct {
+	uploadFn func(ctx context.Context, input service.AttachmentUploadInput) (model.Attachment, error)
+	getFn    func(ctx context.Context, id string) (model.Attachment, error)
+	openFn   func(ctx context.Context, id string, thumbnail bool) (model.Attachment, io.ReadCloser, error)
+	listFn   func(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error)
+	maxBytes int64
+}
+
+func (m *mockAttachmentService) Upload(ctx context.Context, input service.AttachmentUploadInput) (model.Attachment, error) {
+	return m.uploadFn(ctx, input)
+}
+func (m *mockAttachmentService) ListForTask(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	return m.listFn(ctx, taskID, params)
+}
+func (m *mockAttachmentService) ListForComment(ctx context.Context, commentID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	return nil, 0, gorm.ErrRecordNotFound
+}
+func (m *mockAttachmentService) Get(ctx context.Context, id string) (model.Attachment, error) {
+	return m.getFn(ctx, id)
+}
+func (m *mockAttachmentService) Open(ctx context.Context, id string, thumbnail bool) (model.Attachment, io.ReadCloser, error) {
+	return m.openFn(ctx, id, thumbnail)
+}
+func (m *mockAttachmentService) Delete(ctx context.Context, id string) error { return nil }
+func (m *mockAttachmentService) CleanupOrphans(ctx context.Context) error    { return nil }
+func (m *mockAttachmentService) MaxBytes() int64                             { return m.maxBytes }
+
+func multipartBody(t *testing.T, field, name string, content []byte) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile(field, name)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	fw.Write(content)
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func TestAttachmentHandlerUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	h := NewAttachmentHandler(&mockAttachmentService{
+		maxBytes: 1 << 10,
+		uploadFn: func(ctx context.Context, input service.AttachmentUploadInput) (model.Attachment, error) {
+			data, _ := io.ReadAll(input.Content)
+			switch string(data) {
+			case "exe":
+				return model.Attachment{}, service.ErrAttachmentType
+			case "big":
+				return model.Attachment{}, service.ErrAttachmentTooLarge
+			}
+			if input.TaskID != "3" || input.UploadedBy != 9 || input.FileName != "a.png" {
+				t.Fatalf("input = %+v", input)
+			}
+			return model.Attachment{ID: 5, TaskID: 3, FileName: input.FileName, HasThumbnail: true}, nil
+		},
+	})
+	r := gin.New()
+	api := r.Group("/api")
+	h.RegisterPublic(api)
+	api.POST("/tasks/:id/attachments", func(c *gin.Context) { c.Set("userID", uint(9)) }, h.UploadTaskAttachment)
+
+	tests := []struct {
+		name    string
+		field   string
+		content []byte
+		want    int
+	}{
+		{"created", "file", []byte("png"), http.StatusCreated},
+		{"missing file", "other", []byte("png"), http.StatusBadRequest},
+		{"type", "file", []byte("exe"), http.StatusUnsupportedMediaType},
+		{"too large", "file", []byte("big"), http.StatusRequestEntityTooLarge},
+		{"body over limit", "file", bytes.Repeat([]byte("x"), 2<<20), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartBody(t, tt.field, "a.png", tt.content)
+			req := httptest.NewRequest(http.MethodPost, "/api/tasks/3/attachments", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if tt.want != http.StatusCreated {
+				return
+			}
+			var got model.Attachment
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !strings.HasPrefix(got.DownloadURL, "/api/attachments/5/download?") || !strings.HasPrefix(got.ThumbnailURL, "/api/attachments/5/thumbnail?") {
+				t.Fatalf("urls = %q %q", got.DownloadURL, got.ThumbnailURL)
+			}
+		})
+	}
+}
+
+func TestAttachmentHandlerList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	h := NewAttachmentHandler(&mockAttachmentService{
+		listFn: func(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+			if taskID != "3" {
+				return nil, 0, gorm.ErrRecordNotFound
+			}
+			if params.Cursor != nil {
+				return nil, 0, httpx.ErrInvalidCursor
+			}
+			return []model.Attachment{{ID: 5, TaskID: 3, FileName: "a.png"}}, 7, nil
+		},
+	})
+	r := gin.New()
+	r.GET("/api/tasks/:id/attachments", h.ListTaskAttachments)
+
+	tests := []struct {
+		path     string
+		want     int
+		contains string
+	}{
+		{"/api/tasks/3/attachments?page=2&pageSize=5", http.StatusOK, `"total":7,"totalPages":2`},
+		{"/api/tasks/3/attachments", http.StatusOK, `"downloadUrl":"/attachments/5/download?`},
+		{"/api/tasks/3/attachments?cursor=x", http.StatusBadRequest, ""},
+		{"/api/tasks/4/attachments", http.StatusNotFound, "task not found"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want || !strings.Contains(w.Body.String(), tt.contains) {
+			t.Fatalf("GET %s = %d %s, want %d with %s", tt.path, w.Code, w.Body.String(), tt.want, tt.contains)
+		}
+	}
+}
+
+func TestAttachmentHandlerDownload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-secret")
+	h := NewAttachmentHandler(&mockAttachmentService{
+		getFn: func(ctx context.Context, id string) (model.Attachment, error) {
+			return model.Attachment{ID: 5, FileName: "report \"q1\".pdf"}, nil
+		},
+		openFn: func(ctx context.Context, id string, thumbnail bool) (model.Attachment, io.ReadCloser, error) {
+			if id != "5" {
+				return model.Attachment{}, nil, gorm.ErrRecordNotFound
+			}
+			if thumbnail {
+				return model.Attachment{}, nil, service.ErrNoThumbnail
+			}
+			return model.Attachment{ID: 5, FileName: "report.pdf", MimeType: "application/pdf", Size: 4}, io.NopCloser(strings.NewReader("%PDF")), nil
+		},
+	})
+	r := gin.New()
+	api := r.Group("/api")
+	h.RegisterPublic(api)
+	api.GET("/attachments/:id", h.Get)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/attachments/5", nil))
+	var got model.Attachment
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.ThumbnailURL != "" {
+		t.Fatalf("thumbnailUrl = %q, want empty", got.ThumbnailURL)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, got.DownloadURL, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "%PDF" {
+		t.Fatalf("status = %d body = %q", w.Code, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename=report.pdf` {
+		t.Fatalf("Content-Disposition = %q", cd)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	for path, want := range map[string]int{
+		"/api/attachments/5/download":                                http.StatusForbidden,
+		strings.Replace(got.DownloadURL, "/5/", "/6/", 1):            http.StatusForbidden,
+		strings.Replace(got.DownloadURL, "download", "thumbnail", 2): http.StatusForbidden,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -114,13 +114,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 }
 
 func (h *AuthHandler) Me(c *gin.Context) {
-	userIDRaw, ok := c.Get("userID")
-	if !ok {
-		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
-		return
-	}
-
-	userID, ok := userIDRaw.(uint)
+	userID, ok := currentUserID(c)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
 		return
@@ -139,3 +133,13 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		CreatedAt: user.CreatedAt,
 	})
 }
+
+// currentUserID returns the ID that middleware.JWTAuth stored for the request.
+func currentUserID(c *gin.Context) (uint, bool) {
+	raw, ok := c.Get("userID")
+	if !ok {
+		return 0, false
+	}
+	userID, ok := raw.(uint)
+	return userID, ok
+}
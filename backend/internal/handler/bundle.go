@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBundleBytes caps the size of an uploaded project bundle.
+const maxBundleBytes = 200 << 20
+
+type BundleHandler struct{ service service.BundleService }
+
+func NewBundleHandler(service service.BundleService) *BundleHandler {
+	return &BundleHandler{service: service}
+}
+
+func (h *BundleHandler) Register(r *gin.RouterGroup) {
+	r.GET("/projects/:id/export", h.Export)
+	r.POST("/projects/import", h.Import)
+	r.POST("/projects/import/trello", h.ImportTrello)
+	r.POST("/projects/import/jira", h.ImportJira)
+}
+
+// ExternalImportReport answers a Trello or Jira import: how the export was
+// mapped and what the import created. Tasks maps card short links or issue
+// keys to the new task IDs.
+type ExternalImportReport struct {
+	Mapping service.ImportMapping `json:"mapping"`
+	model.BundleImportReport
+	Tasks map[string]uint `json:"tasks,omitempty"`
+}
+
+// Export downloads the project as a bundle: JSON by default, or with
+// format=zip a zip archive that also holds the attachment contents.
+func (h *BundleHandler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "format must be json or zip"))
+		return
+	}
+	bundle, err := h.service.Export(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "project not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="project-%d.%s"`, bundle.Project.ID, format))
+	if format == "json" {
+		c.JSON(http.StatusOK, bundle)
+		return
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	if err := h.service.WriteZip(c.Request.Context(), c.Writer, bundle); err != nil {
+		// The status is sent; all that is left is to cut the file short.
+		_ = c.Error(err)
+	}
+}
+
+// Import creates a project owned by the caller from an uploaded bundle
+// (multipart field "file", JSON or zip). The optional "title" renames the
+// project; dryRun=true only reports what would be created.
+func (h *BundleHandler) Import(c *gin.Context) {
+	file, ok := bundleFile(c)
+	if !ok {
+		return
+	}
+	defer file.Close()
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+
+	bundle, files, err := service.ReadBundle(file, size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	input := service.BundleImportInput{
+		Bundle: bundle,
+		Files:  files,
+		Title:  c.Request.FormValue("title"),
+		DryRun: c.Query("dryRun") == "true" || c.Request.FormValue("dryRun") == "true",
+	}
+	if userID, ok := currentUserID(c); ok {
+		input.OwnerID = &userID
+	}
+	report, ok := h.importBundle(c, input)
+	if ok {
+		c.JSON(importStatus(report), report)
+	}
+}
+
+// ImportTrello creates a project from a Trello board JSON export, ImportJira
+// from a Jira CSV export (multipart field "file"). Optional fields:
+// "statuses" maps list or status names to task statuses, "members" maps
+// Trello usernames or Jira users to emails, "includeArchived" takes archived
+// Trello cards too, and "title" names the project. With dryRun=true the
+// answer previews the mapping and the import.
+func (h *BundleHandler) ImportTrello(c *gin.Context) {
+	h.importExternal(c, func(file io.Reader, title string, opts service.ExternalImportOptions) (model.ProjectBundle, service.ImportMapping, error) {
+		return service.ReadTrello(file, opts)
+	})
+}
+
+func (h *BundleHandler) ImportJira(c *gin.Context) {
+	h.importExternal(c, service.ReadJira)
+}
+
+func (h *BundleHandler) importExternal(c *gin.Context, read func(io.Reader, string, service.ExternalImportOptions) (model.ProjectBundle, service.ImportMapping, error)) {
+	file, ok := bundleFile(c)
+	if !ok {
+		return
+	}
+	defer file.Close()
+	var opts service.ExternalImportOptions
+	if !formJSON(c, "statuses", &opts.Statuses, "statuses must be a JSON object of list or status names to task statuses") ||
+		!formJSON(c, "members", &opts.Members, "members must be a JSON object of member names to emails") {
+		return
+	}
+	for source, status := range opts.Statuses {
+		if status != model.TaskTodo && status != model.TaskInProgress && status != model.TaskDone {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, fmt.Sprintf("statuses: %q maps to %q; use todo, in_progress or done", source, status)))
+			return
+		}
+	}
+	opts.IncludeArchived = c.Request.FormValue("includeArchived") == "true"
+	title := strings.TrimSpace(c.Request.FormValue("title"))
+
+	bundle, mapping, err := read(file, title, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	input := service.BundleImportInput{
+		Bundle: bundle,
+		Title:  title,
+		DryRun: c.Query("dryRun") == "true" || c.Request.FormValue("dryRun") == "true",
+	}
+	if userID, ok := currentUserID(c); ok {
+		input.OwnerID = &userID
+	}
+	report, ok := h.importBundle(c, input)
+	if !ok {
+		return
+	}
+	answer := ExternalImportReport{Mapping: mapping, BundleImportReport: report}
+	if report.Applied {
+		answer.Tasks = make(map[string]uint, len(report.TaskIDs))
+		for id, newID := range report.TaskIDs {
+			if key := mapping.TaskKeys[id-1]; key != "" {
+				answer.Tasks[key] = newID
+			}
+		}
+	}
+	// The IDs of the bundle were made up while reading the export.
+	answer.TaskIDs, answer.CommentIDs = nil, nil
+	c.JSON(importStatus(report), answer)
+}
+
+// formJSON decodes the optional JSON form field into dst, answering 400 with
+// msg if it is malformed.
+func formJSON(c *gin.Context, field string, dst any, msg string) bool {
+	raw := c.Request.FormValue(field)
+	if raw == "" {
+		return true
+	}
+	if err := json.Unmarshal([]byte(raw), dst); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, msg))
+		return false
+	}
+	return true
+}
+
+// bundleFile opens the uploaded file of an import.
+func bundleFile(c *gin.Context) (multipart.File, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBundleBytes)
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(httpx.StatusFor(httpx.CodePayloadTooLarge), httpx.Err(httpx.CodePayloadTooLarge, fmt.Sprintf("the file must not exceed %d MiB", maxBundleBytes>>20)))
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "multipart field \"file\" is required"))
+		return nil, false
+	}
+	return file, true
+}
+
+func (h *BundleHandler) importBundle(c *gin.Context, input service.BundleImportInput) (model.BundleImportReport, bool) {
+	report, err := h.service.Import(c.Request.Context(), input)
+	if err != nil {
+		if errors.Is(err, service.ErrBundleFormat) {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return report, false
+		}
+		if errors.Is(err, service.ErrBundleBlobGone) {
+			c.JSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+			return report, false
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return report, false
+	}
+	return report, true
+}
+
+// importStatus is 201 when the import created the project, 200 for a dry run.
+func importStatus(report model.BundleImportReport) int {
+	if report.Applied {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
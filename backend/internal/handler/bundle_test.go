@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockBundleService struct {
+	exportFn   func(ctx context.Context, id string) (model.ProjectBundle, error)
+	writeZipFn func(ctx context.Context, w io.Writer, bundle model.ProjectBundle) error
+	importFn   func(ctx context.Context, input service.BundleImportInput) (model.BundleImportReport, error)
+}
+
+func (m *mockBundleService) Export(ctx context.Context, id string) (model.ProjectBundle, error) {
+	return m.exportFn(ctx, id)
+}
+func (m *mockBundleService) WriteZip(ctx context.Context, w io.Writer, bundle model.ProjectBundle) error {
+	return m.writeZipFn(ctx, w, bundle)
+}
+func (m *mockBundleService) Import(ctx context.Context, input service.BundleImportInput) (model.BundleImportReport, error) {
+	return m.importFn(ctx, input)
+}
+
+func TestBundleHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBundleHandler(&mockBundleService{
+		exportFn: func(ctx context.Context, id string) (model.ProjectBundle, error) {
+			if id == "404" {
+				return model.ProjectBundle{}, gorm.ErrRecordNotFound
+			}
+			return model.ProjectBundle{Format: service.BundleFormat, Version: service.BundleVersion, Project: model.BundleProject{ID: 3, Title: "Launch"}}, nil
+		},
+		writeZipFn: func(ctx context.Context, w io.Writer, bundle model.ProjectBundle) error {
+			_, err := io.WriteString(w, "PK zip of "+bundle.Project.Title)
+			return err
+		},
+		importFn: func(ctx context.Context, input service.BundleImportInput) (model.BundleImportReport, error) {
+			switch input.Bundle.Project.Title {
+			case "Broken":
+				return model.BundleImportReport{}, service.ErrBundleFormat
+			case "Down":
+				return model.BundleImportReport{}, errors.New("db down")
+			}
+			if input.OwnerID == nil || *input.OwnerID != 9 || input.Title != "Copy" {
+				t.Fatalf("input = %+v", input)
+			}
+			return model.BundleImportReport{DryRun: input.DryRun, Applied: !input.DryRun, Project: &model.Project{ID: 50, Title: input.Title}}, nil
+		},
+	})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(9)) })
+	r.GET("/projects/:id/export", h.Export)
+	r.POST("/projects/import", h.Import)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects/3/export", nil))
+	var bundle model.ProjectBundle
+	if err := json.Unmarshal(w.Body.Bytes(), &bundle); err != nil || w.Code != http.StatusOK || bundle.Project.Title != "Launch" || !strings.Contains(w.Header().Get("Content-Disposition"), "project-3.json") {
+		t.Fatalf("json export status = %d, headers = %v, body = %s", w.Code, w.Header(), w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects/3/export?format=zip", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" || w.Body.String() != "PK zip of Launch" || !strings.Contains(w.Header().Get("Content-Disposition"), "project-3.zip") {
+		t.Fatalf("zip export status = %d, headers = %v, body = %s", w.Code, w.Header(), w.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/projects/404/export":          http.StatusNotFound,
+		"/projects/3/export?format=tar": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+
+	bundleJSON := func(title string) string {
+		return `{"format":"` + service.BundleFormat + `","version":1,"project":{"title":"` + title + `"}}`
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, importRequest(t, "/projects/import", bundleJSON("Launch"), map[string]string{"title": "Copy"}))
+	var report model.BundleImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || w.Code != http.StatusCreated || !report.Applied || report.Project.ID != 50 {
+		t.Fatalf("import status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, importRequest(t, "/projects/import?dryRun=true", bundleJSON("Launch"), map[string]string{"title": "Copy"}))
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || w.Code != http.StatusOK || report.Applied || !report.DryRun {
+		t.Fatalf("dry run status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	for _, tt := range []struct {
+		file string
+		want int
+	}{
+		{"", http.StatusBadRequest},
+		{"not json", http.StatusBadRequest},
+		{"PK\x03\x04 not a zip", http.StatusBadRequest},
+		{bundleJSON("Broken"), http.StatusBadRequest},
+		{bundleJSON("Down"), http.StatusInternalServerError},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, importRequest(t, "/projects/import", tt.file, nil))
+		if w.Code != tt.want {
+			t.Fatalf("import %q status = %d, want %d: %s", tt.file, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
+
+func TestBundleHandlerExternalImport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got service.BundleImportInput
+	h := NewBundleHandler(&mockBundleService{importFn: func(ctx context.Context, input service.BundleImportInput) (model.BundleImportReport, error) {
+		got = input
+		report := model.BundleImportReport{DryRun: input.DryRun, Applied: !input.DryRun}
+		if report.Applied {
+			report.TaskIDs = map[uint]uint{}
+			for i, task := range input.Bundle.Tasks {
+				report.TaskIDs[task.ID] = uint(100 + i)
+			}
+		}
+		return report, nil
+	}})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(9)) })
+	r.POST("/projects/import/trello", h.ImportTrello)
+	r.POST("/projects/import/jira", h.ImportJira)
+
+	board := `{"name": "Board", "lists": [{"id": "l1", "name": "Doing"}], "members": [{"id": "m1", "username": "ann"}],
+		"cards": [{"id": "c1", "shortLink": "aaa", "name": "Card", "idList": "l1", "idMembers": ["m1"]}]}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, importRequest(t, "/projects/import/trello", board, map[string]string{
+		"statuses": `{"Doing": "done"}`,
+		"members":  `{"ann": "ann@example.com"}`,
+		"title":    "Migrated",
+	}))
+	var report ExternalImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got.Title != "Migrated" || *got.OwnerID != 9 || got.Bundle.Tasks[0].Status != model.TaskDone || len(got.Bundle.Users) != 1 {
+		t.Fatalf("input = %+v", got)
+	}
+	if report.Tasks["aaa"] != 100 || report.TaskIDs != nil || len(report.Mapping.Statuses) != 1 || report.Mapping.Members[0].Email != "ann@example.com" {
+		t.Fatalf("report = %+v", report)
+	}
+
+	jira := "Summary,Issue key,Status\nLogin,WEB-1,Done\n"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, importRequest(t, "/projects/import/jira?dryRun=true", jira, map[string]string{"title": "Website"}))
+	report = ExternalImportReport{}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || w.Code != http.StatusOK || !got.DryRun || report.Tasks != nil || report.Mapping.Statuses[0].Status != model.TaskDone {
+		t.Fatalf("dry run status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	for _, tt := range []struct {
+		path, file string
+		fields     map[string]string
+	}{
+		{"/projects/import/trello", "", nil},
+		{"/projects/import/trello", "[]", nil},
+		{"/projects/import/trello", board, map[string]string{"statuses": `{"Doing": "blocked"}`}},
+		{"/projects/import/trello", board, map[string]string{"members": `["ann"]`}},
+		{"/projects/import/jira", "Key\nWEB-1\n", nil},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, importRequest(t, tt.path, tt.file, tt.fields))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("POST %s %q %v status = %d: %s", tt.path, tt.file, tt.fields, w.Code, w.Body.String())
+		}
+	}
+}
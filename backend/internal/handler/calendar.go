@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// icsTime and icsDate are the UTC date-time and date formats of RFC 5545.
+const (
+	icsTime = "20060102T150405Z"
+	icsDate = "20060102"
+)
+
+// icsStatus maps a task status to the STATUS of its VTODO.
+var icsStatus = map[model.TaskStatus]string{
+	model.TaskTodo:       "NEEDS-ACTION",
+	model.TaskInProgress: "IN-PROCESS",
+	model.TaskDone:       "COMPLETED",
+}
+
+type CalendarHandler struct {
+	service  service.CalendarService
+	basePath string
+}
+
+func NewCalendarHandler(service service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: service}
+}
+
+// CalendarFeed is the response of POST /me/calendar. The token is shown only
+// once; URL is the feed's path relative to the host.
+type CalendarFeed struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+func (h *CalendarHandler) Register(r *gin.RouterGroup) {
+	r.POST("/me/calendar", h.Rotate)
+	r.DELETE("/me/calendar", h.Revoke)
+}
+
+// RegisterPublic mounts the feed. Calendar apps cannot send a bearer token,
+// so the feed is authorized by the secret token in its URL.
+func (h *CalendarHandler) RegisterPublic(r *gin.RouterGroup) {
+	h.basePath = r.BasePath()
+	if h.basePath == "/" {
+		h.basePath = ""
+	}
+	r.GET("/calendar/:token", h.Feed)
+}
+
+func (h *CalendarHandler) Rotate(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	token, err := h.service.Rotate(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, CalendarFeed{Token: token, URL: h.basePath + "/calendar/" + token + ".ics"})
+}
+
+func (h *CalendarHandler) Revoke(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	if err := h.service.Revoke(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Feed serves GET /calendar/:token.ics, the due dates of the token's user as
+// an iCalendar feed of VTODOs. ?projectId= narrows it to one project.
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("token"), ".ics")
+	if !ok || token == "" {
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "calendar not found"))
+		return
+	}
+	var projectID *uint
+	if raw := c.Query("projectId"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "invalid projectId"))
+			return
+		}
+		pid := uint(id)
+		projectID = &pid
+	}
+	tasks, err := h.service.Feed(c.Request.Context(), token, projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "calendar not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+	writeICS(c.Writer, tasks, time.Now())
+}
+
+// writeICS writes tasks as an RFC 5545 calendar of VTODOs. A due date at
+// midnight UTC is a whole day; other due dates keep their time, in UTC.
+func writeICS(w io.Writer, tasks []model.Task, now time.Time) error {
+	out := bufio.NewWriter(w)
+	line := func(name, value string) { writeICSLine(out, name+":"+value) }
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//project-management//tasks//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", "Tasks")
+	for _, t := range tasks {
+		if t.DueDate == nil {
+			continue
+		}
+		line("BEGIN", "VTODO")
+		line("UID", fmt.Sprintf("task-%d@project-management", t.ID))
+		line("DTSTAMP", now.UTC().Format(icsTime))
+		line("SUMMARY", icsText(t.Title))
+		if t.Description != "" {
+			line("DESCRIPTION", icsText(t.Description))
+		}
+		due := t.DueDate.UTC()
+		if due.Equal(due.Truncate(24 * time.Hour)) {
+			line("DUE;VALUE=DATE", due.Format(icsDate))
+		} else {
+			line("DUE", due.Format(icsTime))
+		}
+		if status, ok := icsStatus[t.Status]; ok {
+			line("STATUS", status)
+		}
+		if len(t.Labels) > 0 {
+			names := make([]string, len(t.Labels))
+			for i, l := range t.Labels {
+				names[i] = icsText(l.Name)
+			}
+			line("CATEGORIES", strings.Join(names, ","))
+		}
+		if !t.CreatedAt.IsZero() {
+			line("CREATED", t.CreatedAt.UTC().Format(icsTime))
+		}
+		if !t.UpdatedAt.IsZero() {
+			line("LAST-MODIFIED", t.UpdatedAt.UTC().Format(icsTime))
+		}
+		line("SEQUENCE", strconv.FormatUint(uint64(t.Version), 10))
+		line("END", "VTODO")
+	}
+	line("END", "VCALENDAR")
+	return out.Flush()
+}
+
+// icsText escapes a TEXT value.
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// writeICSLine writes a content line folded at 75 octets, without splitting
+// a UTF-8 sequence, and ended by CRLF.
+func writeICSLine(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
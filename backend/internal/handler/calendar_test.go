@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"project-management/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockCalendarService struct {
+	rotateFn func(ctx context.Context, userID uint) (string, error)
+	revokeFn func(ctx context.Context, userID uint) error
+	feedFn   func(ctx context.Context, token string, projectID *uint) ([]model.Task, error)
+}
+
+func (m *mockCalendarService) Rotate(ctx context.Context, userID uint) (string, error) {
+	return m.rotateFn(ctx, userID)
+}
+func (m *mockCalendarService) Revoke(ctx context.Context, userID uint) error {
+	return m.revokeFn(ctx, userID)
+}
+func (m *mockCalendarService) Feed(ctx context.Context, token string, projectID *uint) ([]model.Task, error) {
+	return m.feedFn(ctx, token, projectID)
+}
+
+func TestCalendarHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	due := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	h := NewCalendarHandler(&mockCalendarService{
+		rotateFn: func(ctx context.Context, userID uint) (string, error) {
+			if userID != 9 {
+				t.Fatalf("userID = %d", userID)
+			}
+			return "secret", nil
+		},
+		revokeFn: func(ctx context.Context, userID uint) error { return nil },
+		feedFn: func(ctx context.Context, token string, projectID *uint) ([]model.Task, error) {
+			if token != "secret" {
+				return nil, gorm.ErrRecordNotFound
+			}
+			if projectID != nil && *projectID != 3 {
+				t.Fatalf("projectID = %d", *projectID)
+			}
+			return []model.Task{{ID: 1, Title: "Ship", Status: model.TaskDone, DueDate: &due, Version: 2}}, nil
+		},
+	})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(9)) })
+	api := r.Group("/api")
+	h.Register(api)
+	h.RegisterPublic(api)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/me/calendar", nil))
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"url":"/api/calendar/secret.ics"`) {
+		t.Fatalf("rotate = %d %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/calendar/secret.ics", http.StatusOK},
+		{http.MethodGet, "/api/calendar/secret.ics?projectId=3", http.StatusOK},
+		{http.MethodGet, "/api/calendar/secret.ics?projectId=x", http.StatusBadRequest},
+		{http.MethodGet, "/api/calendar/secret", http.StatusNotFound},
+		{http.MethodGet, "/api/calendar/other.ics", http.StatusNotFound},
+		{http.MethodDelete, "/api/me/calendar", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Fatalf("%s %s status = %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+		}
+		if tt.want == http.StatusOK {
+			if ct := w.Header().Get("Content-Type"); ct != "text/calendar; charset=utf-8" {
+				t.Fatalf("Content-Type = %q", ct)
+			}
+			if !strings.Contains(w.Body.String(), "\r\nSTATUS:COMPLETED\r\n") {
+				t.Fatalf("body = %q", w.Body.String())
+			}
+		}
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	day := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2026, 11, 2, 17, 30, 0, 0, time.FixedZone("CET", 3600))
+	tasks := []model.Task{
+		{ID: 1, Title: "Plan; review, ship", Description: "line one\nline two \\ done", Status: model.TaskTodo, DueDate: &day, Version: 1, CreatedAt: now,
+			Labels: []model.Label{{Name: "bug"}, {Name: "a,b"}}},
+		{ID: 2, Title: strings.Repeat("é", 60), Status: model.TaskInProgress, DueDate: &at, Version: 3},
+		{ID: 3, Title: "No due date"},
+	}
+	var buf bytes.Buffer
+	if err := writeICS(&buf, tasks, now); err != nil {
+		t.Fatalf("writeICS: %v", err)
+	}
+	body := buf.String()
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") {
+		t.Fatalf("body = %q", body)
+	}
+	for _, want := range []string{
+		"UID:task-1@project-management\r\n",
+		"DTSTAMP:20261018T120000Z\r\n",
+		`SUMMARY:Plan\; review\, ship` + "\r\n",
+		`DESCRIPTION:line one\nline two \\ done` + "\r\n",
+		"DUE;VALUE=DATE:20261102\r\n",
+		"STATUS:NEEDS-ACTION\r\n",
+		`CATEGORIES:bug,a\,b` + "\r\n",
+		"CREATED:20261018T120000Z\r\n",
+		"DUE:20261102T163000Z\r\n",
+		"STATUS:IN-PROCESS\r\n",
+		"SEQUENCE:3\r\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("body has no %q:\n%s", want, body)
+		}
+	}
+	if strings.Count(body, "BEGIN:VTODO") != 2 {
+		t.Fatalf("a task without a due date was written:\n%s", body)
+	}
+
+	// Long lines fold at 75 octets without splitting a character.
+	var unfolded strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(line) > 75 {
+			t.Fatalf("line of %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Fatalf("folded line splits a character: %q", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+			continue
+		}
+		unfolded.WriteString("\n" + line)
+	}
+	if !strings.Contains(unfolded.String(), "\nSUMMARY:"+strings.Repeat("é", 60)+"\n") {
+		t.Fatalf("unfolded = %q", unfolded.String())
+	}
+}
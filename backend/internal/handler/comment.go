@@ -1,27 +1,38 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"reflect"
 	"strings"
 
 	"project-management/internal/httpx"
+	"project-management/internal/model"
 	"project-management/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-type CommentHandler struct{ service service.CommentService }
+type CommentHandler struct {
+	service       service.CommentService
+	preconditions Preconditions
+}
 
 func NewCommentHandler(service service.CommentService) *CommentHandler {
-	return &CommentHandler{service: service}
+	return NewCommentHandlerWithPreconditions(service, Preconditions{})
+}
+
+func NewCommentHandlerWithPreconditions(service service.CommentService, preconditions Preconditions) *CommentHandler {
+	return &CommentHandler{service: service, preconditions: preconditions}
 }
 
 type CommentCreate struct {
-	TaskID uint   `json:"taskId" binding:"required"`
-	Author string `json:"author" binding:"required"`
-	Text   string `json:"text" binding:"required"`
+	TaskID   uint   `json:"taskId" binding:"required"`
+	ParentID *uint  `json:"parentId"`
+	Author   string `json:"author" binding:"required"`
+	Text     string `json:"text" binding:"required"`
 }
 
 type CommentUpdate struct {
@@ -34,11 +45,25 @@ func (h *CommentHandler) Register(r *gin.RouterGroup) {
 	r.POST("/comments", h.Create)
 	r.GET("/comments/:id", h.Get)
 	r.PUT("/comments/:id", h.Update)
+	r.PATCH("/comments/:id", h.Patch)
 	r.DELETE("/comments/:id", h.Delete)
+	r.GET("/comments/:id/replies", h.ListReplies)
+	r.POST("/comments/:id/reactions/:emoji", h.AddReaction)
+	r.DELETE("/comments/:id/reactions/:emoji", h.RemoveReaction)
+	r.GET("/comments/:id/revisions", h.ListRevisions)
+}
+
+// RegisterAdmin mounts moderation routes; the group must already be restricted
+// to administrators.
+func (h *CommentHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.DELETE("/comments/:id", h.Purge)
 }
 
 func (h *CommentHandler) List(c *gin.Context) {
-	lp := httpx.ParseListParams(c.Query("page"), c.Query("pageSize"), c.Query("sort"))
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
 
 	items, total, err := h.service.List(c.Request.Context(), service.CommentListFilter{
 		Params: lp,
@@ -46,16 +71,11 @@ func (h *CommentHandler) List(c *gin.Context) {
 		Author: strings.TrimSpace(c.Query("author")),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		listError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"page":     lp.Page,
-		"pageSize": lp.PageSize,
-		"items":    items,
-		"isLast":   httpx.IsLast(total, lp),
-	})
+	c.JSON(http.StatusOK, listBody(c, lp, items, total))
 }
 
 func (h *CommentHandler) Create(c *gin.Context) {
@@ -66,11 +86,19 @@ func (h *CommentHandler) Create(c *gin.Context) {
 	}
 
 	x, err := h.service.Create(c.Request.Context(), service.CommentCreateInput{
-		TaskID: body.TaskID,
-		Author: body.Author,
-		Text:   body.Text,
+		TaskID:   body.TaskID,
+		ParentID: body.ParentID,
+		Author:   body.Author,
+		Text:     body.Text,
 	})
+	if errors.Is(err, service.ErrInvalidParent) {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
 	if err != nil {
+		if projectArchived(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
@@ -87,10 +115,15 @@ func (h *CommentHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
+	setETag(c, x.Version)
 	c.JSON(http.StatusOK, x)
 }
 
 func (h *CommentHandler) Update(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
 	var body CommentUpdate
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
@@ -98,25 +131,175 @@ func (h *CommentHandler) Update(c *gin.Context) {
 	}
 
 	x, err := h.service.Update(c.Request.Context(), c.Param("id"), service.CommentUpdateInput{
-		Author: body.Author,
-		Text:   body.Text,
+		Author:  body.Author,
+		Text:    body.Text,
+		Version: version,
 	})
 	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+
+	setETag(c, x.Version)
+	c.JSON(http.StatusOK, x)
+}
+
+// Patch applies a merge patch or JSON Patch to the comment's fields as they
+// are posted on create; taskId and parentId cannot change. The patched
+// comment must pass the create rules and is saved only if the comment did
+// not change meanwhile.
+func (h *CommentHandler) Patch(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
+	current, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+	var body CommentCreate
+	if !patchDocument(c, CommentCreate{TaskID: current.TaskID, ParentID: current.ParentID, Author: current.Author, Text: current.Text}, &body) {
+		return
+	}
+	if body.TaskID != current.TaskID || !reflect.DeepEqual(body.ParentID, current.ParentID) {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "taskId and parentId cannot be patched"))
+		return
+	}
+	if version == nil {
+		version = &current.Version
+	}
+
+	x, err := h.service.Update(c.Request.Context(), c.Param("id"), service.CommentUpdateInput{
+		Author:  &body.Author,
+		Text:    &body.Text,
+		Version: version,
+	})
+	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+
+	setETag(c, x.Version)
+	c.JSON(http.StatusOK, x)
+}
+
+func (h *CommentHandler) updateFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCommentDeleted):
+		c.JSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "comment not found"))
+	case versionMismatch(c, err):
+	case projectArchived(c, err):
+	default:
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
+
+func (h *CommentHandler) Delete(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), version); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "comment not found"))
 			return
 		}
+		if versionMismatch(c, err) || projectArchived(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
-
-	c.JSON(http.StatusOK, x)
+	c.Status(http.StatusNoContent)
 }
 
-func (h *CommentHandler) Delete(c *gin.Context) {
-	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+func (h *CommentHandler) Purge(c *gin.Context) {
+	if err := h.service.Purge(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "comment not found"))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *CommentHandler) ListRevisions(c *gin.Context) {
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+
+	items, total, err := h.service.ListRevisions(c.Request.Context(), c.Param("id"), lp)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "comment not found"))
+			return
+		}
+		listError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, listBody(c, lp, items, total))
+}
+
+func (h *CommentHandler) ListReplies(c *gin.Context) {
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+
+	items, total, err := h.service.ListReplies(c.Request.Context(), c.Param("id"), lp)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "comment not found"))
+			return
+		}
+		listError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, listBody(c, lp, items, total))
+}
+
+func (h *CommentHandler) AddReaction(c *gin.Context) {
+	h.react(c, h.service.AddReaction)
+}
+
+func (h *CommentHandler) RemoveReaction(c *gin.Context) {
+	h.react(c, h.service.RemoveReaction)
+}
+
+func (h *CommentHandler) react(c *gin.Context, apply func(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error)) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+
+	x, err := apply(c.Request.Context(), c.Param("id"), userID, c.Param("emoji"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidEmoji) {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+		if errors.Is(err, service.ErrCommentDeleted) {
+			c.JSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "comment not found"))
+			return
+		}
+		if projectArchived(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, x)
+}
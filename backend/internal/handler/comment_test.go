@@ -23,7 +23,13 @@ type mockCommentService struct {
 	createFn func(ctx context.Context, input service.CommentCreateInput) (model.Comment, error)
 	getFn    func(ctx context.Context, id string) (model.Comment, error)
 	updateFn func(ctx context.Context, id string, input service.CommentUpdateInput) (model.Comment, error)
-	deleteFn func(ctx context.Context, id string) error
+	deleteFn func(ctx context.Context, id string, version *uint) error
+
+	listRepliesFn    func(ctx context.Context, id string, params httpx.ListParams) ([]model.Comment, int64, error)
+	addReactionFn    func(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error)
+	removeReactionFn func(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error)
+	listRevisionsFn  func(ctx context.Context, id string, params httpx.ListParams) ([]model.CommentRevision, int64, error)
+	purgeFn          func(ctx context.Context, id string) error
 }
 
 func (m *mockCommentService) List(ctx context.Context, filter service.CommentListFilter) ([]model.Comment, int64, error) {
@@ -38,7 +44,22 @@ func (m *mockCommentService) Get(ctx context.Context, id string) (model.Comment,
 func (m *mockCommentService) Update(ctx context.Context, id string, input service.CommentUpdateInput) (model.Comment, error) {
 	return m.updateFn(ctx, id, input)
 }
-func (m *mockCommentService) Delete(ctx context.Context, id string) error { return m.deleteFn(ctx, id) }
+func (m *mockCommentService) Delete(ctx context.Context, id string, version *uint) error {
+	return m.deleteFn(ctx, id, version)
+}
+func (m *mockCommentService) ListReplies(ctx context.Context, id string, params httpx.ListParams) ([]model.Comment, int64, error) {
+	return m.listRepliesFn(ctx, id, params)
+}
+func (m *mockCommentService) AddReaction(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error) {
+	return m.addReactionFn(ctx, id, userID, emoji)
+}
+func (m *mockCommentService) RemoveReaction(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error) {
+	return m.removeReactionFn(ctx, id, userID, emoji)
+}
+func (m *mockCommentService) ListRevisions(ctx context.Context, id string, params httpx.ListParams) ([]model.CommentRevision, int64, error) {
+	return m.listRevisionsFn(ctx, id, params)
+}
+func (m *mockCommentService) Purge(ctx context.Context, id string) error { return m.purgeFn(ctx, id) }
 
 func TestCommentHandlerList(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -218,7 +239,7 @@ func TestCommentHandlerUpdateInternalError(t *testing.T) {
 
 func TestCommentHandlerDeleteInternalError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewCommentHandler(&mockCommentService{deleteFn: func(ctx context.Context, id string) error {
+	h := NewCommentHandler(&mockCommentService{deleteFn: func(ctx context.Context, id string, version *uint) error {
 		return errors.New("delete failed")
 	}})
 	r := gin.New()
@@ -241,7 +262,7 @@ func TestCommentHandlerDeleteInternalError(t *testing.T) {
 
 func TestCommentHandlerDeleteNoContent(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewCommentHandler(&mockCommentService{deleteFn: func(ctx context.Context, id string) error {
+	h := NewCommentHandler(&mockCommentService{deleteFn: func(ctx context.Context, id string, version *uint) error {
 		return nil
 	}})
 	r := gin.New()
@@ -254,3 +275,190 @@ func TestCommentHandlerDeleteNoContent(t *testing.T) {
 		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
 	}
 }
+
+func TestCommentHandlerCreateInvalidParent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCommentHandler(&mockCommentService{createFn: func(ctx context.Context, input service.CommentCreateInput) (model.Comment, error) {
+		if input.ParentID == nil || *input.ParentID != 8 {
+			t.Fatalf("unexpected input: %+v", input)
+		}
+		return model.Comment{}, service.ErrInvalidParent
+	}})
+	r := gin.New()
+	r.POST("/comments", h.Create)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/comments", bytes.NewBufferString(`{"taskId":2,"parentId":8,"author":"Ann","text":"re"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCommentHandlerListReplies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	parentID := uint(4)
+	h := NewCommentHandler(&mockCommentService{listRepliesFn: func(ctx context.Context, id string, params httpx.ListParams) ([]model.Comment, int64, error) {
+		if id != "4" || params.PageSize != 5 {
+			t.Fatalf("id=%s params=%+v", id, params)
+		}
+		return []model.Comment{{ID: 5, TaskID: 1, ParentID: &parentID, Author: "Bob", Text: "re"}}, 1, nil
+	}})
+	r := gin.New()
+	r.GET("/comments/:id/replies", h.ListReplies)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/comments/4/replies?pageSize=5", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var body CommentsListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(body.Items) != 1 || body.Items[0].ParentID == nil || *body.Items[0].ParentID != 4 {
+		t.Fatalf("body = %+v", body)
+	}
+}
+
+func TestCommentHandlerListRepliesNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCommentHandler(&mockCommentService{listRepliesFn: func(ctx context.Context, id string, params httpx.ListParams) ([]model.Comment, int64, error) {
+		return nil, 0, gorm.ErrRecordNotFound
+	}})
+	r := gin.New()
+	r.GET("/comments/:id/replies", h.ListReplies)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/comments/4/replies", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestCommentHandlerReactions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCommentHandler(&mockCommentService{
+		addReactionFn: func(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error) {
+			if id != "4" || userID != 9 || emoji != "👍" {
+				t.Fatalf("id=%s userID=%d emoji=%s", id, userID, emoji)
+			}
+			return model.Comment{ID: 4, Reactions: []model.ReactionSummary{{Emoji: emoji, Count: 1, Users: []model.ReactionUser{{ID: 9, Name: "Ann"}}}}}, nil
+		},
+		removeReactionFn: func(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error) {
+			if emoji == "bad" {
+				return model.Comment{}, service.ErrInvalidEmoji
+			}
+			return model.Comment{}, gorm.ErrRecordNotFound
+		},
+	})
+	r := gin.New()
+	withUser := func(c *gin.Context) { c.Set("userID", uint(9)) }
+	r.POST("/comments/:id/reactions/:emoji", withUser, h.AddReaction)
+	r.DELETE("/comments/:id/reactions/:emoji", withUser, h.RemoveReaction)
+	r.POST("/anonymous/:id/reactions/:emoji", h.AddReaction)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodPost, "/comments/4/reactions/%F0%9F%91%8D", http.StatusOK},
+		{http.MethodDelete, "/comments/4/reactions/bad", http.StatusBadRequest},
+		{http.MethodDelete, "/comments/4/reactions/%F0%9F%91%8D", http.StatusNotFound},
+		{http.MethodPost, "/anonymous/4/reactions/%F0%9F%91%8D", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Fatalf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestCommentHandlerUpdateDeleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCommentHandler(&mockCommentService{updateFn: func(ctx context.Context, id string, input service.CommentUpdateInput) (model.Comment, error) {
+		return model.Comment{}, service.ErrCommentDeleted
+	}})
+	r := gin.New()
+	r.PUT("/comments/:id", h.Update)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/comments/4", bytes.NewBufferString(`{"text":"Updated"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestCommentHandlerDeleteNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCommentHandler(&mockCommentService{deleteFn: func(ctx context.Context, id string, version *uint) error {
+		return gorm.ErrRecordNotFound
+	}})
+	r := gin.New()
+	r.DELETE("/comments/:id", h.Delete)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/comments/4", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestCommentHandlerListRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCommentHandler(&mockCommentService{listRevisionsFn: func(ctx context.Context, id string, params httpx.ListParams) ([]model.CommentRevision, int64, error) {
+		if id == "404" {
+			return nil, 0, gorm.ErrRecordNotFound
+		}
+		return []model.CommentRevision{{ID: 1, CommentID: 4, Text: "v1"}}, 1, nil
+	}})
+	r := gin.New()
+	r.GET("/comments/:id/revisions", h.ListRevisions)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/comments/4/revisions", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/comments/404/revisions", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestCommentHandlerPurge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCommentHandler(&mockCommentService{purgeFn: func(ctx context.Context, id string) error {
+		if id == "404" {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	}})
+	r := gin.New()
+	r.DELETE("/admin/comments/:id", h.Purge)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/comments/4", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/comments/404", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -95,7 +96,7 @@ func TestProjectHandlerAdditionalErrors(t *testing.T) {
 	})
 
 	t.Run("get internal error", func(t *testing.T) {
-		h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+		h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
 			return model.Project{}, errors.New("boom")
 		}})
 		r := gin.New()
@@ -147,7 +148,7 @@ func TestTaskHandlerAdditionalErrors(t *testing.T) {
 	})
 
 	t.Run("get internal error", func(t *testing.T) {
-		h := NewTaskHandler(&mockTaskService{getFn: func(ctx context.Context, id string, includeComments bool) (model.Task, error) {
+		h := NewTaskHandler(&mockTaskService{getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
 			return model.Task{}, errors.New("boom")
 		}})
 		r := gin.New()
@@ -157,6 +158,22 @@ func TestTaskHandlerAdditionalErrors(t *testing.T) {
 		assertAPIError(t, w, http.StatusInternalServerError, "INTERNAL", "boom")
 	})
 
+	t.Run("update invalid recurrence", func(t *testing.T) {
+		h := NewTaskHandler(&mockTaskService{updateFn: func(ctx context.Context, id string, input service.TaskUpdateInput) (model.Task, error) {
+			if input.Recurrence == nil || *input.Recurrence != "FREQ=YEARLY" {
+				t.Fatalf("input = %+v", input)
+			}
+			return model.Task{}, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", service.ErrInvalidRecurrence)
+		}})
+		r := gin.New()
+		r.PUT("/tasks/:id", h.Update)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/tasks/1", bytes.NewBufferString(`{"recurrence":"FREQ=YEARLY"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		assertAPIError(t, w, http.StatusBadRequest, httpx.CodeBadRequest, "invalid recurrence: FREQ must be DAILY, WEEKLY or MONTHLY")
+	})
+
 	t.Run("update invalid body", func(t *testing.T) {
 		h := NewTaskHandler(&mockTaskService{})
 		r := gin.New()
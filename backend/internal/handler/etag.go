@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag sets the ETag of a versioned resource: its version in quotes.
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", `"`+strconv.FormatUint(uint64(version), 10)+`"`)
+}
+
+// Preconditions configures how the handlers of versioned resources treat
+// If-Match.
+type Preconditions struct {
+	// RequireIfMatch answers writes without If-Match with 428.
+	RequireIfMatch bool
+}
+
+// ifMatch reads the If-Match precondition of a write: the version of its
+// single ETag, or nil when the header is absent or "*". A missing header is
+// answered with 428 if p requires it, a malformed one with 400 and a weak
+// ETag with 412, since If-Match compares strongly (RFC 9110 §13.1.1); then
+// it returns false.
+func (p Preconditions) ifMatch(c *gin.Context) (*uint, bool) {
+	raw := strings.TrimSpace(c.GetHeader("If-Match"))
+	if raw == "" {
+		if p.RequireIfMatch {
+			c.JSON(httpx.StatusFor(httpx.CodePreconditionRequired), httpx.Err(httpx.CodePreconditionRequired, "If-Match is required; send the ETag from the last GET"))
+			return nil, false
+		}
+		return nil, true
+	}
+	if raw == "*" {
+		return nil, true
+	}
+	if strings.HasPrefix(raw, "W/") {
+		c.JSON(httpx.StatusFor(httpx.CodePreconditionFailed), httpx.Err(httpx.CodePreconditionFailed, "If-Match needs a strong ETag; a weak one never matches"))
+		return nil, false
+	}
+	n, err := strconv.ParseUint(strings.Trim(raw, `"`), 10, 32)
+	if err != nil || len(raw) < 3 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+		c.JSON(httpx.StatusFor(httpx.CodeBadRequest), httpx.Err(httpx.CodeBadRequest, "If-Match must be a single ETag such as \"3\", or *"))
+		return nil, false
+	}
+	version := uint(n)
+	return &version, true
+}
+
+// versionMismatch answers 412 and returns true when err is a failed If-Match
+// or a concurrent write.
+func versionMismatch(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrVersionMismatch) {
+		return false
+	}
+	c.JSON(httpx.StatusFor(httpx.CodePreconditionFailed), httpx.Err(httpx.CodePreconditionFailed, err.Error()))
+	return true
+}
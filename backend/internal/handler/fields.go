@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldsetTypes names the resource types ?fields[type]= can trim.
+var fieldsetTypes = map[string]reflect.Type{
+	"project": reflect.TypeOf(model.Project{}),
+	"task":    reflect.TypeOf(model.Task{}),
+	"comment": reflect.TypeOf(model.Comment{}),
+	"label":   reflect.TypeOf(model.Label{}),
+	"user":    reflect.TypeOf(model.User{}),
+}
+
+// shape is what a read asks for beyond the default representation: the
+// relation paths to include and, per resource type, the fields to keep.
+type shape struct {
+	include []string
+	fields  map[string]map[string]bool
+}
+
+// parseShape reads ?include= against allowed (nil for resources without
+// relations) and ?fields[type]=a,b. It answers 400 and returns false when
+// either names something unknown.
+func parseShape(c *gin.Context, allowed httpx.Relations) (shape, bool) {
+	var s shape
+	if raw := c.Query("include"); raw != "" {
+		include, err := httpx.ParseInclude(raw, allowed)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return shape{}, false
+		}
+		s.include = include
+	}
+	for typ, values := range c.QueryMap("fields") {
+		t, ok := fieldsetTypes[typ]
+		if !ok {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, fmt.Sprintf("fields[%s]: unknown type; allowed are %s", typ, strings.Join(sortedKeys(fieldsetTypes), ", "))))
+			return shape{}, false
+		}
+		known := jsonFieldNames(t)
+		selected := map[string]bool{}
+		for _, name := range splitList(values) {
+			if !slices.Contains(known, name) {
+				c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, fmt.Sprintf("fields[%s]: unknown field %q; allowed are %s", typ, name, strings.Join(known, ", "))))
+				return shape{}, false
+			}
+			selected[name] = true
+		}
+		if s.fields == nil {
+			s.fields = map[string]map[string]bool{}
+		}
+		s.fields[typ] = selected
+	}
+	return s, true
+}
+
+// render returns v trimmed to the requested fieldsets. Types without a
+// fieldset keep all their fields, "id" is always kept, and included relations
+// are kept even when their field is not listed.
+func (s shape) render(v any) any {
+	if len(s.fields) == 0 {
+		return v
+	}
+	return s.trim(reflect.ValueOf(v), "")
+}
+
+func (s shape) trim(v reflect.Value, path string) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		if _, ok := resourceName(v.Type()); !ok {
+			return v.Interface()
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = s.trim(v.Index(i), path)
+		}
+		return out
+	case reflect.Struct:
+		name, ok := resourceName(v.Type())
+		if !ok {
+			return v.Interface()
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return v.Interface()
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return v.Interface()
+		}
+		selected, trimmed := s.fields[name]
+		keep := func(key string) bool { return !trimmed || selected[key] || key == "id" }
+
+		out := make(map[string]any, len(obj))
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			value, ok := obj[key]
+			if !ok {
+				continue
+			}
+			if _, rel := resourceName(t.Field(i).Type); rel {
+				child := strings.TrimPrefix(path+"."+key, ".")
+				if keep(key) || slices.Contains(s.include, child) {
+					out[key] = s.trim(v.Field(i), child)
+				}
+				continue
+			}
+			if keep(key) {
+				out[key] = value
+			}
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// resourceName reports the fieldset type of t, looking through pointers and
+// slices.
+func resourceName(t reflect.Type) (string, bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	for name, rt := range fieldsetTypes {
+		if rt == t {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// jsonFieldNames lists the JSON field names of the struct type t in
+// declaration order.
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key != "" && key != "-" {
+			names = append(names, key)
+		}
+	}
+	return names
+}
+
+func splitList(values string) []string {
+	var out []string
+	for _, part := range strings.Split(values, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
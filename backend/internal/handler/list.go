@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"project-management/internal/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listParams reads page or cursor pagination and the sort from the query. It
+// answers 400 and returns false for a malformed cursor.
+func listParams(c *gin.Context) (httpx.ListParams, bool) {
+	lp, err := httpx.ParseListQuery(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return httpx.ListParams{}, false
+	}
+	return lp, true
+}
+
+// listBody is the list envelope. Page mode reports page and pageSize, cursor
+// mode limit and the cursors of the neighbouring pages (null at either end).
+// total and totalPages are null when the request skipped the count. It also
+// sets X-Total-Count and the RFC 8288 Link header for the neighbouring pages.
+func listBody[T any](c *gin.Context, lp httpx.ListParams, items []T, total int64) gin.H {
+	body := gin.H{"items": items, "total": nil, "totalPages": nil}
+	if total >= 0 {
+		c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+		body["total"] = total
+		body["totalPages"] = httpx.TotalPages(total, lp)
+	}
+
+	var links []string
+	link := func(rel string, set map[string]string) {
+		q := c.Request.URL.Query()
+		for key, value := range set {
+			if value == "" {
+				q.Del(key)
+			} else {
+				q.Set(key, value)
+			}
+		}
+		u := url.URL{Path: c.Request.URL.Path, RawQuery: q.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", u.String(), rel))
+	}
+
+	if lp.Cursor != nil {
+		body["limit"] = lp.Cursor.Limit
+		body["nextCursor"] = optionalCursor(lp.Cursor.Next)
+		body["prevCursor"] = optionalCursor(lp.Cursor.Prev)
+		body["isLast"] = lp.Cursor.Next == ""
+		if c.Query("cursor") != "" {
+			link("first", map[string]string{"cursor": ""})
+		}
+		if lp.Cursor.Prev != "" {
+			link("prev", map[string]string{"cursor": lp.Cursor.Prev})
+		}
+		if lp.Cursor.Next != "" {
+			link("next", map[string]string{"cursor": lp.Cursor.Next})
+		}
+	} else {
+		// Without a count the page is the last one when it is not full.
+		isLast := len(items) < lp.PageSize
+		if total >= 0 {
+			isLast = httpx.IsLast(total, lp)
+		}
+		body["page"] = lp.Page
+		body["pageSize"] = lp.PageSize
+		body["isLast"] = isLast
+		page := func(n int64) map[string]string { return map[string]string{"page": strconv.FormatInt(n, 10)} }
+		link("first", page(1))
+		if lp.Page > 1 {
+			link("prev", page(int64(lp.Page-1)))
+		}
+		if !isLast {
+			link("next", page(int64(lp.Page+1)))
+		}
+		if total >= 0 {
+			link("last", page(max(httpx.TotalPages(total, lp), 1)))
+		}
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+	return body
+}
+
+func optionalCursor(cursor string) any {
+	if cursor == "" {
+		return nil
+	}
+	return cursor
+}
+
+// listError answers a failed list query: 400 for a cursor that does not fit
+// the endpoint, 500 otherwise.
+func listError(c *gin.Context, err error) {
+	if errors.Is(err, httpx.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MilestoneHandler struct{ service service.MilestoneService }
+
+func NewMilestoneHandler(service service.MilestoneService) *MilestoneHandler {
+	return &MilestoneHandler{service: service}
+}
+
+// MilestoneBody creates or updates a milestone; only the day of targetDate
+// counts.
+type MilestoneBody struct {
+	Name        string    `json:"name" binding:"required"`
+	Description string    `json:"description"`
+	TargetDate  time.Time `json:"targetDate" binding:"required"`
+}
+
+func (h *MilestoneHandler) Register(r *gin.RouterGroup) {
+	r.GET("/projects/:id/milestones", h.List)
+	r.POST("/projects/:id/milestones", h.Create)
+	r.GET("/milestones/:id", h.Get)
+	r.PUT("/milestones/:id", h.Update)
+	r.DELETE("/milestones/:id", h.Delete)
+}
+
+// List returns the project's milestones by target date.
+func (h *MilestoneHandler) List(c *gin.Context) {
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	milestones, total, err := h.service.List(c.Request.Context(), c.Param("id"), lp)
+	if err != nil {
+		milestoneError(c, err, "project not found")
+		return
+	}
+	c.JSON(http.StatusOK, listBody(c, lp, milestones, total))
+}
+
+func (h *MilestoneHandler) Create(c *gin.Context) {
+	var body MilestoneBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	milestone, err := h.service.Create(c.Request.Context(), c.Param("id"), milestoneInput(body))
+	if err != nil {
+		milestoneError(c, err, "project not found")
+		return
+	}
+	c.JSON(http.StatusCreated, milestone)
+}
+
+func (h *MilestoneHandler) Get(c *gin.Context) {
+	milestone, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		milestoneError(c, err, "milestone not found")
+		return
+	}
+	c.JSON(http.StatusOK, milestone)
+}
+
+func (h *MilestoneHandler) Update(c *gin.Context) {
+	var body MilestoneBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	milestone, err := h.service.Update(c.Request.Context(), c.Param("id"), milestoneInput(body))
+	if err != nil {
+		milestoneError(c, err, "milestone not found")
+		return
+	}
+	c.JSON(http.StatusOK, milestone)
+}
+
+func milestoneInput(body MilestoneBody) service.MilestoneInput {
+	return service.MilestoneInput{Name: body.Name, Description: body.Description, TargetDate: body.TargetDate}
+}
+
+func (h *MilestoneHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		milestoneError(c, err, "milestone not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func milestoneError(c *gin.Context, err error, notFound string) {
+	switch {
+	case projectArchived(c, err):
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, notFound))
+	case errors.Is(err, httpx.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
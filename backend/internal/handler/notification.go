@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotificationHandler struct {
+	service   service.NotificationService
+	reminders service.ReminderService
+}
+
+func NewNotificationHandler(service service.NotificationService, reminders service.ReminderService) *NotificationHandler {
+	return &NotificationHandler{service: service, reminders: reminders}
+}
+
+// ReminderPreferences is the body of PUT /me/reminders. Offsets are minutes
+// before the due date, negative for after it; an empty list turns reminders
+// off. Email defaults to true.
+type ReminderPreferences struct {
+	Offsets []int `json:"offsets"`
+	Email   *bool `json:"email"`
+}
+
+func (h *NotificationHandler) Register(r *gin.RouterGroup) {
+	r.GET("/me/notifications", h.List)
+	r.POST("/me/notifications/read", h.ReadAll)
+	r.POST("/me/notifications/:id/read", h.Read)
+	r.GET("/me/reminders", h.GetReminders)
+	r.PUT("/me/reminders", h.SetReminders)
+}
+
+// List returns the caller's newest notifications, up to ?limit= (50 by
+// default, at most 100), with the number of unread ones. ?unread=true leaves
+// out read ones.
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	filter := service.NotificationListFilter{UserID: userID}
+	if raw := c.Query("unread"); raw != "" {
+		unread, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "unread must be true or false"))
+			return
+		}
+		filter.Unread = unread
+	}
+	if filter.Params, ok = listParams(c); !ok {
+		return
+	}
+	items, total, unread, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		listError(c, err)
+		return
+	}
+	body := listBody(c, filter.Params, items, total)
+	body["unread"] = unread
+	c.JSON(http.StatusOK, body)
+}
+
+func (h *NotificationHandler) Read(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	n, err := h.service.Read(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "notification not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, n)
+}
+
+func (h *NotificationHandler) ReadAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	n, err := h.service.ReadAll(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": n})
+}
+
+func (h *NotificationHandler) GetReminders(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	pref, err := h.reminders.Preferences(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
+
+func (h *NotificationHandler) SetReminders(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	var body ReminderPreferences
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	input := service.ReminderPreferenceInput{Offsets: body.Offsets, Email: body.Email == nil || *body.Email}
+	pref, err := h.reminders.SetPreferences(c.Request.Context(), userID, input)
+	if err != nil {
+		if errors.Is(err, service.ErrReminderOffsets) {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
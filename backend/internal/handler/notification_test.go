@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockNotificationService struct {
+	listFn    func(ctx context.Context, filter service.NotificationListFilter) ([]model.Notification, int64, int64, error)
+	readFn    func(ctx context.Context, id string, userID uint) (model.Notification, error)
+	readAllFn func(ctx context.Context, userID uint) (int64, error)
+}
+
+func (m *mockNotificationService) List(ctx context.Context, filter service.NotificationListFilter) ([]model.Notification, int64, int64, error) {
+	return m.listFn(ctx, filter)
+}
+func (m *mockNotificationService) Read(ctx context.Context, id string, userID uint) (model.Notification, error) {
+	return m.readFn(ctx, id, userID)
+}
+func (m *mockNotificationService) ReadAll(ctx context.Context, userID uint) (int64, error) {
+	return m.readAllFn(ctx, userID)
+}
+
+type mockReminderService struct {
+	preferencesFn    func(ctx context.Context, userID uint) (model.ReminderPreference, error)
+	setPreferencesFn func(ctx context.Context, userID uint, input service.ReminderPreferenceInput) (model.ReminderPreference, error)
+}
+
+func (m *mockReminderService) Preferences(ctx context.Context, userID uint) (model.ReminderPreference, error) {
+	return m.preferencesFn(ctx, userID)
+}
+func (m *mockReminderService) SetPreferences(ctx context.Context, userID uint, input service.ReminderPreferenceInput) (model.ReminderPreference, error) {
+	return m.setPreferencesFn(ctx, userID, input)
+}
+func (m *mockReminderService) Run(ctx context.Context, now time.Time) (int, error) {
+	panic("not used")
+}
+
+func TestNotificationHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewNotificationHandler(&mockNotificationService{
+		listFn: func(ctx context.Context, filter service.NotificationListFilter) ([]model.Notification, int64, int64, error) {
+			if filter.UserID != 9 || !filter.Unread || filter.Params.Cursor == nil || filter.Params.Cursor.Limit != 5 {
+				t.Fatalf("filter = %+v", filter)
+			}
+			return []model.Notification{{ID: 1, UserID: 9, Kind: model.NotificationReminder, Title: "Due"}}, 1, 1, nil
+		},
+		readFn: func(ctx context.Context, id string, userID uint) (model.Notification, error) {
+			if id != "1" {
+				return model.Notification{}, gorm.ErrRecordNotFound
+			}
+			return model.Notification{ID: 1, UserID: userID}, nil
+		},
+		readAllFn: func(ctx context.Context, userID uint) (int64, error) { return 4, nil },
+	}, &mockReminderService{
+		preferencesFn: func(ctx context.Context, userID uint) (model.ReminderPreference, error) {
+			return model.ReminderPreference{UserID: userID, Offsets: service.DefaultReminderOffsets, Email: true}, nil
+		},
+		setPreferencesFn: func(ctx context.Context, userID uint, input service.ReminderPreferenceInput) (model.ReminderPreference, error) {
+			if len(input.Offsets) > 2 {
+				return model.ReminderPreference{}, service.ErrReminderOffsets
+			}
+			if !slices.Equal(input.Offsets, []int{60, 0}) || !input.Email {
+				t.Fatalf("input = %+v", input)
+			}
+			return model.ReminderPreference{UserID: userID, Offsets: input.Offsets, Email: input.Email}, nil
+		},
+	})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(9)) })
+	h.Register(r.Group("/"))
+
+	tests := []struct {
+		method, path, body string
+		want               int
+		contains           string
+	}{
+		{http.MethodGet, "/me/notifications?unread=true&limit=5", "", http.StatusOK, `"unread":1`},
+		{http.MethodGet, "/me/notifications?unread=true&limit=5", "", http.StatusOK, `"limit":5,"nextCursor":null`},
+		{http.MethodGet, "/me/notifications?unread=maybe", "", http.StatusBadRequest, ""},
+		{http.MethodGet, "/me/notifications?cursor=bad", "", http.StatusBadRequest, ""},
+		{http.MethodPost, "/me/notifications/1/read", "", http.StatusOK, `"id":1`},
+		{http.MethodPost, "/me/notifications/2/read", "", http.StatusNotFound, ""},
+		{http.MethodPost, "/me/notifications/read", "", http.StatusOK, `"updated":4`},
+		{http.MethodGet, "/me/reminders", "", http.StatusOK, `"offsets":[1440,0]`},
+		{http.MethodPut, "/me/reminders", `{"offsets":[60,0]}`, http.StatusOK, `"email":true`},
+		{http.MethodPut, "/me/reminders", `{"offsets":[60,0,-60]}`, http.StatusBadRequest, "offsets must be"},
+		{http.MethodPut, "/me/reminders", `{"offsets":"soon"}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want || !strings.Contains(w.Body.String(), tt.contains) {
+			t.Fatalf("%s %s %s = %d %s, want %d with %s", tt.method, tt.path, tt.body, w.Code, w.Body.String(), tt.want, tt.contains)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"project-management/internal/httpx"
+	"project-management/internal/patch"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// patchDocument applies the request body, a JSON Merge Patch or a JSON Patch
+// by Content-Type, to the JSON form of current and decodes the result into
+// dst, a create body whose binding rules are then checked. It answers 415 for
+// another media type, 409 for a patch that does not fit the document and 400
+// for anything else, and returns false.
+func patchDocument(c *gin.Context, current, dst any) bool {
+	mediaType := c.ContentType()
+	if mediaType != patch.MergePatchType && mediaType != patch.JSONPatchType {
+		c.JSON(httpx.StatusFor(httpx.CodeUnsupportedMediaType), httpx.Err(httpx.CodeUnsupportedMediaType, "PATCH takes "+patch.MergePatchType+" or "+patch.JSONPatchType))
+		return false
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return false
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return false
+	}
+	patched, err := patch.Apply(mediaType, doc, body)
+	if errors.Is(err, patch.ErrConflict) {
+		c.JSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "patched document: "+err.Error()))
+		return false
+	}
+	if err := binding.Validator.ValidateStruct(dst); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return false
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,10 +16,17 @@ import (
 	"gorm.io/gorm"
 )
 
-type ProjectHandler struct{ service service.ProjectService }
+type ProjectHandler struct {
+	service       service.ProjectService
+	preconditions Preconditions
+}
 
 func NewProjectHandler(service service.ProjectService) *ProjectHandler {
-	return &ProjectHandler{service: service}
+	return NewProjectHandlerWithPreconditions(service, Preconditions{})
+}
+
+func NewProjectHandlerWithPreconditions(service service.ProjectService, preconditions Preconditions) *ProjectHandler {
+	return &ProjectHandler{service: service, preconditions: preconditions}
 }
 
 type ProjectCreate struct {
@@ -27,6 +35,35 @@ type ProjectCreate struct {
 	Status      model.ProjectStatus `json:"status" binding:"required,oneof=active archived"`
 }
 
+// CopyOptionsBody says what a clone or a template takes from a project.
+// Everything but includeSubtasks and resetStatuses defaults to true.
+type CopyOptionsBody struct {
+	IncludeTasks        *bool `json:"includeTasks"`
+	IncludeSubtasks     bool  `json:"includeSubtasks"`
+	IncludeLabels       *bool `json:"includeLabels"`
+	IncludeDescriptions *bool `json:"includeDescriptions"`
+	ResetStatuses       bool  `json:"resetStatuses"`
+}
+
+func (b CopyOptionsBody) options() service.CopyOptions {
+	enabled := func(v *bool) bool { return v == nil || *v }
+	return service.CopyOptions{
+		Tasks:         enabled(b.IncludeTasks),
+		Subtasks:      b.IncludeSubtasks,
+		Labels:        enabled(b.IncludeLabels),
+		Descriptions:  enabled(b.IncludeDescriptions),
+		ResetStatuses: b.ResetStatuses,
+	}
+}
+
+// ProjectClone is the optional body of POST /projects/:id/clone. startDate
+// (2026-11-02) moves the due dates along with the project's first day.
+type ProjectClone struct {
+	Title     string `json:"title"`
+	StartDate string `json:"startDate"`
+	CopyOptionsBody
+}
+
 type ProjectUpdate struct {
 	Title       *string              `json:"title"`
 	Description *string              `json:"description"`
@@ -38,34 +75,47 @@ func (h *ProjectHandler) Register(r *gin.RouterGroup) {
 	r.POST("/projects", h.Create)
 	r.GET("/projects/:id", h.Get)
 	r.PUT("/projects/:id", h.Update)
+	r.PATCH("/projects/:id", h.Patch)
 	r.DELETE("/projects/:id", h.Delete)
+	r.POST("/projects/:id/archive", h.Archive)
+	r.POST("/projects/:id/unarchive", h.Unarchive)
+	r.POST("/projects/:id/clone", h.Clone)
 	r.GET("/projects/:id/tasks", h.ListProjectTasks)
 	r.POST("/projects/:id/tasks", h.CreateProjectTask)
+	r.GET("/projects/:id/tasks/export", h.ExportTasks)
+	r.POST("/projects/:id/tasks/import", h.ImportTasks)
 }
 
 func (h *ProjectHandler) List(c *gin.Context) {
-	lp := httpx.ParseListParams(c.Query("page"), c.Query("pageSize"), c.Query("sort"))
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	sh, ok := parseShape(c, service.ProjectRelations)
+	if !ok {
+		return
+	}
 	q := strings.TrimSpace(c.Query("q"))
 	status := strings.TrimSpace(c.Query("status"))
-	include := strings.TrimSpace(c.Query("include"))
 
+	userID, _ := currentUserID(c)
 	items, total, err := h.service.List(c.Request.Context(), service.ProjectListFilter{
-		Params:       lp,
-		Query:        q,
-		Status:       status,
-		IncludeTasks: include == "tasks",
+		Params:  lp,
+		UserID:  userID,
+		Query:   q,
+		Status:  status,
+		Include: sh.include,
+
+		IncludeArchived: c.Query("includeArchived") == "true",
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		listError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"page":     lp.Page,
-		"pageSize": lp.PageSize,
-		"items":    items,
-		"isLast":   httpx.IsLast(total, lp),
-	})
+	body := listBody(c, lp, items, total)
+	body["items"] = sh.render(items)
+	c.JSON(http.StatusOK, body)
 }
 
 func (h *ProjectHandler) Create(c *gin.Context) {
@@ -75,11 +125,15 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 		return
 	}
 
-	p, err := h.service.Create(c.Request.Context(), service.ProjectCreateInput{
+	input := service.ProjectCreateInput{
 		Title:       body.Title,
 		Description: body.Description,
 		Status:      body.Status,
-	})
+	}
+	if userID, ok := currentUserID(c); ok {
+		input.OwnerID = &userID
+	}
+	p, err := h.service.Create(c.Request.Context(), input)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
@@ -89,7 +143,11 @@ func (h *ProjectHandler) Create(c *gin.Context) {
 }
 
 func (h *ProjectHandler) Get(c *gin.Context) {
-	p, err := h.service.Get(c.Request.Context(), c.Param("id"), strings.TrimSpace(c.Query("include")) == "tasks")
+	sh, ok := parseShape(c, service.ProjectRelations)
+	if !ok {
+		return
+	}
+	p, err := h.service.Get(c.Request.Context(), c.Param("id"), sh.include)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "project not found"))
@@ -98,35 +156,135 @@ func (h *ProjectHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
-	c.JSON(http.StatusOK, p)
+	setETag(c, p.Version)
+	c.JSON(http.StatusOK, sh.render(p))
 }
 
 func (h *ProjectHandler) Update(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
 	var body ProjectUpdate
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
 		return
 	}
 
+	userID, _ := currentUserID(c)
 	p, err := h.service.Update(c.Request.Context(), c.Param("id"), service.ProjectUpdateInput{
 		Title:       body.Title,
 		Description: body.Description,
 		Status:      body.Status,
+		Version:     version,
+		UserID:      userID,
 	})
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "project not found"))
-			return
-		}
+		h.updateFailed(c, err)
+		return
+	}
+
+	setETag(c, p.Version)
+	c.JSON(http.StatusOK, p)
+}
+
+// Patch applies a merge patch or JSON Patch to the project's fields as they
+// are posted on create. The patched project must pass the create rules and is
+// saved only if the project did not change meanwhile.
+func (h *ProjectHandler) Patch(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
+	current, err := h.service.Get(c.Request.Context(), c.Param("id"), nil)
+	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+	var body ProjectCreate
+	if !patchDocument(c, ProjectCreate{Title: current.Title, Description: current.Description, Status: current.Status}, &body) {
+		return
+	}
+	if version == nil {
+		version = &current.Version
+	}
+
+	userID, _ := currentUserID(c)
+	p, err := h.service.Update(c.Request.Context(), c.Param("id"), service.ProjectUpdateInput{
+		Title:       &body.Title,
+		Description: &body.Description,
+		Status:      &body.Status,
+		Version:     version,
+		UserID:      userID,
+	})
+	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+
+	setETag(c, p.Version)
+	c.JSON(http.StatusOK, p)
+}
+
+func (h *ProjectHandler) updateFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "project not found"))
+	case versionMismatch(c, err):
+	case projectArchived(c, err):
+	default:
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
+
+// Archive makes the project read-only. Archived projects are left out of
+// GET /projects unless ?status=archived or ?includeArchived=true.
+func (h *ProjectHandler) Archive(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	p, err := h.service.Archive(c.Request.Context(), c.Param("id"), userID, version)
+	if err != nil {
+		h.updateFailed(c, err)
 		return
 	}
+	setETag(c, p.Version)
+	c.JSON(http.StatusOK, p)
+}
 
+func (h *ProjectHandler) Unarchive(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
+	p, err := h.service.Unarchive(c.Request.Context(), c.Param("id"), version)
+	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+	setETag(c, p.Version)
 	c.JSON(http.StatusOK, p)
 }
 
 func (h *ProjectHandler) Delete(c *gin.Context) {
-	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), version); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "project not found"))
+			return
+		}
+		if versionMismatch(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
@@ -148,7 +306,14 @@ func (h *ProjectHandler) ListProjectTasks(c *gin.Context) {
 		return
 	}
 
-	lp := httpx.ParseListParams(c.Query("page"), c.Query("pageSize"), c.Query("sort"))
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	sh, ok := parseShape(c, service.TaskRelations)
+	if !ok {
+		return
+	}
 	status := strings.TrimSpace(c.Query("status"))
 	assigneeID := strings.TrimSpace(c.Query("assigneeId"))
 
@@ -156,18 +321,16 @@ func (h *ProjectHandler) ListProjectTasks(c *gin.Context) {
 		Params:     lp,
 		Status:     status,
 		AssigneeID: assigneeID,
+		Include:    sh.include,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		listError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"page":     lp.Page,
-		"pageSize": lp.PageSize,
-		"items":    items,
-		"isLast":   httpx.IsLast(total, lp),
-	})
+	body := listBody(c, lp, items, total)
+	body["items"] = sh.render(items)
+	c.JSON(http.StatusOK, body)
 }
 
 func (h *ProjectHandler) CreateProjectTask(c *gin.Context) {
@@ -192,9 +355,58 @@ func (h *ProjectHandler) CreateProjectTask(c *gin.Context) {
 		DueDate:     body.DueDate,
 	})
 	if err != nil {
+		if projectArchived(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
 
 	c.JSON(http.StatusCreated, t)
 }
+
+// Clone copies the project into a new one owned by the caller.
+func (h *ProjectHandler) Clone(c *gin.Context) {
+	var body ProjectClone
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	start, ok := startDate(c, body.StartDate)
+	if !ok {
+		return
+	}
+
+	input := service.ProjectCloneInput{Title: strings.TrimSpace(body.Title), StartDate: start, Options: body.options()}
+	if userID, ok := currentUserID(c); ok {
+		input.OwnerID = &userID
+	}
+	p, err := h.service.Clone(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "project not found"))
+			return
+		}
+		if errors.Is(err, service.ErrSubtasksUnsupported) {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+// startDate parses an optional date such as 2026-11-02. It answers 400 and
+// returns false when raw is not one.
+func startDate(c *gin.Context, raw string) (*time.Time, bool) {
+	if raw == "" {
+		return nil, true
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "startDate must be a date like 2026-11-02"))
+		return nil, false
+	}
+	return &t, true
+}
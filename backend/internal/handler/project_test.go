@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,9 +22,14 @@ import (
 type mockProjectService struct {
 	listFn       func(ctx context.Context, filter service.ProjectListFilter) ([]model.Project, int64, error)
 	createFn     func(ctx context.Context, input service.ProjectCreateInput) (model.Project, error)
-	getFn        func(ctx context.Context, id string, includeTasks bool) (model.Project, error)
+	getFn        func(ctx context.Context, id string, include []string) (model.Project, error)
 	updateFn     func(ctx context.Context, id string, input service.ProjectUpdateInput) (model.Project, error)
-	deleteFn     func(ctx context.Context, id string) error
+	archiveFn    func(ctx context.Context, id string, userID uint, version *uint) (model.Project, error)
+	unarchiveFn  func(ctx context.Context, id string, version *uint) (model.Project, error)
+	cloneFn      func(ctx context.Context, id string, input service.ProjectCloneInput) (model.Project, error)
+	exportFn     func(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter, each func([]model.Task) error) error
+	importFn     func(ctx context.Context, projectID uint, inputs []service.ProjectTaskCreateInput, dryRun bool) ([]model.Task, error)
+	deleteFn     func(ctx context.Context, id string, version *uint) error
 	listTasksFn  func(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter) ([]model.Task, int64, error)
 	createTaskFn func(ctx context.Context, input service.ProjectTaskCreateInput) (model.Task, error)
 }
@@ -34,13 +40,30 @@ func (m *mockProjectService) List(ctx context.Context, filter service.ProjectLis
 func (m *mockProjectService) Create(ctx context.Context, input service.ProjectCreateInput) (model.Project, error) {
 	return m.createFn(ctx, input)
 }
-func (m *mockProjectService) Get(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
-	return m.getFn(ctx, id, includeTasks)
+func (m *mockProjectService) Get(ctx context.Context, id string, include []string) (model.Project, error) {
+	return m.getFn(ctx, id, include)
 }
 func (m *mockProjectService) Update(ctx context.Context, id string, input service.ProjectUpdateInput) (model.Project, error) {
 	return m.updateFn(ctx, id, input)
 }
-func (m *mockProjectService) Delete(ctx context.Context, id string) error { return m.deleteFn(ctx, id) }
+func (m *mockProjectService) Archive(ctx context.Context, id string, userID uint, version *uint) (model.Project, error) {
+	return m.archiveFn(ctx, id, userID, version)
+}
+func (m *mockProjectService) Unarchive(ctx context.Context, id string, version *uint) (model.Project, error) {
+	return m.unarchiveFn(ctx, id, version)
+}
+func (m *mockProjectService) Clone(ctx context.Context, id string, input service.ProjectCloneInput) (model.Project, error) {
+	return m.cloneFn(ctx, id, input)
+}
+func (m *mockProjectService) ExportTasks(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter, each func([]model.Task) error) error {
+	return m.exportFn(ctx, projectID, filter, each)
+}
+func (m *mockProjectService) ImportTasks(ctx context.Context, projectID uint, inputs []service.ProjectTaskCreateInput, dryRun bool) ([]model.Task, error) {
+	return m.importFn(ctx, projectID, inputs, dryRun)
+}
+func (m *mockProjectService) Delete(ctx context.Context, id string, version *uint) error {
+	return m.deleteFn(ctx, id, version)
+}
 func (m *mockProjectService) ListTasks(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter) ([]model.Task, int64, error) {
 	return m.listTasksFn(ctx, projectID, filter)
 }
@@ -51,7 +74,7 @@ func (m *mockProjectService) CreateTask(ctx context.Context, input service.Proje
 func TestProjectHandlerList(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	h := NewProjectHandler(&mockProjectService{listFn: func(ctx context.Context, filter service.ProjectListFilter) ([]model.Project, int64, error) {
-		if filter.Params.Page != 2 || filter.Params.PageSize != 5 || len(filter.Params.Sort) != 1 || filter.Params.Sort[0].Field != "title" || !filter.IncludeTasks {
+		if filter.Params.Page != 2 || filter.Params.PageSize != 5 || len(filter.Params.Sort) != 1 || filter.Params.Sort[0].Field != "title" || strings.Join(filter.Include, ",") != "tasks" {
 			t.Fatalf("unexpected filter: %+v", filter)
 		}
 		return []model.Project{{ID: 1, Title: "API", Status: model.ProjectActive}}, 11, nil
@@ -80,6 +103,54 @@ func TestProjectHandlerList(t *testing.T) {
 	}
 }
 
+func TestProjectHandlerListTotalsAndLinks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewProjectHandler(&mockProjectService{listFn: func(ctx context.Context, filter service.ProjectListFilter) ([]model.Project, int64, error) {
+		if filter.Params.SkipCount {
+			return []model.Project{{ID: 1}}, -1, nil
+		}
+		return []model.Project{{ID: 1}}, 11, nil
+	}})
+	r := gin.New()
+	r.GET("/api/projects", h.List)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/projects?page=2&pageSize=5&sort=-title", nil))
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp["total"] != float64(11) || resp["totalPages"] != float64(3) || resp["isLast"] != false {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "11" {
+		t.Fatalf("X-Total-Count = %q", got)
+	}
+	wantLink := `</api/projects?page=1&pageSize=5&sort=-title>; rel="first", ` +
+		`</api/projects?page=1&pageSize=5&sort=-title>; rel="prev", ` +
+		`</api/projects?page=3&pageSize=5&sort=-title>; rel="next", ` +
+		`</api/projects?page=3&pageSize=5&sort=-title>; rel="last"`
+	if got := w.Header().Get("Link"); got != wantLink {
+		t.Fatalf("Link = %s\nwant   %s", got, wantLink)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/projects?page=2&pageSize=5&count=false", nil))
+	resp = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp["total"] != nil || resp["totalPages"] != nil || resp["isLast"] != true {
+		t.Fatalf("unexpected response without count: %v", resp)
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "" {
+		t.Fatalf("X-Total-Count = %q without count", got)
+	}
+	if got := w.Header().Get("Link"); strings.Contains(got, `rel="next"`) || strings.Contains(got, `rel="last"`) {
+		t.Fatalf("Link = %s without count", got)
+	}
+}
+
 func TestProjectHandlerCreate(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -100,13 +171,13 @@ func TestProjectHandlerCreate(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		h := NewProjectHandler(&mockProjectService{createFn: func(ctx context.Context, input service.ProjectCreateInput) (model.Project, error) {
-			if input.Title != "Project X" || input.Status != model.ProjectActive {
+			if input.Title != "Project X" || input.Status != model.ProjectActive || input.OwnerID == nil || *input.OwnerID != 9 {
 				t.Fatalf("unexpected input: %+v", input)
 			}
 			return model.Project{ID: 10, Title: input.Title, Status: input.Status}, nil
 		}})
 		r := gin.New()
-		r.POST("/projects", h.Create)
+		r.POST("/projects", func(c *gin.Context) { c.Set("userID", uint(9)) }, h.Create)
 
 		w := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/projects", bytes.NewBufferString(`{"title":"Project X","description":"desc","status":"active"}`))
@@ -121,7 +192,7 @@ func TestProjectHandlerCreate(t *testing.T) {
 
 func TestProjectHandlerGetNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+	h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
 		return model.Project{}, gorm.ErrRecordNotFound
 	}})
 	r := gin.New()
@@ -137,9 +208,9 @@ func TestProjectHandlerGetNotFound(t *testing.T) {
 
 func TestProjectHandlerGetSuccess(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
-		if id != "12" || !includeTasks {
-			t.Fatalf("unexpected get: id=%s includeTasks=%v", id, includeTasks)
+	h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
+		if id != "12" || strings.Join(include, ",") != "tasks" {
+			t.Fatalf("unexpected get: id=%s include=%v", id, include)
 		}
 		return model.Project{ID: 12, Title: "Roadmap", Status: model.ProjectActive}, nil
 	}})
@@ -154,9 +225,49 @@ func TestProjectHandlerGetSuccess(t *testing.T) {
 	}
 }
 
+func TestProjectHandlerGetIncludeAndFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	assigneeID := uint(5)
+	h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
+		if strings.Join(include, ",") != "tasks,tasks.comments,tasks.assignee" {
+			t.Fatalf("include = %v", include)
+		}
+		return model.Project{ID: 12, Title: "Roadmap", Status: model.ProjectActive, Tasks: []model.Task{{
+			ID:         3,
+			ProjectID:  12,
+			Title:      "Ship",
+			Status:     model.TaskTodo,
+			AssigneeID: &assigneeID,
+			Assignee:   &model.User{ID: 5, Name: "Ann", Email: "ann@example.com"},
+			Comments:   []model.Comment{{ID: 9, TaskID: 3, Author: "bob", Text: "ok"}},
+			Labels:     []model.Label{{ID: 1, Name: "ui"}},
+		}}}, nil
+	}})
+	r := gin.New()
+	r.GET("/projects/:id", h.Get)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects/12?include=tasks.comments,tasks.assignee&fields[project]=title&fields[task]=title,status&fields[comment]=text&fields[user]=name", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	want := `{"id":12,"tasks":[{"assignee":{"id":5,"name":"Ann"},"comments":[{"id":9,"text":"ok"}],"id":3,"status":"todo","title":"Ship"}],"title":"Roadmap"}`
+	if got := w.Body.String(); got != want {
+		t.Fatalf("body = %s\nwant   %s", got, want)
+	}
+
+	for _, query := range []string{"include=tasks.comments.author", "include=labels", "fields[widget]=id", "fields[task]=secret"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects/12?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
 func TestProjectHandlerUpdateBadRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+	h := NewProjectHandler(&mockProjectService{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
 		return model.Project{}, gorm.ErrRecordNotFound
 	}})
 	r := gin.New()
@@ -332,7 +443,7 @@ func TestProjectHandlerCreateProjectTaskInvalidProjectID(t *testing.T) {
 
 func TestProjectHandlerDeleteInternalError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewProjectHandler(&mockProjectService{deleteFn: func(ctx context.Context, id string) error {
+	h := NewProjectHandler(&mockProjectService{deleteFn: func(ctx context.Context, id string, version *uint) error {
 		return errors.New("delete failed")
 	}})
 	r := gin.New()
@@ -355,7 +466,7 @@ func TestProjectHandlerDeleteInternalError(t *testing.T) {
 
 func TestProjectHandlerDeleteNoContent(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewProjectHandler(&mockProjectService{deleteFn: func(ctx context.Context, id string) error {
+	h := NewProjectHandler(&mockProjectService{deleteFn: func(ctx context.Context, id string, version *uint) error {
 		return nil
 	}})
 	r := gin.New()
@@ -366,3 +477,160 @@ func TestProjectHandlerDeleteNoContent(t *testing.T) {
 		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
 	}
 }
+
+func TestProjectHandlerArchive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	archivedAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	h := NewProjectHandler(&mockProjectService{
+		archiveFn: func(ctx context.Context, id string, userID uint, version *uint) (model.Project, error) {
+			if id == "9" {
+				return model.Project{}, gorm.ErrRecordNotFound
+			}
+			if userID != 4 || version == nil || *version != 2 {
+				t.Fatalf("userID = %d, version = %v", userID, version)
+			}
+			return model.Project{ID: 1, Status: model.ProjectArchived, ArchivedAt: &archivedAt, ArchivedBy: &userID, Version: 3}, nil
+		},
+		unarchiveFn: func(ctx context.Context, id string, version *uint) (model.Project, error) {
+			return model.Project{ID: 1, Status: model.ProjectActive, Version: 4}, nil
+		},
+	})
+	r := gin.New()
+	r.POST("/projects/:id/archive", func(c *gin.Context) { c.Set("userID", uint(4)) }, h.Archive)
+	r.POST("/projects/:id/unarchive", h.Unarchive)
+	r.POST("/anonymous/:id/archive", h.Archive)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/projects/1/archive", nil)
+	req.Header.Set("If-Match", `"2"`)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("archive status = %d, etag = %q", w.Code, w.Header().Get("ETag"))
+	}
+	var p model.Project
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if p.Status != model.ProjectArchived || p.ArchivedBy == nil || *p.ArchivedBy != 4 || p.ArchivedAt == nil {
+		t.Fatalf("project = %+v", p)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/projects/1/unarchive", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"active"`) || strings.Contains(w.Body.String(), "archivedAt") {
+		t.Fatalf("unarchive status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/projects/9/archive":  http.StatusNotFound,
+		"/anonymous/1/archive": http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		if w.Code != want {
+			t.Fatalf("POST %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestProjectHandlerArchivedWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewProjectHandler(&mockProjectService{
+		updateFn: func(ctx context.Context, id string, input service.ProjectUpdateInput) (model.Project, error) {
+			return model.Project{}, service.ErrProjectArchived
+		},
+		createTaskFn: func(ctx context.Context, input service.ProjectTaskCreateInput) (model.Task, error) {
+			return model.Task{}, service.ErrProjectArchived
+		},
+	})
+	r := gin.New()
+	r.PUT("/projects/:id", h.Update)
+	r.POST("/projects/:id/tasks", h.CreateProjectTask)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/projects/1", bytes.NewBufferString(`{"title":"New"}`)),
+		httptest.NewRequest(http.MethodPost, "/projects/1/tasks", bytes.NewBufferString(`{"title":"Task","status":"todo"}`)),
+	} {
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var apiErr httpx.APIError
+		if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if w.Code != http.StatusConflict || apiErr.Code != httpx.CodeProjectArchived {
+			t.Fatalf("%s %s status = %d, body = %s", req.Method, req.URL, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestProjectHandlerListIncludeArchived(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got service.ProjectListFilter
+	h := NewProjectHandler(&mockProjectService{listFn: func(ctx context.Context, filter service.ProjectListFilter) ([]model.Project, int64, error) {
+		got = filter
+		return nil, 0, nil
+	}})
+	r := gin.New()
+	r.GET("/projects", h.List)
+
+	for query, want := range map[string]bool{"": false, "?includeArchived=true": true} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects"+query, nil))
+		if w.Code != http.StatusOK || got.IncludeArchived != want {
+			t.Fatalf("GET /projects%s status = %d, includeArchived = %v", query, w.Code, got.IncludeArchived)
+		}
+	}
+}
+
+func TestProjectHandlerClone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got service.ProjectCloneInput
+	h := NewProjectHandler(&mockProjectService{cloneFn: func(ctx context.Context, id string, input service.ProjectCloneInput) (model.Project, error) {
+		if id == "404" {
+			return model.Project{}, gorm.ErrRecordNotFound
+		}
+		if input.Options.Subtasks {
+			return model.Project{}, service.ErrSubtasksUnsupported
+		}
+		got = input
+		return model.Project{ID: 2, Title: input.Title, Status: model.ProjectActive}, nil
+	}})
+	r := gin.New()
+	r.POST("/projects/:id/clone", func(c *gin.Context) { c.Set("userID", uint(9)) }, h.Clone)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/projects/1/clone", nil))
+	all := service.CopyOptions{Tasks: true, Labels: true, Descriptions: true}
+	if w.Code != http.StatusCreated || got.OwnerID == nil || *got.OwnerID != 9 || got.Options != all || got.StartDate != nil {
+		t.Fatalf("clone without body status = %d, input = %+v", w.Code, got)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/projects/1/clone", bytes.NewBufferString(`{"title":" ACME ","startDate":"2026-11-02","includeTasks":false,"resetStatuses":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	want := service.CopyOptions{Labels: true, Descriptions: true, ResetStatuses: true}
+	if w.Code != http.StatusCreated || got.Title != "ACME" || got.StartDate == nil || !got.StartDate.Equal(time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)) || got.Options != want {
+		t.Fatalf("status = %d, input = %+v", w.Code, got)
+	}
+
+	for path, body := range map[string]string{
+		"/projects/1/clone":   `{"startDate":"soon"}`,
+		"/projects/2/clone":   `{"title":`,
+		"/projects/3/clone":   `{"includeSubtasks":true}`,
+		"/projects/404/clone": "",
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		want := http.StatusBadRequest
+		if body == "" {
+			want = http.StatusNotFound
+		}
+		if w.Code != want {
+			t.Fatalf("POST %s %s status = %d, want %d", path, body, w.Code, want)
+		}
+	}
+}
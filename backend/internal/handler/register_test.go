@@ -2,9 +2,12 @@ package handler
 
 import (
 	"context"
+	"io"
+	"net/url"
 	"sort"
 	"testing"
 
+	"project-management/internal/httpx"
 	"project-management/internal/model"
 	"project-management/internal/service"
 
@@ -29,13 +32,30 @@ func (routeProjectService) List(ctx context.Context, filter service.ProjectListF
 func (routeProjectService) Create(ctx context.Context, input service.ProjectCreateInput) (model.Project, error) {
 	panic("not used")
 }
-func (routeProjectService) Get(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+func (routeProjectService) Get(ctx context.Context, id string, include []string) (model.Project, error) {
 	panic("not used")
 }
 func (routeProjectService) Update(ctx context.Context, id string, input service.ProjectUpdateInput) (model.Project, error) {
 	panic("not used")
 }
-func (routeProjectService) Delete(ctx context.Context, id string) error { panic("not used") }
+func (routeProjectService) Archive(ctx context.Context, id string, userID uint, version *uint) (model.Project, error) {
+	panic("not used")
+}
+func (routeProjectService) Unarchive(ctx context.Context, id string, version *uint) (model.Project, error) {
+	panic("not used")
+}
+func (routeProjectService) Clone(ctx context.Context, id string, input service.ProjectCloneInput) (model.Project, error) {
+	panic("not used")
+}
+func (routeProjectService) ExportTasks(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter, each func([]model.Task) error) error {
+	panic("not used")
+}
+func (routeProjectService) ImportTasks(ctx context.Context, projectID uint, inputs []service.ProjectTaskCreateInput, dryRun bool) ([]model.Task, error) {
+	panic("not used")
+}
+func (routeProjectService) Delete(ctx context.Context, id string, version *uint) error {
+	panic("not used")
+}
 func (routeProjectService) ListTasks(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter) ([]model.Task, int64, error) {
 	panic("not used")
 }
@@ -51,19 +71,30 @@ func (routeTaskService) List(ctx context.Context, filter service.TaskListFilter)
 func (routeTaskService) Create(ctx context.Context, input service.TaskCreateInput) (model.Task, error) {
 	panic("not used")
 }
-func (routeTaskService) Get(ctx context.Context, id string, includeComments bool) (model.Task, error) {
+func (routeTaskService) Get(ctx context.Context, id string, include []string) (model.Task, error) {
 	panic("not used")
 }
 func (routeTaskService) Update(ctx context.Context, id string, input service.TaskUpdateInput) (model.Task, error) {
 	panic("not used")
 }
-func (routeTaskService) Delete(ctx context.Context, id string) error { panic("not used") }
+func (routeTaskService) Delete(ctx context.Context, id string, version *uint) error {
+	panic("not used")
+}
 func (routeTaskService) ListComments(ctx context.Context, taskID string, filter service.TaskCommentListFilter) ([]model.Comment, int64, error) {
 	panic("not used")
 }
 func (routeTaskService) CreateComment(ctx context.Context, input service.TaskCommentCreateInput) (model.Comment, error) {
 	panic("not used")
 }
+func (routeTaskService) Bulk(ctx context.Context, input service.TaskBulkInput) (service.TaskBulkReport, error) {
+	panic("not used")
+}
+func (routeTaskService) Watch(ctx context.Context, id string, userID uint) error {
+	panic("not used")
+}
+func (routeTaskService) Unwatch(ctx context.Context, id string, userID uint) error {
+	panic("not used")
+}
 
 type routeCommentService struct{}
 
@@ -79,7 +110,193 @@ func (routeCommentService) Get(ctx context.Context, id string) (model.Comment, e
 func (routeCommentService) Update(ctx context.Context, id string, input service.CommentUpdateInput) (model.Comment, error) {
 	panic("not used")
 }
-func (routeCommentService) Delete(ctx context.Context, id string) error { panic("not used") }
+func (routeCommentService) Delete(ctx context.Context, id string, version *uint) error {
+	panic("not used")
+}
+func (routeCommentService) ListReplies(ctx context.Context, id string, params httpx.ListParams) ([]model.Comment, int64, error) {
+	panic("not used")
+}
+func (routeCommentService) AddReaction(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error) {
+	panic("not used")
+}
+func (routeCommentService) RemoveReaction(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error) {
+	panic("not used")
+}
+func (routeCommentService) ListRevisions(ctx context.Context, id string, params httpx.ListParams) ([]model.CommentRevision, int64, error) {
+	panic("not used")
+}
+func (routeCommentService) Purge(ctx context.Context, id string) error { panic("not used") }
+
+type routeAttachmentService struct{}
+
+func (routeAttachmentService) Upload(ctx context.Context, input service.AttachmentUploadInput) (model.Attachment, error) {
+	panic("not used")
+}
+func (routeAttachmentService) ListForTask(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	panic("not used")
+}
+func (routeAttachmentService) ListForComment(ctx context.Context, commentID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	panic("not used")
+}
+func (routeAttachmentService) Get(ctx context.Context, id string) (model.Attachment, error) {
+	panic("not used")
+}
+func (routeAttachmentService) Open(ctx context.Context, id string, thumbnail bool) (model.Attachment, io.ReadCloser, error) {
+	panic("not used")
+}
+func (routeAttachmentService) Delete(ctx context.Context, id string) error { panic("not used") }
+func (routeAttachmentService) CleanupOrphans(ctx context.Context) error    { panic("not used") }
+func (routeAttachmentService) MaxBytes() int64                             { panic("not used") }
+
+type routeSearchService struct{}
+
+func (routeSearchService) Search(ctx context.Context, filter service.SearchFilter) ([]model.SearchHit, int64, error) {
+	panic("not used")
+}
+
+type routeCalendarService struct{}
+
+func (routeCalendarService) Rotate(ctx context.Context, userID uint) (string, error) {
+	panic("not used")
+}
+func (routeCalendarService) Revoke(ctx context.Context, userID uint) error { panic("not used") }
+func (routeCalendarService) Feed(ctx context.Context, token string, projectID *uint) ([]model.Task, error) {
+	panic("not used")
+}
+
+type routeNotificationService struct{}
+
+func (routeNotificationService) List(ctx context.Context, filter service.NotificationListFilter) ([]model.Notification, int64, int64, error) {
+	panic("not used")
+}
+func (routeNotificationService) Read(ctx context.Context, id string, userID uint) (model.Notification, error) {
+	panic("not used")
+}
+func (routeNotificationService) ReadAll(ctx context.Context, userID uint) (int64, error) {
+	panic("not used")
+}
+
+type routeTimeService struct{}
+
+func (routeTimeService) List(ctx context.Context, taskID string, params httpx.ListParams) ([]model.TimeEntry, int64, int64, error) {
+	panic("not used")
+}
+func (routeTimeService) Create(ctx context.Context, taskID string, input service.TimeEntryInput) (model.TimeEntry, error) {
+	panic("not used")
+}
+func (routeTimeService) Delete(ctx context.Context, id string, userID uint) error { panic("not used") }
+func (routeTimeService) Running(ctx context.Context, userID uint) (model.TimeEntry, error) {
+	panic("not used")
+}
+func (routeTimeService) Start(ctx context.Context, taskID string, userID uint, note string) (model.TimeEntry, error) {
+	panic("not used")
+}
+func (routeTimeService) Stop(ctx context.Context, userID uint) (model.TimeEntry, error) {
+	panic("not used")
+}
+func (routeTimeService) ProjectTime(ctx context.Context, projectID string) (model.ProjectTime, error) {
+	panic("not used")
+}
+func (routeTimeService) Timesheet(ctx context.Context, filter service.TimesheetFilter) (model.Timesheet, error) {
+	panic("not used")
+}
+
+type routeSprintService struct{}
+
+func (routeSprintService) List(ctx context.Context, projectID string, state string, params httpx.ListParams) ([]model.Sprint, int64, error) {
+	panic("not used")
+}
+func (routeSprintService) Create(ctx context.Context, projectID string, input service.SprintInput) (model.Sprint, error) {
+	panic("not used")
+}
+func (routeSprintService) Get(ctx context.Context, id string) (model.Sprint, error) {
+	panic("not used")
+}
+func (routeSprintService) Update(ctx context.Context, id string, input service.SprintInput) (model.Sprint, error) {
+	panic("not used")
+}
+func (routeSprintService) Delete(ctx context.Context, id string) error { panic("not used") }
+func (routeSprintService) Start(ctx context.Context, id string) (model.Sprint, error) {
+	panic("not used")
+}
+func (routeSprintService) Close(ctx context.Context, id string, input service.SprintCloseInput) (model.Sprint, error) {
+	panic("not used")
+}
+
+type routeMilestoneService struct{}
+
+func (routeMilestoneService) List(ctx context.Context, projectID string, params httpx.ListParams) ([]model.Milestone, int64, error) {
+	panic("not used")
+}
+func (routeMilestoneService) Create(ctx context.Context, projectID string, input service.MilestoneInput) (model.Milestone, error) {
+	panic("not used")
+}
+func (routeMilestoneService) Get(ctx context.Context, id string) (model.Milestone, error) {
+	panic("not used")
+}
+func (routeMilestoneService) Update(ctx context.Context, id string, input service.MilestoneInput) (model.Milestone, error) {
+	panic("not used")
+}
+func (routeMilestoneService) Delete(ctx context.Context, id string) error { panic("not used") }
+
+type routeViewService struct{}
+
+func (routeViewService) Create(ctx context.Context, ownerID uint, input service.ViewInput) (model.SavedView, error) {
+	panic("not used")
+}
+func (routeViewService) List(ctx context.Context, userID uint, params httpx.ListParams) ([]model.SavedView, int64, error) {
+	panic("not used")
+}
+func (routeViewService) Get(ctx context.Context, id string, userID uint) (model.SavedView, error) {
+	panic("not used")
+}
+func (routeViewService) Update(ctx context.Context, id string, userID uint, input service.ViewInput) (model.SavedView, error) {
+	panic("not used")
+}
+func (routeViewService) Delete(ctx context.Context, id string, userID uint) error { panic("not used") }
+func (routeViewService) Tasks(ctx context.Context, id string, userID uint, query url.Values) ([]model.Task, int64, httpx.ListParams, model.SavedView, error) {
+	panic("not used")
+}
+
+type routeTrashService struct{}
+
+func (routeTrashService) List(ctx context.Context, filter service.TrashFilter) ([]model.TrashItem, int64, error) {
+	panic("not used")
+}
+func (routeTrashService) Restore(ctx context.Context, kind, id string, userID uint) error {
+	panic("not used")
+}
+func (routeTrashService) Purge(ctx context.Context) (int64, error) { panic("not used") }
+
+type routeTemplateService struct{}
+
+func (routeTemplateService) List(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error) {
+	panic("not used")
+}
+func (routeTemplateService) Create(ctx context.Context, input service.TemplateCreateInput) (model.ProjectTemplate, error) {
+	panic("not used")
+}
+func (routeTemplateService) Get(ctx context.Context, id string) (model.ProjectTemplate, error) {
+	panic("not used")
+}
+func (routeTemplateService) Delete(ctx context.Context, id string, userID uint) error {
+	panic("not used")
+}
+func (routeTemplateService) Use(ctx context.Context, id string, input service.TemplateUseInput) (model.Project, error) {
+	panic("not used")
+}
+
+type routeBundleService struct{}
+
+func (routeBundleService) Export(ctx context.Context, id string) (model.ProjectBundle, error) {
+	panic("not used")
+}
+func (routeBundleService) WriteZip(ctx context.Context, w io.Writer, bundle model.ProjectBundle) error {
+	panic("not used")
+}
+func (routeBundleService) Import(ctx context.Context, input service.BundleImportInput) (model.BundleImportReport, error) {
+	panic("not used")
+}
 
 type routeUserService struct{}
 
@@ -96,6 +313,20 @@ func TestHandlerRegisterRoutes(t *testing.T) {
 	NewProjectHandler(routeProjectService{}).Register(api)
 	NewTaskHandler(routeTaskService{}).Register(api)
 	NewCommentHandler(routeCommentService{}).Register(api)
+	NewCommentHandler(routeCommentService{}).RegisterAdmin(api.Group("/admin"))
+	NewAttachmentHandler(routeAttachmentService{}).Register(api)
+	NewAttachmentHandler(routeAttachmentService{}).RegisterPublic(api)
+	NewSearchHandler(routeSearchService{}).Register(api)
+	NewViewHandler(routeViewService{}).Register(api)
+	NewTrashHandler(routeTrashService{}).Register(api)
+	NewTemplateHandler(routeTemplateService{}).Register(api)
+	NewBundleHandler(routeBundleService{}).Register(api)
+	NewCalendarHandler(routeCalendarService{}).Register(api)
+	NewCalendarHandler(routeCalendarService{}).RegisterPublic(api)
+	NewNotificationHandler(routeNotificationService{}, nil).Register(api)
+	NewTimeHandler(routeTimeService{}).Register(api)
+	NewSprintHandler(routeSprintService{}).Register(api)
+	NewMilestoneHandler(routeMilestoneService{}).Register(api)
 
 	got := make([]string, 0, len(r.Routes()))
 	for _, route := range r.Routes() {
@@ -104,29 +335,99 @@ func TestHandlerRegisterRoutes(t *testing.T) {
 	sort.Strings(got)
 
 	want := []string{
+		"DELETE /api/admin/comments/:id",
+		"DELETE /api/attachments/:id",
+		"GET /api/attachments/:id",
+		"GET /api/attachments/:id/download",
+		"GET /api/attachments/:id/thumbnail",
+		"GET /api/comments/:id/attachments",
+		"GET /api/tasks/:id/attachments",
+		"POST /api/comments/:id/attachments",
+		"POST /api/tasks/:id/attachments",
 		"DELETE /api/comments/:id",
+		"DELETE /api/comments/:id/reactions/:emoji",
 		"DELETE /api/projects/:id",
 		"DELETE /api/tasks/:id",
 		"GET /api/auth/me",
 		"GET /api/comments",
 		"GET /api/comments/:id",
+		"GET /api/comments/:id/replies",
+		"GET /api/comments/:id/revisions",
 		"GET /api/projects",
 		"GET /api/projects/:id",
 		"GET /api/projects/:id/tasks",
 		"GET /api/tasks",
 		"GET /api/tasks/:id",
 		"GET /api/tasks/:id/comments",
+		"GET /api/search",
+		"GET /api/trash",
+		"POST /api/trash/:type/:id/restore",
 		"GET /api/users",
+		"GET /api/me/views",
+		"POST /api/me/views",
+		"GET /api/views/:id",
+		"PUT /api/views/:id",
+		"DELETE /api/views/:id",
+		"GET /api/views/:id/tasks",
 		"POST /api/auth/login",
 		"POST /api/auth/register",
 		"POST /api/comments",
+		"POST /api/comments/:id/reactions/:emoji",
 		"POST /api/projects",
+		"POST /api/projects/:id/archive",
+		"POST /api/projects/:id/clone",
+		"GET /api/projects/:id/export",
+		"POST /api/projects/import",
+		"POST /api/projects/import/jira",
+		"POST /api/projects/import/trello",
+		"GET /api/project-templates",
+		"POST /api/project-templates",
+		"GET /api/project-templates/:id",
+		"DELETE /api/project-templates/:id",
+		"POST /api/project-templates/:id/projects",
 		"POST /api/projects/:id/tasks",
+		"GET /api/projects/:id/tasks/export",
+		"POST /api/projects/:id/tasks/import",
+		"POST /api/projects/:id/unarchive",
 		"POST /api/tasks",
 		"POST /api/tasks/:id/comments",
+		"POST /api/tasks/bulk",
 		"PUT /api/comments/:id",
 		"PUT /api/projects/:id",
 		"PUT /api/tasks/:id",
+		"PATCH /api/comments/:id",
+		"PATCH /api/projects/:id",
+		"PATCH /api/tasks/:id",
+		"POST /api/tasks/:id/watch",
+		"DELETE /api/tasks/:id/watch",
+		"POST /api/me/calendar",
+		"DELETE /api/me/calendar",
+		"GET /api/calendar/:token",
+		"GET /api/me/notifications",
+		"POST /api/me/notifications/read",
+		"POST /api/me/notifications/:id/read",
+		"GET /api/me/reminders",
+		"PUT /api/me/reminders",
+		"GET /api/tasks/:id/time-entries",
+		"POST /api/tasks/:id/time-entries",
+		"DELETE /api/time-entries/:id",
+		"POST /api/tasks/:id/timer",
+		"GET /api/me/timer",
+		"POST /api/me/timer/stop",
+		"GET /api/projects/:id/time",
+		"GET /api/reports/timesheet",
+		"GET /api/projects/:id/sprints",
+		"POST /api/projects/:id/sprints",
+		"GET /api/sprints/:id",
+		"PUT /api/sprints/:id",
+		"DELETE /api/sprints/:id",
+		"POST /api/sprints/:id/start",
+		"POST /api/sprints/:id/close",
+		"GET /api/projects/:id/milestones",
+		"POST /api/projects/:id/milestones",
+		"GET /api/milestones/:id",
+		"PUT /api/milestones/:id",
+		"DELETE /api/milestones/:id",
 	}
 	sort.Strings(want)
 
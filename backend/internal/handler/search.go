@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct{ service service.SearchService }
+
+func NewSearchHandler(service service.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+func (h *SearchHandler) Register(r *gin.RouterGroup) {
+	r.GET("/search", h.Search)
+}
+
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	var types []string
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	items, total, err := h.service.Search(c.Request.Context(), service.SearchFilter{
+		Params: lp,
+		Query:  c.Query("q"),
+		Types:  types,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrSearchQuery) || errors.Is(err, service.ErrSearchType) {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+		listError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, listBody(c, lp, items, total))
+}
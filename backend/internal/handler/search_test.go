@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mockSearchService struct {
+	searchFn func(ctx context.Context, filter service.SearchFilter) ([]model.SearchHit, int64, error)
+}
+
+func (m *mockSearchService) Search(ctx context.Context, filter service.SearchFilter) ([]model.SearchHit, int64, error) {
+	return m.searchFn(ctx, filter)
+}
+
+func TestSearchHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewSearchHandler(&mockSearchService{searchFn: func(ctx context.Context, filter service.SearchFilter) ([]model.SearchHit, int64, error) {
+		switch filter.Query {
+		case "":
+			return nil, 0, service.ErrSearchQuery
+		case "boom":
+			return nil, 0, errors.New("db down")
+		}
+		if filter.UserID != 9 || len(filter.Types) != 2 || filter.Types[1] != "comment" || filter.Params.PageSize != 5 {
+			t.Fatalf("filter = %+v", filter)
+		}
+		return []model.SearchHit{{Type: "task", ID: 3, ProjectID: 1, Title: "Deploy"}}, 1, nil
+	}})
+	r := gin.New()
+	r.GET("/search", func(c *gin.Context) { c.Set("userID", uint(9)) }, h.Search)
+	r.GET("/anonymous", h.Search)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search?q=dep&type=task,+comment&pageSize=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var body struct {
+		Items  []model.SearchHit `json:"items"`
+		IsLast bool              `json:"isLast"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(body.Items) != 1 || body.Items[0].Type != "task" || !body.IsLast {
+		t.Fatalf("body = %+v", body)
+	}
+
+	for path, want := range map[string]int{
+		"/search":        http.StatusBadRequest,
+		"/search?q=boom": http.StatusInternalServerError,
+		"/anonymous?q=x": http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
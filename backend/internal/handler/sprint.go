@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SprintHandler struct{ service service.SprintService }
+
+func NewSprintHandler(service service.SprintService) *SprintHandler {
+	return &SprintHandler{service: service}
+}
+
+// SprintBody creates or updates a sprint; only the days of the dates count.
+type SprintBody struct {
+	Name      string    `json:"name" binding:"required"`
+	Goal      string    `json:"goal"`
+	StartDate time.Time `json:"startDate" binding:"required"`
+	EndDate   time.Time `json:"endDate" binding:"required"`
+}
+
+// SprintClose says where the unfinished tasks go: to sprintId or, with
+// backlog, to the backlog. Without either they go to the next planned sprint.
+type SprintClose struct {
+	SprintID *uint `json:"sprintId"`
+	Backlog  bool  `json:"backlog"`
+}
+
+func (h *SprintHandler) Register(r *gin.RouterGroup) {
+	r.GET("/projects/:id/sprints", h.List)
+	r.POST("/projects/:id/sprints", h.Create)
+	r.GET("/sprints/:id", h.Get)
+	r.PUT("/sprints/:id", h.Update)
+	r.DELETE("/sprints/:id", h.Delete)
+	r.POST("/sprints/:id/start", h.Start)
+	r.POST("/sprints/:id/close", h.Close)
+}
+
+// List returns the project's sprints by start date; ?state= narrows them.
+func (h *SprintHandler) List(c *gin.Context) {
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	sprints, total, err := h.service.List(c.Request.Context(), c.Param("id"), c.Query("state"), lp)
+	if err != nil {
+		sprintError(c, err, "project not found")
+		return
+	}
+	c.JSON(http.StatusOK, listBody(c, lp, sprints, total))
+}
+
+func (h *SprintHandler) Create(c *gin.Context) {
+	var body SprintBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	sprint, err := h.service.Create(c.Request.Context(), c.Param("id"), sprintInput(body))
+	if err != nil {
+		sprintError(c, err, "project not found")
+		return
+	}
+	c.JSON(http.StatusCreated, sprint)
+}
+
+func (h *SprintHandler) Get(c *gin.Context) {
+	sprint, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		sprintError(c, err, "sprint not found")
+		return
+	}
+	c.JSON(http.StatusOK, sprint)
+}
+
+func (h *SprintHandler) Update(c *gin.Context) {
+	var body SprintBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	sprint, err := h.service.Update(c.Request.Context(), c.Param("id"), sprintInput(body))
+	if err != nil {
+		sprintError(c, err, "sprint not found")
+		return
+	}
+	c.JSON(http.StatusOK, sprint)
+}
+
+func sprintInput(body SprintBody) service.SprintInput {
+	return service.SprintInput{Name: body.Name, Goal: body.Goal, StartDate: body.StartDate, EndDate: body.EndDate}
+}
+
+func (h *SprintHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		sprintError(c, err, "sprint not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SprintHandler) Start(c *gin.Context) {
+	sprint, err := h.service.Start(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		sprintError(c, err, "sprint not found")
+		return
+	}
+	c.JSON(http.StatusOK, sprint)
+}
+
+// Close closes the active sprint and answers with it, including the scope
+// it had.
+func (h *SprintHandler) Close(c *gin.Context) {
+	var body SprintClose
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+	}
+	sprint, err := h.service.Close(c.Request.Context(), c.Param("id"), service.SprintCloseInput{SprintID: body.SprintID, Backlog: body.Backlog})
+	if err != nil {
+		sprintError(c, err, "sprint not found")
+		return
+	}
+	c.JSON(http.StatusOK, sprint)
+}
+
+func sprintError(c *gin.Context, err error, notFound string) {
+	switch {
+	case projectArchived(c, err):
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, notFound))
+	case errors.Is(err, service.ErrSprintActive), errors.Is(err, service.ErrSprintStart), errors.Is(err, service.ErrSprintClose),
+		errors.Is(err, service.ErrSprintClosed):
+		c.JSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+	case errors.Is(err, service.ErrSprintDates), errors.Is(err, service.ErrSprintState), errors.Is(err, service.ErrInvalidSprint),
+		errors.Is(err, service.ErrSprintMoveBoth), errors.Is(err, httpx.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockSprintService struct {
+	listFn   func(ctx context.Context, projectID, state string, params httpx.ListParams) ([]model.Sprint, int64, error)
+	createFn func(ctx context.Context, projectID string, input service.SprintInput) (model.Sprint, error)
+	startFn  func(ctx context.Context, id string) (model.Sprint, error)
+	closeFn  func(ctx context.Context, id string, input service.SprintCloseInput) (model.Sprint, error)
+}
+
+func (m *mockSprintService) List(ctx context.Context, projectID, state string, params httpx.ListParams) ([]model.Sprint, int64, error) {
+	return m.listFn(ctx, projectID, state, params)
+}
+func (m *mockSprintService) Create(ctx context.Context, projectID string, input service.SprintInput) (model.Sprint, error) {
+	return m.createFn(ctx, projectID, input)
+}
+func (m *mockSprintService) Get(ctx context.Context, id string) (model.Sprint, error) {
+	return model.Sprint{}, gorm.ErrRecordNotFound
+}
+func (m *mockSprintService) Update(ctx context.Context, id string, input service.SprintInput) (model.Sprint, error) {
+	return model.Sprint{}, service.ErrSprintClosed
+}
+func (m *mockSprintService) Delete(ctx context.Context, id string) error { return nil }
+func (m *mockSprintService) Start(ctx context.Context, id string) (model.Sprint, error) {
+	return m.startFn(ctx, id)
+}
+func (m *mockSprintService) Close(ctx context.Context, id string, input service.SprintCloseInput) (model.Sprint, error) {
+	return m.closeFn(ctx, id, input)
+}
+
+type mockMilestoneService struct {
+	createFn func(ctx context.Context, projectID string, input service.MilestoneInput) (model.Milestone, error)
+}
+
+func (m *mockMilestoneService) List(ctx context.Context, projectID string, params httpx.ListParams) ([]model.Milestone, int64, error) {
+	return nil, 0, gorm.ErrRecordNotFound
+}
+func (m *mockMilestoneService) Create(ctx context.Context, projectID string, input service.MilestoneInput) (model.Milestone, error) {
+	return m.createFn(ctx, projectID, input)
+}
+func (m *mockMilestoneService) Get(ctx context.Context, id string) (model.Milestone, error) {
+	return model.Milestone{ID: 3, Name: "Beta"}, nil
+}
+func (m *mockMilestoneService) Update(ctx context.Context, id string, input service.MilestoneInput) (model.Milestone, error) {
+	return model.Milestone{}, service.ErrProjectArchived
+}
+func (m *mockMilestoneService) Delete(ctx context.Context, id string) error { return nil }
+
+func TestSprintAndMilestoneHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sprints := NewSprintHandler(&mockSprintService{
+		listFn: func(ctx context.Context, projectID, state string, params httpx.ListParams) ([]model.Sprint, int64, error) {
+			if state == "done" {
+				return nil, 0, service.ErrSprintState
+			}
+			return []model.Sprint{{ID: 1, ProjectID: 2, Name: "Sprint 1", State: model.SprintActive}}, 1, nil
+		},
+		createFn: func(ctx context.Context, projectID string, input service.SprintInput) (model.Sprint, error) {
+			if input.EndDate.Before(input.StartDate) {
+				return model.Sprint{}, service.ErrSprintDates
+			}
+			return model.Sprint{ID: 2, Name: input.Name, Goal: input.Goal, State: model.SprintPlanned}, nil
+		},
+		startFn: func(ctx context.Context, id string) (model.Sprint, error) {
+			return model.Sprint{}, service.ErrSprintActive
+		},
+		closeFn: func(ctx context.Context, id string, input service.SprintCloseInput) (model.Sprint, error) {
+			if input.SprintID != nil && input.Backlog {
+				return model.Sprint{}, service.ErrSprintMoveBoth
+			}
+			scope := &model.SprintScope{Total: 3, Done: 1, Moved: 2, MovedTo: input.SprintID}
+			return model.Sprint{ID: 1, State: model.SprintClosed, Scope: scope}, nil
+		},
+	})
+	milestones := NewMilestoneHandler(&mockMilestoneService{
+		createFn: func(ctx context.Context, projectID string, input service.MilestoneInput) (model.Milestone, error) {
+			return model.Milestone{ID: 3, Name: input.Name, TargetDate: input.TargetDate}, nil
+		},
+	})
+	r := gin.New()
+	sprints.Register(r.Group("/"))
+	milestones.Register(r.Group("/"))
+
+	tests := []struct {
+		method, path, body string
+		want               int
+		contains           string
+	}{
+		{http.MethodGet, "/projects/2/sprints?state=active", "", http.StatusOK, `"state":"active"`},
+		{http.MethodGet, "/projects/2/sprints?pageSize=10", "", http.StatusOK, `"total":1,"totalPages":1`},
+		{http.MethodGet, "/projects/2/sprints?cursor=bad", "", http.StatusBadRequest, ""},
+		{http.MethodGet, "/projects/2/sprints?state=done", "", http.StatusBadRequest, "state must be"},
+		{http.MethodPost, "/projects/2/sprints", `{"name":"Sprint 2","goal":"Ship","startDate":"2026-10-19T00:00:00Z","endDate":"2026-10-30T00:00:00Z"}`, http.StatusCreated, `"goal":"Ship"`},
+		{http.MethodPost, "/projects/2/sprints", `{"name":"Sprint 2","startDate":"2026-10-30T00:00:00Z","endDate":"2026-10-19T00:00:00Z"}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/projects/2/sprints", `{"name":"Sprint 2"}`, http.StatusBadRequest, ""},
+		{http.MethodGet, "/sprints/9", "", http.StatusNotFound, "sprint not found"},
+		{http.MethodPut, "/sprints/1", `{"name":"Renamed","startDate":"2026-10-19T00:00:00Z","endDate":"2026-10-30T00:00:00Z"}`, http.StatusConflict, ""},
+		{http.MethodDelete, "/sprints/1", "", http.StatusNoContent, ""},
+		{http.MethodPost, "/sprints/2/start", "", http.StatusConflict, "active sprint"},
+		{http.MethodPost, "/sprints/1/close", "", http.StatusOK, `"moved":2`},
+		{http.MethodPost, "/sprints/1/close", `{"sprintId":2}`, http.StatusOK, `"movedTo":2`},
+		{http.MethodPost, "/sprints/1/close", `{"sprintId":2,"backlog":true}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/sprints/1/close", `{"backlog":"yes"}`, http.StatusBadRequest, ""},
+		{http.MethodGet, "/projects/9/milestones", "", http.StatusNotFound, "project not found"},
+		{http.MethodPost, "/projects/2/milestones", `{"name":"Beta","targetDate":"2026-12-01T00:00:00Z"}`, http.StatusCreated, `"targetDate":"2026-12-01T00:00:00Z"`},
+		{http.MethodPost, "/projects/2/milestones", `{"name":"Beta"}`, http.StatusBadRequest, ""},
+		{http.MethodGet, "/milestones/3", "", http.StatusOK, `"name":"Beta"`},
+		{http.MethodPut, "/milestones/3", `{"name":"GA","targetDate":"2027-01-01T00:00:00Z"}`, http.StatusConflict, ""},
+		{http.MethodDelete, "/milestones/3", "", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want || !strings.Contains(w.Body.String(), tt.contains) {
+			t.Fatalf("%s %s %s = %d %s, want %d with %s", tt.method, tt.path, tt.body, w.Code, w.Body.String(), tt.want, tt.contains)
+		}
+	}
+}
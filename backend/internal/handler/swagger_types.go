@@ -7,37 +7,64 @@ type ProjectsListResponse struct {
 	Page     int             `json:"page"`
 	PageSize int             `json:"pageSize"`
 	Items    []model.Project `json:"items"`
-	IsLast   bool            `json:"isLast"`
+	// Limit, NextCursor and PrevCursor replace Page and PageSize in cursor mode.
+	Limit      int     `json:"limit,omitempty"`
+	NextCursor *string `json:"nextCursor,omitempty"`
+	PrevCursor *string `json:"prevCursor,omitempty"`
+	// Total and TotalPages are null with count=false.
+	Total      *int64 `json:"total"`
+	TotalPages *int64 `json:"totalPages"`
+	IsLast     bool   `json:"isLast"`
 }
 
 // ProjectTasksListResponse is a paginated list response for tasks under a project.
 type ProjectTasksListResponse struct {
-	Page     int          `json:"page"`
-	PageSize int          `json:"pageSize"`
-	Items    []model.Task `json:"items"`
-	IsLast   bool         `json:"isLast"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"pageSize"`
+	Items      []model.Task `json:"items"`
+	Limit      int          `json:"limit,omitempty"`
+	NextCursor *string      `json:"nextCursor,omitempty"`
+	PrevCursor *string      `json:"prevCursor,omitempty"`
+	Total      *int64       `json:"total"`
+	TotalPages *int64       `json:"totalPages"`
+	IsLast     bool         `json:"isLast"`
 }
 
 // TasksListResponse is a paginated list response for tasks.
 type TasksListResponse struct {
-	Page     int          `json:"page"`
-	PageSize int          `json:"pageSize"`
-	Items    []model.Task `json:"items"`
-	IsLast   bool         `json:"isLast"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"pageSize"`
+	Items      []model.Task `json:"items"`
+	Limit      int          `json:"limit,omitempty"`
+	NextCursor *string      `json:"nextCursor,omitempty"`
+	PrevCursor *string      `json:"prevCursor,omitempty"`
+	Total      *int64       `json:"total"`
+	TotalPages *int64       `json:"totalPages"`
+	IsLast     bool         `json:"isLast"`
 }
 
 // TaskCommentsListResponse is a paginated list response for comments under a task.
 type TaskCommentsListResponse struct {
-	Page     int             `json:"page"`
-	PageSize int             `json:"pageSize"`
-	Items    []model.Comment `json:"items"`
-	IsLast   bool            `json:"isLast"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"pageSize"`
+	Items      []model.Comment `json:"items"`
+	Limit      int             `json:"limit,omitempty"`
+	NextCursor *string         `json:"nextCursor,omitempty"`
+	PrevCursor *string         `json:"prevCursor,omitempty"`
+	Total      *int64          `json:"total"`
+	TotalPages *int64          `json:"totalPages"`
+	IsLast     bool            `json:"isLast"`
 }
 
 // CommentsListResponse is a paginated list response for comments.
 type CommentsListResponse struct {
-	Page     int             `json:"page"`
-	PageSize int             `json:"pageSize"`
-	Items    []model.Comment `json:"items"`
-	IsLast   bool            `json:"isLast"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"pageSize"`
+	Items      []model.Comment `json:"items"`
+	Limit      int             `json:"limit,omitempty"`
+	NextCursor *string         `json:"nextCursor,omitempty"`
+	PrevCursor *string         `json:"prevCursor,omitempty"`
+	Total      *int64          `json:"total"`
+	TotalPages *int64          `json:"totalPages"`
+	IsLast     bool            `json:"isLast"`
 }
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"project-management/internal/filter"
 	"project-management/internal/httpx"
 	"project-management/internal/model"
 	"project-management/internal/service"
@@ -14,9 +17,18 @@ import (
 	"gorm.io/gorm"
 )
 
-type TaskHandler struct{ service service.TaskService }
+type TaskHandler struct {
+	service       service.TaskService
+	preconditions Preconditions
+}
+
+func NewTaskHandler(service service.TaskService) *TaskHandler {
+	return NewTaskHandlerWithPreconditions(service, Preconditions{})
+}
 
-func NewTaskHandler(service service.TaskService) *TaskHandler { return &TaskHandler{service: service} }
+func NewTaskHandlerWithPreconditions(service service.TaskService, preconditions Preconditions) *TaskHandler {
+	return &TaskHandler{service: service, preconditions: preconditions}
+}
 
 type TaskCreate struct {
 	ProjectID   uint             `json:"projectId" binding:"required"`
@@ -25,6 +37,10 @@ type TaskCreate struct {
 	Status      model.TaskStatus `json:"status" binding:"required,oneof=todo in_progress done"`
 	AssigneeID  *uint            `json:"assigneeId"`
 	DueDate     *time.Time       `json:"dueDate"`
+	Labels      []string         `json:"labels"`
+	Recurrence  string           `json:"recurrence"`
+	SprintID    *uint            `json:"sprintId"`
+	MilestoneID *uint            `json:"milestoneId"`
 }
 
 type TaskUpdate struct {
@@ -33,41 +49,98 @@ type TaskUpdate struct {
 	Status      *model.TaskStatus `json:"status" binding:"omitempty,oneof=todo in_progress done"`
 	AssigneeID  **uint            `json:"assigneeId"`
 	DueDate     **time.Time       `json:"dueDate"`
+	Labels      *[]string         `json:"labels"`
+	Recurrence  *string           `json:"recurrence"`
+	SprintID    **uint            `json:"sprintId"`
+	MilestoneID **uint            `json:"milestoneId"`
+}
+
+// TaskBulkBody selects tasks by ids or by the conditions of the task list
+// query and names one operation to run on them; only the field the operation
+// needs is read.
+type TaskBulkBody struct {
+	IDs    []uint          `json:"ids"`
+	Filter *TaskBulkFilter `json:"filter"`
+	Op     string          `json:"op" binding:"required,oneof=setStatus setAssignee setDueDate setLabels move delete"`
+
+	Status     model.TaskStatus `json:"status"`
+	AssigneeID *uint            `json:"assigneeId"`
+	DueDate    *time.Time       `json:"dueDate"`
+	Labels     []string         `json:"labels"`
+	ProjectID  uint             `json:"projectId"`
+	DryRun     bool             `json:"dryRun"`
+}
+
+type TaskBulkFilter struct {
+	ProjectID  string `json:"projectId"`
+	Status     string `json:"status"`
+	AssigneeID string `json:"assigneeId"`
+	DueFrom    string `json:"dueFrom"`
+	DueTo      string `json:"dueTo"`
+	Filter     string `json:"filter"`
+
+	SprintID    string `json:"sprintId"`
+	MilestoneID string `json:"milestoneId"`
 }
 
 func (h *TaskHandler) Register(r *gin.RouterGroup) {
 	r.GET("/tasks", h.List)
 	r.POST("/tasks", h.Create)
+	r.POST("/tasks/bulk", h.Bulk)
 	r.GET("/tasks/:id", h.Get)
 	r.PUT("/tasks/:id", h.Update)
+	r.PATCH("/tasks/:id", h.Patch)
 	r.DELETE("/tasks/:id", h.Delete)
 	r.GET("/tasks/:id/comments", h.ListTaskComments)
 	r.POST("/tasks/:id/comments", h.CreateTaskComment)
+	r.POST("/tasks/:id/watch", h.Watch)
+	r.DELETE("/tasks/:id/watch", h.Unwatch)
 }
 
 func (h *TaskHandler) List(c *gin.Context) {
-	lp := httpx.ParseListParams(c.Query("page"), c.Query("pageSize"), c.Query("sort"))
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	sh, ok := parseShape(c, service.TaskRelations)
+	if !ok {
+		return
+	}
+	userID, _ := currentUserID(c)
+	sprintID, milestoneID := strings.TrimSpace(c.Query("sprintId")), strings.TrimSpace(c.Query("milestoneId"))
+	if !planIDs(c, sprintID, milestoneID) {
+		return
+	}
 
 	items, total, err := h.service.List(c.Request.Context(), service.TaskListFilter{
-		Params:          lp,
-		ProjectID:       strings.TrimSpace(c.Query("projectId")),
-		Status:          strings.TrimSpace(c.Query("status")),
-		AssigneeID:      strings.TrimSpace(c.Query("assigneeId")),
-		DueFrom:         strings.TrimSpace(c.Query("dueFrom")),
-		DueTo:           strings.TrimSpace(c.Query("dueTo")),
-		IncludeComments: strings.TrimSpace(c.Query("include")) == "comments",
+		Params:     lp,
+		ProjectID:  strings.TrimSpace(c.Query("projectId")),
+		Status:     strings.TrimSpace(c.Query("status")),
+		AssigneeID: strings.TrimSpace(c.Query("assigneeId")),
+		DueFrom:    strings.TrimSpace(c.Query("dueFrom")),
+		DueTo:      strings.TrimSpace(c.Query("dueTo")),
+		Include:    sh.include,
+		Filter:     strings.TrimSpace(c.Query("filter")),
+		UserID:     userID,
+
+		SprintID:    sprintID,
+		MilestoneID: milestoneID,
+
+		IncludeArchived: c.Query("includeArchived") == "true",
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		var ferr *filter.Error
+		if errors.As(err, &ferr) {
+			c.JSON(http.StatusBadRequest, filterError(ferr))
+			return
+		}
+		listError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"page":     lp.Page,
-		"pageSize": lp.PageSize,
-		"items":    items,
-		"isLast":   httpx.IsLast(total, lp),
-	})
+	body := listBody(c, lp, items, total)
+	body["items"] = sh.render(items)
+	c.JSON(http.StatusOK, body)
 }
 
 func (h *TaskHandler) Create(c *gin.Context) {
@@ -84,8 +157,20 @@ func (h *TaskHandler) Create(c *gin.Context) {
 		Status:      body.Status,
 		AssigneeID:  body.AssigneeID,
 		DueDate:     body.DueDate,
+		Labels:      body.Labels,
+		Recurrence:  body.Recurrence,
+		SprintID:    body.SprintID,
+		MilestoneID: body.MilestoneID,
 	})
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidLabel) || errors.Is(err, service.ErrInvalidRecurrence) || errors.Is(err, service.ErrRecurrenceDueDate) ||
+			errors.Is(err, service.ErrInvalidSprint) || errors.Is(err, service.ErrInvalidMilestone) {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+		if projectArchived(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
@@ -93,8 +178,67 @@ func (h *TaskHandler) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, t)
 }
 
+// Bulk runs one operation on up to service.MaxBulkTasks tasks in a single
+// transaction and reports the outcome per task. If any task cannot be changed
+// nothing is, and the report says which task failed.
+func (h *TaskHandler) Bulk(c *gin.Context) {
+	var body TaskBulkBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	sel := service.TaskSelection{IDs: body.IDs}
+	if f := body.Filter; f != nil {
+		if !planIDs(c, strings.TrimSpace(f.SprintID), strings.TrimSpace(f.MilestoneID)) {
+			return
+		}
+		userID, _ := currentUserID(c)
+		sel.Filter = &service.TaskListFilter{
+			ProjectID:  strings.TrimSpace(f.ProjectID),
+			Status:     strings.TrimSpace(f.Status),
+			AssigneeID: strings.TrimSpace(f.AssigneeID),
+			DueFrom:    strings.TrimSpace(f.DueFrom),
+			DueTo:      strings.TrimSpace(f.DueTo),
+			Filter:     strings.TrimSpace(f.Filter),
+			UserID:     userID,
+
+			SprintID:    strings.TrimSpace(f.SprintID),
+			MilestoneID: strings.TrimSpace(f.MilestoneID),
+		}
+	}
+
+	report, err := h.service.Bulk(c.Request.Context(), service.TaskBulkInput{
+		Selection:  sel,
+		Op:         body.Op,
+		Status:     body.Status,
+		AssigneeID: body.AssigneeID,
+		DueDate:    body.DueDate,
+		Labels:     body.Labels,
+		ProjectID:  body.ProjectID,
+		DryRun:     body.DryRun,
+	})
+	if err != nil {
+		var ferr *filter.Error
+		switch {
+		case errors.As(err, &ferr):
+			c.JSON(http.StatusBadRequest, filterError(ferr))
+		case errors.Is(err, service.ErrBulkSelection), errors.Is(err, service.ErrBulkTooMany), errors.Is(err, service.ErrBulkOp),
+			errors.Is(err, service.ErrBulkStatus), errors.Is(err, service.ErrBulkProject), errors.Is(err, service.ErrInvalidLabel):
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 func (h *TaskHandler) Get(c *gin.Context) {
-	t, err := h.service.Get(c.Request.Context(), c.Param("id"), strings.TrimSpace(c.Query("include")) == "comments")
+	sh, ok := parseShape(c, service.TaskRelations)
+	if !ok {
+		return
+	}
+	t, err := h.service.Get(c.Request.Context(), c.Param("id"), sh.include)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "task not found"))
@@ -103,10 +247,15 @@ func (h *TaskHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
-	c.JSON(http.StatusOK, t)
+	setETag(c, t.Version)
+	c.JSON(http.StatusOK, sh.render(t))
 }
 
 func (h *TaskHandler) Update(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
 	var body TaskUpdate
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
@@ -119,21 +268,149 @@ func (h *TaskHandler) Update(c *gin.Context) {
 		Status:      body.Status,
 		AssigneeID:  body.AssigneeID,
 		DueDate:     body.DueDate,
+		Labels:      body.Labels,
+		Recurrence:  body.Recurrence,
+		SprintID:    body.SprintID,
+		MilestoneID: body.MilestoneID,
+		Version:     version,
 	})
 	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+
+	setETag(c, t.Version)
+	c.JSON(http.StatusOK, t)
+}
+
+// Patch applies a merge patch or JSON Patch to the task's fields as they are
+// posted on create; projectId cannot change. The patched task must pass the
+// create rules and is saved only if the task did not change meanwhile.
+func (h *TaskHandler) Patch(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
+	current, err := h.service.Get(c.Request.Context(), c.Param("id"), nil)
+	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+	labels := make([]string, len(current.Labels))
+	for i, label := range current.Labels {
+		labels[i] = label.Name
+	}
+	var body TaskCreate
+	if !patchDocument(c, TaskCreate{
+		ProjectID:   current.ProjectID,
+		Title:       current.Title,
+		Description: current.Description,
+		Status:      current.Status,
+		AssigneeID:  current.AssigneeID,
+		DueDate:     current.DueDate,
+		Labels:      labels,
+		Recurrence:  current.Recurrence,
+		SprintID:    current.SprintID,
+		MilestoneID: current.MilestoneID,
+	}, &body) {
+		return
+	}
+	if body.ProjectID != current.ProjectID {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "projectId cannot be patched"))
+		return
+	}
+	if version == nil {
+		version = &current.Version
+	}
+
+	t, err := h.service.Update(c.Request.Context(), c.Param("id"), service.TaskUpdateInput{
+		Title:       &body.Title,
+		Description: &body.Description,
+		Status:      &body.Status,
+		AssigneeID:  &body.AssigneeID,
+		DueDate:     &body.DueDate,
+		Labels:      &body.Labels,
+		Recurrence:  &body.Recurrence,
+		SprintID:    &body.SprintID,
+		MilestoneID: &body.MilestoneID,
+		Version:     version,
+	})
+	if err != nil {
+		h.updateFailed(c, err)
+		return
+	}
+
+	setETag(c, t.Version)
+	c.JSON(http.StatusOK, t)
+}
+
+// planIDs checks the sprintId and milestoneId of a task list query: an ID or
+// "none" for the tasks without one.
+func planIDs(c *gin.Context, sprintID, milestoneID string) bool {
+	for _, p := range [][2]string{{"sprintId", sprintID}, {"milestoneId", milestoneID}} {
+		if p[1] == "" || p[1] == service.TaskUnplanned {
+			continue
+		}
+		if _, err := strconv.ParseUint(p[1], 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "invalid "+p[0]))
+			return false
+		}
+	}
+	return true
+}
+
+func (h *TaskHandler) updateFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "task not found"))
+	case errors.Is(err, service.ErrInvalidLabel), errors.Is(err, service.ErrInvalidRecurrence), errors.Is(err, service.ErrRecurrenceDueDate),
+		errors.Is(err, service.ErrInvalidSprint), errors.Is(err, service.ErrInvalidMilestone):
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+	case versionMismatch(c, err):
+	case projectArchived(c, err):
+	default:
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
+
+func (h *TaskHandler) Delete(c *gin.Context) {
+	version, ok := h.preconditions.ifMatch(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), version); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "task not found"))
 			return
 		}
+		if versionMismatch(c, err) || projectArchived(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
+	c.Status(http.StatusNoContent)
+}
 
-	c.JSON(http.StatusOK, t)
+func (h *TaskHandler) Watch(c *gin.Context) {
+	h.watch(c, h.service.Watch)
 }
 
-func (h *TaskHandler) Delete(c *gin.Context) {
-	if err := h.service.Delete(c.Request.Context(), c.Param("id")); err != nil {
+func (h *TaskHandler) Unwatch(c *gin.Context) {
+	h.watch(c, h.service.Unwatch)
+}
+
+func (h *TaskHandler) watch(c *gin.Context, fn func(ctx context.Context, id string, userID uint) error) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	if err := fn(c.Request.Context(), c.Param("id"), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "task not found"))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
@@ -147,7 +424,14 @@ type CommentCreateUnderTask struct {
 
 func (h *TaskHandler) ListTaskComments(c *gin.Context) {
 	taskID := c.Param("id")
-	lp := httpx.ParseListParams(c.Query("page"), c.Query("pageSize"), c.Query("sort"))
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	sh, ok := parseShape(c, nil)
+	if !ok {
+		return
+	}
 	author := strings.TrimSpace(c.Query("author"))
 
 	items, total, err := h.service.ListComments(c.Request.Context(), taskID, service.TaskCommentListFilter{
@@ -155,16 +439,13 @@ func (h *TaskHandler) ListTaskComments(c *gin.Context) {
 		Author: author,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		listError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"page":     lp.Page,
-		"pageSize": lp.PageSize,
-		"items":    items,
-		"isLast":   httpx.IsLast(total, lp),
-	})
+	body := listBody(c, lp, items, total)
+	body["items"] = sh.render(items)
+	c.JSON(http.StatusOK, body)
 }
 
 func (h *TaskHandler) CreateTaskComment(c *gin.Context) {
@@ -182,6 +463,9 @@ func (h *TaskHandler) CreateTaskComment(c *gin.Context) {
 		Text:   body.Text,
 	})
 	if err != nil {
+		if projectArchived(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
 		return
 	}
@@ -198,3 +482,9 @@ func mustUint(s string) uint {
 	}
 	return uint(n)
 }
+
+// filterError reports a ?filter= syntax or validation error with the position
+// it was found at.
+func filterError(err *filter.Error) httpx.APIError {
+	return httpx.ErrDetails(httpx.CodeBadRequest, err.Error(), gin.H{"position": err.Pos, "reason": err.Msg})
+}
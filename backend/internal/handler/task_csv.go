@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// maxImportBytes caps the size of an uploaded CSV file.
+const maxImportBytes = 10 << 20
+
+// taskCSVColumns are the columns of a task export.
+var taskCSVColumns = []string{"id", "title", "description", "status", "assigneeId", "dueDate", "labels", "createdAt", "updatedAt"}
+
+// taskImportFields are the TaskCreateUnderProject fields an import fills, by
+// default from the columns of the same name.
+var taskImportFields = []string{"title", "description", "status", "assigneeId", "dueDate"}
+
+// TaskImportError is a problem with one row of an import. Line is the row's
+// line in the file, the header being line 1.
+type TaskImportError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// TaskImportReport is the outcome of an import. Tasks are the valid rows as
+// they were, or in a dry run would be, created. Nothing is created when any
+// row has errors.
+type TaskImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Applied bool              `json:"applied"`
+	Rows    int               `json:"rows"`
+	Valid   int               `json:"valid"`
+	Errors  []TaskImportError `json:"errors"`
+	Tasks   []model.Task      `json:"tasks"`
+}
+
+// ExportTasks streams the project's tasks as CSV. It takes the status and
+// assigneeId filters of GET /projects/:id/tasks.
+func (h *ProjectHandler) ExportTasks(c *gin.Context) {
+	if format := c.DefaultQuery("format", "csv"); format != "csv" {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "format must be csv"))
+		return
+	}
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "invalid projectId"))
+		return
+	}
+	filter := service.ProjectTaskListFilter{
+		Status:     strings.TrimSpace(c.Query("status")),
+		AssigneeID: strings.TrimSpace(c.Query("assigneeId")),
+	}
+
+	w := csv.NewWriter(c.Writer)
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="project-%d-tasks.csv"`, projectID))
+		c.Status(http.StatusOK)
+		return w.Write(taskCSVColumns)
+	}
+	err = h.service.ExportTasks(c.Request.Context(), uint(projectID), filter, func(tasks []model.Task) error {
+		if err := start(); err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			if err := w.Write(taskRecord(task)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+	if err == nil {
+		err = start()
+		w.Flush()
+	}
+	switch {
+	case err == nil:
+	case started:
+		// The status is sent; all that is left is to cut the file short.
+		_ = c.Error(err)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "project not found"))
+	default:
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
+
+// ImportTasks creates tasks from the rows of an uploaded CSV file (multipart
+// field "file"). The optional "mapping" field is a JSON object naming the
+// column of each task field, e.g. {"title":"Summary"}; dryRun=true only
+// validates and previews.
+func (h *ProjectHandler) ImportTasks(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "invalid projectId"))
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(httpx.StatusFor(httpx.CodePayloadTooLarge), httpx.Err(httpx.CodePayloadTooLarge, fmt.Sprintf("the CSV file must not exceed %d MiB", maxImportBytes>>20)))
+			return
+		}
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "multipart field \"file\" is required"))
+		return
+	}
+	defer file.Close()
+
+	mapping := map[string]string{}
+	if raw := c.Request.FormValue("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "mapping must be a JSON object of task field to column name"))
+			return
+		}
+	}
+	dryRun := c.Query("dryRun") == "true" || c.Request.FormValue("dryRun") == "true"
+
+	inputs, rowErrors, rows, err := readTaskCSV(file, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	tasks, err := h.service.ImportTasks(c.Request.Context(), uint(projectID), inputs, dryRun || len(rowErrors) > 0)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "project not found"))
+			return
+		}
+		if projectArchived(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, TaskImportReport{
+		DryRun:  dryRun,
+		Applied: !dryRun && len(rowErrors) == 0,
+		Rows:    rows,
+		Valid:   len(inputs),
+		Errors:  rowErrors,
+		Tasks:   tasks,
+	})
+}
+
+// readTaskCSV reads the rows of a task CSV into create inputs, validating each
+// against the rules of TaskCreateUnderProject. Invalid rows are reported, not
+// returned. An error means the file as a whole cannot be imported.
+func readTaskCSV(r io.Reader, mapping map[string]string) ([]service.ProjectTaskCreateInput, []TaskImportError, int, error) {
+	for field := range mapping {
+		if !slices.Contains(taskImportFields, field) {
+			return nil, nil, 0, fmt.Errorf("mapping: unknown field %q; map %s", field, strings.Join(taskImportFields, ", "))
+		}
+	}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, 0, errors.New("the CSV file is empty")
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	index := map[string]int{}
+	for _, field := range taskImportFields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		i, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok && mapped {
+			return nil, nil, 0, fmt.Errorf("mapping: the file has no column %q", name)
+		}
+		if ok {
+			index[field] = i
+		}
+	}
+
+	inputs := []service.ProjectTaskCreateInput{}
+	rowErrors := []TaskImportError{}
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if rows++; rows > service.MaxImportTasks {
+			return nil, nil, 0, fmt.Errorf("an import can create at most %d tasks", service.MaxImportTasks)
+		}
+		line, _ := reader.FieldPos(0)
+		cell := func(field string) string {
+			i, ok := index[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return unescapeCell(record[i])
+		}
+
+		body := TaskCreateUnderProject{
+			Title:       strings.TrimSpace(cell("title")),
+			Description: cell("description"),
+			Status:      model.TaskStatus(normalizeStatus(cell("status"))),
+		}
+		var errs []TaskImportError
+		if v := strings.TrimSpace(cell("assigneeId")); v != "" {
+			if id, err := strconv.ParseUint(v, 10, 32); err == nil && id > 0 {
+				assignee := uint(id)
+				body.AssigneeID = &assignee
+			} else {
+				errs = append(errs, TaskImportError{Line: line, Field: "assigneeId", Message: "must be a user ID"})
+			}
+		}
+		if v := strings.TrimSpace(cell("dueDate")); v != "" {
+			if due, ok := parseDueDate(v); ok {
+				body.DueDate = &due
+			} else {
+				errs = append(errs, TaskImportError{Line: line, Field: "dueDate", Message: "must be a date like 2026-11-02 or an RFC 3339 time"})
+			}
+		}
+		if err := binding.Validator.ValidateStruct(&body); err != nil {
+			errs = append(errs, validationErrors(line, err)...)
+		}
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+		inputs = append(inputs, service.ProjectTaskCreateInput{
+			Title:       body.Title,
+			Description: body.Description,
+			Status:      body.Status,
+			AssigneeID:  body.AssigneeID,
+			DueDate:     body.DueDate,
+		})
+	}
+	return inputs, rowErrors, rows, nil
+}
+
+// validationErrors turns the binding errors of a row into one error per field.
+func validationErrors(line int, err error) []TaskImportError {
+	var fields validator.ValidationErrors
+	if !errors.As(err, &fields) {
+		return []TaskImportError{{Line: line, Message: err.Error()}}
+	}
+	errs := make([]TaskImportError, len(fields))
+	for i, fe := range fields {
+		name := strings.ToLower(fe.Field()[:1]) + fe.Field()[1:]
+		msg := "is invalid"
+		switch fe.Tag() {
+		case "required":
+			msg = "is required"
+		case "oneof":
+			msg = "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+		}
+		errs[i] = TaskImportError{Line: line, Field: name, Message: msg}
+	}
+	return errs
+}
+
+// normalizeStatus lets spreadsheet spellings such as "In Progress" stand for
+// in_progress.
+func normalizeStatus(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(s)
+}
+
+func parseDueDate(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	t, err := time.Parse("2006-01-02", s)
+	return t, err == nil
+}
+
+func taskRecord(task model.Task) []string {
+	record := []string{
+		strconv.FormatUint(uint64(task.ID), 10),
+		escapeCell(task.Title),
+		escapeCell(task.Description),
+		string(task.Status),
+		"",
+		"",
+		escapeCell(strings.Join(taskLabelNames(task.Labels), "; ")),
+		task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if task.AssigneeID != nil {
+		record[4] = strconv.FormatUint(uint64(*task.AssigneeID), 10)
+	}
+	if task.DueDate != nil {
+		record[5] = task.DueDate.UTC().Format(time.RFC3339)
+	}
+	return record
+}
+
+func taskLabelNames(labels []model.Label) []string {
+	names := make([]string, len(labels))
+	for i, label := range labels {
+		names[i] = label.Name
+	}
+	return names
+}
+
+// escapeCell keeps spreadsheets from running a cell as a formula by quoting
+// it with a leading apostrophe; unescapeCell undoes that on import.
+func escapeCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func unescapeCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestProjectHandlerExportTasks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	assignee := uint(3)
+	due := time.Date(2026, 11, 2, 9, 0, 0, 0, time.FixedZone("CET", 3600))
+	h := NewProjectHandler(&mockProjectService{exportFn: func(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter, each func([]model.Task) error) error {
+		switch projectID {
+		case 404:
+			return gorm.ErrRecordNotFound
+		case 2:
+			return nil
+		}
+		if filter.Status != "todo" || filter.AssigneeID != "3" {
+			t.Fatalf("filter = %+v", filter)
+		}
+		if err := each([]model.Task{{ID: 1, Title: "=HYPERLINK(\"x\")", Description: "line 1\nline, 2", Status: model.TaskTodo, AssigneeID: &assignee, DueDate: &due, Labels: []model.Label{{Name: "a"}, {Name: "b"}}}}); err != nil {
+			return err
+		}
+		return each([]model.Task{{ID: 2, Title: "Second", Status: model.TaskTodo}})
+	}})
+	r := gin.New()
+	r.GET("/projects/:id/tasks/export", h.ExportTasks)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects/1/tasks/export?format=csv&status=todo&assigneeId=3", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") || !strings.Contains(w.Header().Get("Content-Disposition"), "project-1-tasks.csv") {
+		t.Fatalf("status = %d, headers = %v", w.Code, w.Header())
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(taskCSVColumns, ",") {
+		t.Fatalf("records = %q", records)
+	}
+	first := records[1]
+	if first[1] != "'=HYPERLINK(\"x\")" || first[2] != "line 1\nline, 2" || first[4] != "3" || first[5] != "2026-11-02T08:00:00Z" || first[6] != "a; b" {
+		t.Fatalf("first = %q", first)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects/2/tasks/export", nil))
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != strings.Join(taskCSVColumns, ",") {
+		t.Fatalf("empty export status = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/projects/404/tasks/export":          http.StatusNotFound,
+		"/projects/1/tasks/export?format=xml": http.StatusBadRequest,
+		"/projects/x/tasks/export":            http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+// importRequest builds a multipart import request with the given CSV and
+// form fields.
+func importRequest(t *testing.T, path, file string, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if file != "" {
+		part, err := mw.CreateFormFile("file", "tasks.csv")
+		if err != nil {
+			t.Fatalf("CreateFormFile: %v", err)
+		}
+		part.Write([]byte(file))
+	}
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestProjectHandlerImportTasks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var gotInputs []service.ProjectTaskCreateInput
+	var gotDryRun bool
+	h := NewProjectHandler(&mockProjectService{importFn: func(ctx context.Context, projectID uint, inputs []service.ProjectTaskCreateInput, dryRun bool) ([]model.Task, error) {
+		switch projectID {
+		case 404:
+			return nil, gorm.ErrRecordNotFound
+		case 409:
+			return nil, service.ErrProjectArchived
+		case 500:
+			return nil, errors.New("db down")
+		}
+		gotInputs, gotDryRun = inputs, dryRun
+		tasks := make([]model.Task, len(inputs))
+		for i, input := range inputs {
+			tasks[i] = model.Task{Title: input.Title, Status: input.Status}
+		}
+		return tasks, nil
+	}})
+	r := gin.New()
+	r.POST("/projects/:id/tasks/import", h.ImportTasks)
+
+	file := "\ufeffSummary,State,Owner,Due,Notes\n" +
+		"Kickoff,In Progress,3,2026-11-02,\"multi\nline\"\n" +
+		"'=SUM(A1),done,,2026-11-09T10:00:00Z,\n"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, importRequest(t, "/projects/1/tasks/import", file, map[string]string{
+		"mapping": `{"title":"summary","status":"State","assigneeId":"Owner","dueDate":"Due","description":"Notes"}`,
+	}))
+	var report TaskImportReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if !report.Applied || report.DryRun || report.Rows != 2 || report.Valid != 2 || len(report.Errors) != 0 || len(report.Tasks) != 2 || gotDryRun {
+		t.Fatalf("report = %+v", report)
+	}
+	kickoff := gotInputs[0]
+	if kickoff.Title != "Kickoff" || kickoff.Status != model.TaskInProgress || *kickoff.AssigneeID != 3 || kickoff.Description != "multi\nline" || !kickoff.DueDate.Equal(time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("kickoff = %+v", kickoff)
+	}
+	if gotInputs[1].Title != "=SUM(A1)" || gotInputs[1].AssigneeID != nil {
+		t.Fatalf("second = %+v", gotInputs[1])
+	}
+
+	file = "title,status,assigneeId,dueDate\n" +
+		"Good,todo,,\n" +
+		",blocked,x,tomorrow\n"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, importRequest(t, "/projects/1/tasks/import?dryRun=true", file, nil))
+	report = TaskImportReport{}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if report.Applied || !report.DryRun || report.Rows != 2 || report.Valid != 1 || !gotDryRun || len(report.Errors) != 4 {
+		t.Fatalf("report = %+v", report)
+	}
+	fields := map[string]string{}
+	for _, e := range report.Errors {
+		if e.Line != 3 {
+			t.Fatalf("error line = %d, want 3", e.Line)
+		}
+		fields[e.Field] = e.Message
+	}
+	if fields["title"] != "is required" || fields["status"] != "must be one of todo, in_progress, done" || fields["assigneeId"] == "" || fields["dueDate"] == "" {
+		t.Fatalf("errors = %+v", report.Errors)
+	}
+
+	// Row errors keep the whole file from being imported.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, importRequest(t, "/projects/1/tasks/import", file, nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || report.Applied || !gotDryRun {
+		t.Fatalf("report = %+v, dryRun passed = %v", report, gotDryRun)
+	}
+
+	for _, tt := range []struct {
+		path, file string
+		fields     map[string]string
+		want       int
+	}{
+		{"/projects/1/tasks/import", "", nil, http.StatusBadRequest},
+		{"/projects/1/tasks/import", "title\nA\n", map[string]string{"mapping": "[]"}, http.StatusBadRequest},
+		{"/projects/1/tasks/import", "title\nA\n", map[string]string{"mapping": `{"priority":"P"}`}, http.StatusBadRequest},
+		{"/projects/1/tasks/import", "title\nA\n", map[string]string{"mapping": `{"title":"Name"}`}, http.StatusBadRequest},
+		{"/projects/1/tasks/import", "title,status\n\"A,todo\n", nil, http.StatusBadRequest},
+		{"/projects/404/tasks/import", "title,status\nA,todo\n", nil, http.StatusNotFound},
+		{"/projects/409/tasks/import", "title,status\nA,todo\n", nil, http.StatusConflict},
+		{"/projects/500/tasks/import", "title,status\nA,todo\n", nil, http.StatusInternalServerError},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, importRequest(t, tt.path, tt.file, tt.fields))
+		if w.Code != tt.want {
+			t.Fatalf("POST %s %q %v status = %d, want %d: %s", tt.path, tt.file, tt.fields, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	filterpkg "project-management/internal/filter"
 	"project-management/internal/httpx"
 	"project-management/internal/model"
 	"project-management/internal/service"
@@ -21,11 +25,14 @@ import (
 type mockTaskService struct {
 	listFn          func(ctx context.Context, filter service.TaskListFilter) ([]model.Task, int64, error)
 	createFn        func(ctx context.Context, input service.TaskCreateInput) (model.Task, error)
-	getFn           func(ctx context.Context, id string, includeComments bool) (model.Task, error)
+	getFn           func(ctx context.Context, id string, include []string) (model.Task, error)
 	updateFn        func(ctx context.Context, id string, input service.TaskUpdateInput) (model.Task, error)
-	deleteFn        func(ctx context.Context, id string) error
+	deleteFn        func(ctx context.Context, id string, version *uint) error
 	listCommentsFn  func(ctx context.Context, taskID string, filter service.TaskCommentListFilter) ([]model.Comment, int64, error)
 	createCommentFn func(ctx context.Context, input service.TaskCommentCreateInput) (model.Comment, error)
+	bulkFn          func(ctx context.Context, input service.TaskBulkInput) (service.TaskBulkReport, error)
+	watchFn         func(ctx context.Context, id string, userID uint) error
+	unwatchFn       func(ctx context.Context, id string, userID uint) error
 }
 
 func (m *mockTaskService) List(ctx context.Context, filter service.TaskListFilter) ([]model.Task, int64, error) {
@@ -34,19 +41,30 @@ func (m *mockTaskService) List(ctx context.Context, filter service.TaskListFilte
 func (m *mockTaskService) Create(ctx context.Context, input service.TaskCreateInput) (model.Task, error) {
 	return m.createFn(ctx, input)
 }
-func (m *mockTaskService) Get(ctx context.Context, id string, includeComments bool) (model.Task, error) {
-	return m.getFn(ctx, id, includeComments)
+func (m *mockTaskService) Get(ctx context.Context, id string, include []string) (model.Task, error) {
+	return m.getFn(ctx, id, include)
 }
 func (m *mockTaskService) Update(ctx context.Context, id string, input service.TaskUpdateInput) (model.Task, error) {
 	return m.updateFn(ctx, id, input)
 }
-func (m *mockTaskService) Delete(ctx context.Context, id string) error { return m.deleteFn(ctx, id) }
+func (m *mockTaskService) Delete(ctx context.Context, id string, version *uint) error {
+	return m.deleteFn(ctx, id, version)
+}
 func (m *mockTaskService) ListComments(ctx context.Context, taskID string, filter service.TaskCommentListFilter) ([]model.Comment, int64, error) {
 	return m.listCommentsFn(ctx, taskID, filter)
 }
 func (m *mockTaskService) CreateComment(ctx context.Context, input service.TaskCommentCreateInput) (model.Comment, error) {
 	return m.createCommentFn(ctx, input)
 }
+func (m *mockTaskService) Bulk(ctx context.Context, input service.TaskBulkInput) (service.TaskBulkReport, error) {
+	return m.bulkFn(ctx, input)
+}
+func (m *mockTaskService) Watch(ctx context.Context, id string, userID uint) error {
+	return m.watchFn(ctx, id, userID)
+}
+func (m *mockTaskService) Unwatch(ctx context.Context, id string, userID uint) error {
+	return m.unwatchFn(ctx, id, userID)
+}
 
 func TestTaskHandlerCreate(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -106,7 +124,7 @@ func TestTaskHandlerCreate(t *testing.T) {
 
 func TestTaskHandlerGetNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewTaskHandler(&mockTaskService{getFn: func(ctx context.Context, id string, includeComments bool) (model.Task, error) {
+	h := NewTaskHandler(&mockTaskService{getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
 		return model.Task{}, gorm.ErrRecordNotFound
 	}})
 	r := gin.New()
@@ -122,9 +140,9 @@ func TestTaskHandlerGetNotFound(t *testing.T) {
 
 func TestTaskHandlerGetSuccess(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewTaskHandler(&mockTaskService{getFn: func(ctx context.Context, id string, includeComments bool) (model.Task, error) {
-		if id != "123" || !includeComments {
-			t.Fatalf("unexpected get: id=%s includeComments=%v", id, includeComments)
+	h := NewTaskHandler(&mockTaskService{getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
+		if id != "123" || strings.Join(include, ",") != "comments" {
+			t.Fatalf("unexpected get: id=%s include=%v", id, include)
 		}
 		return model.Task{ID: 123, Title: "Task", Status: model.TaskTodo}, nil
 	}})
@@ -168,6 +186,136 @@ func TestTaskHandlerUpdateSuccess(t *testing.T) {
 	}
 }
 
+func TestTaskHandlerETagAndIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewTaskHandler(&mockTaskService{
+		getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
+			return model.Task{ID: 6, Version: 4}, nil
+		},
+		updateFn: func(ctx context.Context, id string, input service.TaskUpdateInput) (model.Task, error) {
+			if input.Version == nil {
+				return model.Task{ID: 6, Version: 5}, nil
+			}
+			if *input.Version != 4 {
+				return model.Task{}, service.ErrVersionMismatch
+			}
+			return model.Task{ID: 6, Version: 5}, nil
+		},
+		deleteFn: func(ctx context.Context, id string, version *uint) error {
+			if version == nil || *version != 4 {
+				t.Fatalf("delete version = %v", version)
+			}
+			return nil
+		},
+	})
+	r := gin.New()
+	r.GET("/tasks/:id", h.Get)
+	r.PUT("/tasks/:id", h.Update)
+	r.DELETE("/tasks/:id", h.Delete)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/6", nil))
+	if got := w.Header().Get("ETag"); got != `"4"` {
+		t.Fatalf("ETag = %q", got)
+	}
+
+	put := func(ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/tasks/6", bytes.NewBufferString(`{"title":"x"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := put(`"4"`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"5"` {
+		t.Fatalf("matching If-Match: status = %d, ETag = %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := put(`"3"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := put(`4`); w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed If-Match: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := put(`W/"4"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("weak If-Match: status = %d, want %d", w.Code, http.StatusPreconditionFailed)
+	}
+	if w := put(""); w.Code != http.StatusOK {
+		t.Fatalf("without If-Match: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	h.preconditions.RequireIfMatch = true
+	if w := put(""); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("required If-Match: status = %d, want %d", w.Code, http.StatusPreconditionRequired)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/tasks/6", nil)
+	req.Header.Set("If-Match", `"4"`)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestTaskHandlerPatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	assignee := uint(3)
+	var got service.TaskUpdateInput
+	h := NewTaskHandler(&mockTaskService{
+		getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
+			return model.Task{ID: 6, ProjectID: 2, Title: "Old", Status: model.TaskTodo, AssigneeID: &assignee, Labels: []model.Label{{Name: "bug"}}, Version: 7}, nil
+		},
+		updateFn: func(ctx context.Context, id string, input service.TaskUpdateInput) (model.Task, error) {
+			got = input
+			return model.Task{ID: 6, Title: *input.Title, Version: 8}, nil
+		},
+	})
+	r := gin.New()
+	r.PATCH("/tasks/:id", h.Patch)
+
+	send := func(contentType, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPatch, "/tasks/6", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("application/merge-patch+json", `{"title":"New","assigneeId":null}`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"8"` {
+		t.Fatalf("merge patch: status = %d, ETag = %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	if *got.Title != "New" || *got.Status != model.TaskTodo || *got.AssigneeID != nil || len(*got.Labels) != 1 || *got.Version != 7 {
+		t.Fatalf("input = %+v", got)
+	}
+
+	if w := send("application/merge-patch+json", `{"sprintId":4}`); w.Code != http.StatusOK || **got.SprintID != 4 || *got.MilestoneID != nil {
+		t.Fatalf("sprint patch: status = %d, input = %+v", w.Code, got)
+	}
+
+	w = send("application/json-patch+json", `[{"op":"test","path":"/title","value":"Old"},{"op":"add","path":"/labels/-","value":"ui"}]`)
+	if w.Code != http.StatusOK || strings.Join(*got.Labels, ",") != "bug,ui" || *got.Title != "Old" {
+		t.Fatalf("json patch: status = %d, labels = %v", w.Code, *got.Labels)
+	}
+
+	for _, tt := range []struct {
+		contentType, body string
+		want              int
+	}{
+		{"application/json", `{"title":"x"}`, http.StatusUnsupportedMediaType},
+		{"application/json-patch+json", `[{"op":"test","path":"/title","value":"x"}]`, http.StatusConflict},
+		{"application/merge-patch+json", `{"status":"blocked"}`, http.StatusBadRequest},
+		{"application/merge-patch+json", `{"title":null}`, http.StatusBadRequest},
+		{"application/merge-patch+json", `{"id":9}`, http.StatusBadRequest},
+		{"application/merge-patch+json", `{"projectId":9}`, http.StatusBadRequest},
+	} {
+		if w := send(tt.contentType, tt.body); w.Code != tt.want {
+			t.Fatalf("%s %s: status = %d, want %d", tt.contentType, tt.body, w.Code, tt.want)
+		}
+	}
+}
+
 func TestTaskHandlerUpdateInternalError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	h := NewTaskHandler(&mockTaskService{updateFn: func(ctx context.Context, id string, input service.TaskUpdateInput) (model.Task, error) {
@@ -210,7 +358,7 @@ func TestTaskHandlerUpdateNotFound(t *testing.T) {
 func TestTaskHandlerListSuccess(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	h := NewTaskHandler(&mockTaskService{listFn: func(ctx context.Context, filter service.TaskListFilter) ([]model.Task, int64, error) {
-		if filter.ProjectID != "2" || !filter.IncludeComments {
+		if filter.ProjectID != "2" || strings.Join(filter.Include, ",") != "comments" {
 			t.Fatalf("unexpected filter: %+v", filter)
 		}
 		return []model.Task{{ID: 1, ProjectID: 2, Title: "Implement", Status: model.TaskTodo}}, 1, nil
@@ -226,6 +374,109 @@ func TestTaskHandlerListSuccess(t *testing.T) {
 	}
 }
 
+func TestTaskHandlerListFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewTaskHandler(&mockTaskService{listFn: func(ctx context.Context, filter service.TaskListFilter) ([]model.Task, int64, error) {
+		if filter.UserID != 9 {
+			t.Fatalf("unexpected filter: %+v", filter)
+		}
+		if filter.Filter == "status = todo" {
+			return nil, 0, nil
+		}
+		return nil, 0, &filterpkg.Error{Pos: 8, Msg: "unexpected \"todo\""}
+	}})
+	r := gin.New()
+	r.GET("/tasks", func(c *gin.Context) { c.Set("userID", uint(9)) }, h.List)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks?filter=status+%3D+todo", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks?filter=status+todo", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	var body struct {
+		Code    string         `json:"code"`
+		Details map[string]any `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if body.Code != httpx.CodeBadRequest || body.Details["position"] != float64(8) {
+		t.Fatalf("body = %+v", body)
+	}
+}
+
+func TestTaskHandlerListPlan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got service.TaskListFilter
+	h := NewTaskHandler(&mockTaskService{listFn: func(ctx context.Context, filter service.TaskListFilter) ([]model.Task, int64, error) {
+		got = filter
+		return nil, 0, nil
+	}})
+	r := gin.New()
+	r.GET("/tasks", h.List)
+
+	for _, tt := range []struct {
+		query             string
+		want              int
+		sprint, milestone string
+	}{
+		{"sprintId=4&milestoneId=none", http.StatusOK, "4", "none"},
+		{"sprintId=none", http.StatusOK, "none", ""},
+		{"sprintId=next", http.StatusBadRequest, "", ""},
+		{"milestoneId=-1", http.StatusBadRequest, "", ""},
+	} {
+		got = service.TaskListFilter{}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks?"+tt.query, nil))
+		if w.Code != tt.want || got.SprintID != tt.sprint || got.MilestoneID != tt.milestone {
+			t.Fatalf("%s: status = %d, filter = %+v", tt.query, w.Code, got)
+		}
+	}
+}
+
+func TestTaskHandlerListCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewTaskHandler(&mockTaskService{listFn: func(ctx context.Context, filter service.TaskListFilter) ([]model.Task, int64, error) {
+		if filter.Params.Cursor == nil || filter.Params.Cursor.Limit != 2 {
+			t.Fatalf("params = %+v", filter.Params)
+		}
+		if filter.Status == "boom" {
+			return nil, 0, httpx.ErrInvalidCursor
+		}
+		filter.Params.Cursor.Next = "next-page"
+		return []model.Task{{ID: 1}, {ID: 2}}, 10, nil
+	}})
+	r := gin.New()
+	r.GET("/tasks", h.List)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks?limit=2", nil))
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if body["nextCursor"] != "next-page" || body["prevCursor"] != nil || body["limit"] != float64(2) || body["isLast"] != false {
+		t.Fatalf("body = %v", body)
+	}
+	if _, ok := body["page"]; ok {
+		t.Fatalf("cursor page reports page: %v", body)
+	}
+
+	for _, path := range []string{"/tasks?cursor=not-a-cursor", "/tasks?limit=2&status=boom"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("GET %s status = %d, want %d", path, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
 func TestTaskHandlerListTaskComments(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	createdAt := time.Date(2026, 3, 6, 10, 0, 0, 0, time.UTC)
@@ -269,7 +520,7 @@ func TestTaskHandlerCreateTaskComment(t *testing.T) {
 
 func TestTaskHandlerDeleteInternalError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewTaskHandler(&mockTaskService{deleteFn: func(ctx context.Context, id string) error {
+	h := NewTaskHandler(&mockTaskService{deleteFn: func(ctx context.Context, id string, version *uint) error {
 		return errors.New("delete failed")
 	}})
 	r := gin.New()
@@ -292,7 +543,7 @@ func TestTaskHandlerDeleteInternalError(t *testing.T) {
 
 func TestTaskHandlerDeleteNoContent(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	h := NewTaskHandler(&mockTaskService{deleteFn: func(ctx context.Context, id string) error {
+	h := NewTaskHandler(&mockTaskService{deleteFn: func(ctx context.Context, id string, version *uint) error {
 		return nil
 	}})
 	r := gin.New()
@@ -305,3 +556,77 @@ func TestTaskHandlerDeleteNoContent(t *testing.T) {
 		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
 	}
 }
+
+func TestTaskHandlerBulk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var got service.TaskBulkInput
+	h := NewTaskHandler(&mockTaskService{bulkFn: func(ctx context.Context, input service.TaskBulkInput) (service.TaskBulkReport, error) {
+		got = input
+		if input.Op == service.BulkMove {
+			return service.TaskBulkReport{}, service.ErrBulkProject
+		}
+		return service.TaskBulkReport{DryRun: input.DryRun, Matched: 1, Results: []service.TaskBulkResult{{ID: 4, Result: service.BulkDeleted}}}, nil
+	}})
+	r := gin.New()
+	r.POST("/tasks/bulk", h.Bulk)
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`{"filter":{"projectId":" 2 ","filter":"status = done"},"op":"delete","dryRun":true}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"result":"deleted"`) {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if got.Selection.Filter == nil || got.Selection.Filter.ProjectID != "2" || got.Selection.Filter.Filter != "status = done" || !got.DryRun {
+		t.Fatalf("input = %+v", got)
+	}
+
+	for body, want := range map[string]int{
+		`{"ids":[1],"op":"archive"}`:            http.StatusBadRequest,
+		`{"ids":[1],"op":"move","projectId":9}`: http.StatusBadRequest,
+	} {
+		if w := send(body); w.Code != want {
+			t.Fatalf("%s: status = %d, want %d", body, w.Code, want)
+		}
+	}
+}
+
+func TestTaskHandlerWatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls []string
+	record := func(action string) func(ctx context.Context, id string, userID uint) error {
+		return func(ctx context.Context, id string, userID uint) error {
+			if id == "404" {
+				return gorm.ErrRecordNotFound
+			}
+			calls = append(calls, fmt.Sprintf("%s %s %d", action, id, userID))
+			return nil
+		}
+	}
+	h := NewTaskHandler(&mockTaskService{watchFn: record("watch"), unwatchFn: record("unwatch")})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(9)) })
+	h.Register(r.Group("/"))
+
+	for _, tt := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/tasks/1/watch", http.StatusNoContent},
+		{http.MethodDelete, "/tasks/1/watch", http.StatusNoContent},
+		{http.MethodPost, "/tasks/404/watch", http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Fatalf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+	if want := []string{"watch 1 9", "unwatch 1 9"}; !slices.Equal(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
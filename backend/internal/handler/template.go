@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TemplateHandler struct{ service service.TemplateService }
+
+func NewTemplateHandler(service service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{service: service}
+}
+
+// TemplateCreate is the body of POST /project-templates: the project to take
+// a snapshot of and what to take from it.
+type TemplateCreate struct {
+	ProjectID   uint   `json:"projectId" binding:"required"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	CopyOptionsBody
+}
+
+// TemplateUse is the optional body of POST /project-templates/:id/projects.
+// The template's due dates count from startDate, today by default.
+type TemplateUse struct {
+	Title     string `json:"title"`
+	StartDate string `json:"startDate"`
+}
+
+func (h *TemplateHandler) Register(r *gin.RouterGroup) {
+	r.GET("/project-templates", h.List)
+	r.POST("/project-templates", h.Create)
+	r.GET("/project-templates/:id", h.Get)
+	r.DELETE("/project-templates/:id", h.Delete)
+	r.POST("/project-templates/:id/projects", h.Use)
+}
+
+func (h *TemplateHandler) List(c *gin.Context) {
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	items, total, err := h.service.List(c.Request.Context(), lp)
+	if err != nil {
+		listError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, listBody(c, lp, items, total))
+}
+
+func (h *TemplateHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	var body TemplateCreate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	template, err := h.service.Create(c.Request.Context(), service.TemplateCreateInput{
+		ProjectID:   body.ProjectID,
+		Name:        strings.TrimSpace(body.Name),
+		Description: body.Description,
+		CreatedBy:   userID,
+		Options:     body.options(),
+	})
+	if err != nil {
+		h.fail(c, err, "project not found")
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+func (h *TemplateHandler) Get(c *gin.Context) {
+	template, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, err, "template not found")
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *TemplateHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), userID); err != nil {
+		h.fail(c, err, "template not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Use creates a project owned by the caller from the template.
+func (h *TemplateHandler) Use(c *gin.Context) {
+	var body TemplateUse
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	start, ok := startDate(c, body.StartDate)
+	if !ok {
+		return
+	}
+	input := service.TemplateUseInput{Title: strings.TrimSpace(body.Title), StartDate: start}
+	if userID, ok := currentUserID(c); ok {
+		input.OwnerID = &userID
+	}
+	p, err := h.service.Use(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		h.fail(c, err, "template not found")
+		return
+	}
+	c.JSON(http.StatusCreated, p)
+}
+
+func (h *TemplateHandler) fail(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, notFound))
+	case errors.Is(err, service.ErrTemplateForbidden):
+		c.JSON(httpx.StatusFor(httpx.CodeForbidden), httpx.Err(httpx.CodeForbidden, err.Error()))
+	case errors.Is(err, service.ErrInvalidLabel), errors.Is(err, service.ErrSubtasksUnsupported):
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockTemplateService struct {
+	listFn   func(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error)
+	createFn func(ctx context.Context, input service.TemplateCreateInput) (model.ProjectTemplate, error)
+	getFn    func(ctx context.Context, id string) (model.ProjectTemplate, error)
+	deleteFn func(ctx context.Context, id string, userID uint) error
+	useFn    func(ctx context.Context, id string, input service.TemplateUseInput) (model.Project, error)
+}
+
+func (m *mockTemplateService) List(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error) {
+	return m.listFn(ctx, params)
+}
+func (m *mockTemplateService) Create(ctx context.Context, input service.TemplateCreateInput) (model.ProjectTemplate, error) {
+	return m.createFn(ctx, input)
+}
+func (m *mockTemplateService) Get(ctx context.Context, id string) (model.ProjectTemplate, error) {
+	return m.getFn(ctx, id)
+}
+func (m *mockTemplateService) Delete(ctx context.Context, id string, userID uint) error {
+	return m.deleteFn(ctx, id, userID)
+}
+func (m *mockTemplateService) Use(ctx context.Context, id string, input service.TemplateUseInput) (model.Project, error) {
+	return m.useFn(ctx, id, input)
+}
+
+func TestTemplateHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewTemplateHandler(&mockTemplateService{
+		listFn: func(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error) {
+			return []model.ProjectTemplate{{ID: 1, Name: "Onboarding"}}, 1, nil
+		},
+		createFn: func(ctx context.Context, input service.TemplateCreateInput) (model.ProjectTemplate, error) {
+			if input.ProjectID == 404 {
+				return model.ProjectTemplate{}, gorm.ErrRecordNotFound
+			}
+			if input.Options.Subtasks {
+				return model.ProjectTemplate{}, service.ErrSubtasksUnsupported
+			}
+			want := service.CopyOptions{Tasks: true, Labels: false, Descriptions: true, ResetStatuses: true}
+			if input.CreatedBy != 9 || input.Name != "Onboarding" || input.Options != want {
+				t.Fatalf("input = %+v", input)
+			}
+			return model.ProjectTemplate{ID: 1, Name: input.Name}, nil
+		},
+		getFn: func(ctx context.Context, id string) (model.ProjectTemplate, error) {
+			return model.ProjectTemplate{}, gorm.ErrRecordNotFound
+		},
+		deleteFn: func(ctx context.Context, id string, userID uint) error {
+			if id == "2" {
+				return service.ErrTemplateForbidden
+			}
+			return nil
+		},
+		useFn: func(ctx context.Context, id string, input service.TemplateUseInput) (model.Project, error) {
+			if input.OwnerID == nil || *input.OwnerID != 9 || input.Title != "ACME" || !input.StartDate.Equal(time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("input = %+v", input)
+			}
+			return model.Project{ID: 5, Title: input.Title, Status: model.ProjectActive}, nil
+		},
+	})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(9)) })
+	h.Register(r.Group("/"))
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/project-templates", "", http.StatusOK},
+		{http.MethodPost, "/project-templates", `{"projectId":1,"name":"Onboarding","includeLabels":false,"resetStatuses":true}`, http.StatusCreated},
+		{http.MethodPost, "/project-templates", `{"projectId":404,"name":"Onboarding"}`, http.StatusNotFound},
+		{http.MethodPost, "/project-templates", `{"projectId":1,"name":"Onboarding","includeSubtasks":true}`, http.StatusBadRequest},
+		{http.MethodPost, "/project-templates", `{"name":"Onboarding"}`, http.StatusBadRequest},
+		{http.MethodGet, "/project-templates/3", "", http.StatusNotFound},
+		{http.MethodDelete, "/project-templates/1", "", http.StatusNoContent},
+		{http.MethodDelete, "/project-templates/2", "", http.StatusForbidden},
+		{http.MethodPost, "/project-templates/1/projects", `{"title":"ACME","startDate":"2026-11-02"}`, http.StatusCreated},
+		{http.MethodPost, "/project-templates/1/projects", `{"startDate":"next week"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Fatalf("%s %s %s status = %d, want %d: %s", tt.method, tt.path, tt.body, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
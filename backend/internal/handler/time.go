@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// timesheetCSVColumns are the columns of a timesheet export.
+var timesheetCSVColumns = []string{"date", "projectId", "project", "seconds", "hours"}
+
+type TimeHandler struct{ service service.TimeService }
+
+func NewTimeHandler(service service.TimeService) *TimeHandler {
+	return &TimeHandler{service: service}
+}
+
+// TimeEntryCreate logs time on a task. The entry ends at endedAt or after
+// minutes; exactly one of them must be given.
+type TimeEntryCreate struct {
+	StartedAt time.Time  `json:"startedAt" binding:"required"`
+	EndedAt   *time.Time `json:"endedAt"`
+	Minutes   *int       `json:"minutes"`
+	Note      string     `json:"note"`
+}
+
+type TimerStart struct {
+	Note string `json:"note"`
+}
+
+func (h *TimeHandler) Register(r *gin.RouterGroup) {
+	r.GET("/tasks/:id/time-entries", h.List)
+	r.POST("/tasks/:id/time-entries", h.Create)
+	r.DELETE("/time-entries/:id", h.Delete)
+	r.POST("/tasks/:id/timer", h.Start)
+	r.GET("/me/timer", h.Running)
+	r.POST("/me/timer/stop", h.Stop)
+	r.GET("/projects/:id/time", h.ProjectTime)
+	r.GET("/reports/timesheet", h.Timesheet)
+}
+
+// List returns the task's time entries, newest first, with the seconds
+// logged on the task in all of them.
+func (h *TimeHandler) List(c *gin.Context) {
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	items, total, seconds, err := h.service.List(c.Request.Context(), c.Param("id"), lp)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, "task not found"))
+			return
+		}
+		listError(c, err)
+		return
+	}
+	body := listBody(c, lp, items, total)
+	body["seconds"] = seconds
+	c.JSON(http.StatusOK, body)
+}
+
+func (h *TimeHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	var body TimeEntryCreate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	entry, err := h.service.Create(c.Request.Context(), c.Param("id"), service.TimeEntryInput{
+		UserID:    userID,
+		StartedAt: body.StartedAt,
+		EndedAt:   body.EndedAt,
+		Minutes:   body.Minutes,
+		Note:      body.Note,
+	})
+	if err != nil {
+		timeError(c, err, "task not found")
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *TimeHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), userID); err != nil {
+		timeError(c, err, "time entry not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Start starts the caller's timer on the task. A timer running on another
+// task is stopped first.
+func (h *TimeHandler) Start(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	var body TimerStart
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+	}
+	entry, err := h.service.Start(c.Request.Context(), c.Param("id"), userID, body.Note)
+	if err != nil {
+		timeError(c, err, "task not found")
+		return
+	}
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *TimeHandler) Running(c *gin.Context) {
+	h.timer(c, h.service.Running)
+}
+
+func (h *TimeHandler) Stop(c *gin.Context) {
+	h.timer(c, h.service.Stop)
+}
+
+func (h *TimeHandler) timer(c *gin.Context, fn func(ctx context.Context, userID uint) (model.TimeEntry, error)) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	entry, err := fn(c.Request.Context(), userID)
+	if err != nil {
+		timeError(c, err, "no timer is running")
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// ProjectTime returns the seconds logged on the project and on each of its
+// tasks.
+func (h *TimeHandler) ProjectTime(c *gin.Context) {
+	out, err := h.service.ProjectTime(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		timeError(c, err, "project not found")
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// Timesheet reports the time logged from ?from= to ?to=, both UTC dates and
+// inclusive, by day and project. ?userId= narrows it to one user, "me" to
+// the caller; ?format=csv downloads it.
+func (h *TimeHandler) Timesheet(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "format must be json or csv"))
+		return
+	}
+	var filter service.TimesheetFilter
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		day, err := time.Parse(time.DateOnly, c.Query(p.name))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, p.name+" must be a date like 2026-10-01"))
+			return
+		}
+		*p.dst = day
+	}
+	switch raw := c.Query("userId"); raw {
+	case "":
+	case "me":
+		userID, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+			return
+		}
+		filter.UserID = &userID
+	default:
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "invalid userId"))
+			return
+		}
+		userID := uint(id)
+		filter.UserID = &userID
+	}
+	sheet, err := h.service.Timesheet(c.Request.Context(), filter)
+	if err != nil {
+		timeError(c, err, "")
+		return
+	}
+	if format == "json" {
+		c.JSON(http.StatusOK, sheet)
+		return
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="timesheet-%s-%s.csv"`, sheet.From, sheet.To))
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(timesheetCSVColumns)
+	for _, day := range sheet.Days {
+		for _, p := range day.Projects {
+			_ = w.Write([]string{
+				day.Date,
+				strconv.FormatUint(uint64(p.ProjectID), 10),
+				escapeCell(p.Title),
+				strconv.FormatInt(p.Seconds, 10),
+				strconv.FormatFloat(float64(p.Seconds)/3600, 'f', 2, 64),
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func timeError(c *gin.Context, err error, notFound string) {
+	switch {
+	case projectArchived(c, err):
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, notFound))
+	case errors.Is(err, service.ErrTimeEntryForbidden):
+		c.JSON(httpx.StatusFor(httpx.CodeForbidden), httpx.Err(httpx.CodeForbidden, err.Error()))
+	case errors.Is(err, service.ErrTimeEntryDuration), errors.Is(err, service.ErrTimeEntryRange), errors.Is(err, service.ErrTimesheetRange):
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockTimeService struct {
+	createFn    func(ctx context.Context, taskID string, input service.TimeEntryInput) (model.TimeEntry, error)
+	deleteFn    func(ctx context.Context, id string, userID uint) error
+	startFn     func(ctx context.Context, taskID string, userID uint, note string) (model.TimeEntry, error)
+	stopFn      func(ctx context.Context, userID uint) (model.TimeEntry, error)
+	timesheetFn func(ctx context.Context, filter service.TimesheetFilter) (model.Timesheet, error)
+}
+
+func (m *mockTimeService) List(ctx context.Context, taskID string, params httpx.ListParams) ([]model.TimeEntry, int64, int64, error) {
+	if taskID != "1" {
+		return nil, 0, 0, gorm.ErrRecordNotFound
+	}
+	return []model.TimeEntry{{ID: 1, TaskID: 1, Seconds: 90}}, 1, 90, nil
+}
+func (m *mockTimeService) Create(ctx context.Context, taskID string, input service.TimeEntryInput) (model.TimeEntry, error) {
+	return m.createFn(ctx, taskID, input)
+}
+func (m *mockTimeService) Delete(ctx context.Context, id string, userID uint) error {
+	return m.deleteFn(ctx, id, userID)
+}
+func (m *mockTimeService) Running(ctx context.Context, userID uint) (model.TimeEntry, error) {
+	return model.TimeEntry{}, gorm.ErrRecordNotFound
+}
+func (m *mockTimeService) Start(ctx context.Context, taskID string, userID uint, note string) (model.TimeEntry, error) {
+	return m.startFn(ctx, taskID, userID, note)
+}
+func (m *mockTimeService) Stop(ctx context.Context, userID uint) (model.TimeEntry, error) {
+	return m.stopFn(ctx, userID)
+}
+func (m *mockTimeService) ProjectTime(ctx context.Context, projectID string) (model.ProjectTime, error) {
+	return model.ProjectTime{ProjectID: 3, Seconds: 90, Tasks: []model.TaskTime{{TaskID: 1, Title: "Write", Seconds: 90}}}, nil
+}
+func (m *mockTimeService) Timesheet(ctx context.Context, filter service.TimesheetFilter) (model.Timesheet, error) {
+	return m.timesheetFn(ctx, filter)
+}
+
+func TestTimeHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewTimeHandler(&mockTimeService{
+		createFn: func(ctx context.Context, taskID string, input service.TimeEntryInput) (model.TimeEntry, error) {
+			if input.Minutes == nil || *input.Minutes <= 0 {
+				return model.TimeEntry{}, service.ErrTimeEntryDuration
+			}
+			if input.UserID != 9 || input.Note != "review" {
+				t.Fatalf("input = %+v", input)
+			}
+			return model.TimeEntry{ID: 2, TaskID: 1, UserID: 9, Seconds: int64(*input.Minutes) * 60}, nil
+		},
+		deleteFn: func(ctx context.Context, id string, userID uint) error {
+			if id == "3" {
+				return service.ErrTimeEntryForbidden
+			}
+			return nil
+		},
+		startFn: func(ctx context.Context, taskID string, userID uint, note string) (model.TimeEntry, error) {
+			if taskID == "4" {
+				return model.TimeEntry{}, service.ErrProjectArchived
+			}
+			return model.TimeEntry{ID: 5, TaskID: 1, UserID: userID, Note: note}, nil
+		},
+		stopFn: func(ctx context.Context, userID uint) (model.TimeEntry, error) {
+			return model.TimeEntry{ID: 5, UserID: userID, Seconds: 30}, nil
+		},
+		timesheetFn: func(ctx context.Context, filter service.TimesheetFilter) (model.Timesheet, error) {
+			if filter.UserID == nil || *filter.UserID != 9 || !filter.From.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("filter = %+v", filter)
+			}
+			return model.Timesheet{From: "2026-10-01", To: "2026-10-02", UserID: filter.UserID, Seconds: 5400, Days: []model.TimesheetDay{
+				{Date: "2026-10-01", Seconds: 5400, Projects: []model.TimesheetProject{{ProjectID: 3, Title: "=Ops", Seconds: 5400}}},
+			}}, nil
+		},
+	})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(9)) })
+	h.Register(r.Group("/"))
+
+	tests := []struct {
+		method, path, body string
+		want               int
+		contains           string
+	}{
+		{http.MethodGet, "/tasks/1/time-entries", "", http.StatusOK, `"seconds":90`},
+		{http.MethodGet, "/tasks/2/time-entries", "", http.StatusNotFound, "task not found"},
+		{http.MethodPost, "/tasks/1/time-entries", `{"startedAt":"2026-10-01T09:00:00Z","minutes":30,"note":"review"}`, http.StatusCreated, `"seconds":1800`},
+		{http.MethodPost, "/tasks/1/time-entries", `{"startedAt":"2026-10-01T09:00:00Z"}`, http.StatusBadRequest, "either endedAt or minutes"},
+		{http.MethodPost, "/tasks/1/time-entries", `{"minutes":30}`, http.StatusBadRequest, ""},
+		{http.MethodDelete, "/time-entries/2", "", http.StatusNoContent, ""},
+		{http.MethodDelete, "/time-entries/3", "", http.StatusForbidden, "only its author"},
+		{http.MethodPost, "/tasks/1/timer", "", http.StatusCreated, `"id":5`},
+		{http.MethodPost, "/tasks/1/timer", `{"note":"pairing"}`, http.StatusCreated, `"note":"pairing"`},
+		{http.MethodPost, "/tasks/4/timer", "", http.StatusConflict, ""},
+		{http.MethodGet, "/me/timer", "", http.StatusNotFound, "no timer is running"},
+		{http.MethodPost, "/me/timer/stop", "", http.StatusOK, `"seconds":30`},
+		{http.MethodGet, "/projects/3/time", "", http.StatusOK, `"tasks":[{"taskId":1`},
+		{http.MethodGet, "/reports/timesheet?from=2026-10-01&to=2026-10-02&userId=me", "", http.StatusOK, `"projects":[{"projectId":3`},
+		{http.MethodGet, "/reports/timesheet?from=2026-10-01&to=2026-10-02&userId=9&format=csv", "", http.StatusOK,
+			"date,projectId,project,seconds,hours\n2026-10-01,3,'=Ops,5400,1.50\n"},
+		{http.MethodGet, "/reports/timesheet?from=2026-10-01", "", http.StatusBadRequest, "to must be a date"},
+		{http.MethodGet, "/reports/timesheet?from=2026-10-01&to=2026-10-02&userId=ann", "", http.StatusBadRequest, "invalid userId"},
+		{http.MethodGet, "/reports/timesheet?from=2026-10-01&to=2026-10-02&format=xlsx", "", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want || !strings.Contains(w.Body.String(), tt.contains) {
+			t.Fatalf("%s %s %s = %d %s, want %d with %s", tt.method, tt.path, tt.body, w.Code, w.Body.String(), tt.want, tt.contains)
+		}
+	}
+}
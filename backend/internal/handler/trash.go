@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TrashHandler struct{ service service.TrashService }
+
+func NewTrashHandler(service service.TrashService) *TrashHandler {
+	return &TrashHandler{service: service}
+}
+
+func (h *TrashHandler) Register(r *gin.RouterGroup) {
+	r.GET("/trash", h.List)
+	r.POST("/trash/:type/:id/restore", h.Restore)
+}
+
+func (h *TrashHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	var types []string
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	items, total, err := h.service.List(c.Request.Context(), service.TrashFilter{Params: lp, Types: types, UserID: userID})
+	if err != nil {
+		if errors.Is(err, service.ErrTrashType) {
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+		listError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, listBody(c, lp, items, total))
+}
+
+func (h *TrashHandler) Restore(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	kind := c.Param("type")
+	if err := h.service.Restore(c.Request.Context(), kind, c.Param("id"), userID); err != nil {
+		if projectArchived(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrTrashType):
+			c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "type must be one of project, task, comment"))
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, kind+" not found in the trash"))
+		case errors.Is(err, service.ErrTrashParentDeleted):
+			c.JSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
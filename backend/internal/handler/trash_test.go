@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockTrashService struct {
+	listFn    func(ctx context.Context, filter service.TrashFilter) ([]model.TrashItem, int64, error)
+	restoreFn func(ctx context.Context, kind, id string, userID uint) error
+}
+
+func (m *mockTrashService) List(ctx context.Context, filter service.TrashFilter) ([]model.TrashItem, int64, error) {
+	return m.listFn(ctx, filter)
+}
+func (m *mockTrashService) Restore(ctx context.Context, kind, id string, userID uint) error {
+	return m.restoreFn(ctx, kind, id, userID)
+}
+func (m *mockTrashService) Purge(ctx context.Context) (int64, error) { return 0, nil }
+
+func TestTrashHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewTrashHandler(&mockTrashService{
+		listFn: func(ctx context.Context, filter service.TrashFilter) ([]model.TrashItem, int64, error) {
+			if filter.UserID != 9 || len(filter.Types) != 2 || filter.Types[0] != "project" {
+				t.Fatalf("filter = %+v", filter)
+			}
+			return []model.TrashItem{{Type: "project", ID: 4, ProjectID: 4, Title: "Old"}}, 1, nil
+		},
+		restoreFn: func(ctx context.Context, kind, id string, userID uint) error {
+			switch id {
+			case "1":
+				return nil
+			case "2":
+				return gorm.ErrRecordNotFound
+			case "3":
+				return service.ErrTrashParentDeleted
+			case "4":
+				return service.ErrTrashType
+			case "6":
+				return service.ErrProjectArchived
+			}
+			return errors.New("db down")
+		},
+	})
+	r := gin.New()
+	auth := r.Group("/", func(c *gin.Context) { c.Set("userID", uint(9)) })
+	h.Register(auth)
+	r.GET("/anonymous", h.List)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/trash?type=project,task", nil))
+	var body struct {
+		Items []model.TrashItem `json:"items"`
+		Total int64             `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK || body.Total != 1 || body.Items[0].Title != "Old" {
+		t.Fatalf("status = %d body = %s", w.Code, w.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/trash/task/1/restore":    http.StatusNoContent,
+		"/trash/task/2/restore":    http.StatusNotFound,
+		"/trash/comment/3/restore": http.StatusConflict,
+		"/trash/label/4/restore":   http.StatusBadRequest,
+		"/trash/task/5/restore":    http.StatusInternalServerError,
+		"/trash/task/6/restore":    http.StatusConflict,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		if w.Code != want {
+			t.Fatalf("POST %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/anonymous", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous status = %d", w.Code)
+	}
+}
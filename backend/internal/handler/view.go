@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"project-management/internal/filter"
+	"project-management/internal/httpx"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ViewHandler struct{ service service.ViewService }
+
+func NewViewHandler(service service.ViewService) *ViewHandler { return &ViewHandler{service: service} }
+
+// ViewBody is the body of POST /me/views and PUT /views/:id. assigneeId may be
+// "me"; dueFrom and dueTo may be relative (today, today+7d).
+type ViewBody struct {
+	Name       string `json:"name" binding:"required,max=100"`
+	ProjectID  *uint  `json:"projectId"`
+	Filter     string `json:"filter"`
+	Status     string `json:"status"`
+	AssigneeID string `json:"assigneeId"`
+	DueFrom    string `json:"dueFrom"`
+	DueTo      string `json:"dueTo"`
+	Sort       string `json:"sort"`
+	PageSize   int    `json:"pageSize" binding:"min=0,max=100"`
+	GroupBy    string `json:"groupBy"`
+}
+
+func (b ViewBody) input() service.ViewInput {
+	return service.ViewInput{
+		Name:       b.Name,
+		ProjectID:  b.ProjectID,
+		Filter:     b.Filter,
+		Status:     b.Status,
+		AssigneeID: b.AssigneeID,
+		DueFrom:    b.DueFrom,
+		DueTo:      b.DueTo,
+		Sort:       b.Sort,
+		PageSize:   b.PageSize,
+		GroupBy:    b.GroupBy,
+	}
+}
+
+func (h *ViewHandler) Register(r *gin.RouterGroup) {
+	r.GET("/me/views", h.List)
+	r.POST("/me/views", h.Create)
+	r.GET("/views/:id", h.Get)
+	r.PUT("/views/:id", h.Update)
+	r.DELETE("/views/:id", h.Delete)
+	r.GET("/views/:id/tasks", h.Tasks)
+}
+
+func (h *ViewHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	lp, ok := listParams(c)
+	if !ok {
+		return
+	}
+	items, total, err := h.service.List(c.Request.Context(), userID, lp)
+	if err != nil {
+		listError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, listBody(c, lp, items, total))
+}
+
+func (h *ViewHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	var body ViewBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	view, err := h.service.Create(c.Request.Context(), userID, body.input())
+	if err != nil {
+		h.fail(c, err, "project not found")
+		return
+	}
+	c.JSON(http.StatusCreated, view)
+}
+
+func (h *ViewHandler) Get(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	view, err := h.service.Get(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		h.fail(c, err, "view not found")
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+func (h *ViewHandler) Update(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	var body ViewBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+		return
+	}
+	view, err := h.service.Update(c.Request.Context(), c.Param("id"), userID, body.input())
+	if err != nil {
+		h.fail(c, err, "view not found")
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+func (h *ViewHandler) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), c.Param("id"), userID); err != nil {
+		h.fail(c, err, "view not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ViewHandler) Tasks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, httpx.Err(httpx.CodeUnauthorized, "unauthorized"))
+		return
+	}
+	items, total, lp, view, err := h.service.Tasks(c.Request.Context(), c.Param("id"), userID, c.Request.URL.Query())
+	if err != nil {
+		h.fail(c, err, "view not found")
+		return
+	}
+	resp := listBody(c, lp, items, total)
+	if groups := service.GroupTasks(view, items); groups != nil {
+		resp["groupBy"] = view.GroupBy
+		resp["groups"] = groups
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *ViewHandler) fail(c *gin.Context, err error, notFound string) {
+	var ferr *filter.Error
+	switch {
+	case errors.As(err, &ferr):
+		c.JSON(http.StatusBadRequest, filterError(ferr))
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(httpx.StatusFor(httpx.CodeNotFound), httpx.Err(httpx.CodeNotFound, notFound))
+	case errors.Is(err, service.ErrViewForbidden):
+		c.JSON(httpx.StatusFor(httpx.CodeForbidden), httpx.Err(httpx.CodeForbidden, err.Error()))
+	case errors.Is(err, service.ErrViewGroupBy), errors.Is(err, service.ErrViewDate), errors.Is(err, service.ErrViewAssignee), errors.Is(err, service.ErrViewStatus), errors.Is(err, httpx.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+	}
+}
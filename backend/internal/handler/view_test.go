@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"project-management/internal/filter"
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type mockViewService struct {
+	createFn func(ctx context.Context, ownerID uint, input service.ViewInput) (model.SavedView, error)
+	deleteFn func(ctx context.Context, id string, userID uint) error
+	tasksFn  func(ctx context.Context, id string, userID uint, query url.Values) ([]model.Task, int64, httpx.ListParams, model.SavedView, error)
+}
+
+func (m *mockViewService) Create(ctx context.Context, ownerID uint, input service.ViewInput) (model.SavedView, error) {
+	return m.createFn(ctx, ownerID, input)
+}
+func (m *mockViewService) List(ctx context.Context, userID uint, params httpx.ListParams) ([]model.SavedView, int64, error) {
+	return []model.SavedView{}, 0, nil
+}
+func (m *mockViewService) Get(ctx context.Context, id string, userID uint) (model.SavedView, error) {
+	return model.SavedView{}, gorm.ErrRecordNotFound
+}
+func (m *mockViewService) Update(ctx context.Context, id string, userID uint, input service.ViewInput) (model.SavedView, error) {
+	return model.SavedView{}, gorm.ErrRecordNotFound
+}
+func (m *mockViewService) Delete(ctx context.Context, id string, userID uint) error {
+	return m.deleteFn(ctx, id, userID)
+}
+func (m *mockViewService) Tasks(ctx context.Context, id string, userID uint, query url.Values) ([]model.Task, int64, httpx.ListParams, model.SavedView, error) {
+	return m.tasksFn(ctx, id, userID, query)
+}
+
+func TestViewHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewViewHandler(&mockViewService{
+		createFn: func(ctx context.Context, ownerID uint, input service.ViewInput) (model.SavedView, error) {
+			switch input.Name {
+			case "bad filter":
+				return model.SavedView{}, &filter.Error{Pos: 1, Msg: "unknown field"}
+			case "bad group":
+				return model.SavedView{}, service.ErrViewGroupBy
+			}
+			if ownerID != 9 || input.AssigneeID != "me" || input.DueTo != "today+7d" {
+				t.Fatalf("owner=%d input=%+v", ownerID, input)
+			}
+			return model.SavedView{ID: 1, OwnerID: ownerID, Name: input.Name}, nil
+		},
+		deleteFn: func(ctx context.Context, id string, userID uint) error {
+			if id == "2" {
+				return service.ErrViewForbidden
+			}
+			return gorm.ErrRecordNotFound
+		},
+		tasksFn: func(ctx context.Context, id string, userID uint, query url.Values) ([]model.Task, int64, httpx.ListParams, model.SavedView, error) {
+			items := []model.Task{{ID: 1, Status: model.TaskTodo}, {ID: 2, Status: model.TaskDone}}
+			return items, 2, httpx.ParseListParams(query.Get("page"), query.Get("pageSize"), ""), model.SavedView{ID: 1, GroupBy: "status"}, nil
+		},
+	})
+	r := gin.New()
+	api := r.Group("/", func(c *gin.Context) { c.Set("userID", uint(9)) })
+	h.Register(api)
+
+	for name, want := range map[string]int{
+		"Due soon":   http.StatusCreated,
+		"bad filter": http.StatusBadRequest,
+		"bad group":  http.StatusBadRequest,
+		"":           http.StatusBadRequest,
+	} {
+		body, _ := json.Marshal(map[string]any{"name": name, "assigneeId": "me", "dueTo": "today+7d"})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/me/views", bytes.NewReader(body)))
+		if w.Code != want {
+			t.Fatalf("create %q status = %d, want %d: %s", name, w.Code, want, w.Body.String())
+		}
+	}
+
+	for path, want := range map[string]int{"/views/2": http.StatusForbidden, "/views/5": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+		if w.Code != want {
+			t.Fatalf("DELETE %s status = %d, want %d", path, w.Code, want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/views/1/tasks", nil))
+	var resp struct {
+		Items   []model.Task            `json:"items"`
+		GroupBy string                  `json:"groupBy"`
+		Groups  []service.ViewTaskGroup `json:"groups"`
+		IsLast  bool                    `json:"isLast"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(resp.Items) != 2 || resp.GroupBy != "status" || len(resp.Groups) != 2 || resp.Groups[1].Key != "done" || !resp.IsLast {
+		t.Fatalf("resp = %+v", resp)
+	}
+}
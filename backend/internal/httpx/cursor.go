@@ -0,0 +1,366 @@
+package httpx
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorPage is the cursor-mode part of ListParams. The request's cursor is
+// decoded into it by ParseListQuery; FindPage fills in Next and Prev, the
+// cursors of the neighbouring pages, empty when there is none.
+type CursorPage struct {
+	Limit int
+	Next  string
+	Prev  string
+
+	sort   string
+	before bool
+	keys   []cursorKey
+}
+
+// cursor is the decoded form of the opaque ?cursor= value: the sort it was
+// made for and the sort key values of the row the page starts after (or, when
+// Before is set, ends before).
+type cursor struct {
+	Sort   string      `json:"s,omitempty"`
+	Before bool        `json:"b,omitempty"`
+	Keys   []cursorKey `json:"k"`
+}
+
+// cursorKey is one typed sort key value. Type is empty for NULL.
+type cursorKey struct {
+	Field    string `json:"f"`
+	Type     string `json:"t,omitempty"`
+	Value    string `json:"v,omitempty"`
+	Nullable bool   `json:"n,omitempty"`
+}
+
+// ParseListQuery reads the pagination, sort and count parameters of a list
+// request. The query selects cursor mode by carrying cursor or limit, page mode
+// otherwise. A cursor remembers the sort it was made with, so later pages
+// need only ?cursor=; a different sort is rejected with ErrInvalidCursor.
+func ParseListQuery(q url.Values) (ListParams, error) {
+	p := ParseListParams(q.Get("page"), q.Get("pageSize"), q.Get("sort"))
+	p.SkipCount = q.Get("count") == "false"
+	if !q.Has("cursor") && !q.Has("limit") {
+		return p, nil
+	}
+	p.Page = 1
+	p.PageSize = ParseListParams("", q.Get("limit"), "").PageSize
+	page := &CursorPage{Limit: p.PageSize, sort: formatSort(p.Sort)}
+	if raw := q.Get("cursor"); raw != "" {
+		c, err := decodeCursor(raw)
+		if err != nil {
+			return ListParams{}, err
+		}
+		if q.Get("sort") != "" && page.sort != c.Sort {
+			return ListParams{}, fmt.Errorf("%w: it was made for sort=%s", ErrInvalidCursor, c.Sort)
+		}
+		p.Sort = ParseListParams("", "", c.Sort).Sort
+		page.sort, page.before, page.keys = c.Sort, c.Before, c.Keys
+	}
+	p.Cursor = page
+	return p, nil
+}
+
+// FindPage orders db by the requested sort, or by defaultSort (e.g.
+// "-createdAt") when the request names no allowed field, and breaks ties with
+// the unique fields ("id" when none are given) so the order is total. It runs
+// one page into dest, a pointer to a slice of structs whose JSON field names
+// match the sort fields.
+//
+// In cursor mode it seeks past the cursor's row instead of using OFFSET, reads
+// one extra row to learn whether another page follows, and sets p.Cursor.Next
+// and p.Cursor.Prev.
+func FindPage(db *gorm.DB, dest any, allowed map[string]string, p ListParams, defaultSort string, unique ...string) error {
+	keys := sortKeys(allowed, p.Sort, defaultSort, unique)
+	if p.Cursor == nil {
+		for _, k := range keys {
+			db = db.Order(order(allowed[k.Field], k.Desc))
+		}
+		return ApplyPagination(db, p).Find(dest).Error
+	}
+
+	page := p.Cursor
+	if page.keys != nil {
+		if len(page.keys) != len(keys) {
+			return ErrInvalidCursor
+		}
+		cond, args, err := keysetCondition(allowed, keys, page.keys, page.before)
+		if err != nil {
+			return err
+		}
+		db = db.Where(cond, args...)
+	}
+	for _, k := range keys {
+		db = db.Order(order(allowed[k.Field], k.Desc != page.before))
+	}
+	if err := db.Limit(page.Limit + 1).Find(dest).Error; err != nil {
+		return err
+	}
+
+	items := reflect.ValueOf(dest).Elem()
+	more := items.Len() > page.Limit
+	if more {
+		items.Set(items.Slice(0, page.Limit))
+	}
+	if page.before {
+		swap := reflect.Swapper(items.Interface())
+		for i, j := 0, items.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	page.Next, page.Prev = "", ""
+	if items.Len() == 0 {
+		return nil
+	}
+	var err error
+	if (more && !page.before) || (page.before && page.keys != nil) {
+		if page.Next, err = encodeCursor(page.sort, false, keys, items.Index(items.Len()-1)); err != nil {
+			return err
+		}
+	}
+	if (more && page.before) || (!page.before && page.keys != nil) {
+		if page.Prev, err = encodeCursor(page.sort, true, keys, items.Index(0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortKeys resolves the effective sort: the allowed requested fields, else
+// defaultSort, followed by the unique fields in the direction of the last key.
+func sortKeys(allowed map[string]string, requested []SortField, defaultSort string, unique []string) []SortField {
+	var keys []SortField
+	seen := map[string]bool{}
+	add := func(fields []SortField) {
+		for _, s := range fields {
+			if _, ok := allowed[s.Field]; ok && !seen[s.Field] {
+				seen[s.Field] = true
+				keys = append(keys, s)
+			}
+		}
+	}
+	add(requested)
+	if len(keys) == 0 {
+		add(ParseListParams("", "", defaultSort).Sort)
+	}
+	if len(unique) == 0 {
+		unique = []string{"id"}
+	}
+	desc := len(keys) > 0 && keys[len(keys)-1].Desc
+	for _, field := range unique {
+		add([]SortField{{Field: field, Desc: desc}})
+	}
+	return keys
+}
+
+func order(col string, desc bool) string {
+	if desc {
+		return col + " DESC"
+	}
+	return col + " ASC"
+}
+
+func formatSort(sorts []SortField) string {
+	parts := make([]string, len(sorts))
+	for i, s := range sorts {
+		parts[i] = s.Field
+		if s.Desc {
+			parts[i] = "-" + s.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// keysetCondition selects the rows that come after the cursor's row in the
+// order of keys, or before it when before is set:
+//
+//	k1 > v1 OR (k1 = v1 AND k2 > v2) OR ...
+//
+// NULLs sort last in ascending order and first in descending order, as in
+// Postgres.
+func keysetCondition(allowed map[string]string, keys []SortField, values []cursorKey, before bool) (string, []any, error) {
+	var terms []string
+	var args []any
+	var equal []string
+	var equalArgs []any
+	for i, k := range keys {
+		v := values[i]
+		if v.Field != k.Field {
+			return "", nil, ErrInvalidCursor
+		}
+		arg, err := v.arg()
+		if err != nil {
+			return "", nil, err
+		}
+		col := allowed[k.Field]
+		desc := k.Desc != before
+
+		var next string
+		switch {
+		case arg == nil && !desc:
+			// Nothing sorts after NULL in ascending order.
+		case arg == nil:
+			next = col + " IS NOT NULL"
+		case desc:
+			next = col + " < ?"
+		case v.Nullable:
+			next = "(" + col + " > ? OR " + col + " IS NULL)"
+		default:
+			next = col + " > ?"
+		}
+		if next != "" {
+			terms = append(terms, strings.Join(append(append([]string{}, equal...), next), " AND "))
+			args = append(args, equalArgs...)
+			if arg != nil {
+				args = append(args, arg)
+			}
+		}
+
+		if arg == nil {
+			equal = append(equal, col+" IS NULL")
+		} else {
+			equal = append(equal, col+" = ?")
+			equalArgs = append(equalArgs, arg)
+		}
+	}
+	if len(terms) == 0 {
+		return "1 = 0", nil, nil
+	}
+	return "((" + strings.Join(terms, ") OR (") + "))", args, nil
+}
+
+func encodeCursor(sort string, before bool, keys []SortField, item reflect.Value) (string, error) {
+	c := cursor{Sort: sort, Before: before, Keys: make([]cursorKey, len(keys))}
+	for i, k := range keys {
+		field, ok := jsonField(item, k.Field)
+		if !ok {
+			return "", fmt.Errorf("httpx: %s has no field %q to build a cursor from", item.Type(), k.Field)
+		}
+		key, err := newCursorKey(k.Field, field)
+		if err != nil {
+			return "", err
+		}
+		c.Keys[i] = key
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(raw string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || json.Unmarshal(data, &c) != nil || len(c.Keys) == 0 {
+		return cursor{}, ErrInvalidCursor
+	}
+	for _, k := range c.Keys {
+		if _, err := k.arg(); err != nil {
+			return cursor{}, err
+		}
+	}
+	return c, nil
+}
+
+// jsonField finds the field of the struct v (or pointer to it) serialized as
+// name, looking into embedded structs.
+func jsonField(v reflect.Value, name string) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.Anonymous && tag == "" {
+			if found, ok := jsonField(v.Field(i), name); ok {
+				return found, true
+			}
+			continue
+		}
+		if tag == name || tag == "" && f.Name == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func newCursorKey(name string, v reflect.Value) (cursorKey, error) {
+	key := cursorKey{Field: name}
+	if v.Kind() == reflect.Pointer {
+		key.Nullable = true
+		if v.IsNil() {
+			return key, nil
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		key.Type, key.Value = "t", t.UTC().Format(time.RFC3339Nano)
+		return key, nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		key.Type, key.Value = "s", v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		key.Type, key.Value = "i", strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		key.Type, key.Value = "u", strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		key.Type, key.Value = "f", strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Bool:
+		key.Type, key.Value = "b", strconv.FormatBool(v.Bool())
+	default:
+		return cursorKey{}, fmt.Errorf("httpx: cannot build a cursor from %s field %q", v.Type(), name)
+	}
+	return key, nil
+}
+
+// arg is the query argument for the key, nil for NULL.
+func (k cursorKey) arg() (any, error) {
+	var (
+		v   any
+		err error
+	)
+	switch k.Type {
+	case "":
+		return nil, nil
+	case "t":
+		v, err = time.Parse(time.RFC3339Nano, k.Value)
+	case "s":
+		v = k.Value
+	case "i":
+		v, err = strconv.ParseInt(k.Value, 10, 64)
+	case "u":
+		v, err = strconv.ParseUint(k.Value, 10, 64)
+	case "f":
+		v, err = strconv.ParseFloat(k.Value, 64)
+	case "b":
+		v, err = strconv.ParseBool(k.Value)
+	default:
+		err = errors.New("unknown type")
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return v, nil
+}
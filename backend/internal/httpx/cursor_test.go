@@ -0,0 +1,138 @@
+package httpx
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/logger"
+)
+
+type cursorTestRow struct {
+	ID      uint       `json:"id"`
+	Title   string     `json:"title"`
+	DueDate *time.Time `json:"dueDate"`
+}
+
+// newRowsDB returns a dry-run DB whose queries "return" rows and record
+// their SQL in *sql.
+func newRowsDB(t *testing.T, rows *[]cursorTestRow, sql *string) *gorm.DB {
+	t.Helper()
+	db := newDryRunDB(t)
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	err := db.Callback().Query().After("gorm:query").Register("test:rows", func(tx *gorm.DB) {
+		*sql = logger.ExplainSQL(tx.Statement.SQL.String(), nil, "'", tx.Statement.Vars...)
+		reflect.ValueOf(tx.Statement.Dest).Elem().Set(reflect.ValueOf(append([]cursorTestRow(nil), *rows...)))
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return db
+}
+
+func TestParseListQuery(t *testing.T) {
+	p, err := ParseListQuery(url.Values{"page": {"2"}, "pageSize": {"5"}})
+	if err != nil || p.Cursor != nil || p.Page != 2 || p.PageSize != 5 {
+		t.Fatalf("page mode = %+v, %v", p, err)
+	}
+	p, err = ParseListQuery(url.Values{"cursor": {""}, "limit": {"500"}, "page": {"3"}})
+	if err != nil || p.Cursor == nil || p.Cursor.Limit != 100 || p.Page != 1 {
+		t.Fatalf("cursor mode = %+v, %v", p, err)
+	}
+	for _, raw := range []string{"%%%", "bm90IGpzb24", "eyJrIjpbXX0"} {
+		if _, err := ParseListQuery(url.Values{"cursor": {raw}}); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("cursor %q err = %v, want ErrInvalidCursor", raw, err)
+		}
+	}
+}
+
+func TestFindPageCursor(t *testing.T) {
+	day := func(d int) *time.Time {
+		t := time.Date(2026, 11, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	allowed := map[string]string{"id": "id", "title": "title", "dueDate": "due_date"}
+	var rows []cursorTestRow
+	var sql string
+	db := newRowsDB(t, &rows, &sql)
+
+	find := func(q url.Values) ([]cursorTestRow, *CursorPage) {
+		t.Helper()
+		p, err := ParseListQuery(q)
+		if err != nil {
+			t.Fatalf("ParseListQuery: %v", err)
+		}
+		var items []cursorTestRow
+		if err := FindPage(db.Model(&cursorTestRow{}), &items, allowed, p, "-id"); err != nil {
+			t.Fatalf("FindPage: %v", err)
+		}
+		return items, p.Cursor
+	}
+
+	// First page: one row more than the limit means there is a next page.
+	rows = []cursorTestRow{{ID: 1, DueDate: nil}, {ID: 4, DueDate: day(9)}, {ID: 2, DueDate: day(3)}}
+	items, page := find(url.Values{"limit": {"2"}, "sort": {"-dueDate"}})
+	if !strings.Contains(sql, "ORDER BY due_date DESC,id DESC LIMIT 3") || strings.Contains(sql, "WHERE") {
+		t.Fatalf("sql = %s", sql)
+	}
+	if len(items) != 2 || items[1].ID != 4 || page.Next == "" || page.Prev != "" {
+		t.Fatalf("items = %+v, page = %+v", items, page)
+	}
+
+	// The next page seeks past (2026-11-09, 4) and keeps the cursor's sort.
+	rows = []cursorTestRow{{ID: 2, DueDate: day(3)}}
+	items, next := find(url.Values{"cursor": {page.Next}})
+	if !strings.Contains(sql, "WHERE ((due_date < '2026-11-09 00:00:00') OR (due_date = '2026-11-09 00:00:00' AND id < 4)) ORDER BY due_date DESC,id DESC LIMIT 21") {
+		t.Fatalf("sql = %s", sql)
+	}
+	if len(items) != 1 || next.Next != "" || next.Prev == "" {
+		t.Fatalf("items = %+v, page = %+v", items, next)
+	}
+
+	// Going back reverses the order, then returns the rows in sort order.
+	rows = []cursorTestRow{{ID: 4, DueDate: day(9)}, {ID: 1}}
+	items, prev := find(url.Values{"cursor": {next.Prev}, "limit": {"2"}})
+	if !strings.Contains(sql, "WHERE (((due_date > '2026-11-03 00:00:00' OR due_date IS NULL)) OR (due_date = '2026-11-03 00:00:00' AND id > 2)) ORDER BY due_date ASC,id ASC LIMIT 3") {
+		t.Fatalf("sql = %s", sql)
+	}
+	if len(items) != 2 || items[0].ID != 1 || items[1].ID != 4 || prev.Prev != "" || prev.Next == "" {
+		t.Fatalf("items = %+v, page = %+v", items, prev)
+	}
+
+	// NULLs come first in descending order, so after a NULL only non-NULL
+	// values and NULLs with a smaller ID remain.
+	rows = []cursorTestRow{{ID: 1}, {ID: 4, DueDate: day(9)}}
+	_, page = find(url.Values{"limit": {"1"}, "sort": {"-dueDate"}})
+	find(url.Values{"cursor": {page.Next}})
+	if !strings.Contains(sql, "WHERE ((due_date IS NOT NULL) OR (due_date IS NULL AND id < 1))") {
+		t.Fatalf("sql = %s", sql)
+	}
+
+	if _, err := ParseListQuery(url.Values{"cursor": {page.Next}, "sort": {"title"}}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("err = %v, want ErrInvalidCursor for a different sort", err)
+	}
+	p, _ := ParseListQuery(url.Values{"cursor": {page.Next}})
+	var items2 []cursorTestRow
+	if err := FindPage(db.Model(&cursorTestRow{}), &items2, map[string]string{"id": "id"}, p, "-id"); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("err = %v, want ErrInvalidCursor for another endpoint's cursor", err)
+	}
+}
+
+func TestFindPageDefaultSortIsTotal(t *testing.T) {
+	var rows []cursorTestRow
+	var sql string
+	db := newRowsDB(t, &rows, &sql)
+	var items []cursorTestRow
+	p := ListParams{Page: 2, PageSize: 10, Sort: []SortField{{Field: "unknown"}}}
+	if err := FindPage(db.Model(&cursorTestRow{}), &items, map[string]string{"id": "id", "title": "title"}, p, "-title"); err != nil {
+		t.Fatalf("FindPage: %v", err)
+	}
+	if !strings.Contains(sql, "ORDER BY title DESC,id DESC LIMIT 10 OFFSET 10") {
+		t.Fatalf("sql = %s", sql)
+	}
+}
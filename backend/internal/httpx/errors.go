@@ -5,16 +5,30 @@ import "net/http"
 type APIError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
 }
 
 func Err(code, msg string) APIError {
 	return APIError{Code: code, Message: msg}
 }
 
+// ErrDetails is Err with machine-readable details, such as the position of a
+// syntax error.
+func ErrDetails(code, msg string, details any) APIError {
+	return APIError{Code: code, Message: msg, Details: details}
+}
+
 const (
-	CodeBadRequest   = "BAD_REQUEST"
-	CodeNotFound     = "NOT_FOUND"
-	CodeUnauthorized = "UNAUTHORIZED"
+	CodeBadRequest           = "BAD_REQUEST"
+	CodeNotFound             = "NOT_FOUND"
+	CodeUnauthorized         = "UNAUTHORIZED"
+	CodeForbidden            = "FORBIDDEN"
+	CodeConflict             = "CONFLICT"
+	CodePayloadTooLarge      = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	CodePreconditionFailed   = "PRECONDITION_FAILED"
+	CodePreconditionRequired = "PRECONDITION_REQUIRED"
+	CodeProjectArchived      = "PROJECT_ARCHIVED"
 )
 
 func StatusFor(code string) int {
@@ -23,6 +37,18 @@ func StatusFor(code string) int {
 		return http.StatusNotFound
 	case CodeUnauthorized:
 		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeConflict, CodeProjectArchived:
+		return http.StatusConflict
+	case CodePayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case CodeUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case CodePreconditionFailed:
+		return http.StatusPreconditionFailed
+	case CodePreconditionRequired:
+		return http.StatusPreconditionRequired
 	default:
 		return http.StatusBadRequest
 	}
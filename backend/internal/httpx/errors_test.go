@@ -12,6 +12,13 @@ func TestErr(t *testing.T) {
 	}
 }
 
+func TestErrDetails(t *testing.T) {
+	err := ErrDetails(CodeBadRequest, "bad", map[string]int{"position": 3})
+	if err.Code != CodeBadRequest || err.Details.(map[string]int)["position"] != 3 {
+		t.Fatalf("err = %+v", err)
+	}
+}
+
 func TestStatusFor(t *testing.T) {
 	tests := []struct {
 		code string
@@ -19,6 +26,11 @@ func TestStatusFor(t *testing.T) {
 	}{
 		{CodeNotFound, http.StatusNotFound},
 		{CodeUnauthorized, http.StatusUnauthorized},
+		{CodeForbidden, http.StatusForbidden},
+		{CodeConflict, http.StatusConflict},
+		{CodeProjectArchived, http.StatusConflict},
+		{CodePayloadTooLarge, http.StatusRequestEntityTooLarge},
+		{CodeUnsupportedMediaType, http.StatusUnsupportedMediaType},
 		{"OTHER", http.StatusBadRequest},
 	}
 	for _, tt := range tests {
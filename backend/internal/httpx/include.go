@@ -0,0 +1,66 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxIncludeDepth bounds include paths: tasks.comments is two levels deep.
+const MaxIncludeDepth = 2
+
+var ErrInclude = errors.New("invalid include")
+
+// Relations is the tree of relations a resource can include. Each relation
+// name maps to the relations of the related resource.
+type Relations map[string]Relations
+
+// Names returns the relation names in sorted order.
+func (r Relations) Names() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseInclude parses ?include=, a comma-separated list of dotted relation
+// paths such as "tasks.comments,tasks.assignee", against allowed. The result
+// lists every path once, parents before their children: "tasks.comments"
+// implies "tasks".
+func ParseInclude(raw string, allowed Relations) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	for _, part := range splitComma(raw) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		names := strings.Split(part, ".")
+		if len(names) > MaxIncludeDepth {
+			return nil, fmt.Errorf("%w: %q is nested deeper than %d levels", ErrInclude, part, MaxIncludeDepth)
+		}
+		rel := allowed
+		for i, name := range names {
+			next, ok := rel[name]
+			if !ok {
+				if len(rel) == 0 && i == 0 {
+					return nil, fmt.Errorf("%w: there are no relations to include", ErrInclude)
+				}
+				if len(rel) == 0 {
+					return nil, fmt.Errorf("%w: %q has no relations to include", ErrInclude, strings.Join(names[:i], "."))
+				}
+				return nil, fmt.Errorf("%w: unknown relation %q; allowed are %s", ErrInclude, strings.Join(names[:i+1], "."), strings.Join(rel.Names(), ", "))
+			}
+			rel = next
+			path := strings.Join(names[:i+1], ".")
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,32 @@
+package httpx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseInclude(t *testing.T) {
+	tasks := Relations{"comments": nil, "assignee": nil}
+	allowed := Relations{"owner": nil, "tasks": tasks}
+
+	got, err := ParseInclude(" tasks.comments, owner,tasks.assignee,tasks ", allowed)
+	if err != nil {
+		t.Fatalf("ParseInclude: %v", err)
+	}
+	if want := []string{"tasks", "tasks.comments", "owner", "tasks.assignee"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	if got, err := ParseInclude("", allowed); err != nil || got != nil {
+		t.Fatalf("empty include = %v, %v", got, err)
+	}
+
+	for _, raw := range []string{"labels", "tasks.owner", "owner.tasks", "tasks.comments.author"} {
+		if _, err := ParseInclude(raw, allowed); !errors.Is(err, ErrInclude) {
+			t.Fatalf("ParseInclude(%q) err = %v, want ErrInclude", raw, err)
+		}
+	}
+	if _, err := ParseInclude("tasks", nil); !errors.Is(err, ErrInclude) {
+		t.Fatalf("err = %v, want ErrInclude without relations", err)
+	}
+}
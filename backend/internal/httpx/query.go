@@ -11,6 +11,10 @@ type ListParams struct {
 	Page     int
 	PageSize int
 	Sort     []SortField
+	// Cursor is set in cursor mode; see ParseListQuery and FindPage.
+	Cursor *CursorPage
+	// SkipCount is set by ?count=false; see Count.
+	SkipCount bool
 }
 
 type SortField struct {
@@ -98,3 +102,21 @@ func atoiDefault(s string, def int) int {
 	}
 	return v
 }
+
+// Count counts the rows of db into total, or sets total to -1 without
+// querying when the request asked for count=false.
+func Count(db *gorm.DB, p ListParams, total *int64) error {
+	if p.SkipCount {
+		*total = -1
+		return nil
+	}
+	return db.Count(total).Error
+}
+
+// TotalPages is the number of pages of p.PageSize rows holding total rows.
+func TotalPages(total int64, p ListParams) int64 {
+	if p.PageSize < 1 {
+		return 0
+	}
+	return (total + int64(p.PageSize) - 1) / int64(p.PageSize)
+}
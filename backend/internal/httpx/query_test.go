@@ -81,6 +81,25 @@ func TestIsLast(t *testing.T) {
 	}
 }
 
+func TestTotalPages(t *testing.T) {
+	for total, want := range map[int64]int64{0: 0, 1: 1, 20: 1, 21: 2} {
+		if got := TotalPages(total, ListParams{Page: 1, PageSize: 20}); got != want {
+			t.Fatalf("TotalPages(%d) = %d, want %d", total, got, want)
+		}
+	}
+}
+
+func TestCountSkipped(t *testing.T) {
+	p, err := ParseListQuery(map[string][]string{"count": {"false"}})
+	if err != nil || !p.SkipCount {
+		t.Fatalf("params = %+v, %v", p, err)
+	}
+	total := int64(5)
+	if err := Count(nil, p, &total); err != nil || total != -1 {
+		t.Fatalf("total = %d, err = %v", total, err)
+	}
+}
+
 func TestSplitCommaAndAtoiDefault(t *testing.T) {
 	if got := splitComma(" "); got != nil {
 		t.Fatalf("splitComma blank = %#v", got)
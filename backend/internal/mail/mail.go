@@ -0,0 +1,106 @@
+// Package mail sends plain-text email through a pluggable Mailer.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds the mailer selected by MAILER: "log" (the default) writes
+// messages to the log, "smtp" sends them through SMTP_ADDR as SMTP_FROM,
+// signing in with SMTP_USERNAME and SMTP_PASSWORD when they are set, and
+// "none" drops them.
+func FromEnv() (Mailer, error) {
+	switch kind := strings.ToLower(strings.TrimSpace(os.Getenv("MAILER"))); kind {
+	case "", "log":
+		return LogMailer{}, nil
+	case "none":
+		return NopMailer{}, nil
+	case "smtp":
+		return NewSMTPMailer(SMTPConfig{
+			Addr:     os.Getenv("SMTP_ADDR"),
+			From:     os.Getenv("SMTP_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", kind)
+	}
+}
+
+// LogMailer writes messages to the log instead of sending them.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("mail to %s: %s", msg.To, msg.Subject)
+	return nil
+}
+
+type NopMailer struct{}
+
+func (NopMailer) Send(ctx context.Context, msg Message) error { return nil }
+
+type SMTPConfig struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+}
+
+type SMTPMailer struct {
+	cfg  SMTPConfig
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	if cfg.Addr == "" || cfg.From == "" {
+		return nil, fmt.Errorf("smtp mailer needs SMTP_ADDR and SMTP_FROM")
+	}
+	m := &SMTPMailer{cfg: cfg}
+	if cfg.Username != "" {
+		host, _, _ := strings.Cut(cfg.Addr, ":")
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return m, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.cfg.Addr, m.auth, m.cfg.From, []string{msg.To}, format(m.cfg.From, msg, time.Now()))
+}
+
+// format renders msg as an RFC 5322 message with CRLF line endings.
+func format(from string, msg Message, now time.Time) []byte {
+	var b strings.Builder
+	header := func(name, value string) { b.WriteString(name + ": " + value + "\r\n") }
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+	text := strings.ReplaceAll(strings.ReplaceAll(msg.Text, "\r\n", "\n"), "\n", "\r\n")
+	b.WriteString(text)
+	return []byte(b.String())
+}
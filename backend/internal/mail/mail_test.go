@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("MAILER", "")
+	if m, err := FromEnv(); err != nil || m != (LogMailer{}) {
+		t.Fatalf("default = %T, %v", m, err)
+	}
+	t.Setenv("MAILER", "smtp")
+	t.Setenv("SMTP_ADDR", "")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("smtp without SMTP_ADDR was accepted")
+	}
+	t.Setenv("SMTP_ADDR", "mail.example.com:587")
+	t.Setenv("SMTP_FROM", "tasks@example.com")
+	t.Setenv("SMTP_USERNAME", "tasks")
+	if m, err := FromEnv(); err != nil || m.(*SMTPMailer).auth == nil {
+		t.Fatalf("smtp = %+v, %v", m, err)
+	}
+	t.Setenv("MAILER", "pigeon")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("unknown mailer was accepted")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	got := string(format("tasks@example.com", Message{To: "ann@example.com", Subject: "Fällig: Report", Text: "line one\nline two"}, now))
+	want := "From: tasks@example.com\r\n" +
+		"To: ann@example.com\r\n" +
+		"Subject: =?utf-8?q?F=C3=A4llig:_Report?=\r\n" +
+		"Date: Sun, 18 Oct 2026 09:00:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: 8bit\r\n" +
+		"\r\n" +
+		"line one\r\nline two"
+	if got != want {
+		t.Fatalf("format =\n%q\nwant\n%q", got, want)
+	}
+	if plain := string(format("a@example.com", Message{Subject: "Due"}, now)); !strings.Contains(plain, "Subject: Due\r\n") {
+		t.Fatalf("ASCII subject was encoded: %q", plain)
+	}
+}
@@ -0,0 +1,153 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// TaskLinkPrefix is prepended to the task ID when rendering a #123 reference.
+const TaskLinkPrefix = "/tasks/"
+
+var (
+	engine = goldmark.New(
+		goldmark.WithExtensions(extension.TaskList, extension.Strikethrough, extension.Table),
+		goldmark.WithParserOptions(
+			parser.WithInlineParsers(util.Prioritized(taskRefParser{}, 500)),
+		),
+	)
+	policy = newPolicy()
+)
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^task-ref$`)).OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9_+-]+$`)).OnElements("code")
+	return p
+}
+
+// TaskRefs returns those of the task IDs referenced as #123 that may be
+// linked.
+type TaskRefs func(ids []uint) ([]uint, error)
+
+// Render converts CommonMark source into sanitized HTML. Empty input renders
+// to an empty string. #123 references stay plain text.
+func Render(src string) (string, error) {
+	return RenderWithRefs(src, nil)
+}
+
+// RenderWithRefs is Render, but links the #123 references that refs returns.
+// refs is only called when the source has references; unknown IDs and a nil
+// refs leave them as plain text.
+func RenderWithRefs(src string, refs TaskRefs) (string, error) {
+	if src == "" {
+		return "", nil
+	}
+	source := []byte(src)
+	doc := engine.Parser().Parse(text.NewReader(source))
+	if err := resolveTaskRefs(doc, refs); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := engine.Renderer().Render(&buf, source, doc); err != nil {
+		return "", err
+	}
+	return string(policy.SanitizeBytes(buf.Bytes())), nil
+}
+
+// resolveTaskRefs replaces the task reference links in doc that refs does not
+// return by their text.
+func resolveTaskRefs(doc ast.Node, refs TaskRefs) error {
+	byID := map[uint][]*ast.Link{}
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if link, ok := n.(*ast.Link); ok && entering {
+			if id, ok := taskRefID(link); ok {
+				byID[id] = append(byID[id], link)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil || len(byID) == 0 {
+		return err
+	}
+	linkable := map[uint]bool{}
+	if refs != nil {
+		ids := make([]uint, 0, len(byID))
+		for id := range byID {
+			ids = append(ids, id)
+		}
+		found, err := refs(ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range found {
+			linkable[id] = true
+		}
+	}
+	for id, links := range byID {
+		if linkable[id] {
+			continue
+		}
+		for _, link := range links {
+			label := link.FirstChild()
+			link.RemoveChild(link, label)
+			link.Parent().ReplaceChild(link.Parent(), link, label)
+		}
+	}
+	return nil
+}
+
+// taskRefID returns the task ID of a link made by taskRefParser.
+func taskRefID(link *ast.Link) (uint, bool) {
+	if class, ok := link.AttributeString("class"); !ok || string(class.([]byte)) != "task-ref" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(string(link.Destination), TaskLinkPrefix), 10, 64)
+	return uint(id), err == nil
+}
+
+// taskRefParser turns "#123" into a link to the referenced task. The hash must
+// not directly follow a word character so anchors like "page#12" are left alone.
+type taskRefParser struct{}
+
+func (taskRefParser) Trigger() []byte { return []byte{'#'} }
+
+func (taskRefParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if prev := block.PrecendingCharacter(); isWordRune(prev) {
+		return nil
+	}
+	line, segment := block.PeekLine()
+	end := 1
+	for end < len(line) && line[end] >= '0' && line[end] <= '9' {
+		end++
+	}
+	if end == 1 || (end < len(line) && isWordRune(rune(line[end]))) {
+		return nil
+	}
+	id, err := strconv.ParseUint(string(line[1:end]), 10, 64)
+	if err != nil || id == 0 {
+		return nil
+	}
+	block.Advance(end)
+
+	link := ast.NewLink()
+	link.Destination = []byte(TaskLinkPrefix + strconv.FormatUint(id, 10))
+	link.SetAttributeString("class", []byte("task-ref"))
+	link.AppendChild(link, ast.NewTextSegment(text.NewSegment(segment.Start, segment.Start+end)))
+	return link
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
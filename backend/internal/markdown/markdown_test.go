@@ -0,0 +1,92 @@
+package markdown
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRenderEmpty(t *testing.T) {
+	html, err := Render("")
+	if err != nil || html != "" {
+		t.Fatalf("html=%q err=%v", html, err)
+	}
+}
+
+func TestRenderCommonMark(t *testing.T) {
+	html, err := Render("**bold** [site](https://example.com)\n\n```go\nx := 1\n```")
+	if err != nil {
+		t.Fatalf("Render error = %v", err)
+	}
+	for _, want := range []string{
+		"<strong>bold</strong>",
+		`<a href="https://example.com" rel="nofollow">site</a>`,
+		`<code class="language-go">x := 1`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("html %q missing %q", html, want)
+		}
+	}
+}
+
+func TestRenderTaskList(t *testing.T) {
+	html, err := Render("- [x] done\n- [ ] todo")
+	if err != nil {
+		t.Fatalf("Render error = %v", err)
+	}
+	if !strings.Contains(html, `<input checked="" disabled="" type="checkbox"> done`) || !strings.Contains(html, `<input disabled="" type="checkbox"> todo`) {
+		t.Fatalf("html = %q", html)
+	}
+}
+
+func TestRenderSanitizes(t *testing.T) {
+	html, err := Render("<script>alert(1)</script>[x](javascript:alert(1)) <img src=x onerror=alert(1)>")
+	if err != nil {
+		t.Fatalf("Render error = %v", err)
+	}
+	for _, banned := range []string{"<script", "javascript:", "onerror"} {
+		if strings.Contains(html, banned) {
+			t.Fatalf("html %q contains %q", html, banned)
+		}
+	}
+}
+
+func TestRenderTaskReferences(t *testing.T) {
+	var asked []uint
+	html, err := RenderWithRefs("see #12, (#4), #12 again and #99 and page#3 or #7x", func(ids []uint) ([]uint, error) {
+		asked = ids
+		return []uint{4, 12}, nil
+	})
+	if err != nil {
+		t.Fatalf("Render error = %v", err)
+	}
+	if len(asked) != 3 {
+		t.Fatalf("looked up %v, want 12, 4 and 99 once each", asked)
+	}
+	if strings.Count(html, `<a href="/tasks/12" class="task-ref" rel="nofollow">#12</a>`) != 2 {
+		t.Fatalf("html %q missing #12 links", html)
+	}
+	if !strings.Contains(html, `(<a href="/tasks/4" class="task-ref" rel="nofollow">#4</a>)`) {
+		t.Fatalf("html %q missing #4 link", html)
+	}
+	// An unknown or invisible task stays plain text.
+	if !strings.Contains(html, "and #99 and") || strings.Contains(html, "/tasks/99") {
+		t.Fatalf("html %q links #99", html)
+	}
+	if strings.Contains(html, "/tasks/3") || strings.Contains(html, "/tasks/7") {
+		t.Fatalf("html %q links a non-reference", html)
+	}
+}
+
+func TestRenderTaskReferencesWithoutRefs(t *testing.T) {
+	html, err := Render("see **#12**")
+	if err != nil {
+		t.Fatalf("Render error = %v", err)
+	}
+	if html != "<p>see <strong>#12</strong></p>\n" {
+		t.Fatalf("html = %q", html)
+	}
+	if _, err := RenderWithRefs("see #12", func([]uint) ([]uint, error) { return nil, errors.New("db down") }); err == nil {
+		t.Fatal("lookup error not returned")
+	}
+}
@@ -35,3 +35,19 @@ func JWTAuth() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireAdmin must run after JWTAuth. It lets through the users whose email
+// is one of admins, ignoring case.
+func RequireAdmin(admins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(admins))
+	for _, admin := range admins {
+		allowed[strings.ToLower(admin)] = true
+	}
+	return func(c *gin.Context) {
+		if email := strings.ToLower(c.GetString("userEmail")); email != "" && allowed[email] {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, httpx.Err(httpx.CodeForbidden, "admin access required"))
+	}
+}
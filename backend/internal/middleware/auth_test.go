@@ -76,6 +76,35 @@ func TestJWTAuth(t *testing.T) {
 	})
 }
 
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	admins := []string{"root@example.com", "Ops@Example.com"}
+
+	newRouter := func(email string) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) { c.Set("userEmail", email) }, RequireAdmin(admins))
+		r.GET("/admin", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		return r
+	}
+
+	tests := []struct {
+		email string
+		want  int
+	}{
+		{"root@example.com", http.StatusNoContent},
+		{"ops@example.com", http.StatusNoContent},
+		{"user@example.com", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		newRouter(tt.email).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+		if w.Code != tt.want {
+			t.Fatalf("email %q status = %d, want %d", tt.email, w.Code, tt.want)
+		}
+	}
+}
+
 func assertMiddlewareError(t *testing.T, w *httptest.ResponseRecorder, wantMsg string) {
 	t.Helper()
 	if w.Code != http.StatusUnauthorized {
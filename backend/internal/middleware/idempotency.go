@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxIdempotencyKey = 255
+	// maxIdempotentBody bounds the request body read to fingerprint it.
+	maxIdempotentBody = 64 << 20
+)
+
+// replayedHeaders are the response headers stored with a response besides
+// its body.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Idempotency must run after JWTAuth. A POST with an Idempotency-Key header
+// runs once per user and key: a retry with the same method, path and body
+// gets the stored response with Idempotent-Replayed: true, the same key with
+// another request answers 409, and so does a retry while the first request
+// is still running. Server errors are not stored, so such a request can be
+// retried with the same key.
+func Idempotency(svc service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			c.AbortWithStatusJSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, "Idempotency-Key must be at most 255 characters"))
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(httpx.StatusFor(httpx.CodePayloadTooLarge), httpx.Err(httpx.CodePayloadTooLarge, "request body too large"))
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, httpx.Err(httpx.CodeBadRequest, err.Error()))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		record, replay, err := svc.Begin(ctx, c.GetUint("userID"), key, fingerprint(c.Request, body))
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyReused):
+			c.AbortWithStatusJSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+			return
+		case errors.Is(err, service.ErrIdempotencyInProgress):
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(httpx.StatusFor(httpx.CodeConflict), httpx.Err(httpx.CodeConflict, err.Error()))
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, httpx.Err("INTERNAL", err.Error()))
+			return
+		case replay:
+			replayResponse(c, record)
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		completed := false
+		defer func() {
+			// A panicking handler leaves no response to store; free the key.
+			if !completed {
+				if err := svc.Abandon(ctx, record.ID); err != nil {
+					log.Printf("idempotency: abandon key %d: %v", record.ID, err)
+				}
+			}
+		}()
+		c.Next()
+
+		completed = true
+		if w.Status() >= http.StatusInternalServerError {
+			completed = false
+			return
+		}
+		header := map[string]string{}
+		for _, name := range replayedHeaders {
+			if v := w.Header().Get(name); v != "" {
+				header[name] = v
+			}
+		}
+		encoded, _ := json.Marshal(header)
+		record.Status, record.Header, record.Body = w.Status(), string(encoded), w.body.Bytes()
+		if err := svc.Complete(ctx, record); err != nil {
+			log.Printf("idempotency: store response for key %d: %v", record.ID, err)
+		}
+	}
+}
+
+// fingerprint identifies a request by method, path with query, and body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replayResponse(c *gin.Context, record model.IdempotencyKey) {
+	var header map[string]string
+	_ = json.Unmarshal([]byte(record.Header), &header)
+	for name, value := range header {
+		c.Header(name, value)
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Header("Content-Length", strconv.Itoa(len(record.Body)))
+	c.Status(record.Status)
+	c.Writer.Write(record.Body)
+	c.Abort()
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// memIdempotencyRepo keeps keys in memory; the mutex stands in for the
+// unique index.
+type memIdempotencyRepo struct {
+	mu      sync.Mutex
+	records map[string]model.IdempotencyKey
+	nextID  uint
+}
+
+func (r *memIdempotencyRepo) Reserve(ctx context.Context, record *model.IdempotencyKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.records[record.Key]; ok && stored.ExpiresAt.After(record.CreatedAt) {
+		return false, nil
+	}
+	r.nextID++
+	record.ID = r.nextID
+	r.records[record.Key] = *record
+	return true, nil
+}
+func (r *memIdempotencyRepo) Find(ctx context.Context, userID uint, key string) (model.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.records[key]
+	if !ok {
+		return model.IdempotencyKey{}, gorm.ErrRecordNotFound
+	}
+	return stored, nil
+}
+func (r *memIdempotencyRepo) Complete(ctx context.Context, record model.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.Key] = record
+	return nil
+}
+func (r *memIdempotencyRepo) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, stored := range r.records {
+		if stored.ID == id {
+			delete(r.records, key)
+		}
+	}
+	return nil
+}
+func (r *memIdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &memIdempotencyRepo{records: map[string]model.IdempotencyKey{}}
+	created := 0
+	release := make(chan struct{})
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userID", uint(1)) }, Idempotency(service.NewIdempotencyService(repo)))
+	r.POST("/tasks", func(c *gin.Context) {
+		created++
+		c.Header("Location", "/tasks/1")
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+	r.POST("/slow", func(c *gin.Context) {
+		<-release
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/fail", func(c *gin.Context) {
+		created++
+		c.JSON(http.StatusInternalServerError, gin.H{"code": "INTERNAL"})
+	})
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("/tasks", "a", `{"title":"x"}`)
+	retry := send("/tasks", "a", `{"title":"x"}`)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || created != 1 {
+		t.Fatalf("first = %d %s, retry = %d %s, created = %d", first.Code, first.Body, retry.Code, retry.Body, created)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" || retry.Header().Get("Location") != "/tasks/1" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("retry headers = %v", retry.Header())
+	}
+	if w := send("/tasks", "a", `{"title":"y"}`); w.Code != http.StatusConflict {
+		t.Fatalf("other body: status = %d, want 409", w.Code)
+	}
+	if send("/tasks", "", `{"title":"x"}`); created != 2 {
+		t.Fatalf("request without a key was not run: created = %d", created)
+	}
+
+	send("/fail", "b", `{}`)
+	send("/fail", "b", `{}`)
+	if created != 4 {
+		t.Fatalf("server error was replayed: created = %d", created)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send("/slow", "c", `{}`) }()
+	for {
+		if _, err := repo.Find(context.Background(), 1, "c"); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if w := send("/slow", "c", `{}`); w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("in-flight duplicate: status = %d, headers = %v", w.Code, w.Header())
+	}
+	close(release)
+	if w := <-done; w.Code != http.StatusNoContent {
+		t.Fatalf("slow status = %d", w.Code)
+	}
+	if w := send("/slow", "c", `{}`); w.Code != http.StatusNoContent || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replayed slow: status = %d", w.Code)
+	}
+}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type ProjectStatus string
 
@@ -18,37 +22,217 @@ const (
 )
 
 type Project struct {
-	ID          uint          `json:"id" gorm:"primaryKey"`
-	Title       string        `json:"title" gorm:"not null;index"`
-	Description string        `json:"description"`
-	Status      ProjectStatus `json:"status" gorm:"not null;index"`
-	CreatedAt   time.Time     `json:"createdAt" gorm:"index"`
-	UpdatedAt   time.Time     `json:"updatedAt"`
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Title           string         `json:"title" gorm:"not null;index"`
+	Description     string         `json:"description"`
+	DescriptionHTML string         `json:"descriptionHtml"`
+	Status          ProjectStatus  `json:"status" gorm:"not null;index"`
+	OwnerID         *uint          `json:"ownerId,omitempty" gorm:"index"`
+	ArchivedAt      *time.Time     `json:"archivedAt,omitempty"`
+	ArchivedBy      *uint          `json:"archivedBy,omitempty"`
+	Version         uint           `json:"version" gorm:"not null;default:1"`
+	CreatedAt       time.Time      `json:"createdAt" gorm:"index"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 
+	Owner *User  `json:"owner,omitempty" gorm:"foreignKey:OwnerID;-:migration"`
 	Tasks []Task `json:"tasks,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
 }
 
 type Task struct {
-	ID          uint       `json:"id" gorm:"primaryKey"`
-	ProjectID   uint       `json:"projectId" gorm:"not null;index"`
-	Title       string     `json:"title" gorm:"not null;index"`
-	Description string     `json:"description"`
-	Status      TaskStatus `json:"status" gorm:"not null;index"`
-	AssigneeID  *uint      `json:"assigneeId,omitempty" gorm:"index"`
-	DueDate     *time.Time `json:"dueDate,omitempty" gorm:"index"`
-	CreatedAt   time.Time  `json:"createdAt" gorm:"index"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	ProjectID       uint       `json:"projectId" gorm:"not null;index"`
+	Title           string     `json:"title" gorm:"not null;index"`
+	Description     string     `json:"description"`
+	DescriptionHTML string     `json:"descriptionHtml"`
+	Status          TaskStatus `json:"status" gorm:"not null;index"`
+	AssigneeID      *uint      `json:"assigneeId,omitempty" gorm:"index"`
+	DueDate         *time.Time `json:"dueDate,omitempty" gorm:"index"`
+	Version         uint       `json:"version" gorm:"not null;default:1"`
+	CreatedAt       time.Time  `json:"createdAt" gorm:"index"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	// DeletedAt is set while the task is in the trash; deleting a project
+	// gives its tasks the project's DeletedAt.
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Recurrence is the RRULE of a recurring task. Completing the task
+	// creates the next occurrence, which takes the rule over. SeriesID is
+	// the ID of the series' first task and Occurrence the task's position in
+	// it, counting from 1.
+	Recurrence string `json:"recurrence,omitempty"`
+	SeriesID   *uint  `json:"seriesId,omitempty" gorm:"index"`
+	Occurrence uint   `json:"occurrence,omitempty" gorm:"not null;default:0"`
+
+	// SprintID and MilestoneID plan the task within its project. A task
+	// without a sprint is in the project's backlog.
+	SprintID    *uint `json:"sprintId,omitempty" gorm:"index"`
+	MilestoneID *uint `json:"milestoneId,omitempty" gorm:"index"`
 
+	Assignee *User     `json:"assignee,omitempty" gorm:"foreignKey:AssigneeID;-:migration"`
 	Comments []Comment `json:"comments,omitempty" gorm:"constraint:OnDelete:CASCADE;"`
+	Labels   []Label   `json:"labels,omitempty" gorm:"many2many:task_labels;constraint:OnDelete:CASCADE;"`
+
+	Sprint    *Sprint    `json:"-" gorm:"constraint:OnDelete:SET NULL;"`
+	Milestone *Milestone `json:"-" gorm:"constraint:OnDelete:SET NULL;"`
+
+	// NextOccurrence is the task that completing a recurring task created.
+	NextOccurrence *Task `json:"nextOccurrence,omitempty" gorm:"-"`
+}
+
+type SprintState string
+
+const (
+	SprintPlanned SprintState = "planned"
+	SprintActive  SprintState = "active"
+	SprintClosed  SprintState = "closed"
+)
+
+// Sprint is a timebox of a project's work. A project has at most one active
+// sprint. Scope records what the sprint held when it closed.
+type Sprint struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	ProjectID uint         `json:"projectId" gorm:"not null;index;uniqueIndex:idx_sprints_active,where:state = 'active'"`
+	Name      string       `json:"name" gorm:"not null"`
+	Goal      string       `json:"goal"`
+	StartDate time.Time    `json:"startDate" gorm:"type:date;not null"`
+	EndDate   time.Time    `json:"endDate" gorm:"type:date;not null"`
+	State     SprintState  `json:"state" gorm:"not null;index"`
+	ClosedAt  *time.Time   `json:"closedAt,omitempty"`
+	Scope     *SprintScope `json:"scope,omitempty" gorm:"serializer:json"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+
+	Project *Project `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// SprintScope is a closed sprint's tasks as they were at closing. MovedTo is
+// the sprint that took the unfinished ones, nil when they went to the
+// backlog.
+type SprintScope struct {
+	Total   int               `json:"total"`
+	Done    int               `json:"done"`
+	Moved   int               `json:"moved"`
+	MovedTo *uint             `json:"movedTo"`
+	Tasks   []SprintScopeTask `json:"tasks"`
+}
+
+type SprintScopeTask struct {
+	ID         uint       `json:"id"`
+	Title      string     `json:"title"`
+	Status     TaskStatus `json:"status"`
+	AssigneeID *uint      `json:"assigneeId,omitempty"`
+}
+
+// Milestone is a target date that a project's tasks work toward.
+type Milestone struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ProjectID   uint      `json:"projectId" gorm:"not null;index"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description"`
+	TargetDate  time.Time `json:"targetDate" gorm:"type:date;not null;index"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	Project *Project `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// Label is a per-project tag. Labels are created on first use when a task is
+// saved with a new label name.
+type Label struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProjectID uint      `json:"projectId" gorm:"not null;uniqueIndex:idx_project_label"`
+	Name      string    `json:"name" gorm:"not null;size:64;uniqueIndex:idx_project_label"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Project *Project `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
 }
 
 type Comment struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	TaskID    uint       `json:"taskId" gorm:"not null;index"`
+	ParentID  *uint      `json:"parentId,omitempty" gorm:"index"`
+	Author    string     `json:"author" gorm:"not null;index"`
+	Text      string     `json:"text" gorm:"not null"`
+	HTML      string     `json:"html"`
+	Edited    bool       `json:"edited" gorm:"not null;default:false"`
+	EditedAt  *time.Time `json:"editedAt,omitempty"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+	Version   uint       `json:"version" gorm:"not null;default:1"`
+	CreatedAt time.Time  `json:"createdAt" gorm:"index"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	// DeletedText and DeletedHTML keep the content of a deleted comment
+	// until it is restored from the trash or purged.
+	DeletedText string `json:"-"`
+	DeletedHTML string `json:"-"`
+
+	Parent     *Comment          `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	ReplyCount int64             `json:"replyCount" gorm:"-"`
+	Reactions  []ReactionSummary `json:"reactions,omitempty" gorm:"-"`
+}
+
+type CommentReaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"commentId" gorm:"not null;uniqueIndex:idx_comment_reaction"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_comment_reaction"`
+	Emoji     string    `json:"emoji" gorm:"not null;size:32;uniqueIndex:idx_comment_reaction"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Comment *Comment `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	User    *User    `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// CommentRevision keeps a previous text of an edited comment. WrittenAt is
+// when that text was posted, CreatedAt when it was replaced.
+type CommentRevision struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
-	TaskID    uint      `json:"taskId" gorm:"not null;index"`
-	Author    string    `json:"author" gorm:"not null;index"`
+	CommentID uint      `json:"commentId" gorm:"not null;index"`
 	Text      string    `json:"text" gorm:"not null"`
-	CreatedAt time.Time `json:"createdAt" gorm:"index"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	HTML      string    `json:"html"`
+	WrittenAt time.Time `json:"writtenAt"`
+	CreatedAt time.Time `json:"replacedAt" gorm:"index"`
+
+	Comment *Comment `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// ReactionSummary aggregates all reactions with the same emoji on a comment.
+type ReactionSummary struct {
+	Emoji string         `json:"emoji"`
+	Count int            `json:"count"`
+	Users []ReactionUser `json:"users"`
+}
+
+type ReactionUser struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type Attachment struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	TaskID       uint      `json:"taskId" gorm:"not null;index"`
+	CommentID    *uint     `json:"commentId,omitempty" gorm:"index"`
+	Checksum     string    `json:"checksum" gorm:"not null;size:64;index"`
+	FileName     string    `json:"fileName" gorm:"not null"`
+	MimeType     string    `json:"mimeType" gorm:"not null"`
+	Size         int64     `json:"size" gorm:"not null"`
+	HasThumbnail bool      `json:"hasThumbnail" gorm:"not null;default:false"`
+	UploadedBy   uint      `json:"uploadedBy" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"index"`
+
+	DownloadURL  string `json:"downloadUrl,omitempty" gorm:"-"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty" gorm:"-"`
+
+	Task    *Task    `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Comment *Comment `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// Blob is one stored file content, shared by every attachment with the same
+// SHA-256 checksum.
+type Blob struct {
+	Checksum     string    `json:"checksum" gorm:"primaryKey;size:64"`
+	Size         int64     `json:"size" gorm:"not null"`
+	MimeType     string    `json:"mimeType" gorm:"not null"`
+	HasThumbnail bool      `json:"hasThumbnail" gorm:"not null;default:false"`
+	CreatedAt    time.Time `json:"createdAt"`
 }
 
 type User struct {
@@ -59,3 +243,326 @@ type User struct {
 	CreatedAt    time.Time `json:"createdAt" gorm:"index"`
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
+
+// SavedView is a named task query. Relative values ("me" as the assignee,
+// "today+7d" as a due bound, and the same terms inside Filter) are kept as
+// written and resolved for whoever runs the view. A view with a ProjectID is
+// shared with everyone who can see that project and only lists its tasks.
+type SavedView struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	OwnerID    uint      `json:"ownerId" gorm:"not null;index"`
+	ProjectID  *uint     `json:"projectId,omitempty" gorm:"index"`
+	Name       string    `json:"name" gorm:"not null;size:100"`
+	Filter     string    `json:"filter"`
+	Status     string    `json:"status"`
+	AssigneeID string    `json:"assigneeId"`
+	DueFrom    string    `json:"dueFrom"`
+	DueTo      string    `json:"dueTo"`
+	Sort       string    `json:"sort"`
+	PageSize   int       `json:"pageSize"`
+	GroupBy    string    `json:"groupBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	Owner   *User    `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Project *Project `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// ProjectTemplate is a reusable snapshot of a project's labels and tasks from
+// which new projects are created. Due dates are kept as days after the day
+// the project starts.
+type ProjectTemplate struct {
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	Name               string         `json:"name" gorm:"not null;size:100;index"`
+	Description        string         `json:"description"`
+	ProjectDescription string         `json:"projectDescription"`
+	SourceProjectID    *uint          `json:"sourceProjectId,omitempty"`
+	CreatedBy          uint           `json:"createdBy" gorm:"not null;index"`
+	Labels             []string       `json:"labels" gorm:"serializer:json"`
+	Tasks              []TemplateTask `json:"tasks" gorm:"serializer:json"`
+	CreatedAt          time.Time      `json:"createdAt" gorm:"index"`
+	UpdatedAt          time.Time      `json:"updatedAt"`
+}
+
+// TemplateTask is a task of a ProjectTemplate. DueInDays counts from the day
+// the new project starts.
+type TemplateTask struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Status      TaskStatus `json:"status"`
+	AssigneeID  *uint      `json:"assigneeId,omitempty"`
+	DueInDays   *int       `json:"dueInDays,omitempty"`
+	Labels      []string   `json:"labels,omitempty"`
+}
+
+// TaskWatcher subscribes a user to a task that is not necessarily assigned to
+// them, such as for the calendar feed.
+type TaskWatcher struct {
+	TaskID    uint      `json:"taskId" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Task *Task `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	User *User `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// CalendarToken is the secret in the URL of a user's calendar feed. Only
+// its SHA-256 hash is stored; a user has at most one.
+type CalendarToken struct {
+	UserID    uint   `gorm:"primaryKey"`
+	TokenHash string `gorm:"not null;size:64;uniqueIndex"`
+	CreatedAt time.Time
+
+	User *User `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// ReminderPreference is when a user wants reminders of the tasks assigned to
+// them. Offsets are minutes before the due date; 0 reminds at the due date
+// and negative offsets after it.
+type ReminderPreference struct {
+	UserID    uint      `json:"userId" gorm:"primaryKey"`
+	Offsets   []int     `json:"offsets" gorm:"serializer:json"`
+	Email     bool      `json:"email" gorm:"not null"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	User *User `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// SentReminder records that the reminder at OffsetMinutes fired for a due
+// date, so that it fires once. A new due date arms the reminders again.
+type SentReminder struct {
+	TaskID        uint      `gorm:"primaryKey"`
+	UserID        uint      `gorm:"primaryKey;index"`
+	OffsetMinutes int       `gorm:"primaryKey"`
+	DueDate       time.Time `gorm:"primaryKey"`
+	SentAt        time.Time `gorm:"not null"`
+
+	Task *Task `gorm:"constraint:OnDelete:CASCADE;"`
+	User *User `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+type NotificationKind string
+
+const NotificationReminder NotificationKind = "reminder"
+
+// Notification is an in-app message to a user.
+type Notification struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	UserID    uint             `json:"userId" gorm:"not null;index"`
+	Kind      NotificationKind `json:"kind" gorm:"not null"`
+	TaskID    *uint            `json:"taskId,omitempty" gorm:"index"`
+	Title     string           `json:"title" gorm:"not null"`
+	Text      string           `json:"text"`
+	ReadAt    *time.Time       `json:"readAt,omitempty"`
+	CreatedAt time.Time        `json:"createdAt" gorm:"index"`
+
+	User *User `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Task *Task `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// TimeEntry is time a user spent on a task. A running timer is an entry
+// without EndedAt; a user has at most one.
+type TimeEntry struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	TaskID    uint       `json:"taskId" gorm:"not null;index"`
+	UserID    uint       `json:"userId" gorm:"not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL"`
+	StartedAt time.Time  `json:"startedAt" gorm:"not null;index"`
+	EndedAt   *time.Time `json:"endedAt"`
+	Seconds   int64      `json:"seconds" gorm:"not null"` // 0 while running
+	Note      string     `json:"note"`
+	CreatedAt time.Time  `json:"createdAt"`
+
+	User *User `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+	Task *Task `json:"-" gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// TaskTime is the time logged on a task.
+type TaskTime struct {
+	TaskID  uint   `json:"taskId"`
+	Title   string `json:"title"`
+	Seconds int64  `json:"seconds"`
+}
+
+// ProjectTime is the time logged on a project, with its tasks.
+type ProjectTime struct {
+	ProjectID uint       `json:"projectId"`
+	Seconds   int64      `json:"seconds"`
+	Tasks     []TaskTime `json:"tasks"`
+}
+
+// Timesheet is the time logged from From to To, both inclusive, by day and
+// project. UserID is nil when it covers everyone.
+type Timesheet struct {
+	From    string         `json:"from"`
+	To      string         `json:"to"`
+	UserID  *uint          `json:"userId"`
+	Seconds int64          `json:"seconds"`
+	Days    []TimesheetDay `json:"days"`
+}
+
+type TimesheetDay struct {
+	Date     string             `json:"date"` // YYYY-MM-DD, UTC
+	Seconds  int64              `json:"seconds"`
+	Projects []TimesheetProject `json:"projects"`
+}
+
+type TimesheetProject struct {
+	ProjectID uint   `json:"projectId"`
+	Title     string `json:"title"`
+	Seconds   int64  `json:"seconds"`
+}
+
+// TrashItem is a deleted project, task or comment that can still be
+// restored. Title is the comment's text for a comment.
+type TrashItem struct {
+	Type      string    `json:"type"`
+	ID        uint      `json:"id"`
+	ProjectID uint      `json:"projectId"`
+	TaskID    *uint     `json:"taskId,omitempty"`
+	Title     string    `json:"title"`
+	DeletedAt time.Time `json:"deletedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+// SearchHit is one ranked result of GET /search. Snippet is HTML: the source
+// text is escaped and matched words are wrapped in <mark>.
+type SearchHit struct {
+	Type      string  `json:"type"`
+	ID        uint    `json:"id"`
+	ProjectID uint    `json:"projectId"`
+	TaskID    *uint   `json:"taskId,omitempty"`
+	Title     string  `json:"title"`
+	Snippet   string  `json:"snippet"`
+	Rank      float64 `json:"rank"`
+}
+
+// IdempotencyKey remembers the response to a POST sent with an
+// Idempotency-Key header so that a retry gets the same answer instead of
+// repeating the write. Keys are per user. Status is 0 while the first request
+// is still running; LockedUntil ends that reservation should the request
+// never finish, as when its server crashed.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key         string `gorm:"not null;size:255;uniqueIndex:idx_idempotency_user_key"`
+	Fingerprint string `gorm:"not null;size:64"`
+	Status      int    `gorm:"not null;default:0"`
+	Header      string // JSON object of the replayed response headers
+	Body        []byte `gorm:"type:bytea"`
+	CreatedAt   time.Time
+	LockedUntil *time.Time
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+// ProjectBundle is a portable copy of a project, written by the project
+// export and read by the import. IDs are those of the exporting deployment
+// and only serve to link the parts of the bundle; users are referenced by
+// ID and listed in Users so that the import can match them by email.
+type ProjectBundle struct {
+	Format      string             `json:"format"`
+	Version     int                `json:"version"`
+	ExportedAt  time.Time          `json:"exportedAt"`
+	Project     BundleProject      `json:"project"`
+	Users       []BundleUser       `json:"users"`
+	Labels      []string           `json:"labels"`
+	Tasks       []BundleTask       `json:"tasks"`
+	Comments    []BundleComment    `json:"comments"`
+	Attachments []BundleAttachment `json:"attachments"`
+}
+
+type BundleProject struct {
+	ID          uint          `json:"id"`
+	Title       string        `json:"title"`
+	Description string        `json:"description,omitempty"`
+	Status      ProjectStatus `json:"status"`
+	OwnerID     *uint         `json:"ownerId,omitempty"`
+	CreatedAt   time.Time     `json:"createdAt"`
+}
+
+type BundleUser struct {
+	ID    uint   `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+type BundleTask struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Status      TaskStatus `json:"status"`
+	AssigneeID  *uint      `json:"assigneeId,omitempty"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	Labels      []string   `json:"labels,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// BundleComment is a comment of a bundled task. Comments come after their
+// parent.
+type BundleComment struct {
+	ID        uint             `json:"id"`
+	TaskID    uint             `json:"taskId"`
+	ParentID  *uint            `json:"parentId,omitempty"`
+	Author    string           `json:"author"`
+	Text      string           `json:"text"`
+	EditedAt  *time.Time       `json:"editedAt,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Reactions []BundleReaction `json:"reactions,omitempty"`
+}
+
+type BundleReaction struct {
+	UserID uint   `json:"userId"`
+	Emoji  string `json:"emoji"`
+}
+
+// BundleAttachment describes an attachment. Its content is in the zip form
+// of the bundle under attachments/<checksum>.
+type BundleAttachment struct {
+	ID         uint      `json:"id"`
+	TaskID     uint      `json:"taskId"`
+	CommentID  *uint     `json:"commentId,omitempty"`
+	FileName   string    `json:"fileName"`
+	MimeType   string    `json:"mimeType"`
+	Size       int64     `json:"size"`
+	Checksum   string    `json:"checksum"`
+	UploadedBy uint      `json:"uploadedBy"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// BundleImportReport tells what an import of a ProjectBundle created, or
+// would create in a dry run, and what it could not carry over.
+type BundleImportReport struct {
+	DryRun    bool              `json:"dryRun"`
+	Applied   bool              `json:"applied"`
+	Project   *Project          `json:"project,omitempty"`
+	Created   BundleCounts      `json:"created"`
+	Users     []BundleUserMatch `json:"users"`
+	Conflicts []BundleConflict  `json:"conflicts"`
+	// TaskIDs and CommentIDs map the IDs of the bundle to the new ones.
+	TaskIDs    map[uint]uint `json:"taskIds,omitempty"`
+	CommentIDs map[uint]uint `json:"commentIds,omitempty"`
+}
+
+type BundleCounts struct {
+	Labels      int `json:"labels"`
+	Tasks       int `json:"tasks"`
+	Comments    int `json:"comments"`
+	Reactions   int `json:"reactions"`
+	Attachments int `json:"attachments"`
+}
+
+// BundleUserMatch is a user of the bundle and the local user with the same
+// email, if there is one.
+type BundleUserMatch struct {
+	ID     uint   `json:"id"`
+	Email  string `json:"email"`
+	UserID *uint  `json:"userId"`
+}
+
+// BundleConflict is a part of the bundle the import changed or left out.
+// Type is "user", "title", "attachment" or "invalid"; Ref names the part,
+// such as "task 12".
+type BundleConflict struct {
+	Type    string `json:"type"`
+	Ref     string `json:"ref"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,337 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types of the two patch formats.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalid means the patch document itself is malformed.
+	ErrInvalid = errors.New("invalid patch")
+	// ErrConflict means a well-formed patch does not fit the document: a
+	// path does not exist or a test operation failed.
+	ErrConflict = errors.New("patch does not apply")
+)
+
+// Apply applies patch, of the given media type, to doc and returns the
+// patched document. A JSON Patch is applied as a whole or not at all.
+func Apply(mediaType string, doc, patch []byte) ([]byte, error) {
+	switch mediaType {
+	case MergePatchType:
+		return Merge(doc, patch)
+	case JSONPatchType:
+		return ApplyJSONPatch(doc, patch)
+	default:
+		return nil, fmt.Errorf("%w: unsupported media type %q", ErrInvalid, mediaType)
+	}
+}
+
+// Merge applies a JSON Merge Patch: objects are merged recursively, null
+// removes a member and any other value replaces the target.
+func Merge(doc, patch []byte) ([]byte, error) {
+	var target, p any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = merge(t[key], value)
+		}
+	}
+	return t
+}
+
+// Operation is one JSON Patch operation.
+type Operation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies a JSON Patch, an array of add, remove, replace,
+// move, copy and test operations addressed by JSON Pointers (RFC 6901).
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: a JSON Patch is an array of operations: %v", ErrInvalid, err)
+	}
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		var err error
+		if target, err = apply(target, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func apply(doc any, op Operation) (any, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: missing path", ErrInvalid)
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+	value := func() (any, error) {
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalid)
+		}
+		var v any
+		if err := json.Unmarshal(*op.Value, &v); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		return v, nil
+	}
+	from := func() ([]string, error) {
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: missing from", ErrInvalid)
+		}
+		return parsePointer(*op.From)
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "remove":
+		doc, _, err := remove(doc, path)
+		return doc, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "move":
+		src, err := from()
+		if err != nil {
+			return nil, err
+		}
+		if len(src) < len(path) && reflect.DeepEqual(src, path[:len(src)]) {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalid)
+		}
+		doc, v, err := remove(doc, src)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	case "copy":
+		src, err := from()
+		if err != nil {
+			return nil, err
+		}
+		v, err := get(doc, src)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(v))
+	case "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, v) {
+			return nil, fmt.Errorf("%w: %s does not have the tested value", ErrConflict, *op.Path)
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalid, op.Op)
+	}
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalid, p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, key := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			v, ok := node[key]
+			if !ok {
+				return nil, missing(key)
+			}
+			doc = v
+		case []any:
+			i, err := index(key, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, missing(key)
+		}
+	}
+	return doc, nil
+}
+
+// add returns doc with v added at path: a new or replaced object member, or
+// an array element inserted before the index ("-" appends).
+func add(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	key, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			node[key] = v
+			return node, nil
+		}
+		child, ok := node[key]
+		if !ok {
+			return nil, missing(key)
+		}
+		child, err := add(child, rest, v)
+		node[key] = child
+		return node, err
+	case []any:
+		if len(rest) == 0 {
+			i := len(node)
+			if key != "-" {
+				var err error
+				if i, err = index(key, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = v
+			return node, nil
+		}
+		i, err := index(key, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i], err = add(node[i], rest, v)
+		return node, err
+	default:
+		return nil, missing(key)
+	}
+}
+
+// remove returns doc without the value at path, and that value.
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+	key, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[key]
+		if !ok {
+			return nil, nil, missing(key)
+		}
+		if len(rest) == 0 {
+			delete(node, key)
+			return node, child, nil
+		}
+		child, removed, err := remove(child, rest)
+		node[key] = child
+		return node, removed, err
+	case []any:
+		i, err := index(key, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+		child, removed, err := remove(node[i], rest)
+		node[i] = child
+		return node, removed, err
+	default:
+		return nil, nil, missing(key)
+	}
+}
+
+// index parses an array index token no greater than max.
+func index(key string, max int) (int, error) {
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || (len(key) > 1 && key[0] == '0') {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrInvalid, key)
+	}
+	if i > max {
+		return 0, fmt.Errorf("%w: index %d is out of range", ErrConflict, i)
+	}
+	return i, nil
+}
+
+func missing(key string) error {
+	return fmt.Errorf("%w: %q does not exist", ErrConflict, key)
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = deepCopy(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,97 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func equalJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("unmarshal %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("unmarshal %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":1}}`, `{"a":{"b":"c","f":1}}`},
+		{`{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+	}
+	for _, tt := range tests {
+		got, err := Merge([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("Merge(%s, %s): %v", tt.doc, tt.patch, err)
+		}
+		equalJSON(t, got, tt.want)
+	}
+	if _, err := Merge([]byte(`{}`), []byte(`{`)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := `{"title":"a","labels":["x","y"],"meta":{"a/b":1,"m~n":2}}`
+	tests := []struct{ patch, want string }{
+		{`[{"op":"replace","path":"/title","value":"b"}]`, `{"title":"b","labels":["x","y"],"meta":{"a/b":1,"m~n":2}}`},
+		{`[{"op":"add","path":"/labels/-","value":"z"},{"op":"add","path":"/labels/0","value":"w"}]`, `{"title":"a","labels":["w","x","y","z"],"meta":{"a/b":1,"m~n":2}}`},
+		{`[{"op":"remove","path":"/labels/0"},{"op":"remove","path":"/meta/a~1b"}]`, `{"title":"a","labels":["y"],"meta":{"m~n":2}}`},
+		{`[{"op":"move","from":"/meta/m~0n","path":"/count"}]`, `{"title":"a","labels":["x","y"],"meta":{"a/b":1},"count":2}`},
+		{`[{"op":"copy","from":"/labels","path":"/tags"},{"op":"add","path":"/tags/-","value":"t"}]`, `{"title":"a","labels":["x","y"],"tags":["x","y","t"],"meta":{"a/b":1,"m~n":2}}`},
+		{`[{"op":"test","path":"/labels/1","value":"y"},{"op":"replace","path":"/labels/1","value":"v"}]`, `{"title":"a","labels":["x","v"],"meta":{"a/b":1,"m~n":2}}`},
+	}
+	for _, tt := range tests {
+		got, err := ApplyJSONPatch([]byte(doc), []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("ApplyJSONPatch(%s): %v", tt.patch, err)
+		}
+		equalJSON(t, got, tt.want)
+	}
+
+	failures := []struct {
+		patch string
+		want  error
+	}{
+		{`[{"op":"test","path":"/title","value":"b"}]`, ErrConflict},
+		{`[{"op":"remove","path":"/missing"}]`, ErrConflict},
+		{`[{"op":"replace","path":"/labels/5","value":"q"}]`, ErrConflict},
+		{`[{"op":"add","path":"/labels/01","value":"q"}]`, ErrInvalid},
+		{`[{"op":"add","path":"title","value":"q"}]`, ErrInvalid},
+		{`[{"op":"add","path":"/title"}]`, ErrInvalid},
+		{`[{"op":"frobnicate","path":"/title"}]`, ErrInvalid},
+		{`[{"op":"move","from":"/meta","path":"/meta/inner"}]`, ErrInvalid},
+		{`{"op":"remove","path":"/title"}`, ErrInvalid},
+	}
+	for _, tt := range failures {
+		if _, err := ApplyJSONPatch([]byte(doc), []byte(tt.patch)); !errors.Is(err, tt.want) {
+			t.Fatalf("ApplyJSONPatch(%s) err = %v, want %v", tt.patch, err, tt.want)
+		}
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	doc := []byte(`{"title":"a"}`)
+	_, err := Apply(JSONPatchType, doc, []byte(`[{"op":"replace","path":"/title","value":"b"},{"op":"test","path":"/title","value":"a"}]`))
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("err = %v, want ErrConflict", err)
+	}
+	if string(doc) != `{"title":"a"}` {
+		t.Fatalf("doc changed: %s", doc)
+	}
+	if _, err := Apply("application/json", doc, []byte(`{}`)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("err = %v, want ErrInvalid for another media type", err)
+	}
+}
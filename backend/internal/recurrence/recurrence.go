@@ -0,0 +1,288 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules that
+// recurring tasks use, e.g.
+//
+//	FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;UNTIL=20261231
+//	FREQ=MONTHLY;BYDAY=-1FR;COUNT=6
+//
+// FREQ is DAILY, WEEKLY or MONTHLY. INTERVAL, BYDAY and one of UNTIL or
+// COUNT are optional. BYDAY days may carry an ordinal (1MO, -1FR) only in
+// monthly rules. Weeks start on Monday.
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// Day is a BYDAY entry. N is the ordinal within the month, counted from the
+// end when negative; 0 means every such weekday.
+type Day struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed recurrence rule. Until, if set, is the last moment an
+// occurrence may fall on; a date-only UNTIL includes the whole day in UTC.
+type Rule struct {
+	Freq      Frequency
+	Interval  int
+	ByDay     []Day
+	Until     *time.Time
+	Count     int
+	untilDate bool
+}
+
+// Error reports why a rule was rejected.
+type Error struct{ Msg string }
+
+func (e *Error) Error() string { return "recurrence: " + e.Msg }
+
+func errorf(format string, args ...any) *Error {
+	return &Error{Msg: fmt.Sprintf(format, args...)}
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// maxInterval bounds INTERVAL so that finding the next occurrence stays
+// cheap.
+const maxInterval = 999
+
+// Parse reads a rule, with or without an "RRULE:" prefix. Names are not case
+// sensitive.
+func Parse(s string) (Rule, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "RRULE:")
+	if s == "" {
+		return Rule{}, errorf("the rule is empty")
+	}
+	r := Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Rule{}, errorf("%q is not NAME=VALUE", part)
+		}
+		if seen[name] {
+			return Rule{}, errorf("%s is given twice", name)
+		}
+		seen[name] = true
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly {
+				return Rule{}, errorf("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxInterval {
+				return Rule{}, errorf("INTERVAL must be a number from 1 to %d", maxInterval)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Rule{}, errorf("COUNT must be a positive number")
+			}
+			r.Count = n
+		case "UNTIL":
+			if t, err := time.Parse("20060102T150405Z", value); err == nil {
+				r.Until = &t
+			} else if t, err := time.Parse("20060102", value); err == nil {
+				t = t.Add(24*time.Hour - time.Second)
+				r.Until, r.untilDate = &t, true
+			} else {
+				return Rule{}, errorf("UNTIL must be a date like 20261231 or a UTC time like 20261231T170000Z")
+			}
+		case "BYDAY":
+			for _, entry := range strings.Split(value, ",") {
+				day, err := parseDay(entry)
+				if err != nil {
+					return Rule{}, err
+				}
+				if !slices.Contains(r.ByDay, day) {
+					r.ByDay = append(r.ByDay, day)
+				}
+			}
+		default:
+			return Rule{}, errorf("%s is not supported", name)
+		}
+	}
+	if r.Freq == "" {
+		return Rule{}, errorf("FREQ is required")
+	}
+	if r.Until != nil && r.Count > 0 {
+		return Rule{}, errorf("UNTIL and COUNT cannot be combined")
+	}
+	if r.Freq != Monthly {
+		for _, day := range r.ByDay {
+			if day.N != 0 {
+				return Rule{}, errorf("BYDAY ordinals like 1MO need FREQ=MONTHLY")
+			}
+		}
+	}
+	return r, nil
+}
+
+func parseDay(s string) (Day, error) {
+	if len(s) < 2 {
+		return Day{}, errorf("%q is not a BYDAY day", s)
+	}
+	weekday, ok := weekdays[s[len(s)-2:]]
+	if !ok {
+		return Day{}, errorf("%q is not a BYDAY day", s)
+	}
+	day := Day{Weekday: weekday}
+	if ordinal := s[:len(s)-2]; ordinal != "" {
+		n, err := strconv.Atoi(ordinal)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return Day{}, errorf("the ordinal of %q must be 1 to 5 or -1 to -5", s)
+		}
+		day.N = n
+	}
+	return day, nil
+}
+
+// String returns the rule in canonical form, without the "RRULE:" prefix.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		if r.untilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405Z"))
+		}
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (d Day) String() string {
+	name := strings.ToUpper(d.Weekday.String()[:2])
+	if d.N != 0 {
+		return strconv.Itoa(d.N) + name
+	}
+	return name
+}
+
+// Next returns the occurrence after prev, the n-th occurrence of the series,
+// at the same time of day. It returns false when the series ends before.
+func (r Rule) Next(prev time.Time, n int) (time.Time, bool) {
+	if r.Count > 0 && n >= r.Count {
+		return time.Time{}, false
+	}
+	next, ok := r.next(prev)
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r Rule) next(prev time.Time) (time.Time, bool) {
+	interval := max(r.Interval, 1)
+	switch r.Freq {
+	case Daily:
+		// Seven steps visit every weekday unless the interval is a multiple
+		// of seven.
+		for d, i := prev.AddDate(0, 0, interval), 0; i < 7; d, i = d.AddDate(0, 0, interval), i+1 {
+			if r.weekdayMatches(d.Weekday()) {
+				return d, true
+			}
+		}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return prev.AddDate(0, 0, 7*interval), true
+		}
+		offset := (int(prev.Weekday()) + 6) % 7 // days since Monday
+		monday := prev.AddDate(0, 0, -offset)
+		for i := offset + 1; i < 7; i++ {
+			if d := monday.AddDate(0, 0, i); r.weekdayMatches(d.Weekday()) {
+				return d, true
+			}
+		}
+		monday = monday.AddDate(0, 0, 7*interval)
+		for i := range 7 {
+			if d := monday.AddDate(0, 0, i); r.weekdayMatches(d.Weekday()) {
+				return d, true
+			}
+		}
+	case Monthly:
+		first := time.Date(prev.Year(), prev.Month(), 1, prev.Hour(), prev.Minute(), prev.Second(), prev.Nanosecond(), prev.Location())
+		// A day that some months lack, such as the 31st, is skipped in them;
+		// a hundred steps cover even February 29th every twelve months.
+		for i := range 100 {
+			month := first.AddDate(0, i*interval, 0)
+			for _, d := range r.monthDays(month, prev.Day()) {
+				if d.After(prev) {
+					return d, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (r Rule) weekdayMatches(weekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDays returns the days of a monthly rule in the month starting at
+// first, in order: day of month day without BYDAY, the BYDAY days with it.
+func (r Rule) monthDays(first time.Time, day int) []time.Time {
+	days := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if len(r.ByDay) == 0 {
+		if day > days {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, day-1)}
+	}
+	var out []time.Time
+	for i := range days {
+		d := first.AddDate(0, 0, i)
+		for _, by := range r.ByDay {
+			if by.Weekday != d.Weekday() {
+				continue
+			}
+			// The weekday's position in the month, from the start and the end.
+			fromStart, fromEnd := i/7+1, -((days-1-i)/7 + 1)
+			if by.N == 0 || by.N == fromStart || by.N == fromEnd {
+				out = append(out, d)
+				break
+			}
+		}
+	}
+	return out
+}
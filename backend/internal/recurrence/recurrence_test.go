@@ -0,0 +1,94 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"rrule:freq=weekly;interval=2;byday=mo,th,mo", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH"},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=6", "FREQ=MONTHLY;BYDAY=-1FR;COUNT=6"},
+		{"FREQ=WEEKLY;INTERVAL=1;UNTIL=20261231", "FREQ=WEEKLY;UNTIL=20261231"},
+		{"FREQ=DAILY;UNTIL=20261231T170000Z", "FREQ=DAILY;UNTIL=20261231T170000Z"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.src, err)
+		}
+		if got := r.String(); got != tt.want {
+			t.Fatalf("Parse(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	for _, src := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;UNTIL=20261231;COUNT=3",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ",
+	} {
+		var perr *Error
+		if _, err := Parse(src); !errors.As(err, &perr) {
+			t.Fatalf("Parse(%q) = %v, want an *Error", src, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		rule string
+		prev string
+		want []string // the following occurrences
+		ends bool     // whether the series ends after them
+	}{
+		{rule: "FREQ=DAILY;INTERVAL=2", prev: "2026-10-30 09:00", want: []string{"2026-11-01 09:00", "2026-11-03 09:00"}},
+		// Weekdays only.
+		{rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", prev: "2026-10-23 09:00", want: []string{"2026-10-26 09:00", "2026-10-27 09:00"}},
+		{rule: "FREQ=WEEKLY", prev: "2026-10-19 00:00", want: []string{"2026-10-26 00:00"}},
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", prev: "2026-10-19 00:00", want: []string{"2026-10-22 00:00", "2026-11-02 00:00", "2026-11-05 00:00"}},
+		// The 31st is skipped in months without one.
+		{rule: "FREQ=MONTHLY", prev: "2026-01-31 12:00", want: []string{"2026-03-31 12:00", "2026-05-31 12:00"}},
+		{rule: "FREQ=MONTHLY;BYDAY=-1FR", prev: "2026-10-30 00:00", want: []string{"2026-11-27 00:00", "2026-12-25 00:00"}},
+		{rule: "FREQ=MONTHLY;INTERVAL=3;BYDAY=1MO", prev: "2026-10-05 00:00", want: []string{"2027-01-04 00:00"}},
+		{rule: "FREQ=DAILY;COUNT=3", prev: "2026-10-18 00:00", want: []string{"2026-10-19 00:00", "2026-10-20 00:00"}, ends: true},
+		{rule: "FREQ=WEEKLY;UNTIL=20261102", prev: "2026-10-19 18:00", want: []string{"2026-10-26 18:00", "2026-11-02 18:00"}, ends: true},
+		{rule: "FREQ=WEEKLY;UNTIL=20261102T170000Z", prev: "2026-10-19 18:00", want: []string{"2026-10-26 18:00"}, ends: true},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.rule, err)
+		}
+		prev, n := at(tt.prev), 1
+		for _, want := range tt.want {
+			next, ok := r.Next(prev, n)
+			if !ok || !next.Equal(at(want)) {
+				t.Fatalf("%s after %s = %s %v, want %s", tt.rule, prev, next, ok, want)
+			}
+			prev, n = next, n+1
+		}
+		if next, ok := r.Next(prev, n); ok == tt.ends {
+			t.Fatalf("%s after %s = %s %v, want ends %v", tt.rule, prev, next, ok, tt.ends)
+		}
+	}
+}
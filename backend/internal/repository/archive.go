@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Subqueries selecting the project of a task or of a comment by ID.
+const (
+	projectOfTask       = "SELECT project_id FROM tasks WHERE id = ?"
+	projectOfComment    = "SELECT t.project_id FROM tasks t JOIN comments c ON c.task_id = t.id WHERE c.id = ?"
+	projectOfAttachment = "SELECT t.project_id FROM tasks t JOIN attachments a ON a.task_id = t.id WHERE a.id = ?"
+)
+
+// writable returns service.ErrProjectArchived when one of the projects the
+// subquery selects is archived. Writes below a project call it first, inside
+// their transaction, so that archived projects stay read-only: the projects
+// are locked FOR SHARE, so an archive waits until the write commits.
+func writable(tx *gorm.DB, projects string, arg any) error {
+	var statuses []model.ProjectStatus
+	err := tx.Model(&model.Project{}).Clauses(clause.Locking{Strength: "SHARE"}).Where("id IN ("+projects+")", arg).Pluck("status", &statuses).Error
+	for _, status := range statuses {
+		if status == model.ProjectArchived {
+			return service.ErrProjectArchived
+		}
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AttachmentRepository struct{ db *gorm.DB }
+
+func NewAttachmentRepository(db *gorm.DB) service.AttachmentRepository {
+	return AttachmentRepository{db: db}
+}
+
+func (r AttachmentRepository) GetTask(ctx context.Context, id string) (model.Task, error) {
+	var task model.Task
+	err := r.db.WithContext(ctx).First(&task, id).Error
+	return task, err
+}
+
+func (r AttachmentRepository) GetComment(ctx context.Context, id string) (model.Comment, error) {
+	var comment model.Comment
+	err := r.db.WithContext(ctx).First(&comment, id).Error
+	return comment, err
+}
+
+func (r AttachmentRepository) FindBlob(ctx context.Context, checksum string) (model.Blob, error) {
+	var blob model.Blob
+	err := r.db.WithContext(ctx).Where("checksum = ?", checksum).First(&blob).Error
+	return blob, err
+}
+
+func (r AttachmentRepository) Create(ctx context.Context, blob *model.Blob, attachment *model.Attachment, store func(blob *model.Blob) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, attachment.TaskID); err != nil {
+			return err
+		}
+		if err := lockOrInsertBlob(tx, blob, store); err != nil {
+			return err
+		}
+		return tx.Create(attachment).Error
+	})
+}
+
+// lockOrInsertBlob locks the blob row, or inserts it and stores its file. A
+// row deleted by a cleanup while the lock was awaited is inserted again.
+func lockOrInsertBlob(tx *gorm.DB, blob *model.Blob, store func(blob *model.Blob) error) error {
+	for attempt := 0; ; attempt++ {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(blob)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			return store(blob)
+		}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("checksum = ?", blob.Checksum).First(blob).Error
+		if !errors.Is(err, gorm.ErrRecordNotFound) || attempt > 0 {
+			return err
+		}
+	}
+}
+
+func (r AttachmentRepository) ListForTask(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	return r.list(r.db.WithContext(ctx).Where("task_id = ? AND comment_id IS NULL", taskID), params)
+}
+
+func (r AttachmentRepository) ListForComment(ctx context.Context, commentID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	return r.list(r.db.WithContext(ctx).Where("comment_id = ?", commentID), params)
+}
+
+// list returns a page of the attachments db selects, oldest first unless
+// params sort them otherwise.
+func (r AttachmentRepository) list(db *gorm.DB, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	db = db.Model(&model.Attachment{})
+	var total int64
+	if err := httpx.Count(db, params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "id", "fileName": "file_name", "size": "size", "createdAt": "created_at"}
+	var items []model.Attachment
+	err := httpx.FindPage(db, &items, allowedSort, params, "createdAt")
+	return items, total, err
+}
+
+func (r AttachmentRepository) Get(ctx context.Context, id string) (model.Attachment, error) {
+	var attachment model.Attachment
+	err := r.db.WithContext(ctx).First(&attachment, id).Error
+	return attachment, err
+}
+
+func (r AttachmentRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfAttachment, id); err != nil {
+			return err
+		}
+		return tx.Delete(&model.Attachment{}, id).Error
+	})
+}
+
+func (r AttachmentRepository) OrphanBlobs(ctx context.Context) ([]model.Blob, error) {
+	var blobs []model.Blob
+	err := r.db.WithContext(ctx).
+		Where("NOT EXISTS (SELECT 1 FROM attachments a WHERE a.checksum = blobs.checksum)").
+		Find(&blobs).Error
+	return blobs, err
+}
+
+func (r AttachmentRepository) DeleteBlobIfOrphan(ctx context.Context, checksum string, remove func(blob model.Blob) error) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var blob model.Blob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("checksum = ?", checksum).First(&blob).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Checked after locking, so that an upload that locked the row first
+		// has committed its attachment.
+		var refs int64
+		if err := tx.Model(&model.Attachment{}).Where("checksum = ?", checksum).Count(&refs).Error; err != nil || refs > 0 {
+			return err
+		}
+		if err := tx.Delete(&blob).Error; err != nil {
+			return err
+		}
+		deleted = true
+		return remove(blob)
+	})
+	return deleted && err == nil, err
+}
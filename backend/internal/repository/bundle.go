@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+)
+
+type BundleRepository struct{ db *gorm.DB }
+
+func NewBundleRepository(db *gorm.DB) service.BundleRepository { return BundleRepository{db: db} }
+
+func (r BundleRepository) Comments(ctx context.Context, taskIDs []uint) ([]model.Comment, error) {
+	var items []model.Comment
+	if len(taskIDs) == 0 {
+		return items, nil
+	}
+	err := r.db.WithContext(ctx).Where("task_id IN ?", taskIDs).Order("id").Find(&items).Error
+	return items, err
+}
+
+func (r BundleRepository) Reactions(ctx context.Context, commentIDs []uint) ([]model.CommentReaction, error) {
+	var items []model.CommentReaction
+	if len(commentIDs) == 0 {
+		return items, nil
+	}
+	err := r.db.WithContext(ctx).Where("comment_id IN ?", commentIDs).Order("id").Find(&items).Error
+	return items, err
+}
+
+func (r BundleRepository) Attachments(ctx context.Context, taskIDs []uint) ([]model.Attachment, error) {
+	var items []model.Attachment
+	if len(taskIDs) == 0 {
+		return items, nil
+	}
+	err := r.db.WithContext(ctx).Where("task_id IN ?", taskIDs).Order("id").Find(&items).Error
+	return items, err
+}
+
+func (r BundleRepository) Users(ctx context.Context, ids []uint) ([]model.User, error) {
+	var items []model.User
+	if len(ids) == 0 {
+		return items, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&items).Error
+	return items, err
+}
+
+func (r BundleRepository) UsersByEmail(ctx context.Context, emails []string) ([]model.User, error) {
+	var items []model.User
+	if len(emails) == 0 {
+		return items, nil
+	}
+	err := r.db.WithContext(ctx).Where("LOWER(email) IN ?", emails).Find(&items).Error
+	return items, err
+}
+
+func (r BundleRepository) Blobs(ctx context.Context, checksums []string) ([]model.Blob, error) {
+	var items []model.Blob
+	if len(checksums) == 0 {
+		return items, nil
+	}
+	err := r.db.WithContext(ctx).Where("checksum IN ?", checksums).Find(&items).Error
+	return items, err
+}
+
+func (r BundleRepository) TitleTaken(ctx context.Context, title string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&model.Project{}).Where("LOWER(title) = LOWER(?)", title).Count(&n).Error
+	return n > 0, err
+}
+
+// Import creates the project and its tasks like CreateTree, then the
+// comments in order so that each parent has its ID before its replies, then
+// the attachments with any blob rows that are missing.
+func (r BundleRepository) Import(ctx context.Context, records *service.BundleRecords) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createTree(tx, &records.Project, records.Labels); err != nil {
+			return err
+		}
+		tasks := records.Project.Tasks
+		for i := range records.Comments {
+			record := &records.Comments[i]
+			record.Comment.TaskID = tasks[record.Task].ID
+			if record.Parent >= 0 {
+				record.Comment.ParentID = &records.Comments[record.Parent].Comment.ID
+			}
+			if err := tx.Create(&record.Comment).Error; err != nil {
+				return err
+			}
+			for j := range record.Reactions {
+				record.Reactions[j].CommentID = record.Comment.ID
+			}
+			if len(record.Reactions) > 0 {
+				if err := tx.Create(&record.Reactions).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for i := range records.Attachments {
+			record := &records.Attachments[i]
+			record.Attachment.TaskID = tasks[record.Task].ID
+			if record.Comment >= 0 {
+				record.Attachment.CommentID = &records.Comments[record.Comment].Comment.ID
+			}
+			err := lockOrInsertBlob(tx, &record.Blob, func(blob *model.Blob) error {
+				if !record.Stored {
+					return service.ErrBundleBlobGone
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&record.Attachment).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CalendarRepository struct{ db *gorm.DB }
+
+func NewCalendarRepository(db *gorm.DB) service.CalendarRepository {
+	return CalendarRepository{db: db}
+}
+
+func (r CalendarRepository) SetToken(ctx context.Context, userID uint, hash string) error {
+	token := model.CalendarToken{UserID: userID, TokenHash: hash}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_hash", "created_at"}),
+	}).Create(&token).Error
+}
+
+func (r CalendarRepository) DeleteToken(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Delete(&model.CalendarToken{}, "user_id = ?", userID).Error
+}
+
+func (r CalendarRepository) TokenUser(ctx context.Context, hash string) (uint, error) {
+	var token model.CalendarToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	return token.UserID, err
+}
+
+// DueTasks leaves out the tasks of archived projects unless the feed is for
+// one project.
+func (r CalendarRepository) DueTasks(ctx context.Context, userID uint, projectID *uint) ([]model.Task, error) {
+	db := r.db.WithContext(ctx).Preload("Labels").
+		Where("due_date IS NOT NULL").
+		Where("assignee_id = ? OR EXISTS (SELECT 1 FROM task_watchers w WHERE w.task_id = tasks.id AND w.user_id = ?)", userID, userID)
+	if projectID != nil {
+		db = db.Where("project_id = ?", *projectID)
+	} else {
+		db = db.Where("project_id NOT IN (SELECT id FROM projects WHERE status = ?)", model.ProjectArchived)
+	}
+	var tasks []model.Task
+	err := db.Order("due_date, id").Find(&tasks).Error
+	return tasks, err
+}
@@ -8,6 +8,7 @@ import (
 	"project-management/internal/service"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CommentRepository struct{ db *gorm.DB }
@@ -15,7 +16,7 @@ type CommentRepository struct{ db *gorm.DB }
 func NewCommentRepository(db *gorm.DB) service.CommentRepository { return CommentRepository{db: db} }
 
 func (r CommentRepository) List(ctx context.Context, filter service.CommentListFilter) ([]model.Comment, int64, error) {
-	db := r.db.WithContext(ctx).Model(&model.Comment{})
+	db := r.db.WithContext(ctx).Model(&model.Comment{}).Where(commentOfLiveTask)
 	if filter.TaskID != "" {
 		db = db.Where("task_id = ?", filter.TaskID)
 	}
@@ -23,25 +24,183 @@ func (r CommentRepository) List(ctx context.Context, filter service.CommentListF
 		db = db.Where("author = ?", filter.Author)
 	}
 	var total int64
-	if err := db.Count(&total).Error; err != nil {
+	if err := httpx.Count(db, filter.Params, &total); err != nil {
 		return nil, 0, err
 	}
 	allowedSort := map[string]string{"id": "id", "createdAt": "created_at"}
 	var items []model.Comment
-	err := httpx.ApplyPagination(httpx.ApplySorting(db, allowedSort, filter.Params, "created_at DESC"), filter.Params).Find(&items).Error
-	return items, total, err
+	if err := httpx.FindPage(db, &items, allowedSort, filter.Params, "-createdAt"); err != nil {
+		return nil, 0, err
+	}
+	return items, total, loadCommentThreads(r.db.WithContext(ctx), items)
 }
+
 func (r CommentRepository) Create(ctx context.Context, comment *model.Comment) error {
-	return r.db.WithContext(ctx).Create(comment).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, comment.TaskID); err != nil {
+			return err
+		}
+		return tx.Create(comment).Error
+	})
 }
+
 func (r CommentRepository) Get(ctx context.Context, id string) (model.Comment, error) {
 	var comment model.Comment
-	err := r.db.WithContext(ctx).First(&comment, id).Error
-	return comment, err
+	if err := r.db.WithContext(ctx).Where(commentOfLiveTask).First(&comment, id).Error; err != nil {
+		return comment, err
+	}
+	items := []model.Comment{comment}
+	if err := loadCommentThreads(r.db.WithContext(ctx), items); err != nil {
+		return comment, err
+	}
+	return items[0], nil
 }
+
 func (r CommentRepository) Save(ctx context.Context, comment *model.Comment) error {
-	return r.db.WithContext(ctx).Save(comment).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, comment.TaskID); err != nil {
+			return err
+		}
+		return saveVersioned(tx, comment, &comment.Version)
+	})
 }
+
 func (r CommentRepository) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Delete(&model.Comment{}, id).Error
 }
+
+func (r CommentRepository) ListReplies(ctx context.Context, parentID string, params httpx.ListParams) ([]model.Comment, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.Comment{}).Where("parent_id = ?", parentID).Where(commentOfLiveTask)
+	var total int64
+	if err := httpx.Count(db, params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "id", "createdAt": "created_at"}
+	var items []model.Comment
+	if err := httpx.FindPage(db, &items, allowedSort, params, "createdAt"); err != nil {
+		return nil, 0, err
+	}
+	return items, total, loadCommentThreads(r.db.WithContext(ctx), items)
+}
+
+func (r CommentRepository) AddReaction(ctx context.Context, reaction *model.CommentReaction) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfComment, reaction.CommentID); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+	})
+}
+
+func (r CommentRepository) RemoveReaction(ctx context.Context, commentID, userID uint, emoji string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfComment, commentID); err != nil {
+			return err
+		}
+		return tx.
+			Where("comment_id = ? AND user_id = ? AND emoji = ?", commentID, userID, emoji).
+			Delete(&model.CommentReaction{}).Error
+	})
+}
+
+func (r CommentRepository) SaveWithRevision(ctx context.Context, comment *model.Comment, revision *model.CommentRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, comment.TaskID); err != nil {
+			return err
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return saveVersioned(tx, comment, &comment.Version)
+	})
+}
+
+// Tombstone saves the cleared comment. Its revisions and reactions stay for a
+// restore from the trash; they are hidden until then and dropped on purge.
+func (r CommentRepository) Tombstone(ctx context.Context, comment *model.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, comment.TaskID); err != nil {
+			return err
+		}
+		comment.Reactions = nil
+		return saveVersioned(tx, comment, &comment.Version)
+	})
+}
+
+func (r CommentRepository) LinkableTasks(ctx context.Context, taskID uint, ids []uint) ([]uint, error) {
+	return linkableTasks(r.db.WithContext(ctx), projectOfTask, taskID, ids)
+}
+
+func (r CommentRepository) ListRevisions(ctx context.Context, commentID string, params httpx.ListParams) ([]model.CommentRevision, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.CommentRevision{}).Where("comment_id = ?", commentID)
+	var total int64
+	if err := httpx.Count(db, params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "id", "replacedAt": "created_at", "writtenAt": "written_at"}
+	var items []model.CommentRevision
+	err := httpx.FindPage(db, &items, allowedSort, params, "-replacedAt")
+	return items, total, err
+}
+
+// loadCommentThreads fills in the reply counts and aggregated reactions of the
+// given comments with one query each. Deleted comments show no reactions.
+func loadCommentThreads(db *gorm.DB, comments []model.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(comments))
+	index := make(map[uint]int, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+		index[c.ID] = i
+	}
+	var counts []struct {
+		ParentID uint
+		Count    int64
+	}
+	if err := db.Model(&model.Comment{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ?", ids).
+		Group("parent_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	for _, c := range counts {
+		comments[index[c.ParentID]].ReplyCount = c.Count
+	}
+	var rows []struct {
+		CommentID uint
+		Emoji     string
+		UserID    uint
+		UserName  string
+	}
+	if err := db.Table("comment_reactions AS cr").
+		Select("cr.comment_id, cr.emoji, u.id AS user_id, u.name AS user_name").
+		Joins("JOIN users u ON u.id = cr.user_id").
+		Where("cr.comment_id IN ?", ids).
+		Order("cr.created_at, cr.id").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		c := &comments[index[row.CommentID]]
+		if c.DeletedAt != nil {
+			continue
+		}
+		pos := -1
+		for i, s := range c.Reactions {
+			if s.Emoji == row.Emoji {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 {
+			c.Reactions = append(c.Reactions, model.ReactionSummary{Emoji: row.Emoji})
+			pos = len(c.Reactions) - 1
+		}
+		c.Reactions[pos].Count++
+		c.Reactions[pos].Users = append(c.Reactions[pos].Users, model.ReactionUser{ID: row.UserID, Name: row.UserName})
+	}
+	return nil
+}
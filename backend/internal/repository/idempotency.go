@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository struct{ db *gorm.DB }
+
+func NewIdempotencyRepository(db *gorm.DB) service.IdempotencyRepository {
+	return IdempotencyRepository{db: db}
+}
+
+// Reserve relies on the unique (user_id, key) index: of two concurrent
+// requests with the same key exactly one inserts its row. An in-progress row
+// without a lease predates leases and counts as lapsed.
+func (r IdempotencyRepository) Reserve(ctx context.Context, record *model.IdempotencyKey) (bool, error) {
+	var inserted bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND key = ?", record.UserID, record.Key).
+			Where("expires_at <= @now OR (status = 0 AND (locked_until IS NULL OR locked_until <= @now))", map[string]any{"now": record.CreatedAt}).
+			Delete(&model.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		inserted = res.RowsAffected == 1
+		return res.Error
+	})
+	return inserted, err
+}
+
+func (r IdempotencyRepository) Find(ctx context.Context, userID uint, key string) (model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	err := r.db.WithContext(ctx).Where("user_id = ? AND key = ?", userID, key).First(&record).Error
+	return record, err
+}
+
+func (r IdempotencyRepository) Complete(ctx context.Context, record model.IdempotencyKey) error {
+	return r.db.WithContext(ctx).Model(&model.IdempotencyKey{}).Where("id = ?", record.ID).
+		Updates(map[string]any{"status": record.Status, "header": record.Header, "body": record.Body, "locked_until": nil}).Error
+}
+
+func (r IdempotencyRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.IdempotencyKey{}, id).Error
+}
+
+func (r IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&model.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// preload loads the relations named by ?include= paths; "tasks.comments"
+// preloads Tasks.Comments.
+func preload(db *gorm.DB, include []string) *gorm.DB {
+	for _, path := range include {
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			names[i] = strings.ToUpper(name[:1]) + name[1:]
+		}
+		db = db.Preload(strings.Join(names, "."))
+	}
+	return db
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MilestoneRepository struct{ db *gorm.DB }
+
+func NewMilestoneRepository(db *gorm.DB) service.MilestoneRepository {
+	return MilestoneRepository{db: db}
+}
+
+func (r MilestoneRepository) Project(ctx context.Context, id string) (model.Project, error) {
+	var project model.Project
+	err := r.db.WithContext(ctx).First(&project, id).Error
+	return project, err
+}
+
+func (r MilestoneRepository) List(ctx context.Context, projectID uint, params httpx.ListParams) ([]model.Milestone, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.Milestone{}).Where("project_id = ?", projectID)
+	var total int64
+	if err := httpx.Count(db, params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "id", "name": "name", "targetDate": "target_date", "createdAt": "created_at"}
+	var milestones []model.Milestone
+	err := httpx.FindPage(db, &milestones, allowedSort, params, "targetDate")
+	return milestones, total, err
+}
+
+func (r MilestoneRepository) Create(ctx context.Context, milestone *model.Milestone) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", milestone.ProjectID); err != nil {
+			return err
+		}
+		return tx.Create(milestone).Error
+	})
+}
+
+func (r MilestoneRepository) Get(ctx context.Context, id string) (model.Milestone, error) {
+	var milestone model.Milestone
+	err := r.db.WithContext(ctx).First(&milestone, id).Error
+	return milestone, err
+}
+
+func (r MilestoneRepository) Save(ctx context.Context, milestone *model.Milestone) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", milestone.ProjectID); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(milestone).Error
+	})
+}
+
+func (r MilestoneRepository) Delete(ctx context.Context, milestone model.Milestone) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", milestone.ProjectID); err != nil {
+			return err
+		}
+		return tx.Delete(&model.Milestone{}, milestone.ID).Error
+	})
+}
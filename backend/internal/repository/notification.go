@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct{ db *gorm.DB }
+
+func NewNotificationRepository(db *gorm.DB) service.NotificationRepository {
+	return NotificationRepository{db: db}
+}
+
+func (r NotificationRepository) List(ctx context.Context, filter service.NotificationListFilter) ([]model.Notification, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ?", filter.UserID)
+	if filter.Unread {
+		db = db.Where("read_at IS NULL")
+	}
+	var total int64
+	if err := httpx.Count(db, filter.Params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "id", "createdAt": "created_at"}
+	var items []model.Notification
+	err := httpx.FindPage(db, &items, allowedSort, filter.Params, "-createdAt")
+	return items, total, err
+}
+
+func (r NotificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&n).Error
+	return n, err
+}
+
+func (r NotificationRepository) Get(ctx context.Context, id string) (model.Notification, error) {
+	var n model.Notification
+	err := r.db.WithContext(ctx).First(&n, id).Error
+	return n, err
+}
+
+// MarkRead marks the user's unread notifications with the given IDs, or all
+// of them when ids is empty.
+func (r NotificationRepository) MarkRead(ctx context.Context, userID uint, ids []uint, at time.Time) (int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		db = db.Where("id IN ?", ids)
+	}
+	res := db.Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
@@ -10,29 +10,36 @@ import (
 	"gorm.io/gorm"
 )
 
+// visibleProject limits p to the projects @user can see: those without an
+// owner, which predate owners and stay shared, those @user owns and those
+// with a task assigned to @user. The project list, search, the trash and
+// saved views all go by it.
+const visibleProject = `(p.owner_id IS NULL OR p.owner_id = @user OR EXISTS (SELECT 1 FROM tasks vt WHERE vt.project_id = p.id AND vt.assignee_id = @user AND vt.deleted_at IS NULL))`
+
 type ProjectRepository struct{ db *gorm.DB }
 
 func NewProjectRepository(db *gorm.DB) service.ProjectRepository { return ProjectRepository{db: db} }
 
 func (r ProjectRepository) List(ctx context.Context, filter service.ProjectListFilter) ([]model.Project, int64, error) {
-	db := r.db.WithContext(ctx).Model(&model.Project{})
+	db := r.db.WithContext(ctx).Model(&model.Project{}).
+		Where("projects.id IN (SELECT p.id FROM projects p WHERE "+visibleProject+")", map[string]any{"user": filter.UserID})
 	if filter.Query != "" {
 		like := "%" + filter.Query + "%"
 		db = db.Where("title ILIKE ? OR description ILIKE ?", like, like)
 	}
 	if filter.Status != "" {
 		db = db.Where("status = ?", filter.Status)
+	} else if !filter.IncludeArchived {
+		db = db.Where("status <> ?", model.ProjectArchived)
 	}
 	var total int64
-	if err := db.Count(&total).Error; err != nil {
+	if err := httpx.Count(db, filter.Params, &total); err != nil {
 		return nil, 0, err
 	}
-	if filter.IncludeTasks {
-		db = db.Preload("Tasks")
-	}
+	db = preload(db, filter.Include)
 	allowedSort := map[string]string{"id": "id", "title": "title", "status": "status", "createdAt": "created_at"}
 	var items []model.Project
-	err := httpx.ApplyPagination(httpx.ApplySorting(db, allowedSort, filter.Params, "created_at DESC"), filter.Params).Find(&items).Error
+	err := httpx.FindPage(db, &items, allowedSort, filter.Params, "-createdAt")
 	return items, total, err
 }
 
@@ -40,42 +47,136 @@ func (r ProjectRepository) Create(ctx context.Context, project *model.Project) e
 	return r.db.WithContext(ctx).Create(project).Error
 }
 
-func (r ProjectRepository) Get(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+func (r ProjectRepository) Get(ctx context.Context, id string, include []string) (model.Project, error) {
 	var project model.Project
-	db := r.db.WithContext(ctx)
-	if includeTasks {
-		db = db.Preload("Tasks")
-	}
-	err := db.First(&project, id).Error
+	err := preload(r.db.WithContext(ctx), include).First(&project, id).Error
 	return project, err
 }
 
 func (r ProjectRepository) Save(ctx context.Context, project *model.Project) error {
-	return r.db.WithContext(ctx).Save(project).Error
+	return saveVersioned(r.db.WithContext(ctx), project, &project.Version)
 }
 
-func (r ProjectRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.Project{}, id).Error
+// Delete moves the project to the trash together with its tasks, which get
+// the project's DeletedAt so that restoring the project restores them.
+func (r ProjectRepository) Delete(ctx context.Context, id string, version *uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteVersioned(tx, &model.Project{}, id, version); err != nil {
+			return err
+		}
+		var project model.Project
+		if err := tx.Unscoped().First(&project, id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Task{}).Where("project_id = ?", project.ID).Update("deleted_at", project.DeletedAt).Error
+	})
 }
 
 func (r ProjectRepository) ListTasks(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter) ([]model.Task, int64, error) {
-	db := r.db.WithContext(ctx).Model(&model.Task{}).Where("project_id = ?", projectID)
-	if filter.Status != "" {
-		db = db.Where("status = ?", filter.Status)
-	}
-	if filter.AssigneeID != "" {
-		db = db.Where("assignee_id = ?", filter.AssigneeID)
-	}
+	db := projectTaskConditions(r.db.WithContext(ctx).Model(&model.Task{}), projectID, filter)
 	var total int64
-	if err := db.Count(&total).Error; err != nil {
+	if err := httpx.Count(db, filter.Params, &total); err != nil {
 		return nil, 0, err
 	}
 	allowedSort := map[string]string{"id": "id", "title": "title", "status": "status", "dueDate": "due_date", "createdAt": "created_at"}
 	var items []model.Task
-	err := httpx.ApplyPagination(httpx.ApplySorting(db, allowedSort, filter.Params, "created_at DESC"), filter.Params).Find(&items).Error
+	err := httpx.FindPage(preload(db.Preload("Labels"), filter.Include), &items, allowedSort, filter.Params, "-createdAt")
 	return items, total, err
 }
 
+// projectTaskConditions narrows db to the tasks of the project matching
+// filter.
+func projectTaskConditions(db *gorm.DB, projectID uint, filter service.ProjectTaskListFilter) *gorm.DB {
+	db = db.Where("project_id = ?", projectID)
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.AssigneeID != "" {
+		db = db.Where("assignee_id = ?", filter.AssigneeID)
+	}
+	return db
+}
+
+// EachTask passes the project's tasks matching filter, with their labels, to
+// each in batches of size, in ID order.
+func (r ProjectRepository) EachTask(ctx context.Context, projectID uint, filter service.ProjectTaskListFilter, size int, each func([]model.Task) error) error {
+	db := projectTaskConditions(r.db.WithContext(ctx).Model(&model.Task{}), projectID, filter)
+	var batch []model.Task
+	return db.Preload("Labels").FindInBatches(&batch, size, func(tx *gorm.DB, _ int) error {
+		return each(batch)
+	}).Error
+}
+
+func (r ProjectRepository) LinkableTasks(ctx context.Context, projectID uint, ids []uint) ([]uint, error) {
+	return linkableTasks(r.db.WithContext(ctx), "?", projectID, ids)
+}
+
+// CreateTasks inserts the tasks into the project in one transaction.
+func (r ProjectRepository) CreateTasks(ctx context.Context, projectID uint, tasks []model.Task) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", projectID); err != nil {
+			return err
+		}
+		for i := range tasks {
+			tasks[i].ProjectID = projectID
+		}
+		return tx.CreateInBatches(tasks, 500).Error
+	})
+}
+
 func (r ProjectRepository) CreateTask(ctx context.Context, task *model.Task) error {
-	return r.db.WithContext(ctx).Create(task).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", task.ProjectID); err != nil {
+			return err
+		}
+		return tx.Create(task).Error
+	})
+}
+
+// GetTree loads the project with its tasks and their labels, and all labels
+// of the project, for copying it.
+func (r ProjectRepository) GetTree(ctx context.Context, id string) (model.Project, []model.Label, error) {
+	db := r.db.WithContext(ctx)
+	var project model.Project
+	err := db.Preload("Tasks", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Preload("Tasks.Labels").First(&project, id).Error
+	if err != nil {
+		return project, nil, err
+	}
+	var labels []model.Label
+	err = db.Where("project_id = ?", project.ID).Order("name").Find(&labels).Error
+	return project, labels, err
+}
+
+// CreateTree inserts the project, its labels and its tasks in one
+// transaction. The labels of the tasks need only a name.
+func (r ProjectRepository) CreateTree(ctx context.Context, project *model.Project, labels []model.Label) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createTree(tx, project, labels)
+	})
+}
+
+func createTree(tx *gorm.DB, project *model.Project, labels []model.Label) error {
+	if err := tx.Omit("Tasks").Create(project).Error; err != nil {
+		return err
+	}
+	for i := range labels {
+		labels[i].ProjectID = project.ID
+	}
+	if err := upsertLabels(tx, labels); err != nil {
+		return err
+	}
+	for i := range project.Tasks {
+		task := &project.Tasks[i]
+		task.ProjectID = project.ID
+		for j := range task.Labels {
+			task.Labels[j].ProjectID = project.ID
+		}
+		if err := upsertLabels(tx, task.Labels); err != nil {
+			return err
+		}
+		if err := tx.Omit("Labels.*").Create(task).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
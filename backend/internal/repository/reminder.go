@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReminderRepository struct{ db *gorm.DB }
+
+func NewReminderRepository(db *gorm.DB) service.ReminderRepository {
+	return ReminderRepository{db: db}
+}
+
+func (r ReminderRepository) Preferences(ctx context.Context, userIDs []uint) ([]model.ReminderPreference, error) {
+	var prefs []model.ReminderPreference
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&prefs).Error
+	return prefs, err
+}
+
+func (r ReminderRepository) SavePreference(ctx context.Context, pref *model.ReminderPreference) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"offsets", "email", "updated_at"}),
+	}).Create(pref).Error
+}
+
+func (r ReminderRepository) Upcoming(ctx context.Context, from, to time.Time) ([]model.Task, error) {
+	var tasks []model.Task
+	err := r.db.WithContext(ctx).Preload("Assignee").
+		Where("assignee_id IS NOT NULL AND status <> ? AND due_date BETWEEN ? AND ?", model.TaskDone, from, to).
+		Where("project_id NOT IN (SELECT id FROM projects WHERE status = ?)", model.ProjectArchived).
+		Order("due_date, id").Find(&tasks).Error
+	return tasks, err
+}
+
+func (r ReminderRepository) Fire(ctx context.Context, sent model.SentReminder, notification *model.Notification) (bool, error) {
+	fired := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sent)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		fired = true
+		if notification == nil {
+			return nil
+		}
+		return tx.Create(notification).Error
+	})
+	return fired, err
+}
+
+// TryLock holds a session-level advisory lock, so it pins one connection of
+// the pool until fn returns.
+func (r ReminderRepository) TryLock(ctx context.Context, key int64, fn func() error) (bool, error) {
+	locked := false
+	err := r.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&locked).Error; err != nil || !locked {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+		return fn()
+	})
+	return locked, err
+}
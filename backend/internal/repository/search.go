@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+)
+
+type SearchRepository struct{ db *gorm.DB }
+
+func NewSearchRepository(db *gorm.DB) service.SearchRepository { return SearchRepository{db: db} }
+
+var searchBranches = map[string]string{
+	service.SearchProject: `SELECT 'project' AS type, p.id, p.id AS project_id, NULL::bigint AS task_id, p.title, coalesce(p.description, '') AS body, ts_rank(p.search_vector, to_tsquery('simple', @query)) AS rank
+		FROM projects p
+		WHERE p.deleted_at IS NULL AND p.search_vector @@ to_tsquery('simple', @query) AND ` + visibleProject,
+	service.SearchTask: `SELECT 'task' AS type, t.id, t.project_id, t.id AS task_id, t.title, coalesce(t.description, '') AS body, ts_rank(t.search_vector, to_tsquery('simple', @query)) AS rank
+		FROM tasks t JOIN projects p ON p.id = t.project_id
+		WHERE t.deleted_at IS NULL AND t.search_vector @@ to_tsquery('simple', @query) AND ` + visibleProject,
+	service.SearchComment: `SELECT 'comment' AS type, c.id, t.project_id, c.task_id, t.title, c.text AS body, ts_rank(c.search_vector, to_tsquery('simple', @query)) AS rank
+		FROM comments c JOIN tasks t ON t.id = c.task_id JOIN projects p ON p.id = t.project_id
+		WHERE c.deleted_at IS NULL AND t.deleted_at IS NULL AND c.search_vector @@ to_tsquery('simple', @query) AND ` + visibleProject,
+}
+
+func (r SearchRepository) Search(ctx context.Context, filter service.SearchFilter) ([]model.SearchHit, int64, error) {
+	types := filter.Types
+	if len(types) == 0 {
+		types = []string{service.SearchProject, service.SearchTask, service.SearchComment}
+	}
+	branches := make([]string, 0, len(types))
+	for _, t := range types {
+		branches = append(branches, searchBranches[t])
+	}
+	args := map[string]any{"query": service.PrefixQuery(filter.Query), "user": filter.UserID}
+	hits := r.db.Raw(strings.Join(branches, " UNION ALL "), args)
+
+	db := r.db.WithContext(ctx).Table("(?) AS hits", hits)
+	var total int64
+	if err := httpx.Count(db, filter.Params, &total); err != nil {
+		return nil, 0, err
+	}
+
+	options := "StartSel=" + service.SearchMarkStart + ", StopSel=" + service.SearchMarkStop + ", MaxWords=30, MinWords=10, MaxFragments=2"
+	db = db.Select("type, id, project_id, task_id, title, rank, ts_headline('simple', CASE WHEN body = '' THEN title ELSE body END, to_tsquery('simple', ?), ?) AS snippet", args["query"], options)
+	allowedSort := map[string]string{"rank": "rank", "type": "type", "title": "title", "id": "id"}
+	var items []model.SearchHit
+	err := httpx.FindPage(db, &items, allowedSort, filter.Params, "-rank,type", "type", "id")
+	return items, total, err
+}
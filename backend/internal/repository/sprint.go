@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SprintRepository struct{ db *gorm.DB }
+
+func NewSprintRepository(db *gorm.DB) service.SprintRepository {
+	return SprintRepository{db: db}
+}
+
+func (r SprintRepository) Project(ctx context.Context, id string) (model.Project, error) {
+	var project model.Project
+	err := r.db.WithContext(ctx).First(&project, id).Error
+	return project, err
+}
+
+func (r SprintRepository) List(ctx context.Context, projectID uint, state model.SprintState, params httpx.ListParams) ([]model.Sprint, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.Sprint{}).Where("project_id = ?", projectID)
+	if state != "" {
+		db = db.Where("state = ?", state)
+	}
+	var total int64
+	if err := httpx.Count(db, params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "id", "name": "name", "startDate": "start_date", "endDate": "end_date", "createdAt": "created_at"}
+	var sprints []model.Sprint
+	err := httpx.FindPage(db, &sprints, allowedSort, params, "startDate")
+	return sprints, total, err
+}
+
+func (r SprintRepository) Create(ctx context.Context, sprint *model.Sprint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", sprint.ProjectID); err != nil {
+			return err
+		}
+		return tx.Create(sprint).Error
+	})
+}
+
+func (r SprintRepository) Get(ctx context.Context, id string) (model.Sprint, error) {
+	var sprint model.Sprint
+	err := r.db.WithContext(ctx).First(&sprint, id).Error
+	return sprint, err
+}
+
+func (r SprintRepository) Save(ctx context.Context, sprint *model.Sprint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", sprint.ProjectID); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(sprint).Error
+	})
+}
+
+func (r SprintRepository) Delete(ctx context.Context, sprint model.Sprint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", sprint.ProjectID); err != nil {
+			return err
+		}
+		return tx.Delete(&model.Sprint{}, sprint.ID).Error
+	})
+}
+
+func (r SprintRepository) Active(ctx context.Context, projectID uint) (model.Sprint, error) {
+	var sprint model.Sprint
+	err := r.db.WithContext(ctx).Where("project_id = ? AND state = ?", projectID, model.SprintActive).First(&sprint).Error
+	return sprint, err
+}
+
+func (r SprintRepository) Next(ctx context.Context, projectID uint) (model.Sprint, error) {
+	var sprint model.Sprint
+	err := r.db.WithContext(ctx).Where("project_id = ? AND state = ?", projectID, model.SprintPlanned).
+		Order("start_date, id").First(&sprint).Error
+	return sprint, err
+}
+
+// Close also snapshots and moves unfinished tasks that are in the trash, so
+// that they are not restored into a closed sprint. It moves exactly the
+// tasks it locked and snapshotted, and closes the sprint only if it is still
+// active, so of two concurrent closes one fails with service.ErrSprintClose.
+func (r SprintRepository) Close(ctx context.Context, sprint *model.Sprint, moveTo *uint, snapshot func(tasks []model.Task) *model.SprintScope) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", sprint.ProjectID); err != nil {
+			return err
+		}
+		var tasks []model.Task
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("sprint_id = ?", sprint.ID).Order("id").Find(&tasks).Error; err != nil {
+			return err
+		}
+		sprint.Scope = snapshot(tasks)
+		res := tx.Model(sprint).Where("state = ?", model.SprintActive).
+			Select("state", "closed_at", "scope", "updated_at").Updates(sprint)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return service.ErrSprintClose
+		}
+		var unfinished []uint
+		for _, task := range tasks {
+			if task.Status != model.TaskDone {
+				unfinished = append(unfinished, task.ID)
+			}
+		}
+		if len(unfinished) == 0 {
+			return nil
+		}
+		return tx.Unscoped().Model(&model.Task{}).Where("id IN ?", unfinished).
+			Updates(map[string]any{"sprint_id": moveTo, "version": gorm.Expr("version + 1"), "updated_at": time.Now()}).Error
+	})
+}
+
+// checkPlan refuses a sprint or milestone of another project, and a closed
+// sprint unless the task is in it already.
+func checkPlan(tx *gorm.DB, task *model.Task) error {
+	if task.SprintID != nil {
+		var n int64
+		err := tx.Model(&model.Sprint{}).
+			Where("id = ? AND project_id = ?", *task.SprintID, task.ProjectID).
+			Where("state <> ? OR id IN (SELECT sprint_id FROM tasks WHERE id = ?)", model.SprintClosed, task.ID).
+			Count(&n).Error
+		if err == nil && n == 0 {
+			err = service.ErrInvalidSprint
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if task.MilestoneID != nil {
+		var n int64
+		err := tx.Model(&model.Milestone{}).Where("id = ? AND project_id = ?", *task.MilestoneID, task.ProjectID).Count(&n).Error
+		if err == nil && n == 0 {
+			err = service.ErrInvalidMilestone
+		}
+		return err
+	}
+	return nil
+}
+
+// plannedIn narrows db to the tasks in the sprint or milestone with the
+// given ID in column, or without one for service.TaskUnplanned.
+func plannedIn(db *gorm.DB, column, id string) *gorm.DB {
+	switch id {
+	case "":
+		return db
+	case service.TaskUnplanned:
+		return db.Where(column + " IS NULL")
+	default:
+		return db.Where(column+" = ?", id)
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"project-management/internal/httpx"
@@ -9,6 +10,7 @@ import (
 	"project-management/internal/service"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TaskRepository struct{ db *gorm.DB }
@@ -16,9 +18,27 @@ type TaskRepository struct{ db *gorm.DB }
 func NewTaskRepository(db *gorm.DB) service.TaskRepository { return TaskRepository{db: db} }
 
 func (r TaskRepository) List(ctx context.Context, filter service.TaskListFilter) ([]model.Task, int64, error) {
-	db := r.db.WithContext(ctx).Model(&model.Task{})
+	db := taskConditions(r.db.WithContext(ctx).Model(&model.Task{}), filter)
+	var total int64
+	if err := httpx.Count(db, filter.Params, &total); err != nil {
+		return nil, 0, err
+	}
+	db = preload(db.Preload("Labels"), filter.Include)
+	allowedSort := map[string]string{"id": "id", "title": "title", "status": "status", "dueDate": "due_date", "createdAt": "created_at", "assigneeId": "assignee_id", "projectId": "project_id"}
+	var items []model.Task
+	err := httpx.FindPage(db, &items, allowedSort, filter.Params, "-createdAt")
+	return items, total, err
+}
+
+// taskConditions narrows db to the tasks matching filter.
+func taskConditions(db *gorm.DB, filter service.TaskListFilter) *gorm.DB {
+	if filter.Where != nil {
+		db = db.Where(filter.Where.SQL, filter.Where.Args...)
+	}
 	if filter.ProjectID != "" {
 		db = db.Where("project_id = ?", filter.ProjectID)
+	} else if !filter.IncludeArchived {
+		db = db.Where("project_id NOT IN (SELECT id FROM projects WHERE status = ?)", model.ProjectArchived)
 	}
 	if filter.Status != "" {
 		db = db.Where("status = ?", filter.Status)
@@ -26,6 +46,8 @@ func (r TaskRepository) List(ctx context.Context, filter service.TaskListFilter)
 	if filter.AssigneeID != "" {
 		db = db.Where("assignee_id = ?", filter.AssigneeID)
 	}
+	db = plannedIn(db, "sprint_id", filter.SprintID)
+	db = plannedIn(db, "milestone_id", filter.MilestoneID)
 	if filter.DueFrom != "" {
 		if t, err := time.Parse("2006-01-02", filter.DueFrom); err == nil {
 			db = db.Where("due_date >= ?", t)
@@ -36,56 +58,193 @@ func (r TaskRepository) List(ctx context.Context, filter service.TaskListFilter)
 			db = db.Where("due_date <= ?", t)
 		}
 	}
-	var total int64
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	if filter.IncludeComments {
-		db = db.Preload("Comments")
-	}
-	allowedSort := map[string]string{"id": "id", "title": "title", "status": "status", "dueDate": "due_date", "createdAt": "created_at"}
-	var items []model.Task
-	err := httpx.ApplyPagination(httpx.ApplySorting(db, allowedSort, filter.Params, "created_at DESC"), filter.Params).Find(&items).Error
-	return items, total, err
+	return db
 }
 
 func (r TaskRepository) Create(ctx context.Context, task *model.Task) error {
-	return r.db.WithContext(ctx).Create(task).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, "?", task.ProjectID); err != nil {
+			return err
+		}
+		return createTask(tx, task)
+	})
 }
 
-func (r TaskRepository) Get(ctx context.Context, id string, includeComments bool) (model.Task, error) {
-	var task model.Task
-	db := r.db.WithContext(ctx)
-	if includeComments {
-		db = db.Preload("Comments")
+// createTask inserts the task with its labels. A recurring task without a
+// series starts one.
+func createTask(tx *gorm.DB, task *model.Task) error {
+	if err := checkPlan(tx, task); err != nil {
+		return err
 	}
-	err := db.First(&task, id).Error
+	if err := upsertLabels(tx, task.Labels); err != nil {
+		return err
+	}
+	if err := tx.Omit("Labels.*").Create(task).Error; err != nil {
+		return err
+	}
+	if task.Recurrence == "" || task.SeriesID != nil {
+		return nil
+	}
+	task.SeriesID = &task.ID
+	return tx.Model(task).UpdateColumn("series_id", task.ID).Error
+}
+
+func (r TaskRepository) Get(ctx context.Context, id string, include []string) (model.Task, error) {
+	var task model.Task
+	err := preload(r.db.WithContext(ctx).Preload("Labels"), include).First(&task, id).Error
 	return task, err
 }
 
 func (r TaskRepository) Save(ctx context.Context, task *model.Task) error {
-	return r.db.WithContext(ctx).Save(task).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return saveTask(tx, task)
+	})
 }
 
-func (r TaskRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.Task{}, id).Error
+// saveTask refuses the write when the task is in, or moves to, an archived
+// project.
+func saveTask(tx *gorm.DB, task *model.Task) error {
+	if err := writable(tx, projectOfTask, task.ID); err != nil {
+		return err
+	}
+	if err := writable(tx, "?", task.ProjectID); err != nil {
+		return err
+	}
+	if err := checkPlan(tx, task); err != nil {
+		return err
+	}
+	if err := saveVersioned(tx, task, &task.Version); err != nil {
+		return err
+	}
+	if err := upsertLabels(tx, task.Labels); err != nil {
+		return err
+	}
+	return tx.Model(task).Association("Labels").Replace(task.Labels)
+}
+
+func (r TaskRepository) Complete(ctx context.Context, task *model.Task, next *model.Task) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := saveTask(tx, task); err != nil {
+			return err
+		}
+		return createOccurrence(tx, task, next)
+	})
+}
+
+// createOccurrence creates next, the next occurrence of task, with the task's
+// watchers.
+func createOccurrence(tx *gorm.DB, task *model.Task, next *model.Task) error {
+	if err := createTask(tx, next); err != nil {
+		return err
+	}
+	return tx.Exec("INSERT INTO task_watchers (task_id, user_id, created_at) SELECT ?, user_id, ? FROM task_watchers WHERE task_id = ?", next.ID, time.Now(), task.ID).Error
+}
+
+func (r TaskRepository) Delete(ctx context.Context, id string, version *uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, id); err != nil {
+			return err
+		}
+		return deleteVersioned(tx, &model.Task{}, id, version)
+	})
+}
+
+func (r TaskRepository) Select(ctx context.Context, sel service.TaskSelection, limit int) ([]model.Task, error) {
+	db := r.db.WithContext(ctx).Model(&model.Task{}).Preload("Labels")
+	if sel.Filter != nil {
+		db = taskConditions(db, *sel.Filter)
+	} else {
+		db = db.Where("id IN ?", sel.IDs)
+	}
+	var items []model.Task
+	err := db.Order("id").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+func (r TaskRepository) ProjectExists(ctx context.Context, projectID uint) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&model.Project{}).Where("id = ?", projectID).Count(&n).Error
+	return n > 0, err
+}
+
+func (r TaskRepository) LinkableTasks(ctx context.Context, projectID uint, ids []uint) ([]uint, error) {
+	return linkableTasks(r.db.WithContext(ctx), "?", projectID, ids)
+}
+
+// linkableTasks returns those of ids that are live tasks of the projects the
+// subquery selects.
+func linkableTasks(db *gorm.DB, projects string, arg any, ids []uint) ([]uint, error) {
+	var found []uint
+	err := db.Model(&model.Task{}).Where("id IN ? AND project_id IN ("+projects+")", ids, arg).Pluck("id", &found).Error
+	return found, err
+}
+
+func (r TaskRepository) ApplyBulk(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range save {
+			if err := saveTask(tx, &save[i]); err != nil {
+				return &service.TaskBulkError{TaskID: save[i].ID, Err: err}
+			}
+			if occurrence, ok := next[save[i].ID]; ok {
+				if err := createOccurrence(tx, &save[i], occurrence); err != nil {
+					return &service.TaskBulkError{TaskID: save[i].ID, Err: err}
+				}
+			}
+		}
+		for _, task := range remove {
+			version := task.Version
+			if err := writable(tx, projectOfTask, task.ID); err != nil {
+				return &service.TaskBulkError{TaskID: task.ID, Err: err}
+			}
+			if err := deleteVersioned(tx, &model.Task{}, strconv.FormatUint(uint64(task.ID), 10), &version); err != nil {
+				return &service.TaskBulkError{TaskID: task.ID, Err: err}
+			}
+		}
+		return nil
+	})
 }
 
 func (r TaskRepository) ListComments(ctx context.Context, taskID string, filter service.TaskCommentListFilter) ([]model.Comment, int64, error) {
-	db := r.db.WithContext(ctx).Model(&model.Comment{}).Where("task_id = ?", taskID)
+	db := r.db.WithContext(ctx).Model(&model.Comment{}).Where("task_id = ? AND parent_id IS NULL", taskID).Where(commentOfLiveTask)
 	if filter.Author != "" {
 		db = db.Where("author = ?", filter.Author)
 	}
 	var total int64
-	if err := db.Count(&total).Error; err != nil {
+	if err := httpx.Count(db, filter.Params, &total); err != nil {
 		return nil, 0, err
 	}
 	allowedSort := map[string]string{"id": "id", "createdAt": "created_at"}
 	var items []model.Comment
-	err := httpx.ApplyPagination(httpx.ApplySorting(db, allowedSort, filter.Params, "created_at DESC"), filter.Params).Find(&items).Error
-	return items, total, err
+	if err := httpx.FindPage(db, &items, allowedSort, filter.Params, "-createdAt"); err != nil {
+		return nil, 0, err
+	}
+	return items, total, loadCommentThreads(r.db.WithContext(ctx), items)
 }
 
 func (r TaskRepository) CreateComment(ctx context.Context, comment *model.Comment) error {
-	return r.db.WithContext(ctx).Create(comment).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, comment.TaskID); err != nil {
+			return err
+		}
+		return tx.Create(comment).Error
+	})
+}
+
+// upsertLabels creates missing labels and fills in the IDs of existing ones.
+func upsertLabels(tx *gorm.DB, labels []model.Label) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name"}),
+	}).Create(&labels).Error
+}
+
+func (r TaskRepository) Watch(ctx context.Context, taskID, userID uint) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.TaskWatcher{TaskID: taskID, UserID: userID}).Error
+}
+
+func (r TaskRepository) Unwatch(ctx context.Context, taskID, userID uint) error {
+	return r.db.WithContext(ctx).Where("task_id = ? AND user_id = ?", taskID, userID).Delete(&model.TaskWatcher{}).Error
 }
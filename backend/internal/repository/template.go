@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+)
+
+type TemplateRepository struct{ db *gorm.DB }
+
+func NewTemplateRepository(db *gorm.DB) service.TemplateRepository {
+	return TemplateRepository{db: db}
+}
+
+func (r TemplateRepository) List(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.ProjectTemplate{})
+	var total int64
+	if err := httpx.Count(db, params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "id", "name": "name", "createdAt": "created_at"}
+	var items []model.ProjectTemplate
+	err := httpx.FindPage(db, &items, allowedSort, params, "name")
+	return items, total, err
+}
+
+func (r TemplateRepository) Create(ctx context.Context, template *model.ProjectTemplate) error {
+	return r.db.WithContext(ctx).Create(template).Error
+}
+
+func (r TemplateRepository) Get(ctx context.Context, id string) (model.ProjectTemplate, error) {
+	var template model.ProjectTemplate
+	err := r.db.WithContext(ctx).First(&template, id).Error
+	return template, err
+}
+
+func (r TemplateRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&model.ProjectTemplate{}, id).Error
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// timeOfLiveTask leaves out the entries of tasks in the trash.
+const timeOfLiveTask = "NOT EXISTS (SELECT 1 FROM tasks dt WHERE dt.id = time_entries.task_id AND dt.deleted_at IS NOT NULL)"
+
+type TimeRepository struct{ db *gorm.DB }
+
+func NewTimeRepository(db *gorm.DB) service.TimeRepository {
+	return TimeRepository{db: db}
+}
+
+func (r TimeRepository) Task(ctx context.Context, id string) (model.Task, error) {
+	var task model.Task
+	err := r.db.WithContext(ctx).First(&task, id).Error
+	return task, err
+}
+
+func (r TimeRepository) List(ctx context.Context, taskID uint, params httpx.ListParams) ([]model.TimeEntry, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.TimeEntry{}).Where("task_id = ?", taskID)
+	var total int64
+	if err := httpx.Count(db, params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "id", "startedAt": "started_at", "seconds": "seconds"}
+	var items []model.TimeEntry
+	err := httpx.FindPage(db, &items, allowedSort, params, "-startedAt")
+	return items, total, err
+}
+
+func (r TimeRepository) TaskSeconds(ctx context.Context, taskID uint) (int64, error) {
+	var seconds int64
+	err := r.db.WithContext(ctx).Model(&model.TimeEntry{}).
+		Where("task_id = ? AND ended_at IS NOT NULL", taskID).
+		Select("COALESCE(SUM(seconds), 0)").Scan(&seconds).Error
+	return seconds, err
+}
+
+func (r TimeRepository) Create(ctx context.Context, entry *model.TimeEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, entry.TaskID); err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r TimeRepository) Get(ctx context.Context, id string) (model.TimeEntry, error) {
+	var entry model.TimeEntry
+	err := r.db.WithContext(ctx).Where(timeOfLiveTask).First(&entry, id).Error
+	return entry, err
+}
+
+func (r TimeRepository) Delete(ctx context.Context, entry model.TimeEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, entry.TaskID); err != nil {
+			return err
+		}
+		return tx.Delete(&model.TimeEntry{}, entry.ID).Error
+	})
+}
+
+func (r TimeRepository) Running(ctx context.Context, userID uint) (model.TimeEntry, error) {
+	var entry model.TimeEntry
+	err := r.db.WithContext(ctx).Where("user_id = ? AND ended_at IS NULL", userID).First(&entry).Error
+	return entry, err
+}
+
+func (r TimeRepository) Start(ctx context.Context, stop *model.TimeEntry, entry *model.TimeEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := writable(tx, projectOfTask, entry.TaskID); err != nil {
+			return err
+		}
+		if stop != nil {
+			if err := stopEntry(tx, stop); err != nil {
+				return err
+			}
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r TimeRepository) Stop(ctx context.Context, entry *model.TimeEntry) error {
+	return stopEntry(r.db.WithContext(ctx), entry)
+}
+
+// stopEntry saves the end of entry unless another request stopped it first.
+// Stopping is allowed in archived projects so that no timer is left running.
+func stopEntry(tx *gorm.DB, entry *model.TimeEntry) error {
+	res := tx.Model(&model.TimeEntry{}).Where("id = ? AND ended_at IS NULL", entry.ID).
+		Updates(map[string]any{"ended_at": entry.EndedAt, "seconds": entry.Seconds})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+func (r TimeRepository) ProjectTime(ctx context.Context, projectID string) (model.ProjectTime, error) {
+	db := r.db.WithContext(ctx)
+	var project model.Project
+	if err := db.Select("id").First(&project, projectID).Error; err != nil {
+		return model.ProjectTime{}, err
+	}
+	out := model.ProjectTime{ProjectID: project.ID}
+	err := db.Table("time_entries e").
+		Select("t.id AS task_id, t.title, SUM(e.seconds) AS seconds").
+		Joins("JOIN tasks t ON t.id = e.task_id").
+		Where("t.project_id = ? AND t.deleted_at IS NULL AND e.ended_at IS NOT NULL", project.ID).
+		Group("t.id, t.title").Order("t.id").
+		Scan(&out.Tasks).Error
+	return out, err
+}
+
+// Timesheet counts the archived projects too; it is a record of the past.
+func (r TimeRepository) Timesheet(ctx context.Context, from, to time.Time, userID *uint) ([]service.TimesheetRow, error) {
+	db := r.db.WithContext(ctx).Table("time_entries e").
+		Select("(e.started_at AT TIME ZONE 'UTC')::date AS day, p.id AS project_id, p.title, SUM(e.seconds) AS seconds").
+		Joins("JOIN tasks t ON t.id = e.task_id").
+		Joins("JOIN projects p ON p.id = t.project_id").
+		Where("t.deleted_at IS NULL AND e.ended_at IS NOT NULL AND e.started_at >= ? AND e.started_at < ?", from, to)
+	if userID != nil {
+		db = db.Where("e.user_id = ?", *userID)
+	}
+	var rows []service.TimesheetRow
+	err := db.Group("day, p.id, p.title").Order("day, p.title, p.id").Scan(&rows).Error
+	return rows, err
+}
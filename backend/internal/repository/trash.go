@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+)
+
+type TrashRepository struct{ db *gorm.DB }
+
+func NewTrashRepository(db *gorm.DB) service.TrashRepository { return TrashRepository{db: db} }
+
+// commentOfLiveTask hides the comments of tasks in the trash.
+const commentOfLiveTask = "NOT EXISTS (SELECT 1 FROM tasks dt WHERE dt.id = comments.task_id AND dt.deleted_at IS NOT NULL)"
+
+var trashBranches = map[string]string{
+	service.TrashProject: `SELECT 'project' AS type, p.id, p.id AS project_id, NULL::bigint AS task_id, p.title, p.deleted_at
+		FROM projects p
+		WHERE p.deleted_at IS NOT NULL AND ` + visibleProject,
+	service.TrashTask: `SELECT 'task' AS type, t.id, t.project_id, t.id AS task_id, t.title, t.deleted_at
+		FROM tasks t JOIN projects p ON p.id = t.project_id
+		WHERE t.deleted_at IS NOT NULL AND p.deleted_at IS NULL AND ` + visibleProject,
+	service.TrashComment: `SELECT 'comment' AS type, c.id, t.project_id, c.task_id, left(c.deleted_text, 200) AS title, c.deleted_at
+		FROM comments c JOIN tasks t ON t.id = c.task_id JOIN projects p ON p.id = t.project_id
+		WHERE c.deleted_at IS NOT NULL AND c.deleted_text <> '' AND t.deleted_at IS NULL AND ` + visibleProject,
+}
+
+func (r TrashRepository) List(ctx context.Context, filter service.TrashFilter) ([]model.TrashItem, int64, error) {
+	types := filter.Types
+	if len(types) == 0 {
+		types = []string{service.TrashProject, service.TrashTask, service.TrashComment}
+	}
+	branches := make([]string, 0, len(types))
+	for _, t := range types {
+		branches = append(branches, trashBranches[t])
+	}
+	items := r.db.Raw(strings.Join(branches, " UNION ALL "), map[string]any{"user": filter.UserID})
+
+	db := r.db.WithContext(ctx).Table("(?) AS trash", items)
+	var total int64
+	if err := httpx.Count(db, filter.Params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"deletedAt": "deleted_at", "type": "type", "title": "title", "id": "id"}
+	var out []model.TrashItem
+	err := httpx.FindPage(db, &out, allowedSort, filter.Params, "-deletedAt,type", "type", "id")
+	return out, total, err
+}
+
+func (r TrashRepository) Restore(ctx context.Context, kind, id string, userID uint) error {
+	visible := map[string]any{"id": id, "user": userID}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		switch kind {
+		case service.TrashProject:
+			var project model.Project
+			if err := tx.Unscoped().
+				Where("id = @id AND deleted_at IS NOT NULL AND id IN (SELECT p.id FROM projects p WHERE "+visibleProject+")", visible).
+				First(&project).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&model.Task{}).
+				Where("project_id = ? AND deleted_at = ?", project.ID, project.DeletedAt).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+			return restoreRow(tx, &model.Project{}, project.ID, map[string]any{"deleted_at": nil})
+
+		case service.TrashTask:
+			var task model.Task
+			if err := tx.Unscoped().
+				Where("id = @id AND deleted_at IS NOT NULL AND project_id IN (SELECT p.id FROM projects p WHERE "+visibleProject+")", visible).
+				First(&task).Error; err != nil {
+				return err
+			}
+			if err := requireLive(tx, &model.Project{}, task.ProjectID); err != nil {
+				return err
+			}
+			if err := writable(tx, "?", task.ProjectID); err != nil {
+				return err
+			}
+			return restoreRow(tx, &model.Task{}, task.ID, map[string]any{"deleted_at": nil})
+
+		default:
+			var comment model.Comment
+			if err := tx.Where("id = @id AND deleted_at IS NOT NULL AND deleted_text <> '' AND task_id IN (SELECT t.id FROM tasks t JOIN projects p ON p.id = t.project_id WHERE "+visibleProject+")", visible).
+				First(&comment).Error; err != nil {
+				return err
+			}
+			if err := requireLive(tx, &model.Task{}, comment.TaskID); err != nil {
+				return err
+			}
+			if err := writable(tx, projectOfTask, comment.TaskID); err != nil {
+				return err
+			}
+			return restoreRow(tx, &model.Comment{}, comment.ID, map[string]any{
+				"text": gorm.Expr("deleted_text"), "html": gorm.Expr("deleted_html"),
+				"deleted_text": "", "deleted_html": "", "deleted_at": nil,
+			})
+		}
+	})
+}
+
+// restoreRow applies the restoring changes and a new version, so that
+// writes based on the deleted row fail their If-Match check.
+func restoreRow(tx *gorm.DB, model any, id uint, changes map[string]any) error {
+	changes["version"] = gorm.Expr("version + 1")
+	return tx.Unscoped().Model(model).Where("id = ?", id).Updates(changes).Error
+}
+
+// requireLive returns service.ErrTrashParentDeleted unless the row of model
+// with the given ID is out of the trash.
+func requireLive(tx *gorm.DB, model any, id uint) error {
+	var n int64
+	if err := tx.Model(model).Where("id = ?", id).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrTrashParentDeleted
+	}
+	return nil
+}
+
+// Purge hard-deletes projects and tasks trashed before cutoff; the foreign
+// keys remove their tasks, comments and attachments. Deleted comments stay
+// tombstones but lose the text, revisions and reactions kept for restoring
+// them.
+func (r TrashRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, value := range []any{&model.Project{}, &model.Task{}} {
+			res := tx.Unscoped().Where("deleted_at <= ?", cutoff).Delete(value)
+			if res.Error != nil {
+				return res.Error
+			}
+			n += res.RowsAffected
+		}
+		purged := tx.Model(&model.Comment{}).Select("id").Where("deleted_at <= ?", cutoff)
+		for _, value := range []any{&model.CommentRevision{}, &model.CommentReaction{}} {
+			if err := tx.Where("comment_id IN (?)", purged).Delete(value).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Model(&model.Comment{}).Where("deleted_at <= ? AND deleted_text <> ''", cutoff).
+			Updates(map[string]any{"deleted_text": "", "deleted_html": ""})
+		n += res.RowsAffected
+		return res.Error
+	})
+	return n, err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// saveVersioned updates all columns of value, a pointer to a row read with
+// *version, only while the stored version is still *version, and increments
+// it. It returns service.ErrVersionMismatch when another write came first.
+func saveVersioned(tx *gorm.DB, value any, version *uint) error {
+	read := *version
+	*version = read + 1
+	res := tx.Model(value).Where("version = ?", read).Omit(clause.Associations).Select("*").Updates(value)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = service.ErrVersionMismatch
+	}
+	if res.Error != nil {
+		*version = read
+	}
+	return res.Error
+}
+
+// deleteVersioned deletes the row of model with the given ID, only at the
+// given version when one is set. A stale version is reported as
+// service.ErrVersionMismatch, a missing row as gorm.ErrRecordNotFound.
+func deleteVersioned(tx *gorm.DB, model any, id string, version *uint) error {
+	if version == nil {
+		return tx.Delete(model, id).Error
+	}
+	res := tx.Where("version = ?", *version).Delete(model, id)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	var n int64
+	if err := tx.Model(model).Where("id = ?", id).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return service.ErrVersionMismatch
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/service"
+
+	"gorm.io/gorm"
+)
+
+type ViewRepository struct{ db *gorm.DB }
+
+func NewViewRepository(db *gorm.DB) service.ViewRepository { return ViewRepository{db: db} }
+
+func (r ViewRepository) Create(ctx context.Context, view *model.SavedView) error {
+	return r.db.WithContext(ctx).Create(view).Error
+}
+
+func (r ViewRepository) Get(ctx context.Context, id string) (model.SavedView, error) {
+	var view model.SavedView
+	err := r.db.WithContext(ctx).First(&view, id).Error
+	return view, err
+}
+
+// ListForUser returns the user's own views and the views shared with projects
+// the user can see.
+func (r ViewRepository) ListForUser(ctx context.Context, userID uint, params httpx.ListParams) ([]model.SavedView, int64, error) {
+	db := r.db.WithContext(ctx).Model(&model.SavedView{}).
+		Where("saved_views.owner_id = @user OR EXISTS (SELECT 1 FROM projects p WHERE p.id = saved_views.project_id AND p.deleted_at IS NULL AND "+visibleProject+")", map[string]any{"user": userID})
+	var total int64
+	if err := httpx.Count(db, params, &total); err != nil {
+		return nil, 0, err
+	}
+	allowedSort := map[string]string{"id": "saved_views.id", "name": "saved_views.name", "createdAt": "saved_views.created_at", "updatedAt": "saved_views.updated_at"}
+	var views []model.SavedView
+	err := httpx.FindPage(db, &views, allowedSort, params, "name")
+	return views, total, err
+}
+
+func (r ViewRepository) Save(ctx context.Context, view *model.SavedView) error {
+	return r.db.WithContext(ctx).Save(view).Error
+}
+
+func (r ViewRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&model.SavedView{}, id).Error
+}
+
+func (r ViewRepository) ProjectVisible(ctx context.Context, projectID, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("projects AS p").
+		Where("p.id = @project AND p.deleted_at IS NULL AND "+visibleProject, map[string]any{"project": projectID, "user": userID}).
+		Count(&count).Error
+	return count > 0, err
+}
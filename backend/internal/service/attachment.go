@@ -0,0 +1,315 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"project-management/internal/config"
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAttachmentTooLarge = errors.New("attachment exceeds the size limit")
+	ErrAttachmentType     = errors.New("attachment type is not allowed")
+	ErrNoThumbnail        = errors.New("attachment has no thumbnail")
+)
+
+const defaultAttachmentMaxBytes = 10 << 20
+
+var defaultAttachmentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp",
+	"application/pdf", "application/zip", "text/plain", "text/csv",
+}
+
+type AttachmentUploadInput struct {
+	TaskID     string
+	CommentID  string
+	UploadedBy uint
+	FileName   string
+	Content    io.Reader
+}
+
+type AttachmentService interface {
+	Upload(ctx context.Context, input AttachmentUploadInput) (model.Attachment, error)
+	ListForTask(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error)
+	ListForComment(ctx context.Context, commentID string, params httpx.ListParams) ([]model.Attachment, int64, error)
+	Get(ctx context.Context, id string) (model.Attachment, error)
+	Open(ctx context.Context, id string, thumbnail bool) (model.Attachment, io.ReadCloser, error)
+	Delete(ctx context.Context, id string) error
+	CleanupOrphans(ctx context.Context) error
+	MaxBytes() int64
+}
+
+type AttachmentRepository interface {
+	GetTask(ctx context.Context, id string) (model.Task, error)
+	GetComment(ctx context.Context, id string) (model.Comment, error)
+	FindBlob(ctx context.Context, checksum string) (model.Blob, error)
+	// Create stores the attachment and the blob row unless it exists, which
+	// it then locks. If it inserts the row it calls store inside the
+	// transaction, so that the row never exists without its file even when a
+	// blob found earlier was cleaned up in the meantime.
+	Create(ctx context.Context, blob *model.Blob, attachment *model.Attachment, store func(blob *model.Blob) error) error
+	ListForTask(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error)
+	ListForComment(ctx context.Context, commentID string, params httpx.ListParams) ([]model.Attachment, int64, error)
+	Get(ctx context.Context, id string) (model.Attachment, error)
+	Delete(ctx context.Context, id string) error
+	OrphanBlobs(ctx context.Context) ([]model.Blob, error)
+	// DeleteBlobIfOrphan locks the blob row and, if no attachment refers to
+	// it, deletes it and calls remove in the same transaction.
+	DeleteBlobIfOrphan(ctx context.Context, checksum string, remove func(blob model.Blob) error) (bool, error)
+}
+
+// BlobCleaner removes stored files no attachment refers to any more. Services
+// that delete tasks, projects or comments call it after the rows are gone.
+type BlobCleaner interface {
+	CleanupOrphans(ctx context.Context) error
+}
+
+func cleanupBlobs(ctx context.Context, blobs BlobCleaner) {
+	if blobs == nil {
+		return
+	}
+	if err := blobs.CleanupOrphans(ctx); err != nil {
+		log.Printf("warn: blob cleanup failed: %v", err)
+	}
+}
+
+type attachmentService struct {
+	repo     AttachmentRepository
+	store    storage.BlobStore
+	maxBytes int64
+	allowed  map[string]bool
+}
+
+// NewAttachmentService reads its limits from ATTACHMENT_MAX_BYTES and
+// ATTACHMENT_ALLOWED_TYPES (comma-separated MIME types).
+func NewAttachmentService(repo AttachmentRepository, store storage.BlobStore) AttachmentService {
+	types := defaultAttachmentTypes
+	if raw := strings.TrimSpace(os.Getenv("ATTACHMENT_ALLOWED_TYPES")); raw != "" {
+		types = strings.Split(raw, ",")
+	}
+	return NewAttachmentServiceWithLimits(repo, store, int64(config.GetEnvInt("ATTACHMENT_MAX_BYTES", defaultAttachmentMaxBytes)), types)
+}
+
+func NewAttachmentServiceWithLimits(repo AttachmentRepository, store storage.BlobStore, maxBytes int64, allowedTypes []string) AttachmentService {
+	allowed := make(map[string]bool, len(allowedTypes))
+	for _, t := range allowedTypes {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return &attachmentService{repo: repo, store: store, maxBytes: maxBytes, allowed: allowed}
+}
+
+func (s *attachmentService) MaxBytes() int64 { return s.maxBytes }
+
+func (s *attachmentService) Upload(ctx context.Context, input AttachmentUploadInput) (model.Attachment, error) {
+	attachment := model.Attachment{UploadedBy: input.UploadedBy, FileName: cleanFileName(input.FileName)}
+	if input.CommentID != "" {
+		comment, err := s.repo.GetComment(ctx, input.CommentID)
+		if err != nil {
+			return model.Attachment{}, err
+		}
+		if comment.DeletedAt != nil {
+			return model.Attachment{}, ErrCommentDeleted
+		}
+		attachment.TaskID = comment.TaskID
+		attachment.CommentID = &comment.ID
+	} else {
+		task, err := s.repo.GetTask(ctx, input.TaskID)
+		if err != nil {
+			return model.Attachment{}, err
+		}
+		attachment.TaskID = task.ID
+	}
+
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(input.Content, s.maxBytes+1))
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	if size > s.maxBytes {
+		return model.Attachment{}, ErrAttachmentTooLarge
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return model.Attachment{}, err
+	}
+	mimeType := detectMimeType(head[:n], attachment.FileName)
+	if !s.allowed[mimeType] {
+		return model.Attachment{}, fmt.Errorf("%w: %s", ErrAttachmentType, mimeType)
+	}
+
+	blob := model.Blob{Checksum: hex.EncodeToString(hash.Sum(nil)), Size: size, MimeType: mimeType}
+	stored := false
+	if existing, err := s.repo.FindBlob(ctx, blob.Checksum); err == nil {
+		blob = existing
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Attachment{}, err
+	} else if err := putBlob(ctx, s.store, &blob, tmp); err != nil {
+		return model.Attachment{}, err
+	} else {
+		stored = true
+	}
+	// The blob found above may be cleaned up before Create; then its file
+	// is stored again.
+	store := func(blob *model.Blob) error {
+		if stored {
+			return nil
+		}
+		return putBlob(ctx, s.store, blob, tmp)
+	}
+
+	attachment.Checksum = blob.Checksum
+	attachment.MimeType = blob.MimeType
+	attachment.Size = blob.Size
+	attachment.HasThumbnail = blob.HasThumbnail
+	return attachment, s.repo.Create(ctx, &blob, &attachment, store)
+}
+
+func (s *attachmentService) ListForTask(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	if _, err := s.repo.GetTask(ctx, taskID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListForTask(ctx, taskID, params)
+}
+
+func (s *attachmentService) ListForComment(ctx context.Context, commentID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	if _, err := s.repo.GetComment(ctx, commentID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListForComment(ctx, commentID, params)
+}
+
+func (s *attachmentService) Get(ctx context.Context, id string) (model.Attachment, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *attachmentService) Open(ctx context.Context, id string, thumbnail bool) (model.Attachment, io.ReadCloser, error) {
+	attachment, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+	key := blobKey(attachment.Checksum)
+	if thumbnail {
+		if !attachment.HasThumbnail {
+			return model.Attachment{}, nil, ErrNoThumbnail
+		}
+		key = thumbnailKey(attachment.Checksum)
+	}
+	body, err := s.store.Get(ctx, key)
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+	return attachment, body, nil
+}
+
+func (s *attachmentService) Delete(ctx context.Context, id string) error {
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	cleanupBlobs(ctx, s)
+	return nil
+}
+
+// CleanupOrphans deletes blob rows without attachments and their stored files.
+// A row is only dropped if it is still unreferenced once locked, and its files
+// are deleted before the lock is released, so a concurrent upload reusing the
+// blob either keeps it or stores it again.
+func (s *attachmentService) CleanupOrphans(ctx context.Context) error {
+	orphans, err := s.repo.OrphanBlobs(ctx)
+	if err != nil {
+		return err
+	}
+	remove := func(blob model.Blob) error { return s.removeBlob(ctx, blob) }
+	for _, orphan := range orphans {
+		if _, err := s.repo.DeleteBlobIfOrphan(ctx, orphan.Checksum, remove); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeBlob deletes the stored files of a blob.
+func (s *attachmentService) removeBlob(ctx context.Context, blob model.Blob) error {
+	if err := s.store.Delete(ctx, blobKey(blob.Checksum)); err != nil {
+		return err
+	}
+	if blob.HasThumbnail {
+		return s.store.Delete(ctx, thumbnailKey(blob.Checksum))
+	}
+	return nil
+}
+
+// putBlob stores the content of a new blob, and a thumbnail if it is an
+// image, setting blob.HasThumbnail.
+func putBlob(ctx context.Context, store storage.BlobStore, blob *model.Blob, content io.ReaderAt) error {
+	if err := store.Put(ctx, blobKey(blob.Checksum), io.NewSectionReader(content, 0, blob.Size), blob.Size, blob.MimeType); err != nil {
+		return err
+	}
+	if thumb, ok := makeThumbnail(io.NewSectionReader(content, 0, blob.Size), blob.MimeType); ok {
+		if err := store.Put(ctx, thumbnailKey(blob.Checksum), bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+			return err
+		}
+		blob.HasThumbnail = true
+	}
+	return nil
+}
+
+func blobKey(checksum string) string { return "blobs/" + checksum[:2] + "/" + checksum }
+
+func thumbnailKey(checksum string) string { return "thumbnails/" + checksum[:2] + "/" + checksum }
+
+// detectMimeType trusts the sniffed content type; the file extension is only
+// used to refine plain text into a more specific text type such as text/csv.
+func detectMimeType(head []byte, fileName string) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	if mediaType == "text/plain" {
+		if byExt, _, err := mime.ParseMediaType(mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName)))); err == nil && strings.HasPrefix(byExt, "text/") {
+			return byExt
+		}
+	}
+	return mediaType
+}
+
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+	"project-management/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+type memAttachmentRepo struct {
+	blobs       map[string]model.Blob
+	attachments map[uint]model.Attachment
+	comments    map[string]model.Comment
+	nextID      uint
+
+	// beforeCreate runs at the start of Create, between the upload's blob
+	// lookup and its write.
+	beforeCreate func()
+}
+
+func newMemAttachmentRepo() *memAttachmentRepo {
+	return &memAttachmentRepo{blobs: map[string]model.Blob{}, attachments: map[uint]model.Attachment{}, comments: map[string]model.Comment{}}
+}
+
+func (r *memAttachmentRepo) GetTask(ctx context.Context, id string) (model.Task, error) {
+	if id == "404" {
+		return model.Task{}, gorm.ErrRecordNotFound
+	}
+	return model.Task{ID: 1}, nil
+}
+func (r *memAttachmentRepo) GetComment(ctx context.Context, id string) (model.Comment, error) {
+	c, ok := r.comments[id]
+	if !ok {
+		return model.Comment{}, gorm.ErrRecordNotFound
+	}
+	return c, nil
+}
+func (r *memAttachmentRepo) FindBlob(ctx context.Context, checksum string) (model.Blob, error) {
+	b, ok := r.blobs[checksum]
+	if !ok {
+		return model.Blob{}, gorm.ErrRecordNotFound
+	}
+	return b, nil
+}
+func (r *memAttachmentRepo) Create(ctx context.Context, blob *model.Blob, attachment *model.Attachment, store func(blob *model.Blob) error) error {
+	if r.beforeCreate != nil {
+		r.beforeCreate()
+	}
+	if _, ok := r.blobs[blob.Checksum]; !ok {
+		if err := store(blob); err != nil {
+			return err
+		}
+	}
+	r.blobs[blob.Checksum] = *blob
+	r.nextID++
+	attachment.ID = r.nextID
+	r.attachments[attachment.ID] = *attachment
+	return nil
+}
+func (r *memAttachmentRepo) ListForTask(ctx context.Context, taskID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	return nil, 0, nil
+}
+func (r *memAttachmentRepo) ListForComment(ctx context.Context, commentID string, params httpx.ListParams) ([]model.Attachment, int64, error) {
+	return nil, 0, nil
+}
+func (r *memAttachmentRepo) Get(ctx context.Context, id string) (model.Attachment, error) {
+	for _, a := range r.attachments {
+		if strconv.FormatUint(uint64(a.ID), 10) == id {
+			return a, nil
+		}
+	}
+	return model.Attachment{}, gorm.ErrRecordNotFound
+}
+func (r *memAttachmentRepo) Delete(ctx context.Context, id string) error {
+	a, err := r.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	delete(r.attachments, a.ID)
+	return nil
+}
+func (r *memAttachmentRepo) OrphanBlobs(ctx context.Context) ([]model.Blob, error) {
+	var out []model.Blob
+	for sum, b := range r.blobs {
+		if !r.referenced(sum) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+func (r *memAttachmentRepo) DeleteBlobIfOrphan(ctx context.Context, checksum string, remove func(blob model.Blob) error) (bool, error) {
+	blob, ok := r.blobs[checksum]
+	if !ok || r.referenced(checksum) {
+		return false, nil
+	}
+	delete(r.blobs, checksum)
+	return true, remove(blob)
+}
+func (r *memAttachmentRepo) referenced(checksum string) bool {
+	for _, a := range r.attachments {
+		if a.Checksum == checksum {
+			return true
+		}
+	}
+	return false
+}
+
+// countingStore counts Put calls on top of a local store.
+type countingStore struct {
+	storage.BlobStore
+	puts int
+}
+
+func (s *countingStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	s.puts++
+	return s.BlobStore.Put(ctx, key, body, size, contentType)
+}
+
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 600, 300))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestAttachmentServiceUpload(t *testing.T) {
+	ctx := context.Background()
+	local, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	store := &countingStore{BlobStore: local}
+	repo := newMemAttachmentRepo()
+	repo.comments["7"] = model.Comment{ID: 7, TaskID: 1}
+	deletedAt := time.Now()
+	repo.comments["8"] = model.Comment{ID: 8, TaskID: 1, DeletedAt: &deletedAt}
+	svc := NewAttachmentServiceWithLimits(repo, store, 1<<20, []string{"image/png", "text/csv"})
+	img := pngBytes(t)
+
+	a, err := svc.Upload(ctx, AttachmentUploadInput{TaskID: "1", UploadedBy: 2, FileName: "../shot.png", Content: bytes.NewReader(img)})
+	if err != nil {
+		t.Fatalf("Upload error = %v", err)
+	}
+	if a.FileName != "shot.png" || a.MimeType != "image/png" || a.Size != int64(len(img)) || !a.HasThumbnail || len(a.Checksum) != 64 {
+		t.Fatalf("attachment = %+v", a)
+	}
+	if store.puts != 2 {
+		t.Fatalf("puts = %d, want blob and thumbnail", store.puts)
+	}
+
+	_, thumb, err := svc.Open(ctx, strconv.FormatUint(uint64(a.ID), 10), true)
+	if err != nil {
+		t.Fatalf("Open thumbnail error = %v", err)
+	}
+	cfg, format, err := image.DecodeConfig(thumb)
+	thumb.Close()
+	if err != nil || format != "jpeg" || cfg.Width != 256 || cfg.Height != 128 {
+		t.Fatalf("thumbnail = %+v %s %v", cfg, format, err)
+	}
+
+	dup, err := svc.Upload(ctx, AttachmentUploadInput{CommentID: "7", UploadedBy: 3, FileName: "copy.png", Content: bytes.NewReader(img)})
+	if err != nil {
+		t.Fatalf("duplicate Upload error = %v", err)
+	}
+	if store.puts != 2 || dup.Checksum != a.Checksum || dup.CommentID == nil || *dup.CommentID != 7 {
+		t.Fatalf("duplicate = %+v puts = %d", dup, store.puts)
+	}
+
+	csv, err := svc.Upload(ctx, AttachmentUploadInput{TaskID: "1", FileName: "data.csv", Content: strings.NewReader("a,b\n1,2\n")})
+	if err != nil || csv.MimeType != "text/csv" || csv.HasThumbnail {
+		t.Fatalf("csv = %+v err = %v", csv, err)
+	}
+
+	for name, input := range map[string]AttachmentUploadInput{
+		"type":      {TaskID: "1", FileName: "a.pdf", Content: strings.NewReader("%PDF-1.4\n")},
+		"too large": {TaskID: "1", FileName: "a.csv", Content: bytes.NewReader(bytes.Repeat([]byte("a"), 1<<20+1))},
+		"no task":   {TaskID: "404", FileName: "a.csv", Content: strings.NewReader("a")},
+		"deleted":   {CommentID: "8", FileName: "a.csv", Content: strings.NewReader("a")},
+	} {
+		_, err := svc.Upload(ctx, input)
+		want := map[string]error{"type": ErrAttachmentType, "too large": ErrAttachmentTooLarge, "no task": gorm.ErrRecordNotFound, "deleted": ErrCommentDeleted}[name]
+		if !errors.Is(err, want) {
+			t.Fatalf("%s: err = %v, want %v", name, err, want)
+		}
+	}
+}
+
+func TestAttachmentServiceCleanup(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	repo := newMemAttachmentRepo()
+	svc := NewAttachmentServiceWithLimits(repo, store, 1<<20, []string{"image/png"})
+	img := pngBytes(t)
+
+	first, _ := svc.Upload(ctx, AttachmentUploadInput{TaskID: "1", FileName: "a.png", Content: bytes.NewReader(img)})
+	second, _ := svc.Upload(ctx, AttachmentUploadInput{TaskID: "1", FileName: "b.png", Content: bytes.NewReader(img)})
+
+	if err := svc.Delete(ctx, strconv.FormatUint(uint64(first.ID), 10)); err != nil {
+		t.Fatalf("Delete error = %v", err)
+	}
+	if ok, _ := store.Exists(ctx, blobKey(first.Checksum)); !ok {
+		t.Fatal("blob still referenced by the second attachment was removed")
+	}
+
+	// Simulate a task delete cascading the remaining attachment row.
+	delete(repo.attachments, second.ID)
+	task := &taskService{repo: stubTaskRepo{deleteFn: func(ctx context.Context, id string, version *uint) error { return nil }}, blobs: svc}
+	if err := task.Delete(ctx, "1", nil); err != nil {
+		t.Fatalf("task Delete error = %v", err)
+	}
+	for _, key := range []string{blobKey(first.Checksum), thumbnailKey(first.Checksum)} {
+		if ok, _ := store.Exists(ctx, key); ok {
+			t.Fatalf("%s not cleaned up", key)
+		}
+	}
+	if len(repo.blobs) != 0 {
+		t.Fatalf("blobs = %v", repo.blobs)
+	}
+}
+
+func TestAttachmentServiceUploadRacesCleanup(t *testing.T) {
+	ctx := context.Background()
+	local, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	store := &countingStore{BlobStore: local}
+	repo := newMemAttachmentRepo()
+	svc := NewAttachmentServiceWithLimits(repo, store, 1<<20, []string{"image/png"})
+	img := pngBytes(t)
+
+	first, err := svc.Upload(ctx, AttachmentUploadInput{TaskID: "1", FileName: "a.png", Content: bytes.NewReader(img)})
+	if err != nil {
+		t.Fatalf("Upload error = %v", err)
+	}
+	// The second upload finds the blob, which is then deleted with the only
+	// attachment referring to it before the upload writes its own.
+	repo.beforeCreate = func() {
+		repo.beforeCreate = nil
+		if err := svc.Delete(ctx, strconv.FormatUint(uint64(first.ID), 10)); err != nil {
+			t.Fatalf("Delete error = %v", err)
+		}
+	}
+	second, err := svc.Upload(ctx, AttachmentUploadInput{TaskID: "1", FileName: "b.png", Content: bytes.NewReader(img)})
+	if err != nil {
+		t.Fatalf("second Upload error = %v", err)
+	}
+	if store.puts != 4 || !second.HasThumbnail {
+		t.Fatalf("puts = %d, attachment = %+v", store.puts, second)
+	}
+	for _, thumbnail := range []bool{false, true} {
+		_, body, err := svc.Open(ctx, strconv.FormatUint(uint64(second.ID), 10), thumbnail)
+		if err != nil {
+			t.Fatalf("Open(thumbnail=%v) error = %v", thumbnail, err)
+		}
+		body.Close()
+	}
+}
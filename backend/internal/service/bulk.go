@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"project-management/internal/model"
+)
+
+// MaxBulkTasks caps the number of tasks one bulk operation may touch.
+const MaxBulkTasks = 500
+
+var (
+	ErrBulkSelection = errors.New("select tasks with either ids or a filter with at least one condition")
+	ErrBulkTooMany   = fmt.Errorf("a bulk operation can change at most %d tasks; narrow the selection", MaxBulkTasks)
+	ErrBulkOp        = errors.New("op must be one of setStatus, setAssignee, setDueDate, setLabels, move, delete")
+	ErrBulkStatus    = errors.New("setStatus needs status todo, in_progress or done")
+	ErrBulkProject   = errors.New("move needs the projectId of an existing project")
+)
+
+// Bulk operations.
+const (
+	BulkSetStatus   = "setStatus"
+	BulkSetAssignee = "setAssignee"
+	BulkSetDueDate  = "setDueDate"
+	BulkSetLabels   = "setLabels"
+	BulkMove        = "move"
+	BulkDelete      = "delete"
+)
+
+// Per-task outcomes of a bulk operation. In a dry run they tell what would
+// happen.
+const (
+	BulkUpdated   = "updated"
+	BulkUnchanged = "unchanged"
+	BulkDeleted   = "deleted"
+	BulkNotFound  = "not_found"
+	BulkFailed    = "failed"
+)
+
+// TaskSelection picks tasks by ID or, when IDs is empty, by filter.
+type TaskSelection struct {
+	IDs    []uint
+	Filter *TaskListFilter
+}
+
+// TaskBulkInput is one operation on a selection of tasks. Only the field of
+// the operation is used: Status, AssigneeID (nil unassigns), DueDate (nil
+// clears), Labels (replacing the task's labels) or ProjectID.
+type TaskBulkInput struct {
+	Selection  TaskSelection
+	Op         string
+	Status     model.TaskStatus
+	AssigneeID *uint
+	DueDate    *time.Time
+	Labels     []string
+	ProjectID  uint
+	DryRun     bool
+}
+
+type TaskBulkChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// TaskBulkResult.NextOccurrenceID is the task created for the next date of a
+// recurring task the operation completed.
+type TaskBulkResult struct {
+	ID               uint                      `json:"id"`
+	Result           string                    `json:"result"`
+	Changes          map[string]TaskBulkChange `json:"changes,omitempty"`
+	NextOccurrenceID *uint                     `json:"nextOccurrenceId,omitempty"`
+	Error            string                    `json:"error,omitempty"`
+}
+
+// TaskBulkReport is the outcome of a bulk operation. Applied is false for a
+// dry run and when any task failed, in which case nothing was changed.
+type TaskBulkReport struct {
+	DryRun  bool             `json:"dryRun"`
+	Applied bool             `json:"applied"`
+	Matched int              `json:"matched"`
+	Changed int              `json:"changed"`
+	Results []TaskBulkResult `json:"results"`
+}
+
+// TaskBulkError is the failed write of one task that rolled back a bulk
+// operation.
+type TaskBulkError struct {
+	TaskID uint
+	Err    error
+}
+
+func (e *TaskBulkError) Error() string { return fmt.Sprintf("task %d: %v", e.TaskID, e.Err) }
+func (e *TaskBulkError) Unwrap() error { return e.Err }
+
+func (s *taskService) Bulk(ctx context.Context, input TaskBulkInput) (TaskBulkReport, error) {
+	if err := s.checkBulkOp(ctx, input); err != nil {
+		return TaskBulkReport{}, err
+	}
+	tasks, err := s.selectTasks(ctx, input.Selection)
+	if err != nil {
+		return TaskBulkReport{}, err
+	}
+
+	report := TaskBulkReport{DryRun: input.DryRun, Matched: len(tasks), Results: []TaskBulkResult{}}
+	byID := make(map[uint]model.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	order := input.Selection.IDs
+	if input.Selection.Filter != nil {
+		order = make([]uint, len(tasks))
+		for i, task := range tasks {
+			order[i] = task.ID
+		}
+	}
+
+	var save, remove []model.Task
+	next := map[uint]*model.Task{}
+	failed := false
+	seen := map[uint]bool{}
+	for _, id := range order {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		task, ok := byID[id]
+		if !ok {
+			report.Results = append(report.Results, TaskBulkResult{ID: id, Result: BulkNotFound, Error: "task not found"})
+			failed = true
+			continue
+		}
+		if input.Op == BulkDelete {
+			report.Results = append(report.Results, TaskBulkResult{ID: id, Result: BulkDeleted})
+			remove = append(remove, task)
+			continue
+		}
+		wasDone := task.Status == model.TaskDone
+		changes, err := applyBulkOp(&task, input)
+		if err != nil {
+			return TaskBulkReport{}, err
+		}
+		if len(changes) == 0 {
+			report.Results = append(report.Results, TaskBulkResult{ID: id, Result: BulkUnchanged})
+			continue
+		}
+		// Completing a recurring task continues its series as Update does.
+		if !wasDone && task.Status == model.TaskDone && task.Recurrence != "" {
+			if occurrence, ok := nextOccurrence(&task); ok {
+				next[task.ID] = &occurrence
+			}
+		}
+		report.Results = append(report.Results, TaskBulkResult{ID: id, Result: BulkUpdated, Changes: changes})
+		save = append(save, task)
+	}
+	report.Changed = len(save) + len(remove)
+	if input.DryRun || failed || report.Changed == 0 {
+		report.Applied = !input.DryRun && !failed
+		return report, nil
+	}
+
+	err = s.repo.ApplyBulk(ctx, save, next, remove)
+	var itemErr *TaskBulkError
+	if errors.As(err, &itemErr) {
+		for i := range report.Results {
+			if report.Results[i].ID == itemErr.TaskID {
+				report.Results[i].Result = BulkFailed
+				report.Results[i].Error = itemErr.Err.Error()
+			}
+		}
+		return report, nil
+	}
+	if err != nil {
+		return TaskBulkReport{}, err
+	}
+	report.Applied = true
+	for i := range report.Results {
+		if occurrence, ok := next[report.Results[i].ID]; ok {
+			report.Results[i].NextOccurrenceID = &occurrence.ID
+		}
+	}
+	if len(remove) > 0 {
+		cleanupBlobs(ctx, s.blobs)
+	}
+	return report, nil
+}
+
+func (s *taskService) selectTasks(ctx context.Context, sel TaskSelection) ([]model.Task, error) {
+	if (len(sel.IDs) > 0) == (sel.Filter != nil) {
+		return nil, ErrBulkSelection
+	}
+	if len(sel.IDs) > MaxBulkTasks {
+		return nil, ErrBulkTooMany
+	}
+	if f := sel.Filter; f != nil {
+		if f.ProjectID == "" && f.Status == "" && f.AssigneeID == "" && f.DueFrom == "" && f.DueTo == "" && f.Filter == "" {
+			return nil, ErrBulkSelection
+		}
+		if err := compileTaskFilter(f); err != nil {
+			return nil, err
+		}
+	}
+	tasks, err := s.repo.Select(ctx, sel, MaxBulkTasks+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) > MaxBulkTasks {
+		return nil, ErrBulkTooMany
+	}
+	return tasks, nil
+}
+
+func (s *taskService) checkBulkOp(ctx context.Context, input TaskBulkInput) error {
+	switch input.Op {
+	case BulkSetStatus:
+		if input.Status != model.TaskTodo && input.Status != model.TaskInProgress && input.Status != model.TaskDone {
+			return ErrBulkStatus
+		}
+	case BulkSetLabels:
+		if _, err := taskLabels(0, input.Labels); err != nil {
+			return err
+		}
+	case BulkMove:
+		if input.ProjectID == 0 {
+			return ErrBulkProject
+		}
+		exists, err := s.repo.ProjectExists(ctx, input.ProjectID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrBulkProject
+		}
+	case BulkSetAssignee, BulkSetDueDate, BulkDelete:
+	default:
+		return ErrBulkOp
+	}
+	return nil
+}
+
+// applyBulkOp changes task as input says and reports the changed fields.
+func applyBulkOp(task *model.Task, input TaskBulkInput) (map[string]TaskBulkChange, error) {
+	changes := map[string]TaskBulkChange{}
+	switch input.Op {
+	case BulkSetStatus:
+		if task.Status != input.Status {
+			changes["status"] = TaskBulkChange{From: task.Status, To: input.Status}
+			task.Status = input.Status
+		}
+	case BulkSetAssignee:
+		if !equalPtr(task.AssigneeID, input.AssigneeID) {
+			changes["assigneeId"] = TaskBulkChange{From: task.AssigneeID, To: input.AssigneeID}
+			task.AssigneeID = input.AssigneeID
+		}
+	case BulkSetDueDate:
+		if !equalTime(task.DueDate, input.DueDate) {
+			changes["dueDate"] = TaskBulkChange{From: task.DueDate, To: input.DueDate}
+			task.DueDate = input.DueDate
+		}
+	case BulkSetLabels:
+		labels, err := taskLabels(task.ProjectID, input.Labels)
+		if err != nil {
+			return nil, err
+		}
+		from, to := labelNames(task.Labels), labelNames(labels)
+		if !slices.Equal(sorted(from), sorted(to)) {
+			changes["labels"] = TaskBulkChange{From: from, To: to}
+			task.Labels = labels
+		}
+	case BulkMove:
+		if task.ProjectID != input.ProjectID {
+			changes["projectId"] = TaskBulkChange{From: task.ProjectID, To: input.ProjectID}
+			// Labels belong to a project, so the task takes labels of the
+			// same names in the new one.
+			labels, err := taskLabels(input.ProjectID, labelNames(task.Labels))
+			if err != nil {
+				return nil, err
+			}
+			task.ProjectID = input.ProjectID
+			task.Labels = labels
+			// Sprints and milestones belong to a project too; the task
+			// leaves them.
+			task.SprintID, task.MilestoneID = nil, nil
+		}
+	}
+	return changes, nil
+}
+
+func labelNames(labels []model.Label) []string {
+	names := make([]string, len(labels))
+	for i, label := range labels {
+		names[i] = label.Name
+	}
+	return names
+}
+
+func sorted(s []string) []string {
+	s = slices.Clone(s)
+	slices.Sort(s)
+	return s
+}
+
+func equalPtr(a, b *uint) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func equalTime(a, b *time.Time) bool {
+	return a == nil && b == nil || a != nil && b != nil && a.Equal(*b)
+}
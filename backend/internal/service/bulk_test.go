@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+)
+
+func TestTaskServiceBulk(t *testing.T) {
+	ctx := context.Background()
+	tasks := []model.Task{
+		{ID: 1, ProjectID: 2, Status: model.TaskTodo, Labels: []model.Label{{ProjectID: 2, Name: "bug"}}, Version: 4},
+		{ID: 2, ProjectID: 2, Status: model.TaskDone},
+	}
+	selectAll := func(ctx context.Context, sel TaskSelection, limit int) ([]model.Task, error) {
+		if limit != MaxBulkTasks+1 {
+			t.Fatalf("limit = %d", limit)
+		}
+		return tasks, nil
+	}
+
+	t.Run("applies changed tasks only", func(t *testing.T) {
+		var saved []model.Task
+		svc := &taskService{repo: stubTaskRepo{
+			selectFn: selectAll,
+			applyBulkFn: func(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error {
+				saved = save
+				return nil
+			},
+		}}
+		report, err := svc.Bulk(ctx, TaskBulkInput{Selection: TaskSelection{IDs: []uint{1, 2}}, Op: BulkSetStatus, Status: model.TaskDone})
+		if err != nil {
+			t.Fatalf("Bulk error = %v", err)
+		}
+		if !report.Applied || report.Matched != 2 || report.Changed != 1 || len(saved) != 1 || saved[0].Status != model.TaskDone || saved[0].Version != 4 {
+			t.Fatalf("report = %+v saved = %+v", report, saved)
+		}
+		if r := report.Results; r[0].Result != BulkUpdated || r[0].Changes["status"].To != model.TaskDone || r[1].Result != BulkUnchanged {
+			t.Fatalf("results = %+v", r)
+		}
+	})
+
+	t.Run("dry run and missing ids write nothing", func(t *testing.T) {
+		svc := &taskService{repo: stubTaskRepo{
+			selectFn:        selectAll,
+			projectExistsFn: func(ctx context.Context, projectID uint) (bool, error) { return projectID == 5, nil },
+			applyBulkFn: func(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error {
+				t.Fatal("ApplyBulk called")
+				return nil
+			},
+		}}
+		report, err := svc.Bulk(ctx, TaskBulkInput{Selection: TaskSelection{IDs: []uint{1}}, Op: BulkMove, ProjectID: 5, DryRun: true})
+		if err != nil || report.Applied || report.Results[0].Changes["projectId"].To != uint(5) {
+			t.Fatalf("dry run report = %+v err = %v", report, err)
+		}
+		report, err = svc.Bulk(ctx, TaskBulkInput{Selection: TaskSelection{IDs: []uint{9, 1}}, Op: BulkDelete})
+		if err != nil || report.Applied || report.Results[0].Result != BulkNotFound || report.Results[1].Result != BulkDeleted {
+			t.Fatalf("missing report = %+v err = %v", report, err)
+		}
+		if _, err := svc.Bulk(ctx, TaskBulkInput{Selection: TaskSelection{IDs: []uint{1}}, Op: BulkMove, ProjectID: 6}); !errors.Is(err, ErrBulkProject) {
+			t.Fatalf("err = %v, want ErrBulkProject", err)
+		}
+	})
+
+	t.Run("move leaves the sprint and milestone", func(t *testing.T) {
+		sprint, milestone := uint(3), uint(4)
+		planned := []model.Task{{ID: 1, ProjectID: 2, SprintID: &sprint, MilestoneID: &milestone}}
+		var saved []model.Task
+		svc := &taskService{repo: stubTaskRepo{
+			selectFn:        func(ctx context.Context, sel TaskSelection, limit int) ([]model.Task, error) { return planned, nil },
+			projectExistsFn: func(ctx context.Context, projectID uint) (bool, error) { return true, nil },
+			applyBulkFn: func(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error {
+				saved = save
+				return nil
+			},
+		}}
+		if _, err := svc.Bulk(ctx, TaskBulkInput{Selection: TaskSelection{IDs: []uint{1}}, Op: BulkMove, ProjectID: 5}); err != nil {
+			t.Fatalf("Bulk error = %v", err)
+		}
+		if len(saved) != 1 || saved[0].SprintID != nil || saved[0].MilestoneID != nil {
+			t.Fatalf("saved = %+v", saved)
+		}
+	})
+
+	t.Run("completing a recurring task continues its series", func(t *testing.T) {
+		due := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+		recurring := []model.Task{
+			{ID: 1, ProjectID: 2, Title: "Standup", Status: model.TaskTodo, DueDate: &due, Recurrence: "FREQ=DAILY", Occurrence: 1},
+			{ID: 2, ProjectID: 2, Title: "Last", Status: model.TaskInProgress, DueDate: &due, Recurrence: "FREQ=DAILY;COUNT=1", Occurrence: 1},
+		}
+		var saved []model.Task
+		svc := &taskService{repo: stubTaskRepo{
+			selectFn: func(ctx context.Context, sel TaskSelection, limit int) ([]model.Task, error) { return recurring, nil },
+			applyBulkFn: func(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error {
+				saved = save
+				if len(next) != 1 || next[1] == nil {
+					t.Fatalf("next = %+v", next)
+				}
+				next[1].ID = 9
+				return nil
+			},
+		}}
+		report, err := svc.Bulk(ctx, TaskBulkInput{Selection: TaskSelection{IDs: []uint{1, 2}}, Op: BulkSetStatus, Status: model.TaskDone})
+		if err != nil || !report.Applied {
+			t.Fatalf("report = %+v err = %v", report, err)
+		}
+		// Both completed tasks leave the series; only the first has a next date.
+		if len(saved) != 2 || saved[0].Recurrence != "" || saved[1].Recurrence != "" || *saved[0].SeriesID != 1 {
+			t.Fatalf("saved = %+v", saved)
+		}
+		if r := report.Results; r[0].NextOccurrenceID == nil || *r[0].NextOccurrenceID != 9 || r[1].NextOccurrenceID != nil {
+			t.Fatalf("results = %+v", r)
+		}
+	})
+
+	t.Run("a failed write rolls back and is reported", func(t *testing.T) {
+		svc := &taskService{repo: stubTaskRepo{
+			selectFn: selectAll,
+			applyBulkFn: func(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error {
+				return &TaskBulkError{TaskID: 2, Err: ErrVersionMismatch}
+			},
+		}}
+		report, err := svc.Bulk(ctx, TaskBulkInput{Selection: TaskSelection{Filter: &TaskListFilter{ProjectID: "2"}}, Op: BulkSetLabels, Labels: []string{"ui"}})
+		if err != nil || report.Applied || report.Results[1].Result != BulkFailed || report.Results[1].Error != ErrVersionMismatch.Error() {
+			t.Fatalf("report = %+v err = %v", report, err)
+		}
+	})
+
+	t.Run("rejects bad selections", func(t *testing.T) {
+		svc := &taskService{repo: stubTaskRepo{selectFn: func(ctx context.Context, sel TaskSelection, limit int) ([]model.Task, error) {
+			return make([]model.Task, limit), nil
+		}}}
+		for _, tt := range []struct {
+			input TaskBulkInput
+			want  error
+		}{
+			{TaskBulkInput{Op: BulkDelete}, ErrBulkSelection},
+			{TaskBulkInput{Selection: TaskSelection{IDs: []uint{1}, Filter: &TaskListFilter{Status: "done"}}, Op: BulkDelete}, ErrBulkSelection},
+			{TaskBulkInput{Selection: TaskSelection{Filter: &TaskListFilter{}}, Op: BulkDelete}, ErrBulkSelection},
+			{TaskBulkInput{Selection: TaskSelection{Filter: &TaskListFilter{Status: "done"}}, Op: BulkDelete}, ErrBulkTooMany},
+			{TaskBulkInput{Selection: TaskSelection{IDs: []uint{1}}, Op: "archive"}, ErrBulkOp},
+		} {
+			if _, err := svc.Bulk(ctx, tt.input); !errors.Is(err, tt.want) {
+				t.Fatalf("%+v: err = %v, want %v", tt.input, err, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,667 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"project-management/internal/markdown"
+	"project-management/internal/model"
+	"project-management/internal/storage"
+)
+
+// BundleFormat and BundleVersion identify the project bundles this server
+// writes. Import reads bundles up to BundleVersion.
+const (
+	BundleFormat  = "project-management/project"
+	BundleVersion = 1
+)
+
+// In a zip bundle the bundle is bundleFile and each attachment content is
+// stored once under bundleAttachments plus its checksum.
+const (
+	bundleFile        = "project.json"
+	bundleAttachments = "attachments/"
+)
+
+var (
+	ErrBundleFormat = errors.New("not a project bundle of a supported version")
+	// ErrBundleBlobGone is returned when an attachment content that was
+	// stored here and not in the bundle was cleaned up during the import.
+	ErrBundleBlobGone = errors.New("an attachment's content was deleted during the import; import again")
+)
+
+// BundleFiles are the attachment contents of a zip bundle by checksum.
+type BundleFiles map[string]*zip.File
+
+type BundleImportInput struct {
+	Bundle model.ProjectBundle
+	Files  BundleFiles // nil for a JSON bundle
+	// OwnerID owns the project when the bundle's owner has no local match,
+	// and is credited with uploads of unmatched users.
+	OwnerID *uint
+	Title   string // empty keeps the bundle's title
+	DryRun  bool
+}
+
+type BundleService interface {
+	// Export builds the bundle of the project. Deleted tasks and comments
+	// are left out.
+	Export(ctx context.Context, id string) (model.ProjectBundle, error)
+	// WriteZip writes the bundle with the contents of its attachments.
+	WriteZip(ctx context.Context, w io.Writer, bundle model.ProjectBundle) error
+	// Import creates a new project from the bundle in one transaction, or
+	// with DryRun only reports what it would create.
+	Import(ctx context.Context, input BundleImportInput) (model.BundleImportReport, error)
+}
+
+type BundleRepository interface {
+	// Comments returns the comments of the tasks in ID order.
+	Comments(ctx context.Context, taskIDs []uint) ([]model.Comment, error)
+	Reactions(ctx context.Context, commentIDs []uint) ([]model.CommentReaction, error)
+	Attachments(ctx context.Context, taskIDs []uint) ([]model.Attachment, error)
+	Users(ctx context.Context, ids []uint) ([]model.User, error)
+	UsersByEmail(ctx context.Context, emails []string) ([]model.User, error)
+	Blobs(ctx context.Context, checksums []string) ([]model.Blob, error)
+	TitleTaken(ctx context.Context, title string) (bool, error)
+	// Import inserts the records in one transaction and sets their IDs.
+	Import(ctx context.Context, records *BundleRecords) error
+}
+
+// BundleRecords are the rows an import creates. The project's Tasks carry
+// their labels by name. Records refer to each other by index: Task into
+// Project.Tasks, Parent and Comment into Comments, where parents come before
+// their replies; -1 refers to nothing.
+type BundleRecords struct {
+	Project     model.Project
+	Labels      []model.Label
+	Comments    []BundleCommentRecord
+	Attachments []BundleAttachmentRecord
+}
+
+type BundleCommentRecord struct {
+	Comment   model.Comment
+	Task      int
+	Parent    int
+	Reactions []model.CommentReaction
+}
+
+// BundleAttachmentRecord.Stored says whether the import stored the blob's
+// file or found it stored already.
+type BundleAttachmentRecord struct {
+	Attachment model.Attachment
+	Blob       model.Blob
+	Stored     bool
+	Task       int
+	Comment    int
+}
+
+type bundleService struct {
+	repo     BundleRepository
+	projects ProjectRepository
+	store    storage.BlobStore
+	now      func() time.Time
+}
+
+func NewBundleService(repo BundleRepository, projects ProjectRepository, store storage.BlobStore) BundleService {
+	return &bundleService{repo: repo, projects: projects, store: store, now: time.Now}
+}
+
+// ReadBundle reads a bundle in either form, telling a zip bundle by its
+// signature.
+func ReadBundle(r io.ReaderAt, size int64) (model.ProjectBundle, BundleFiles, error) {
+	var bundle model.ProjectBundle
+	head := make([]byte, 4)
+	if n, _ := r.ReadAt(head, 0); n < 4 || !bytes.Equal(head, []byte("PK\x03\x04")) {
+		if err := json.NewDecoder(io.NewSectionReader(r, 0, size)).Decode(&bundle); err != nil {
+			return bundle, nil, fmt.Errorf("%w: %v", ErrBundleFormat, err)
+		}
+		return bundle, nil, nil
+	}
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return bundle, nil, fmt.Errorf("%w: %v", ErrBundleFormat, err)
+	}
+	var manifest *zip.File
+	files := BundleFiles{}
+	for _, f := range archive.File {
+		if f.Name == bundleFile {
+			manifest = f
+		} else if checksum, ok := strings.CutPrefix(f.Name, bundleAttachments); ok {
+			files[checksum] = f
+		}
+	}
+	if manifest == nil {
+		return bundle, nil, fmt.Errorf("%w: the zip has no %s", ErrBundleFormat, bundleFile)
+	}
+	body, err := manifest.Open()
+	if err != nil {
+		return bundle, nil, fmt.Errorf("%w: %v", ErrBundleFormat, err)
+	}
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(&bundle); err != nil {
+		return bundle, nil, fmt.Errorf("%w: %v", ErrBundleFormat, err)
+	}
+	return bundle, files, nil
+}
+
+func (s *bundleService) Export(ctx context.Context, id string) (model.ProjectBundle, error) {
+	project, labels, err := s.projects.GetTree(ctx, id)
+	if err != nil {
+		return model.ProjectBundle{}, err
+	}
+	bundle := model.ProjectBundle{
+		Format:     BundleFormat,
+		Version:    BundleVersion,
+		ExportedAt: s.now().UTC(),
+		Project: model.BundleProject{
+			ID:          project.ID,
+			Title:       project.Title,
+			Description: project.Description,
+			Status:      project.Status,
+			OwnerID:     project.OwnerID,
+			CreatedAt:   project.CreatedAt,
+		},
+		Users:       []model.BundleUser{},
+		Labels:      labelNames(labels),
+		Tasks:       []model.BundleTask{},
+		Comments:    []model.BundleComment{},
+		Attachments: []model.BundleAttachment{},
+	}
+	userIDs := map[uint]bool{}
+	refer := func(id *uint) {
+		if id != nil {
+			userIDs[*id] = true
+		}
+	}
+	refer(project.OwnerID)
+	taskIDs := make([]uint, len(project.Tasks))
+	for i, task := range project.Tasks {
+		taskIDs[i] = task.ID
+		refer(task.AssigneeID)
+		bundle.Tasks = append(bundle.Tasks, model.BundleTask{
+			ID:          task.ID,
+			Title:       task.Title,
+			Description: task.Description,
+			Status:      task.Status,
+			AssigneeID:  task.AssigneeID,
+			DueDate:     task.DueDate,
+			Labels:      labelNames(task.Labels),
+			CreatedAt:   task.CreatedAt,
+		})
+	}
+
+	comments, err := s.repo.Comments(ctx, taskIDs)
+	if err != nil {
+		return model.ProjectBundle{}, err
+	}
+	// Deleted comments are left out; their replies move up to the nearest
+	// ancestor that is kept.
+	byID := make(map[uint]model.Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+	keptParent := func(comment model.Comment) *uint {
+		for id := comment.ParentID; id != nil; {
+			parent, ok := byID[*id]
+			if !ok {
+				return nil
+			}
+			if parent.DeletedAt == nil {
+				return &parent.ID
+			}
+			id = parent.ParentID
+		}
+		return nil
+	}
+	var commentIDs []uint
+	for _, comment := range comments {
+		if comment.DeletedAt == nil {
+			commentIDs = append(commentIDs, comment.ID)
+		}
+	}
+	reactions, err := s.repo.Reactions(ctx, commentIDs)
+	if err != nil {
+		return model.ProjectBundle{}, err
+	}
+	reactionsOf := map[uint][]model.BundleReaction{}
+	for _, reaction := range reactions {
+		userIDs[reaction.UserID] = true
+		reactionsOf[reaction.CommentID] = append(reactionsOf[reaction.CommentID], model.BundleReaction{UserID: reaction.UserID, Emoji: reaction.Emoji})
+	}
+	for _, comment := range comments {
+		if comment.DeletedAt != nil {
+			continue
+		}
+		bundle.Comments = append(bundle.Comments, model.BundleComment{
+			ID:        comment.ID,
+			TaskID:    comment.TaskID,
+			ParentID:  keptParent(comment),
+			Author:    comment.Author,
+			Text:      comment.Text,
+			EditedAt:  comment.EditedAt,
+			CreatedAt: comment.CreatedAt,
+			Reactions: reactionsOf[comment.ID],
+		})
+	}
+
+	attachments, err := s.repo.Attachments(ctx, taskIDs)
+	if err != nil {
+		return model.ProjectBundle{}, err
+	}
+	for _, attachment := range attachments {
+		if attachment.CommentID != nil && byID[*attachment.CommentID].DeletedAt != nil {
+			continue
+		}
+		userIDs[attachment.UploadedBy] = true
+		bundle.Attachments = append(bundle.Attachments, model.BundleAttachment{
+			ID:         attachment.ID,
+			TaskID:     attachment.TaskID,
+			CommentID:  attachment.CommentID,
+			FileName:   attachment.FileName,
+			MimeType:   attachment.MimeType,
+			Size:       attachment.Size,
+			Checksum:   attachment.Checksum,
+			UploadedBy: attachment.UploadedBy,
+			CreatedAt:  attachment.CreatedAt,
+		})
+	}
+
+	ids := make([]uint, 0, len(userIDs))
+	for id := range userIDs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	users, err := s.repo.Users(ctx, ids)
+	if err != nil {
+		return model.ProjectBundle{}, err
+	}
+	for _, user := range users {
+		bundle.Users = append(bundle.Users, model.BundleUser{ID: user.ID, Email: user.Email, Name: user.Name})
+	}
+	return bundle, nil
+}
+
+func (s *bundleService) WriteZip(ctx context.Context, w io.Writer, bundle model.ProjectBundle) error {
+	zw := zip.NewWriter(w)
+	manifest, err := zw.Create(bundleFile)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(manifest)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(bundle); err != nil {
+		return err
+	}
+	written := map[string]bool{}
+	for _, attachment := range bundle.Attachments {
+		if written[attachment.Checksum] {
+			continue
+		}
+		written[attachment.Checksum] = true
+		if err := s.copyBlob(ctx, zw, attachment.Checksum); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (s *bundleService) copyBlob(ctx context.Context, zw *zip.Writer, checksum string) error {
+	body, err := s.store.Get(ctx, blobKey(checksum))
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	f, err := zw.Create(bundleAttachments + checksum)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, body)
+	return err
+}
+
+func (s *bundleService) Import(ctx context.Context, input BundleImportInput) (model.BundleImportReport, error) {
+	bundle := input.Bundle
+	if bundle.Format != BundleFormat || bundle.Version < 1 || bundle.Version > BundleVersion {
+		return model.BundleImportReport{}, ErrBundleFormat
+	}
+	title := strings.TrimSpace(input.Title)
+	if title == "" {
+		title = strings.TrimSpace(bundle.Project.Title)
+	}
+	if title == "" {
+		return model.BundleImportReport{}, fmt.Errorf("%w: the project has no title", ErrBundleFormat)
+	}
+	report := model.BundleImportReport{DryRun: input.DryRun, Users: []model.BundleUserMatch{}, Conflicts: []model.BundleConflict{}}
+	conflict := func(kind, ref, format string, args ...any) {
+		report.Conflicts = append(report.Conflicts, model.BundleConflict{Type: kind, Ref: ref, Message: fmt.Sprintf(format, args...)})
+	}
+
+	users, err := s.matchUsers(ctx, bundle.Users, &report)
+	if err != nil {
+		return model.BundleImportReport{}, err
+	}
+	user := func(id *uint) *uint {
+		if id == nil {
+			return nil
+		}
+		return users[*id]
+	}
+	for _, match := range report.Users {
+		if match.UserID == nil {
+			conflict("user", match.Email, "no user has this email; their assignments and reactions are dropped and their uploads credited to the importing user")
+		}
+	}
+	if taken, err := s.repo.TitleTaken(ctx, title); err != nil {
+		return model.BundleImportReport{}, err
+	} else if taken {
+		conflict("title", "project", "a project named %q exists already; the import creates another one", title)
+	}
+
+	records := BundleRecords{}
+	html, err := markdown.Render(bundle.Project.Description)
+	if err != nil {
+		return model.BundleImportReport{}, err
+	}
+	project := model.Project{Title: title, Description: bundle.Project.Description, DescriptionHTML: html, Status: model.ProjectActive, OwnerID: user(bundle.Project.OwnerID), CreatedAt: bundle.Project.CreatedAt}
+	if project.OwnerID == nil {
+		project.OwnerID = input.OwnerID
+	}
+	if bundle.Project.Status == model.ProjectArchived {
+		archive(&project, input.OwnerID)
+	}
+
+	labels := map[string]bool{}
+	addLabels := func(names []string) []model.Label {
+		var out []model.Label
+		seen := map[string]bool{}
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			out = append(out, model.Label{Name: name})
+			if !labels[name] {
+				labels[name] = true
+				records.Labels = append(records.Labels, model.Label{Name: name})
+			}
+		}
+		return out
+	}
+	addLabels(bundle.Labels)
+
+	tasks := map[uint]int{}
+	for _, t := range bundle.Tasks {
+		ref := fmt.Sprintf("task %d", t.ID)
+		if _, dup := tasks[t.ID]; dup {
+			conflict("invalid", ref, "the ID is used by another task; left out")
+			continue
+		}
+		if strings.TrimSpace(t.Title) == "" {
+			conflict("invalid", ref, "the task has no title; left out")
+			continue
+		}
+		status := t.Status
+		if status != model.TaskTodo && status != model.TaskInProgress && status != model.TaskDone {
+			conflict("invalid", ref, "unknown status %q; imported as todo", status)
+			status = model.TaskTodo
+		}
+		html, err := markdown.Render(t.Description)
+		if err != nil {
+			return model.BundleImportReport{}, err
+		}
+		tasks[t.ID] = len(project.Tasks)
+		project.Tasks = append(project.Tasks, model.Task{
+			Title:           t.Title,
+			Description:     t.Description,
+			DescriptionHTML: html,
+			Status:          status,
+			AssigneeID:      user(t.AssigneeID),
+			DueDate:         t.DueDate,
+			CreatedAt:       t.CreatedAt,
+			Labels:          addLabels(t.Labels),
+		})
+	}
+
+	comments := map[uint]int{}
+	for _, c := range bundle.Comments {
+		ref := fmt.Sprintf("comment %d", c.ID)
+		task, ok := tasks[c.TaskID]
+		if !ok {
+			conflict("invalid", ref, "task %d is not in the bundle; left out", c.TaskID)
+			continue
+		}
+		if _, dup := comments[c.ID]; dup {
+			conflict("invalid", ref, "the ID is used by another comment; left out")
+			continue
+		}
+		parent := -1
+		if c.ParentID != nil {
+			if p, ok := comments[*c.ParentID]; ok && records.Comments[p].Task == task {
+				parent = p
+			} else {
+				conflict("invalid", ref, "comment %d does not precede it on the same task; imported as a top-level comment", *c.ParentID)
+			}
+		}
+		html, err := markdown.Render(c.Text)
+		if err != nil {
+			return model.BundleImportReport{}, err
+		}
+		record := BundleCommentRecord{
+			Comment: model.Comment{Author: c.Author, Text: c.Text, HTML: html, Edited: c.EditedAt != nil, EditedAt: c.EditedAt, CreatedAt: c.CreatedAt},
+			Task:    task,
+			Parent:  parent,
+		}
+		reacted := map[model.BundleReaction]bool{}
+		for _, r := range c.Reactions {
+			userID := user(&r.UserID)
+			key := model.BundleReaction{Emoji: r.Emoji}
+			if userID != nil {
+				key.UserID = *userID
+			}
+			if userID == nil || r.Emoji == "" || reacted[key] {
+				continue
+			}
+			reacted[key] = true
+			record.Reactions = append(record.Reactions, model.CommentReaction{UserID: *userID, Emoji: r.Emoji})
+		}
+		comments[c.ID] = len(records.Comments)
+		records.Comments = append(records.Comments, record)
+		report.Created.Reactions += len(record.Reactions)
+	}
+
+	if err := s.importAttachments(ctx, input, &records, tasks, comments, user, project.OwnerID, conflict); err != nil {
+		return model.BundleImportReport{}, err
+	}
+
+	report.Created.Labels = len(records.Labels)
+	report.Created.Tasks = len(project.Tasks)
+	report.Created.Comments = len(records.Comments)
+	report.Created.Attachments = len(records.Attachments)
+	records.Project = project
+	if !input.DryRun {
+		if err := s.repo.Import(ctx, &records); err != nil {
+			return model.BundleImportReport{}, err
+		}
+		report.Applied = true
+		report.TaskIDs = make(map[uint]uint, len(tasks))
+		for id, i := range tasks {
+			report.TaskIDs[id] = records.Project.Tasks[i].ID
+		}
+		report.CommentIDs = make(map[uint]uint, len(comments))
+		for id, i := range comments {
+			report.CommentIDs[id] = records.Comments[i].Comment.ID
+		}
+	}
+	created := records.Project
+	created.Tasks = nil
+	report.Project = &created
+	return report, nil
+}
+
+// matchUsers maps the users of the bundle to local users with the same email,
+// case-insensitively, and lists the matches in the report.
+func (s *bundleService) matchUsers(ctx context.Context, bundleUsers []model.BundleUser, report *model.BundleImportReport) (map[uint]*uint, error) {
+	emails := make([]string, len(bundleUsers))
+	for i, u := range bundleUsers {
+		emails[i] = strings.ToLower(strings.TrimSpace(u.Email))
+	}
+	local, err := s.repo.UsersByEmail(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	byEmail := make(map[string]uint, len(local))
+	for _, u := range local {
+		byEmail[strings.ToLower(u.Email)] = u.ID
+	}
+	users := make(map[uint]*uint, len(bundleUsers))
+	for i, u := range bundleUsers {
+		match := model.BundleUserMatch{ID: u.ID, Email: u.Email}
+		if id, ok := byEmail[emails[i]]; ok {
+			match.UserID = &id
+			users[u.ID] = &id
+		}
+		report.Users = append(report.Users, match)
+	}
+	return users, nil
+}
+
+// importAttachments adds the bundle's attachments to records. Content this
+// server has already is linked; new content comes from the zip and is
+// stored before the rows are written, so a failed import can leave unused
+// files behind but never rows without files.
+func (s *bundleService) importAttachments(ctx context.Context, input BundleImportInput, records *BundleRecords, tasks, comments map[uint]int, user func(*uint) *uint, owner *uint, conflict func(kind, ref, format string, args ...any)) error {
+	var checksums []string
+	for _, a := range input.Bundle.Attachments {
+		checksums = append(checksums, a.Checksum)
+	}
+	existing, err := s.repo.Blobs(ctx, checksums)
+	if err != nil {
+		return err
+	}
+	blobs := make(map[string]model.Blob, len(existing))
+	for _, blob := range existing {
+		blobs[blob.Checksum] = blob
+	}
+	stored := make(map[string]bool)
+	uploader := input.OwnerID
+	if uploader == nil {
+		uploader = owner
+	}
+
+	for _, a := range input.Bundle.Attachments {
+		ref := fmt.Sprintf("attachment %d", a.ID)
+		task, ok := tasks[a.TaskID]
+		if !ok {
+			conflict("invalid", ref, "task %d is not in the bundle; left out", a.TaskID)
+			continue
+		}
+		comment := -1
+		if a.CommentID != nil {
+			if comment, ok = comments[*a.CommentID]; !ok || records.Comments[comment].Task != task {
+				conflict("invalid", ref, "comment %d is not in the bundle on task %d; left out", *a.CommentID, a.TaskID)
+				continue
+			}
+		}
+		if !validChecksum(a.Checksum) {
+			conflict("invalid", ref, "the checksum is not a SHA-256 hash; left out")
+			continue
+		}
+		uploadedBy := user(&a.UploadedBy)
+		if uploadedBy == nil {
+			uploadedBy = uploader
+		}
+		if uploadedBy == nil {
+			conflict("attachment", ref, "the uploader has no local match and there is no importing user to credit; left out")
+			continue
+		}
+		blob, ok := blobs[a.Checksum]
+		if !ok {
+			f, inZip := input.Files[a.Checksum]
+			if !inZip {
+				conflict("attachment", ref, "the bundle has no content for %q and it is not stored here; left out", a.FileName)
+				continue
+			}
+			if blob, err = s.storeFile(ctx, f, a, input.DryRun); err != nil {
+				if errors.Is(err, errChecksum) {
+					conflict("attachment", ref, "the content of %q does not match its checksum; left out", a.FileName)
+					continue
+				}
+				return err
+			}
+			blobs[a.Checksum] = blob
+			stored[a.Checksum] = true
+		}
+		records.Attachments = append(records.Attachments, BundleAttachmentRecord{
+			Attachment: model.Attachment{
+				Checksum:     blob.Checksum,
+				FileName:     cleanFileName(a.FileName),
+				MimeType:     blob.MimeType,
+				Size:         blob.Size,
+				HasThumbnail: blob.HasThumbnail,
+				UploadedBy:   *uploadedBy,
+				CreatedAt:    a.CreatedAt,
+			},
+			Blob:    blob,
+			Stored:  stored[a.Checksum],
+			Task:    task,
+			Comment: comment,
+		})
+	}
+	return nil
+}
+
+var errChecksum = errors.New("checksum mismatch")
+
+// storeFile checks an attachment content of a zip bundle against its
+// checksum and, unless dryRun, stores it as a new blob.
+func (s *bundleService) storeFile(ctx context.Context, f *zip.File, a model.BundleAttachment, dryRun bool) (model.Blob, error) {
+	body, err := f.Open()
+	if err != nil {
+		return model.Blob{}, err
+	}
+	defer body.Close()
+	tmp, err := os.CreateTemp("", "bundle-*")
+	if err != nil {
+		return model.Blob{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, a.Size+1))
+	if err != nil && !errors.Is(err, zip.ErrChecksum) {
+		return model.Blob{}, err
+	}
+	if err != nil || size != a.Size || hex.EncodeToString(hash.Sum(nil)) != a.Checksum {
+		return model.Blob{}, errChecksum
+	}
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return model.Blob{}, err
+	}
+	blob := model.Blob{Checksum: a.Checksum, Size: size, MimeType: detectMimeType(head[:n], a.FileName)}
+	if dryRun {
+		return blob, nil
+	}
+	return blob, putBlob(ctx, s.store, &blob, tmp)
+}
+
+func validChecksum(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.ToLower(s) == s
+}
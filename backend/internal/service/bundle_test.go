@@ -0,0 +1,279 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+	"project-management/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+type stubBundleRepo struct {
+	comments    []model.Comment
+	reactions   []model.CommentReaction
+	attachments []model.Attachment
+	users       []model.User
+	blobs       []model.Blob
+	taken       bool
+	imported    *BundleRecords
+}
+
+func (r *stubBundleRepo) Comments(ctx context.Context, taskIDs []uint) ([]model.Comment, error) {
+	return r.comments, nil
+}
+func (r *stubBundleRepo) Reactions(ctx context.Context, commentIDs []uint) ([]model.CommentReaction, error) {
+	var out []model.CommentReaction
+	for _, reaction := range r.reactions {
+		for _, id := range commentIDs {
+			if reaction.CommentID == id {
+				out = append(out, reaction)
+			}
+		}
+	}
+	return out, nil
+}
+func (r *stubBundleRepo) Attachments(ctx context.Context, taskIDs []uint) ([]model.Attachment, error) {
+	return r.attachments, nil
+}
+func (r *stubBundleRepo) Users(ctx context.Context, ids []uint) ([]model.User, error) {
+	var out []model.User
+	for _, id := range ids {
+		for _, user := range r.users {
+			if user.ID == id {
+				out = append(out, user)
+			}
+		}
+	}
+	return out, nil
+}
+func (r *stubBundleRepo) UsersByEmail(ctx context.Context, emails []string) ([]model.User, error) {
+	var out []model.User
+	for _, email := range emails {
+		for _, user := range r.users {
+			if strings.ToLower(user.Email) == email {
+				out = append(out, user)
+			}
+		}
+	}
+	return out, nil
+}
+func (r *stubBundleRepo) Blobs(ctx context.Context, checksums []string) ([]model.Blob, error) {
+	return r.blobs, nil
+}
+func (r *stubBundleRepo) TitleTaken(ctx context.Context, title string) (bool, error) {
+	return r.taken, nil
+}
+func (r *stubBundleRepo) Import(ctx context.Context, records *BundleRecords) error {
+	records.Project.ID = 50
+	for i := range records.Project.Tasks {
+		records.Project.Tasks[i].ID = uint(100 + i)
+	}
+	for i := range records.Comments {
+		records.Comments[i].Comment.ID = uint(200 + i)
+	}
+	r.imported = records
+	return nil
+}
+
+func checksumOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// exportFixture is a project with a deleted comment between a comment and
+// its reply, and an attachment on the deleted comment.
+func exportFixture(t *testing.T) (*bundleService, []byte) {
+	t.Helper()
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	content := []byte("meeting notes")
+	checksum := checksumOf(content)
+	if err := store.Put(context.Background(), blobKey(checksum), bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	owner, assignee := uint(1), uint(2)
+	due := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	deleted := time.Now()
+	root, middle := uint(10), uint(11)
+	projects := stubProjectRepo{getTreeFn: func(ctx context.Context, id string) (model.Project, []model.Label, error) {
+		if id != "3" {
+			return model.Project{}, nil, gorm.ErrRecordNotFound
+		}
+		return model.Project{ID: 3, Title: "Launch", Description: "Go live", Status: model.ProjectActive, OwnerID: &owner, Tasks: []model.Task{
+			{ID: 7, Title: "Deploy", Status: model.TaskInProgress, AssigneeID: &assignee, DueDate: &due, Labels: []model.Label{{Name: "ops"}}},
+			{ID: 8, Title: "Announce", Status: model.TaskTodo},
+		}}, []model.Label{{Name: "marketing"}, {Name: "ops"}}, nil
+	}}
+	repo := &stubBundleRepo{
+		comments: []model.Comment{
+			{ID: root, TaskID: 7, Author: "ann", Text: "Ready?"},
+			{ID: middle, TaskID: 7, ParentID: &root, Author: "bob", DeletedAt: &deleted},
+			{ID: 12, TaskID: 7, ParentID: &middle, Author: "cy", Text: "Yes"},
+		},
+		reactions: []model.CommentReaction{{CommentID: root, UserID: 2, Emoji: "👍"}, {CommentID: middle, UserID: 9, Emoji: "👀"}},
+		attachments: []model.Attachment{
+			{ID: 30, TaskID: 7, FileName: "notes.txt", MimeType: "text/plain", Size: int64(len(content)), Checksum: checksum, UploadedBy: 1},
+			{ID: 31, TaskID: 7, CommentID: &middle, FileName: "gone.txt", Checksum: checksum, UploadedBy: 9},
+		},
+		users: []model.User{{ID: 1, Email: "Owner@example.com", Name: "Owner"}, {ID: 2, Email: "dev@example.com", Name: "Dev"}, {ID: 9, Email: "gone@example.com"}},
+	}
+	svc := &bundleService{repo: repo, projects: projects, store: store, now: func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }}
+	return svc, content
+}
+
+func TestBundleServiceExport(t *testing.T) {
+	svc, _ := exportFixture(t)
+	bundle, err := svc.Export(context.Background(), "3")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if bundle.Format != BundleFormat || bundle.Version != BundleVersion || bundle.Project.Title != "Launch" || strings.Join(bundle.Labels, ",") != "marketing,ops" || len(bundle.Tasks) != 2 {
+		t.Fatalf("bundle = %+v", bundle)
+	}
+	if len(bundle.Comments) != 2 || bundle.Comments[1].ID != 12 || *bundle.Comments[1].ParentID != 10 || len(bundle.Comments[0].Reactions) != 1 {
+		t.Fatalf("comments = %+v", bundle.Comments)
+	}
+	if len(bundle.Attachments) != 1 || bundle.Attachments[0].ID != 30 {
+		t.Fatalf("attachments = %+v", bundle.Attachments)
+	}
+	if len(bundle.Users) != 2 || bundle.Users[0].Email != "Owner@example.com" || bundle.Users[1].ID != 2 {
+		t.Fatalf("users = %+v", bundle.Users)
+	}
+	if _, err := svc.Export(context.Background(), "4"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestBundleServiceZipRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source, content := exportFixture(t)
+	bundle, err := source.Export(ctx, "3")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := source.WriteZip(ctx, &buf, bundle); err != nil {
+		t.Fatalf("WriteZip: %v", err)
+	}
+	read, files, err := ReadBundle(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil || len(files) != 1 || len(read.Tasks) != 2 {
+		t.Fatalf("ReadBundle = %+v, %v, %v", read, files, err)
+	}
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	// Only the owner exists in the target, with the email in another case.
+	repo := &stubBundleRepo{users: []model.User{{ID: 41, Email: "owner@example.com"}}, taken: true}
+	svc := &bundleService{repo: repo, store: store, now: time.Now}
+	importer := uint(99)
+
+	report, err := svc.Import(ctx, BundleImportInput{Bundle: read, Files: files, OwnerID: &importer, DryRun: true})
+	if err != nil || report.Applied || repo.imported != nil || report.Created.Tasks != 2 || report.Created.Attachments != 1 {
+		t.Fatalf("dry run = %+v, %v", report, err)
+	}
+	if ok, _ := store.Exists(ctx, blobKey(checksumOf(content))); ok {
+		t.Fatal("dry run stored the attachment")
+	}
+
+	report, err = svc.Import(ctx, BundleImportInput{Bundle: read, Files: files, OwnerID: &importer})
+	if err != nil || !report.Applied {
+		t.Fatalf("Import = %+v, %v", report, err)
+	}
+	records := repo.imported
+	if *records.Project.OwnerID != 41 || records.Project.Title != "Launch" || report.Project.ID != 50 || len(records.Labels) != 2 {
+		t.Fatalf("project = %+v, labels = %+v", records.Project, records.Labels)
+	}
+	if deploy := records.Project.Tasks[0]; deploy.AssigneeID != nil || len(deploy.Labels) != 1 || deploy.DescriptionHTML != "" {
+		t.Fatalf("deploy = %+v", deploy)
+	}
+	if len(records.Comments) != 2 || records.Comments[1].Parent != 0 || records.Comments[1].Task != 0 || len(records.Comments[0].Reactions) != 0 {
+		t.Fatalf("comments = %+v", records.Comments)
+	}
+	attachment := records.Attachments[0]
+	if attachment.Attachment.UploadedBy != 41 || attachment.Blob.MimeType != "text/plain" {
+		t.Fatalf("attachment = %+v", attachment)
+	}
+	if ok, _ := store.Exists(ctx, blobKey(checksumOf(content))); !ok {
+		t.Fatal("attachment content not stored")
+	}
+	if report.TaskIDs[7] != 100 || report.TaskIDs[8] != 101 || report.CommentIDs[12] != 201 {
+		t.Fatalf("ids = %v, %v", report.TaskIDs, report.CommentIDs)
+	}
+	kinds := map[string]int{}
+	for _, c := range report.Conflicts {
+		kinds[c.Type]++
+	}
+	if kinds["user"] != 1 || kinds["title"] != 1 || len(report.Conflicts) != 2 {
+		t.Fatalf("conflicts = %+v", report.Conflicts)
+	}
+	if report.Users[0].UserID == nil || *report.Users[0].UserID != 41 || report.Users[1].UserID != nil {
+		t.Fatalf("users = %+v", report.Users)
+	}
+}
+
+func TestBundleServiceImportProblems(t *testing.T) {
+	ctx := context.Background()
+	svc := &bundleService{repo: &stubBundleRepo{}, now: time.Now}
+	for _, bundle := range []model.ProjectBundle{
+		{Format: "other", Version: 1, Project: model.BundleProject{Title: "A"}},
+		{Format: BundleFormat, Version: BundleVersion + 1, Project: model.BundleProject{Title: "A"}},
+		{Format: BundleFormat, Version: BundleVersion},
+	} {
+		if _, err := svc.Import(ctx, BundleImportInput{Bundle: bundle}); !errors.Is(err, ErrBundleFormat) {
+			t.Fatalf("Import(%+v) err = %v", bundle, err)
+		}
+	}
+
+	parent := uint(5)
+	stray := uint(6)
+	bundle := model.ProjectBundle{
+		Format:  BundleFormat,
+		Version: BundleVersion,
+		Project: model.BundleProject{Title: "A", Status: model.ProjectArchived},
+		Tasks: []model.BundleTask{
+			{ID: 1, Title: "One", Status: "blocked", Labels: []string{"x", "x", " "}},
+			{ID: 1, Title: "Dup", Status: model.TaskTodo},
+			{ID: 2, Status: model.TaskTodo},
+		},
+		Comments: []model.BundleComment{
+			{ID: 4, TaskID: 3, Text: "orphan"},
+			{ID: 6, TaskID: 1, ParentID: &parent, Text: "reply"},
+		},
+		Attachments: []model.BundleAttachment{
+			{ID: 7, TaskID: 1, Checksum: "nothex"},
+			{ID: 8, TaskID: 1, Checksum: checksumOf([]byte("x")), UploadedBy: 1},
+			{ID: 9, TaskID: 1, CommentID: &stray, Checksum: checksumOf([]byte("y"))},
+		},
+	}
+	importer := uint(3)
+	report, err := svc.Import(ctx, BundleImportInput{Bundle: bundle, OwnerID: &importer, DryRun: true})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if report.Created.Tasks != 1 || report.Created.Comments != 1 || report.Created.Attachments != 0 || report.Created.Labels != 1 {
+		t.Fatalf("created = %+v", report.Created)
+	}
+	if report.Project.Status != model.ProjectArchived || *report.Project.ArchivedBy != 3 || *report.Project.OwnerID != 3 {
+		t.Fatalf("project = %+v", report.Project)
+	}
+	refs := make([]string, len(report.Conflicts))
+	for i, c := range report.Conflicts {
+		refs[i] = c.Type + " " + c.Ref
+	}
+	want := "invalid task 1,invalid task 1,invalid task 2,invalid comment 4,invalid comment 6,invalid attachment 7,attachment attachment 8,attachment attachment 9"
+	if got := strings.Join(refs, ","); got != want {
+		t.Fatalf("conflicts = %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"project-management/internal/model"
+)
+
+// CalendarService manages the secret feed tokens of users and reads the
+// tasks of a feed. A token is shown once when it is created; only its hash
+// is stored, so a lost token is replaced by rotating it.
+type CalendarService interface {
+	// Rotate creates a new feed token for the user, replacing any old one.
+	Rotate(ctx context.Context, userID uint) (string, error)
+	Revoke(ctx context.Context, userID uint) error
+	// Feed returns the tasks with a due date the token's user is assigned
+	// to or watches, in projectID only if it is set. An unknown token is
+	// gorm.ErrRecordNotFound.
+	Feed(ctx context.Context, token string, projectID *uint) ([]model.Task, error)
+}
+
+type CalendarRepository interface {
+	SetToken(ctx context.Context, userID uint, hash string) error
+	DeleteToken(ctx context.Context, userID uint) error
+	TokenUser(ctx context.Context, hash string) (uint, error)
+	DueTasks(ctx context.Context, userID uint, projectID *uint) ([]model.Task, error)
+}
+
+type calendarService struct{ repo CalendarRepository }
+
+func NewCalendarService(repo CalendarRepository) CalendarService {
+	return &calendarService{repo: repo}
+}
+
+func (s *calendarService) Rotate(ctx context.Context, userID uint) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	if err := s.repo.SetToken(ctx, userID, calendarTokenHash(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *calendarService) Revoke(ctx context.Context, userID uint) error {
+	return s.repo.DeleteToken(ctx, userID)
+}
+
+func (s *calendarService) Feed(ctx context.Context, token string, projectID *uint) ([]model.Task, error) {
+	userID, err := s.repo.TokenUser(ctx, calendarTokenHash(token))
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.DueTasks(ctx, userID, projectID)
+}
+
+func calendarTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type stubCalendarRepo struct {
+	tokens map[string]uint // token hash → user ID
+	feed   *uint
+}
+
+func (s *stubCalendarRepo) SetToken(ctx context.Context, userID uint, hash string) error {
+	s.DeleteToken(ctx, userID)
+	s.tokens[hash] = userID
+	return nil
+}
+func (s *stubCalendarRepo) DeleteToken(ctx context.Context, userID uint) error {
+	for hash, id := range s.tokens {
+		if id == userID {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
+func (s *stubCalendarRepo) TokenUser(ctx context.Context, hash string) (uint, error) {
+	userID, ok := s.tokens[hash]
+	if !ok {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return userID, nil
+}
+func (s *stubCalendarRepo) DueTasks(ctx context.Context, userID uint, projectID *uint) ([]model.Task, error) {
+	s.feed = &userID
+	return []model.Task{{ID: 1}}, nil
+}
+
+func TestCalendarServiceTokens(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubCalendarRepo{tokens: map[string]uint{}}
+	s := NewCalendarService(repo)
+
+	first, err := s.Rotate(ctx, 7)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if len(first) != 64 {
+		t.Fatalf("token = %q", first)
+	}
+	if _, stored := repo.tokens[first]; stored {
+		t.Fatal("the token itself was stored")
+	}
+	if _, err := s.Feed(ctx, first, nil); err != nil || repo.feed == nil || *repo.feed != 7 {
+		t.Fatalf("Feed = %v, user %v", err, repo.feed)
+	}
+
+	second, err := s.Rotate(ctx, 7)
+	if err != nil || second == first {
+		t.Fatalf("Rotate = %q, %v", second, err)
+	}
+	if _, err := s.Feed(ctx, first, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Feed with a rotated token = %v", err)
+	}
+	if err := s.Revoke(ctx, 7); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := s.Feed(ctx, second, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Feed with a revoked token = %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"project-management/internal/model"
+)
+
+// ErrSubtasksUnsupported rejects copying subtasks: tasks cannot have any
+// yet, so there is nothing a copy could take.
+var ErrSubtasksUnsupported = errors.New("tasks have no subtasks yet, so includeSubtasks cannot be used")
+
+// CopyOptions say what a clone or a template takes from a project.
+type CopyOptions struct {
+	Tasks         bool // the project's tasks
+	Subtasks      bool // the tasks' subtasks; rejected with ErrSubtasksUnsupported
+	Labels        bool // the project's labels, and the tasks' labels
+	Descriptions  bool // the descriptions of the project and its tasks
+	ResetStatuses bool // start every task as todo
+}
+
+type ProjectCloneInput struct {
+	Title   string // empty names the clone "<title> (copy)"
+	OwnerID *uint
+	// StartDate, if set, moves the due dates so that they keep their
+	// distance from the day the source project was created.
+	StartDate *time.Time
+	Options   CopyOptions
+}
+
+func (s *projectService) Clone(ctx context.Context, id string, input ProjectCloneInput) (model.Project, error) {
+	if input.Options.Subtasks {
+		return model.Project{}, ErrSubtasksUnsupported
+	}
+	source, labels, err := s.repo.GetTree(ctx, id)
+	if err != nil {
+		return model.Project{}, err
+	}
+	clone := model.Project{Title: input.Title, Status: model.ProjectActive, OwnerID: input.OwnerID}
+	if clone.Title == "" {
+		clone.Title = source.Title + " (copy)"
+	}
+	opts := input.Options
+	if opts.Descriptions {
+		clone.Description, clone.DescriptionHTML = source.Description, source.DescriptionHTML
+	}
+	var shift time.Duration
+	if input.StartDate != nil {
+		shift = startOfDay(*input.StartDate).Sub(startOfDay(source.CreatedAt))
+	}
+	if opts.Tasks {
+		clone.Tasks = make([]model.Task, 0, len(source.Tasks))
+		for _, task := range source.Tasks {
+			copied := model.Task{Title: task.Title, Status: task.Status, AssigneeID: task.AssigneeID}
+			if opts.Descriptions {
+				copied.Description, copied.DescriptionHTML = task.Description, task.DescriptionHTML
+			}
+			if opts.ResetStatuses {
+				copied.Status = model.TaskTodo
+			}
+			if task.DueDate != nil {
+				due := task.DueDate.Add(shift)
+				copied.DueDate = &due
+			}
+			if opts.Labels {
+				copied.Labels = copyLabels(task.Labels)
+			}
+			clone.Tasks = append(clone.Tasks, copied)
+		}
+	}
+	var cloneLabels []model.Label
+	if opts.Labels {
+		cloneLabels = copyLabels(labels)
+	}
+	return clone, s.repo.CreateTree(ctx, &clone, cloneLabels)
+}
+
+// copyLabels returns new labels with the names of labels and no project.
+func copyLabels(labels []model.Label) []model.Label {
+	copied := make([]model.Label, len(labels))
+	for i, label := range labels {
+		copied[i] = model.Label{Name: label.Name}
+	}
+	return copied
+}
+
+// startOfDay returns midnight UTC of the day t falls on in UTC.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func TestProjectServiceClone(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2026, 1, 5, 15, 30, 0, 0, time.UTC)
+	due := time.Date(2026, 1, 12, 17, 0, 0, 0, time.UTC)
+	assignee := uint(4)
+	source := model.Project{
+		ID: 1, Title: "Onboarding", Description: "Steps", DescriptionHTML: "<p>Steps</p>", Status: model.ProjectArchived, CreatedAt: created,
+		Tasks: []model.Task{
+			{ID: 7, ProjectID: 1, Title: "Kickoff", Description: "Call", DescriptionHTML: "<p>Call</p>", Status: model.TaskDone, AssigneeID: &assignee, DueDate: &due, Labels: []model.Label{{ID: 3, ProjectID: 1, Name: "client"}}},
+			{ID: 8, ProjectID: 1, Title: "Invoice", Status: model.TaskInProgress},
+		},
+	}
+	labels := []model.Label{{ID: 3, ProjectID: 1, Name: "client"}, {ID: 4, ProjectID: 1, Name: "unused"}}
+
+	var gotProject model.Project
+	var gotLabels []model.Label
+	svc := &projectService{repo: stubProjectRepo{
+		getTreeFn: func(ctx context.Context, id string) (model.Project, []model.Label, error) {
+			if id != "1" {
+				return model.Project{}, nil, gorm.ErrRecordNotFound
+			}
+			return source, labels, nil
+		},
+		createTreeFn: func(ctx context.Context, project *model.Project, labels []model.Label) error {
+			gotProject, gotLabels = *project, labels
+			return nil
+		},
+	}}
+
+	t.Run("copies everything", func(t *testing.T) {
+		owner := uint(9)
+		start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+		all := CopyOptions{Tasks: true, Labels: true, Descriptions: true}
+		if _, err := svc.Clone(ctx, "1", ProjectCloneInput{OwnerID: &owner, StartDate: &start, Options: all}); err != nil {
+			t.Fatalf("Clone: %v", err)
+		}
+		p := gotProject
+		if p.ID != 0 || p.Title != "Onboarding (copy)" || p.Status != model.ProjectActive || *p.OwnerID != 9 || p.DescriptionHTML != "<p>Steps</p>" {
+			t.Fatalf("project = %+v", p)
+		}
+		if len(gotLabels) != 2 || gotLabels[1].Name != "unused" || gotLabels[0].ID != 0 {
+			t.Fatalf("labels = %+v", gotLabels)
+		}
+		if len(p.Tasks) != 2 {
+			t.Fatalf("tasks = %+v", p.Tasks)
+		}
+		kickoff := p.Tasks[0]
+		wantDue := time.Date(2026, 3, 9, 17, 0, 0, 0, time.UTC)
+		if kickoff.ID != 0 || kickoff.Status != model.TaskDone || kickoff.Description != "Call" || *kickoff.AssigneeID != 4 || !kickoff.DueDate.Equal(wantDue) {
+			t.Fatalf("kickoff = %+v", kickoff)
+		}
+		if len(kickoff.Labels) != 1 || kickoff.Labels[0].Name != "client" || kickoff.Labels[0].ID != 0 {
+			t.Fatalf("kickoff labels = %+v", kickoff.Labels)
+		}
+	})
+
+	t.Run("leaves out what the options exclude", func(t *testing.T) {
+		if _, err := svc.Clone(ctx, "1", ProjectCloneInput{Title: "ACME", Options: CopyOptions{Tasks: true, ResetStatuses: true}}); err != nil {
+			t.Fatalf("Clone: %v", err)
+		}
+		p := gotProject
+		if p.Title != "ACME" || p.Description != "" || len(gotLabels) != 0 {
+			t.Fatalf("project = %+v, labels = %+v", p, gotLabels)
+		}
+		for _, task := range p.Tasks {
+			if task.Status != model.TaskTodo || task.Description != "" || len(task.Labels) != 0 {
+				t.Fatalf("task = %+v", task)
+			}
+		}
+		if !p.Tasks[0].DueDate.Equal(due) {
+			t.Fatalf("due = %v, want unchanged %v", p.Tasks[0].DueDate, due)
+		}
+
+		if _, err := svc.Clone(ctx, "1", ProjectCloneInput{Options: CopyOptions{Labels: true}}); err != nil || len(gotProject.Tasks) != 0 || len(gotLabels) != 2 {
+			t.Fatalf("labels only: tasks = %+v, labels = %+v, err = %v", gotProject.Tasks, gotLabels, err)
+		}
+	})
+
+	t.Run("subtasks", func(t *testing.T) {
+		if _, err := svc.Clone(ctx, "1", ProjectCloneInput{Options: CopyOptions{Tasks: true, Subtasks: true}}); !errors.Is(err, ErrSubtasksUnsupported) {
+			t.Fatalf("err = %v, want ErrSubtasksUnsupported", err)
+		}
+	})
+
+	t.Run("missing project", func(t *testing.T) {
+		if _, err := svc.Clone(ctx, "2", ProjectCloneInput{}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
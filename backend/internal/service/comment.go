@@ -2,9 +2,29 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
 	"project-management/internal/httpx"
+	"project-management/internal/markdown"
 	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidParent  = errors.New("parent must be a top-level comment on the same task")
+	ErrInvalidEmoji   = errors.New("invalid emoji")
+	ErrCommentDeleted = errors.New("comment deleted")
+)
+
+// Tombstone text returned in place of a deleted comment.
+const (
+	DeletedCommentText = "comment deleted"
+	DeletedCommentHTML = "<p><em>comment deleted</em></p>\n"
 )
 
 type CommentListFilter struct {
@@ -14,14 +34,16 @@ type CommentListFilter struct {
 }
 
 type CommentCreateInput struct {
-	TaskID uint
-	Author string
-	Text   string
+	TaskID   uint
+	ParentID *uint
+	Author   string
+	Text     string
 }
 
 type CommentUpdateInput struct {
-	Author *string
-	Text   *string
+	Author  *string
+	Text    *string
+	Version *uint // version the caller read (If-Match); nil skips the check
 }
 
 type CommentService interface {
@@ -29,25 +51,67 @@ type CommentService interface {
 	Create(ctx context.Context, input CommentCreateInput) (model.Comment, error)
 	Get(ctx context.Context, id string) (model.Comment, error)
 	Update(ctx context.Context, id string, input CommentUpdateInput) (model.Comment, error)
-	Delete(ctx context.Context, id string) error
+	// Delete tombstones the comment; a non-nil version must match the
+	// current one.
+	Delete(ctx context.Context, id string, version *uint) error
+	ListReplies(ctx context.Context, id string, params httpx.ListParams) ([]model.Comment, int64, error)
+	AddReaction(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error)
+	RemoveReaction(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error)
+	ListRevisions(ctx context.Context, id string, params httpx.ListParams) ([]model.CommentRevision, int64, error)
+	Purge(ctx context.Context, id string) error
 }
 
 type CommentRepository interface {
 	List(ctx context.Context, filter CommentListFilter) ([]model.Comment, int64, error)
 	Create(ctx context.Context, comment *model.Comment) error
 	Get(ctx context.Context, id string) (model.Comment, error)
+	// Save, SaveWithRevision and Tombstone write the comment if its version
+	// is unchanged in the database and increment it, or return
+	// ErrVersionMismatch.
 	Save(ctx context.Context, comment *model.Comment) error
 	Delete(ctx context.Context, id string) error
+	ListReplies(ctx context.Context, parentID string, params httpx.ListParams) ([]model.Comment, int64, error)
+	AddReaction(ctx context.Context, reaction *model.CommentReaction) error
+	RemoveReaction(ctx context.Context, commentID, userID uint, emoji string) error
+	SaveWithRevision(ctx context.Context, comment *model.Comment, revision *model.CommentRevision) error
+	Tombstone(ctx context.Context, comment *model.Comment) error
+	ListRevisions(ctx context.Context, commentID string, params httpx.ListParams) ([]model.CommentRevision, int64, error)
+	// LinkableTasks returns those of ids that are tasks of the task's
+	// project and not in the trash.
+	LinkableTasks(ctx context.Context, taskID uint, ids []uint) ([]uint, error)
 }
 
-type commentService struct{ repo CommentRepository }
+type commentService struct {
+	repo  CommentRepository
+	blobs BlobCleaner
+}
 
 func NewCommentService(repo CommentRepository) CommentService { return &commentService{repo: repo} }
+
+func NewCommentServiceWithDeps(repo CommentRepository, blobs BlobCleaner) CommentService {
+	return &commentService{repo: repo, blobs: blobs}
+}
 func (s *commentService) List(ctx context.Context, filter CommentListFilter) ([]model.Comment, int64, error) {
 	return s.repo.List(ctx, filter)
 }
 func (s *commentService) Create(ctx context.Context, input CommentCreateInput) (model.Comment, error) {
-	comment := model.Comment{TaskID: input.TaskID, Author: input.Author, Text: input.Text}
+	if input.ParentID != nil {
+		parent, err := s.repo.Get(ctx, strconv.FormatUint(uint64(*input.ParentID), 10))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Comment{}, ErrInvalidParent
+		}
+		if err != nil {
+			return model.Comment{}, err
+		}
+		if parent.ParentID != nil || parent.TaskID != input.TaskID || parent.DeletedAt != nil {
+			return model.Comment{}, ErrInvalidParent
+		}
+	}
+	html, err := markdown.RenderWithRefs(input.Text, s.taskRefs(ctx, input.TaskID))
+	if err != nil {
+		return model.Comment{}, err
+	}
+	comment := model.Comment{TaskID: input.TaskID, ParentID: input.ParentID, Author: input.Author, Text: input.Text, HTML: html}
 	return comment, s.repo.Create(ctx, &comment)
 }
 func (s *commentService) Get(ctx context.Context, id string) (model.Comment, error) {
@@ -58,12 +122,146 @@ func (s *commentService) Update(ctx context.Context, id string, input CommentUpd
 	if err != nil {
 		return model.Comment{}, err
 	}
+	if comment.DeletedAt != nil {
+		return model.Comment{}, ErrCommentDeleted
+	}
+	if err := checkVersion(input.Version, comment.Version); err != nil {
+		return model.Comment{}, err
+	}
 	if input.Author != nil {
 		comment.Author = *input.Author
 	}
-	if input.Text != nil {
-		comment.Text = *input.Text
+	if input.Text == nil || *input.Text == comment.Text {
+		return comment, s.repo.Save(ctx, &comment)
+	}
+	html, err := markdown.RenderWithRefs(*input.Text, s.taskRefs(ctx, comment.TaskID))
+	if err != nil {
+		return model.Comment{}, err
+	}
+	revision := model.CommentRevision{CommentID: comment.ID, Text: comment.Text, HTML: comment.HTML, WrittenAt: comment.CreatedAt}
+	if comment.EditedAt != nil {
+		revision.WrittenAt = *comment.EditedAt
+	}
+	now := time.Now().UTC()
+	comment.Text = *input.Text
+	comment.HTML = html
+	comment.Edited = true
+	comment.EditedAt = &now
+	return comment, s.repo.SaveWithRevision(ctx, &comment, &revision)
+}
+
+// Delete replaces the comment with a tombstone so replies keep their parent.
+// The text stays in the trash until it is restored or purged.
+func (s *commentService) Delete(ctx context.Context, id string, version *uint) error {
+	comment, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(version, comment.Version); err != nil {
+		return err
+	}
+	if comment.DeletedAt != nil {
+		return nil
+	}
+	now := time.Now().UTC()
+	comment.DeletedText, comment.DeletedHTML = comment.Text, comment.HTML
+	comment.Text = DeletedCommentText
+	comment.HTML = DeletedCommentHTML
+	comment.DeletedAt = &now
+	return s.repo.Tombstone(ctx, &comment)
+}
+func (s *commentService) Purge(ctx context.Context, id string) error {
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	cleanupBlobs(ctx, s.blobs)
+	return nil
+}
+
+// ListRevisions lists no revisions of a deleted comment; they are kept only
+// for restoring it.
+func (s *commentService) ListRevisions(ctx context.Context, id string, params httpx.ListParams) ([]model.CommentRevision, int64, error) {
+	comment, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if comment.DeletedAt != nil {
+		return []model.CommentRevision{}, 0, nil
+	}
+	return s.repo.ListRevisions(ctx, id, params)
+}
+func (s *commentService) ListReplies(ctx context.Context, id string, params httpx.ListParams) ([]model.Comment, int64, error) {
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListReplies(ctx, id, params)
+}
+func (s *commentService) AddReaction(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error) {
+	if !validEmoji(emoji) {
+		return model.Comment{}, ErrInvalidEmoji
+	}
+	comment, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Comment{}, err
+	}
+	if comment.DeletedAt != nil {
+		return model.Comment{}, ErrCommentDeleted
+	}
+	if err := s.repo.AddReaction(ctx, &model.CommentReaction{CommentID: comment.ID, UserID: userID, Emoji: emoji}); err != nil {
+		return model.Comment{}, err
+	}
+	return s.repo.Get(ctx, id)
+}
+func (s *commentService) RemoveReaction(ctx context.Context, id string, userID uint, emoji string) (model.Comment, error) {
+	comment, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Comment{}, err
+	}
+	if comment.DeletedAt != nil {
+		return model.Comment{}, ErrCommentDeleted
+	}
+	if err := s.repo.RemoveReaction(ctx, comment.ID, userID, emoji); err != nil {
+		return model.Comment{}, err
+	}
+	return s.repo.Get(ctx, id)
+}
+
+// validEmoji accepts a single emoji or a short :shortcode: and rejects anything
+// that could not be rendered as a reaction chip.
+// taskRefs links #123 references in comments on the task to live tasks of
+// its project, which everyone who can read the comments can see.
+func (s *commentService) taskRefs(ctx context.Context, taskID uint) markdown.TaskRefs {
+	return func(ids []uint) ([]uint, error) { return s.repo.LinkableTasks(ctx, taskID, ids) }
+}
+
+func validEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > 32 {
+		return false
+	}
+	if strings.HasPrefix(emoji, ":") && strings.HasSuffix(emoji, ":") && len(emoji) > 2 {
+		for _, r := range emoji[1 : len(emoji)-1] {
+			if !(r == '_' || r == '+' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return false
+			}
+		}
+		return true
+	}
+	symbol := false
+	for _, r := range emoji {
+		switch {
+		case r > unicode.MaxASCII:
+			if unicode.IsSpace(r) || unicode.IsControl(r) || unicode.IsLetter(r) {
+				return false
+			}
+			symbol = true
+		case unicode.IsDigit(r) || r == '#' || r == '*':
+			// keycap sequences such as 1️⃣
+		default:
+			return false
+		}
 	}
-	return comment, s.repo.Save(ctx, &comment)
+	return symbol
 }
-func (s *commentService) Delete(ctx context.Context, id string) error { return s.repo.Delete(ctx, id) }
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"project-management/internal/httpx"
 	"project-management/internal/model"
+
+	"gorm.io/gorm"
 )
 
 type stubCommentRepo struct {
@@ -15,6 +18,15 @@ type stubCommentRepo struct {
 	getFn    func(ctx context.Context, id string) (model.Comment, error)
 	saveFn   func(ctx context.Context, comment *model.Comment) error
 	deleteFn func(ctx context.Context, id string) error
+
+	listRepliesFn    func(ctx context.Context, parentID string, params httpx.ListParams) ([]model.Comment, int64, error)
+	addReactionFn    func(ctx context.Context, reaction *model.CommentReaction) error
+	removeReactionFn func(ctx context.Context, commentID, userID uint, emoji string) error
+
+	saveWithRevisionFn func(ctx context.Context, comment *model.Comment, revision *model.CommentRevision) error
+	tombstoneFn        func(ctx context.Context, comment *model.Comment) error
+	listRevisionsFn    func(ctx context.Context, commentID string, params httpx.ListParams) ([]model.CommentRevision, int64, error)
+	linkableTasksFn    func(ctx context.Context, taskID uint, ids []uint) ([]uint, error)
 }
 
 func (s stubCommentRepo) List(ctx context.Context, filter CommentListFilter) ([]model.Comment, int64, error) {
@@ -30,6 +42,28 @@ func (s stubCommentRepo) Save(ctx context.Context, comment *model.Comment) error
 	return s.saveFn(ctx, comment)
 }
 func (s stubCommentRepo) Delete(ctx context.Context, id string) error { return s.deleteFn(ctx, id) }
+func (s stubCommentRepo) ListReplies(ctx context.Context, parentID string, params httpx.ListParams) ([]model.Comment, int64, error) {
+	return s.listRepliesFn(ctx, parentID, params)
+}
+func (s stubCommentRepo) AddReaction(ctx context.Context, reaction *model.CommentReaction) error {
+	return s.addReactionFn(ctx, reaction)
+}
+func (s stubCommentRepo) RemoveReaction(ctx context.Context, commentID, userID uint, emoji string) error {
+	return s.removeReactionFn(ctx, commentID, userID, emoji)
+}
+
+func (s stubCommentRepo) SaveWithRevision(ctx context.Context, comment *model.Comment, revision *model.CommentRevision) error {
+	return s.saveWithRevisionFn(ctx, comment, revision)
+}
+func (s stubCommentRepo) Tombstone(ctx context.Context, comment *model.Comment) error {
+	return s.tombstoneFn(ctx, comment)
+}
+func (s stubCommentRepo) ListRevisions(ctx context.Context, commentID string, params httpx.ListParams) ([]model.CommentRevision, int64, error) {
+	return s.listRevisionsFn(ctx, commentID, params)
+}
+func (s stubCommentRepo) LinkableTasks(ctx context.Context, taskID uint, ids []uint) ([]uint, error) {
+	return s.linkableTasksFn(ctx, taskID, ids)
+}
 
 func TestCommentService(t *testing.T) {
 	ctx := context.Background()
@@ -49,7 +83,7 @@ func TestCommentService(t *testing.T) {
 
 	t.Run("create maps input", func(t *testing.T) {
 		svc := &commentService{repo: stubCommentRepo{createFn: func(ctx context.Context, comment *model.Comment) error {
-			if comment.TaskID != 5 || comment.Author != "Ann" {
+			if comment.TaskID != 5 || comment.Author != "Ann" || comment.HTML != "<p>hello</p>\n" {
 				t.Fatalf("comment = %+v", comment)
 			}
 			return nil
@@ -88,10 +122,13 @@ func TestCommentService(t *testing.T) {
 			getFn: func(ctx context.Context, id string) (model.Comment, error) {
 				return model.Comment{ID: 1, Author: "Old", Text: "Old"}, nil
 			},
-			saveFn: func(ctx context.Context, comment *model.Comment) error {
-				if comment.Author != "New" || comment.Text != "Updated" {
+			saveWithRevisionFn: func(ctx context.Context, comment *model.Comment, revision *model.CommentRevision) error {
+				if comment.Author != "New" || comment.Text != "Updated" || comment.HTML != "<p>Updated</p>\n" || !comment.Edited || comment.EditedAt == nil {
 					t.Fatalf("comment = %+v", comment)
 				}
+				if revision.CommentID != 1 || revision.Text != "Old" {
+					t.Fatalf("revision = %+v", revision)
+				}
 				return nil
 			},
 		}}
@@ -111,6 +148,33 @@ func TestCommentService(t *testing.T) {
 		}
 	})
 
+	t.Run("update without text change keeps history", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{
+			getFn: func(ctx context.Context, id string) (model.Comment, error) {
+				return model.Comment{ID: 1, Author: "Old", Text: "Same"}, nil
+			},
+			saveFn: func(ctx context.Context, comment *model.Comment) error {
+				if comment.Author != "New" || comment.Edited {
+					t.Fatalf("comment = %+v", comment)
+				}
+				return nil
+			},
+		}}
+		if _, err := svc.Update(ctx, "1", CommentUpdateInput{Author: ptr("New"), Text: ptr("Same")}); err != nil {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("update deleted comment", func(t *testing.T) {
+		deletedAt := time.Now()
+		svc := &commentService{repo: stubCommentRepo{getFn: func(ctx context.Context, id string) (model.Comment, error) {
+			return model.Comment{ID: 1, DeletedAt: &deletedAt}, nil
+		}}}
+		if _, err := svc.Update(ctx, "1", CommentUpdateInput{Text: ptr("x")}); !errors.Is(err, ErrCommentDeleted) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
 	t.Run("update save error", func(t *testing.T) {
 		svc := &commentService{repo: stubCommentRepo{
 			getFn:  func(ctx context.Context, id string) (model.Comment, error) { return model.Comment{ID: 1}, nil },
@@ -122,9 +186,228 @@ func TestCommentService(t *testing.T) {
 		}
 	})
 
+	t.Run("delete leaves tombstone", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{
+			getFn: func(ctx context.Context, id string) (model.Comment, error) {
+				return model.Comment{ID: 2, Text: "secret", HTML: "<p>secret</p>"}, nil
+			},
+			tombstoneFn: func(ctx context.Context, comment *model.Comment) error {
+				if comment.Text != DeletedCommentText || comment.HTML != DeletedCommentHTML || comment.DeletedAt == nil || comment.DeletedText != "secret" {
+					t.Fatalf("comment = %+v", comment)
+				}
+				return nil
+			},
+		}}
+		if err := svc.Delete(ctx, "2", nil); err != nil {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("delete is idempotent", func(t *testing.T) {
+		deletedAt := time.Now()
+		svc := &commentService{repo: stubCommentRepo{getFn: func(ctx context.Context, id string) (model.Comment, error) {
+			return model.Comment{ID: 2, DeletedAt: &deletedAt}, nil
+		}}}
+		if err := svc.Delete(ctx, "2", nil); err != nil {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
 	t.Run("delete error propagates", func(t *testing.T) {
-		svc := &commentService{repo: stubCommentRepo{deleteFn: func(ctx context.Context, id string) error { return errors.New("boom") }}}
-		if err := svc.Delete(ctx, "2"); err == nil || err.Error() != "boom" {
+		svc := &commentService{repo: stubCommentRepo{getFn: func(ctx context.Context, id string) (model.Comment, error) {
+			return model.Comment{}, errors.New("boom")
+		}}}
+		if err := svc.Delete(ctx, "2", nil); err == nil || err.Error() != "boom" {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("purge hard deletes", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{
+			getFn:    func(ctx context.Context, id string) (model.Comment, error) { return model.Comment{ID: 2}, nil },
+			deleteFn: func(ctx context.Context, id string) error { return errors.New("boom") },
+		}}
+		if err := svc.Purge(ctx, "2"); err == nil || err.Error() != "boom" {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("list revisions", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{
+			getFn: func(ctx context.Context, id string) (model.Comment, error) { return model.Comment{ID: 2}, nil },
+			listRevisionsFn: func(ctx context.Context, commentID string, params httpx.ListParams) ([]model.CommentRevision, int64, error) {
+				if commentID != "2" {
+					t.Fatalf("commentID = %s", commentID)
+				}
+				return []model.CommentRevision{{ID: 1, CommentID: 2, Text: "v1"}}, 1, nil
+			},
+		}}
+		items, total, err := svc.ListRevisions(ctx, "2", httpx.ListParams{})
+		if err != nil || total != 1 || len(items) != 1 {
+			t.Fatalf("items=%v total=%d err=%v", items, total, err)
+		}
+	})
+
+	t.Run("deleted comment lists no revisions", func(t *testing.T) {
+		now := time.Now()
+		svc := &commentService{repo: stubCommentRepo{
+			getFn: func(ctx context.Context, id string) (model.Comment, error) {
+				return model.Comment{ID: 2, DeletedAt: &now}, nil
+			},
+		}}
+		items, total, err := svc.ListRevisions(ctx, "2", httpx.ListParams{})
+		if err != nil || total != 0 || items == nil || len(items) != 0 {
+			t.Fatalf("items=%v total=%d err=%v", items, total, err)
+		}
+	})
+}
+
+func TestCommentServiceThreads(t *testing.T) {
+	ctx := context.Background()
+	parentID := uint(1)
+
+	t.Run("reply to top-level comment", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{
+			getFn: func(ctx context.Context, id string) (model.Comment, error) {
+				if id != "1" {
+					t.Fatalf("id=%s", id)
+				}
+				return model.Comment{ID: 1, TaskID: 5}, nil
+			},
+			createFn: func(ctx context.Context, comment *model.Comment) error {
+				if comment.ParentID == nil || *comment.ParentID != 1 {
+					t.Fatalf("comment = %+v", comment)
+				}
+				return nil
+			},
+		}}
+		if _, err := svc.Create(ctx, CommentCreateInput{TaskID: 5, ParentID: &parentID, Author: "Ann", Text: "re"}); err != nil {
+			t.Fatalf("Create error = %v", err)
+		}
+	})
+
+	t.Run("rejects invalid parents", func(t *testing.T) {
+		grandParent := uint(9)
+		parents := map[string]func(ctx context.Context, id string) (model.Comment, error){
+			"missing": func(ctx context.Context, id string) (model.Comment, error) {
+				return model.Comment{}, gorm.ErrRecordNotFound
+			},
+			"nested": func(ctx context.Context, id string) (model.Comment, error) {
+				return model.Comment{ID: 1, TaskID: 5, ParentID: &grandParent}, nil
+			},
+			"other task": func(ctx context.Context, id string) (model.Comment, error) {
+				return model.Comment{ID: 1, TaskID: 6}, nil
+			},
+		}
+		for name, getFn := range parents {
+			svc := &commentService{repo: stubCommentRepo{getFn: getFn}}
+			_, err := svc.Create(ctx, CommentCreateInput{TaskID: 5, ParentID: &parentID, Author: "Ann", Text: "re"})
+			if !errors.Is(err, ErrInvalidParent) {
+				t.Fatalf("%s: err = %v", name, err)
+			}
+		}
+	})
+
+	t.Run("list replies requires parent", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{getFn: func(ctx context.Context, id string) (model.Comment, error) {
+			return model.Comment{}, gorm.ErrRecordNotFound
+		}}}
+		if _, _, err := svc.ListReplies(ctx, "1", httpx.ListParams{}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("list replies delegates", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{
+			getFn: func(ctx context.Context, id string) (model.Comment, error) { return model.Comment{ID: 1}, nil },
+			listRepliesFn: func(ctx context.Context, id string, params httpx.ListParams) ([]model.Comment, int64, error) {
+				if id != "1" || params.Page != 2 {
+					t.Fatalf("id=%s params=%+v", id, params)
+				}
+				return []model.Comment{{ID: 2, ParentID: &parentID}}, 1, nil
+			},
+		}}
+		items, total, err := svc.ListReplies(ctx, "1", httpx.ListParams{Page: 2})
+		if err != nil || total != 1 || len(items) != 1 {
+			t.Fatalf("items=%v total=%d err=%v", items, total, err)
+		}
+	})
+}
+
+func TestCommentServiceReactions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("add reaction", func(t *testing.T) {
+		added := false
+		svc := &commentService{repo: stubCommentRepo{
+			getFn: func(ctx context.Context, id string) (model.Comment, error) {
+				c := model.Comment{ID: 3}
+				if added {
+					c.Reactions = []model.ReactionSummary{{Emoji: "👍", Count: 1, Users: []model.ReactionUser{{ID: 7}}}}
+				}
+				return c, nil
+			},
+			addReactionFn: func(ctx context.Context, reaction *model.CommentReaction) error {
+				if reaction.CommentID != 3 || reaction.UserID != 7 || reaction.Emoji != "👍" {
+					t.Fatalf("reaction = %+v", reaction)
+				}
+				added = true
+				return nil
+			},
+		}}
+		comment, err := svc.AddReaction(ctx, "3", 7, "👍")
+		if err != nil || len(comment.Reactions) != 1 {
+			t.Fatalf("comment=%+v err=%v", comment, err)
+		}
+	})
+
+	t.Run("rejects invalid emoji", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{}}
+		for _, emoji := range []string{"", "abc", "a b", "::", "👍/x"} {
+			if _, err := svc.AddReaction(ctx, "3", 7, emoji); !errors.Is(err, ErrInvalidEmoji) {
+				t.Fatalf("emoji %q: err = %v", emoji, err)
+			}
+		}
+	})
+
+	t.Run("accepts shortcodes", func(t *testing.T) {
+		for _, emoji := range []string{":+1:", ":tada:", "🎉", "❤️"} {
+			if !validEmoji(emoji) {
+				t.Fatalf("emoji %q rejected", emoji)
+			}
+		}
+	})
+
+	t.Run("remove reaction on missing comment", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{getFn: func(ctx context.Context, id string) (model.Comment, error) {
+			return model.Comment{}, gorm.ErrRecordNotFound
+		}}}
+		if _, err := svc.RemoveReaction(ctx, "3", 7, "👍"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("remove reaction on deleted comment", func(t *testing.T) {
+		now := time.Now()
+		svc := &commentService{repo: stubCommentRepo{getFn: func(ctx context.Context, id string) (model.Comment, error) {
+			return model.Comment{ID: 3, DeletedAt: &now}, nil
+		}}}
+		if _, err := svc.RemoveReaction(ctx, "3", 7, "👍"); !errors.Is(err, ErrCommentDeleted) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("remove reaction", func(t *testing.T) {
+		svc := &commentService{repo: stubCommentRepo{
+			getFn: func(ctx context.Context, id string) (model.Comment, error) { return model.Comment{ID: 3}, nil },
+			removeReactionFn: func(ctx context.Context, commentID, userID uint, emoji string) error {
+				if commentID != 3 || userID != 7 || emoji != "👍" {
+					t.Fatalf("commentID=%d userID=%d emoji=%s", commentID, userID, emoji)
+				}
+				return nil
+			},
+		}}
+		if _, err := svc.RemoveReaction(ctx, "3", 7, "👍"); err != nil {
 			t.Fatalf("err = %v", err)
 		}
 	})
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"project-management/internal/model"
+)
+
+// ExternalImportOptions adjust how a Trello board or a Jira export becomes a
+// project bundle.
+type ExternalImportOptions struct {
+	// Statuses maps a Trello list or Jira status to a task status; others
+	// get the status their name suggests.
+	Statuses map[string]model.TaskStatus
+	// Members maps a Trello username or a Jira user, as named in the
+	// export, to the email of a user here. A Jira user that is an email
+	// already needs no entry.
+	Members map[string]string
+	// IncludeArchived imports archived Trello cards and the cards of
+	// archived lists too.
+	IncludeArchived bool
+}
+
+// ImportMapping is how an export maps to a project, for review before the
+// import. TaskKeys are the source keys (Trello card short links, Jira issue
+// keys) of the bundle's tasks, by bundle task ID minus one.
+type ImportMapping struct {
+	Statuses []StatusMapping `json:"statuses"`
+	Members  []MemberMapping `json:"members"`
+	Labels   []string        `json:"labels"`
+	Skipped  int             `json:"skipped"`
+	TaskKeys []string        `json:"-"`
+}
+
+// StatusMapping is a Trello list or Jira status, the status its tasks get,
+// and how many there are.
+type StatusMapping struct {
+	Source string           `json:"source"`
+	Status model.TaskStatus `json:"status"`
+	Tasks  int              `json:"tasks"`
+}
+
+// MemberMapping is a member of the export and the email it is matched by;
+// members without an email are not linked to anyone.
+type MemberMapping struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+}
+
+// guessStatus picks the status a list or workflow status name suggests.
+func guessStatus(name string) model.TaskStatus {
+	name = strings.ToLower(name)
+	for _, word := range []string{"done", "complete", "closed", "resolved", "finished", "shipped", "released"} {
+		if strings.Contains(name, word) {
+			return model.TaskDone
+		}
+	}
+	for _, word := range []string{"progress", "doing", "review", "testing", "qa", "active", "started"} {
+		if strings.Contains(name, word) {
+			return model.TaskInProgress
+		}
+	}
+	return model.TaskTodo
+}
+
+func validStatus(status model.TaskStatus) bool {
+	return status == model.TaskTodo || status == model.TaskInProgress || status == model.TaskDone
+}
+
+// externalBundle collects the parts of a bundle while an export is read.
+// Bundle users get the ID of their member's position in the mapping plus one.
+type externalBundle struct {
+	bundle   model.ProjectBundle
+	mapping  ImportMapping
+	opts     ExternalImportOptions
+	statuses map[string]int // source → index in mapping.Statuses
+	members  map[string]int // source → index in mapping.Members
+	labels   map[string]bool
+}
+
+func newExternalBundle(title, description string, opts ExternalImportOptions) *externalBundle {
+	return &externalBundle{
+		bundle: model.ProjectBundle{
+			Format:      BundleFormat,
+			Version:     BundleVersion,
+			Project:     model.BundleProject{Title: title, Description: description, Status: model.ProjectActive},
+			Users:       []model.BundleUser{},
+			Labels:      []string{},
+			Tasks:       []model.BundleTask{},
+			Comments:    []model.BundleComment{},
+			Attachments: []model.BundleAttachment{},
+		},
+		mapping:  ImportMapping{Statuses: []StatusMapping{}, Members: []MemberMapping{}, Labels: []string{}},
+		opts:     opts,
+		statuses: map[string]int{},
+		members:  map[string]int{},
+		labels:   map[string]bool{},
+	}
+}
+
+// status returns the task status of a list or workflow status and counts
+// one more task in it. fallback, if valid, replaces the guess by name.
+func (b *externalBundle) status(source string, fallback model.TaskStatus) model.TaskStatus {
+	i, ok := b.statuses[source]
+	if !ok {
+		status, set := b.opts.Statuses[source]
+		if !set || !validStatus(status) {
+			status = fallback
+		}
+		if !validStatus(status) {
+			status = guessStatus(source)
+		}
+		i = len(b.mapping.Statuses)
+		b.statuses[source] = i
+		b.mapping.Statuses = append(b.mapping.Statuses, StatusMapping{Source: source, Status: status})
+	}
+	b.mapping.Statuses[i].Tasks++
+	return b.mapping.Statuses[i].Status
+}
+
+// member records a member of the export and returns the ID of its bundle
+// user, or nil if it has no email.
+func (b *externalBundle) member(source, name string) *uint {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil
+	}
+	i, ok := b.members[source]
+	if !ok {
+		email := strings.TrimSpace(b.opts.Members[source])
+		if email == "" && strings.Contains(source, "@") {
+			email = source
+		}
+		if name == "" {
+			name = source
+		}
+		i = len(b.mapping.Members)
+		b.members[source] = i
+		b.mapping.Members = append(b.mapping.Members, MemberMapping{Source: source, Name: name, Email: email})
+		if email != "" {
+			b.bundle.Users = append(b.bundle.Users, model.BundleUser{ID: uint(i + 1), Email: email, Name: name})
+		}
+	}
+	if b.mapping.Members[i].Email == "" {
+		return nil
+	}
+	id := uint(i + 1)
+	return &id
+}
+
+func (b *externalBundle) label(name string) string {
+	name = strings.TrimSpace(name)
+	if name != "" && !b.labels[name] {
+		b.labels[name] = true
+		b.bundle.Labels = append(b.bundle.Labels, name)
+	}
+	return name
+}
+
+func (b *externalBundle) addTask(key string, task model.BundleTask) uint {
+	b.mapping.TaskKeys = append(b.mapping.TaskKeys, key)
+	task.ID = uint(len(b.mapping.TaskKeys))
+	b.bundle.Tasks = append(b.bundle.Tasks, task)
+	return task.ID
+}
+
+func (b *externalBundle) addComment(comment model.BundleComment) {
+	comment.ID = uint(len(b.bundle.Comments) + 1)
+	b.bundle.Comments = append(b.bundle.Comments, comment)
+}
+
+func (b *externalBundle) done() (model.ProjectBundle, ImportMapping) {
+	b.mapping.Labels = slices.Clone(b.bundle.Labels)
+	sort.Strings(b.mapping.Labels)
+	return b.bundle, b.mapping
+}
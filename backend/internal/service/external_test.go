@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+)
+
+const trelloExport = `{
+  "name": "Website", "desc": "Relaunch",
+  "lists": [
+    {"id": "l2", "name": "Doing", "pos": 2},
+    {"id": "l1", "name": "Backlog", "pos": 1},
+    {"id": "l3", "name": "Shipped", "pos": 3},
+    {"id": "l4", "name": "Old", "pos": 4, "closed": true}
+  ],
+  "members": [{"id": "m1", "username": "ann", "fullName": "Ann Lee"}, {"id": "m2", "username": "bob", "fullName": "Bob"}],
+  "cards": [
+    {"id": "5f5a5a000000000000000001", "shortLink": "aaa", "name": "Hero image", "idList": "l2", "pos": 1, "idMembers": ["m2", "m1"], "due": "2026-11-02T12:00:00.000Z", "labels": [{"name": "design"}, {"name": "", "color": "red"}]},
+    {"id": "5f5a5a000000000000000002", "shortLink": "bbb", "name": "Copy", "idList": "l1", "pos": 1},
+    {"id": "5f5a5a000000000000000003", "shortLink": "ccc", "name": "Launch", "idList": "l3", "pos": 1, "labels": [{"name": "design"}]},
+    {"id": "5f5a5a000000000000000004", "shortLink": "ddd", "name": "Archived", "idList": "l1", "pos": 2, "closed": true},
+    {"id": "5f5a5a000000000000000005", "shortLink": "eee", "name": "In old list", "idList": "l4", "pos": 1}
+  ],
+  "actions": [
+    {"type": "commentCard", "date": "2026-10-02T10:00:00Z", "idMemberCreator": "m1", "data": {"text": "Second", "card": {"id": "5f5a5a000000000000000001"}}, "memberCreator": {"username": "ann", "fullName": "Ann Lee"}},
+    {"type": "commentCard", "date": "2026-10-01T10:00:00Z", "idMemberCreator": "m9", "data": {"text": "First", "card": {"id": "5f5a5a000000000000000001"}}, "memberCreator": {"username": "zed", "fullName": "Zed"}},
+    {"type": "updateCard", "date": "2026-10-03T10:00:00Z", "data": {"card": {"id": "5f5a5a000000000000000001"}}},
+    {"type": "commentCard", "date": "2026-10-03T10:00:00Z", "data": {"text": "On archived", "card": {"id": "5f5a5a000000000000000004"}}}
+  ]
+}`
+
+func TestReadTrello(t *testing.T) {
+	opts := ExternalImportOptions{Statuses: map[string]model.TaskStatus{"Backlog": model.TaskInProgress}, Members: map[string]string{"ann": "ann@example.com"}}
+	bundle, mapping, err := ReadTrello(strings.NewReader(trelloExport), opts)
+	if err != nil {
+		t.Fatalf("ReadTrello: %v", err)
+	}
+	if bundle.Project.Title != "Website" || bundle.Project.Description != "Relaunch" || len(bundle.Tasks) != 3 || mapping.Skipped != 2 {
+		t.Fatalf("bundle = %+v, mapping = %+v", bundle, mapping)
+	}
+	titles := []string{bundle.Tasks[0].Title, bundle.Tasks[1].Title, bundle.Tasks[2].Title}
+	if strings.Join(titles, ",") != "Copy,Hero image,Launch" || strings.Join(mapping.TaskKeys, ",") != "bbb,aaa,ccc" {
+		t.Fatalf("titles = %v, keys = %v", titles, mapping.TaskKeys)
+	}
+	hero := bundle.Tasks[1]
+	if hero.Status != model.TaskInProgress || hero.AssigneeID == nil || bundle.Users[0].Email != "ann@example.com" || *hero.AssigneeID != bundle.Users[0].ID {
+		t.Fatalf("hero = %+v, users = %+v", hero, bundle.Users)
+	}
+	if hero.DueDate == nil || !hero.DueDate.Equal(time.Date(2026, 11, 2, 12, 0, 0, 0, time.UTC)) || strings.Join(hero.Labels, ",") != "design,red" {
+		t.Fatalf("hero = %+v", hero)
+	}
+	if hero.CreatedAt.Unix() != 0x5f5a5a00 {
+		t.Fatalf("created = %v", hero.CreatedAt)
+	}
+	wantStatuses := []StatusMapping{{"Backlog", model.TaskInProgress, 1}, {"Doing", model.TaskInProgress, 1}, {"Shipped", model.TaskDone, 1}}
+	if len(mapping.Statuses) != 3 || mapping.Statuses[0] != wantStatuses[0] || mapping.Statuses[1] != wantStatuses[1] || mapping.Statuses[2] != wantStatuses[2] {
+		t.Fatalf("statuses = %+v", mapping.Statuses)
+	}
+	if len(mapping.Members) != 3 || mapping.Members[0].Source != "bob" || mapping.Members[0].Email != "" || mapping.Members[2].Name != "Zed" {
+		t.Fatalf("members = %+v", mapping.Members)
+	}
+	if len(bundle.Comments) != 2 || bundle.Comments[0].Text != "First" || bundle.Comments[0].Author != "Zed" || bundle.Comments[1].TaskID != hero.ID {
+		t.Fatalf("comments = %+v", bundle.Comments)
+	}
+	if strings.Join(mapping.Labels, ",") != "design,red" {
+		t.Fatalf("labels = %v", mapping.Labels)
+	}
+
+	bundle, mapping, err = ReadTrello(strings.NewReader(trelloExport), ExternalImportOptions{IncludeArchived: true})
+	if err != nil || len(bundle.Tasks) != 5 || mapping.Skipped != 0 || len(bundle.Comments) != 3 {
+		t.Fatalf("with archived: %d tasks, %d comments, %v", len(bundle.Tasks), len(bundle.Comments), err)
+	}
+	for _, raw := range []string{"[]", "{}", "not json"} {
+		if _, _, err := ReadTrello(strings.NewReader(raw), ExternalImportOptions{}); !errors.Is(err, ErrBundleFormat) {
+			t.Fatalf("ReadTrello(%q) err = %v", raw, err)
+		}
+	}
+}
+
+const jiraExport = "\ufeffSummary,Issue key,Status,Status Category,Assignee,Created,Due Date,Description,Labels,Labels,Comment,Comment,Project name\n" +
+	"Login page,WEB-1,Code Review,In Progress,dev@example.com,01/Oct/26 9:30 AM,02/Nov/26,\"Build *it*\",frontend,auth,\"02/Oct/26 10:00 AM;abc123;Looks good; ship it\",,Website\n" +
+	"Signup,WEB-2,Won't Do,Done,Pat Smith,2026-10-03 08:00,,,frontend,,,,Website\n" +
+	",WEB-3,To Do,To Do,,,,,,,,,Website\n"
+
+func TestReadJira(t *testing.T) {
+	opts := ExternalImportOptions{Members: map[string]string{"abc123": "rev@example.com"}}
+	bundle, mapping, err := ReadJira(strings.NewReader(jiraExport), "", opts)
+	if err != nil {
+		t.Fatalf("ReadJira: %v", err)
+	}
+	if bundle.Project.Title != "Website" || len(bundle.Tasks) != 2 || mapping.Skipped != 1 || strings.Join(mapping.TaskKeys, ",") != "WEB-1,WEB-2" {
+		t.Fatalf("bundle = %+v, mapping = %+v", bundle, mapping)
+	}
+	login := bundle.Tasks[0]
+	if login.Status != model.TaskInProgress || login.Description != "Build *it*" || strings.Join(login.Labels, ",") != "frontend,auth" {
+		t.Fatalf("login = %+v", login)
+	}
+	if !login.CreatedAt.Equal(time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)) || login.DueDate == nil || !login.DueDate.Equal(time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("login dates = %v, %v", login.CreatedAt, login.DueDate)
+	}
+	if login.AssigneeID == nil || bundle.Users[0].Email != "dev@example.com" {
+		t.Fatalf("assignee = %v, users = %+v", login.AssigneeID, bundle.Users)
+	}
+	if signup := bundle.Tasks[1]; signup.Status != model.TaskDone || signup.AssigneeID != nil {
+		t.Fatalf("signup = %+v", signup)
+	}
+	if len(bundle.Comments) != 1 || bundle.Comments[0].Author != "abc123" || bundle.Comments[0].Text != "Looks good; ship it" || bundle.Users[1].Email != "rev@example.com" {
+		t.Fatalf("comments = %+v, users = %+v", bundle.Comments, bundle.Users)
+	}
+	if mapping.Statuses[1].Source != "Won't Do" || mapping.Statuses[1].Status != model.TaskDone {
+		t.Fatalf("statuses = %+v", mapping.Statuses)
+	}
+
+	bundle, _, err = ReadJira(strings.NewReader(jiraExport), "Renamed", ExternalImportOptions{Statuses: map[string]model.TaskStatus{"Won't Do": model.TaskTodo}})
+	if err != nil || bundle.Project.Title != "Renamed" || bundle.Tasks[1].Status != model.TaskTodo {
+		t.Fatalf("bundle = %+v, %v", bundle, err)
+	}
+	for _, raw := range []string{"", "Key,Title\nA,B\n", "Summary,Status\n"} {
+		if _, _, err := ReadJira(strings.NewReader(raw), "", ExternalImportOptions{}); !errors.Is(err, ErrBundleFormat) {
+			t.Fatalf("ReadJira(%q) err = %v", raw, err)
+		}
+	}
+}
+
+func TestGuessStatus(t *testing.T) {
+	for name, want := range map[string]model.TaskStatus{
+		"Backlog":     model.TaskTodo,
+		"In Progress": model.TaskInProgress,
+		"Code review": model.TaskInProgress,
+		"Done ✔":      model.TaskDone,
+		"Closed":      model.TaskDone,
+	} {
+		if got := guessStatus(name); got != want {
+			t.Fatalf("guessStatus(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
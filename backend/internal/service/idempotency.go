@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// IdempotencyTTL is how long a stored response is replayed.
+const IdempotencyTTL = 24 * time.Hour
+
+// IdempotencyLease is how long a reservation blocks retries before another
+// request may take the key over.
+const IdempotencyLease = time.Minute
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("this Idempotency-Key was already used with a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this Idempotency-Key is still in progress")
+)
+
+// IdempotencyService reserves Idempotency-Key values and stores the responses
+// to replay for them.
+type IdempotencyService interface {
+	// Begin reserves key for the user. It returns replay true and the stored
+	// response if the key was used before with the same fingerprint, or the
+	// new reservation, which the caller must Complete or Abandon.
+	Begin(ctx context.Context, userID uint, key, fingerprint string) (record model.IdempotencyKey, replay bool, err error)
+	Complete(ctx context.Context, record model.IdempotencyKey) error
+	// Abandon drops a reservation so the request can be retried, as after a
+	// server error.
+	Abandon(ctx context.Context, id uint) error
+	// Purge deletes expired keys.
+	Purge(ctx context.Context) (int64, error)
+}
+
+type IdempotencyRepository interface {
+	// Reserve inserts record unless the user already has a key of that
+	// value that has not expired and is not an in-progress reservation whose
+	// lease ran out, and reports whether it did.
+	Reserve(ctx context.Context, record *model.IdempotencyKey) (bool, error)
+	Find(ctx context.Context, userID uint, key string) (model.IdempotencyKey, error)
+	Complete(ctx context.Context, record model.IdempotencyKey) error
+	Delete(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotencyService struct {
+	repo IdempotencyRepository
+	now  func() time.Time
+}
+
+func NewIdempotencyService(repo IdempotencyRepository) IdempotencyService {
+	return &idempotencyService{repo: repo, now: time.Now}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, userID uint, key, fingerprint string) (model.IdempotencyKey, bool, error) {
+	now := s.now()
+	lockedUntil := now.Add(IdempotencyLease)
+	record := model.IdempotencyKey{UserID: userID, Key: key, Fingerprint: fingerprint, CreatedAt: now, LockedUntil: &lockedUntil, ExpiresAt: now.Add(IdempotencyTTL)}
+	// The stored key can disappear between Reserve and Find when its first
+	// request is abandoned; then the key is free again, so try once more.
+	for range 2 {
+		ok, err := s.repo.Reserve(ctx, &record)
+		if err != nil {
+			return model.IdempotencyKey{}, false, err
+		}
+		if ok {
+			return record, false, nil
+		}
+		stored, err := s.repo.Find(ctx, userID, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return model.IdempotencyKey{}, false, err
+		}
+		switch {
+		case stored.Fingerprint != fingerprint:
+			return model.IdempotencyKey{}, false, ErrIdempotencyKeyReused
+		case stored.Status == 0:
+			return model.IdempotencyKey{}, false, ErrIdempotencyInProgress
+		default:
+			return stored, true, nil
+		}
+	}
+	return model.IdempotencyKey{}, false, ErrIdempotencyInProgress
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, record model.IdempotencyKey) error {
+	return s.repo.Complete(ctx, record)
+}
+
+func (s *idempotencyService) Abandon(ctx context.Context, id uint) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *idempotencyService) Purge(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx, s.now())
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type stubIdempotencyRepo struct {
+	reserveFn func(ctx context.Context, record *model.IdempotencyKey) (bool, error)
+	findFn    func(ctx context.Context, userID uint, key string) (model.IdempotencyKey, error)
+}
+
+func (s stubIdempotencyRepo) Reserve(ctx context.Context, record *model.IdempotencyKey) (bool, error) {
+	return s.reserveFn(ctx, record)
+}
+func (s stubIdempotencyRepo) Find(ctx context.Context, userID uint, key string) (model.IdempotencyKey, error) {
+	return s.findFn(ctx, userID, key)
+}
+func (s stubIdempotencyRepo) Complete(ctx context.Context, record model.IdempotencyKey) error {
+	return nil
+}
+func (s stubIdempotencyRepo) Delete(ctx context.Context, id uint) error { return nil }
+func (s stubIdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyServiceBegin(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	stored := model.IdempotencyKey{ID: 3, UserID: 1, Key: "k", Fingerprint: "f", Status: 201, Body: []byte(`{}`)}
+	taken := func(ctx context.Context, record *model.IdempotencyKey) (bool, error) { return false, nil }
+
+	t.Run("reserves a new key for a day with a minute's lease", func(t *testing.T) {
+		svc := &idempotencyService{now: func() time.Time { return now }, repo: stubIdempotencyRepo{reserveFn: func(ctx context.Context, record *model.IdempotencyKey) (bool, error) {
+			record.ID = 9
+			return true, nil
+		}}}
+		record, replay, err := svc.Begin(ctx, 1, "k", "f")
+		if err != nil || replay || record.ID != 9 || !record.ExpiresAt.Equal(now.Add(24*time.Hour)) || record.LockedUntil == nil || !record.LockedUntil.Equal(now.Add(time.Minute)) {
+			t.Fatalf("record = %+v replay = %v err = %v", record, replay, err)
+		}
+	})
+
+	t.Run("replays or rejects a used key", func(t *testing.T) {
+		svc := &idempotencyService{now: time.Now, repo: stubIdempotencyRepo{reserveFn: taken, findFn: func(ctx context.Context, userID uint, key string) (model.IdempotencyKey, error) {
+			return stored, nil
+		}}}
+		if record, replay, err := svc.Begin(ctx, 1, "k", "f"); err != nil || !replay || record.Status != 201 {
+			t.Fatalf("record = %+v replay = %v err = %v", record, replay, err)
+		}
+		if _, _, err := svc.Begin(ctx, 1, "k", "other"); !errors.Is(err, ErrIdempotencyKeyReused) {
+			t.Fatalf("err = %v, want ErrIdempotencyKeyReused", err)
+		}
+		stored.Status = 0
+		if _, _, err := svc.Begin(ctx, 1, "k", "f"); !errors.Is(err, ErrIdempotencyInProgress) {
+			t.Fatalf("err = %v, want ErrIdempotencyInProgress", err)
+		}
+	})
+
+	t.Run("retries when the stored key was abandoned meanwhile", func(t *testing.T) {
+		attempts := 0
+		svc := &idempotencyService{now: time.Now, repo: stubIdempotencyRepo{
+			reserveFn: func(ctx context.Context, record *model.IdempotencyKey) (bool, error) {
+				attempts++
+				return attempts == 2, nil
+			},
+			findFn: func(ctx context.Context, userID uint, key string) (model.IdempotencyKey, error) {
+				return model.IdempotencyKey{}, gorm.ErrRecordNotFound
+			},
+		}}
+		if _, replay, err := svc.Begin(ctx, 1, "k", "f"); err != nil || replay || attempts != 2 {
+			t.Fatalf("replay = %v err = %v attempts = %d", replay, err, attempts)
+		}
+	})
+}
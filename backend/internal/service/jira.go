@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"project-management/internal/model"
+)
+
+// jiraTimeLayouts are the date formats of Jira CSV exports, which follow the
+// exporting user's settings.
+var jiraTimeLayouts = []string{
+	"02/Jan/06 3:04 PM",
+	"02/Jan/06",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC3339,
+	"1/2/2006 15:04",
+	"1/2/2006",
+}
+
+// jiraCategories maps the Status Category column to a task status.
+var jiraCategories = map[string]model.TaskStatus{
+	"to do":       model.TaskTodo,
+	"in progress": model.TaskInProgress,
+	"done":        model.TaskDone,
+}
+
+// ReadJira turns a Jira CSV export into a project bundle. Statuses map by
+// their status category when the export has one; issues become tasks,
+// Comment columns comments and Labels columns labels. title names the
+// project when the export has no Project name column.
+func ReadJira(r io.Reader, title string, opts ExternalImportOptions) (model.ProjectBundle, ImportMapping, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return model.ProjectBundle{}, ImportMapping{}, fmt.Errorf("%w: not a Jira CSV export: %v", ErrBundleFormat, err)
+	}
+	// Jira repeats a column for every value of a multi-value field.
+	columns := map[string][]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = append(columns[name], i)
+	}
+	if len(columns["summary"]) == 0 || len(columns["status"]) == 0 {
+		return model.ProjectBundle{}, ImportMapping{}, fmt.Errorf("%w: a Jira CSV export needs Summary and Status columns", ErrBundleFormat)
+	}
+
+	var b *externalBundle
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return model.ProjectBundle{}, ImportMapping{}, fmt.Errorf("%w: %v", ErrBundleFormat, err)
+		}
+		field := func(name string) string {
+			for _, i := range columns[name] {
+				if i < len(record) && strings.TrimSpace(record[i]) != "" {
+					return strings.TrimSpace(record[i])
+				}
+			}
+			return ""
+		}
+		fields := func(name string) []string {
+			var out []string
+			for _, i := range columns[name] {
+				if i < len(record) && strings.TrimSpace(record[i]) != "" {
+					out = append(out, record[i])
+				}
+			}
+			return out
+		}
+		if b == nil {
+			if title == "" {
+				title = field("project name")
+			}
+			b = newExternalBundle(title, "", opts)
+		}
+
+		summary := field("summary")
+		if summary == "" {
+			b.mapping.Skipped++
+			continue
+		}
+		task := model.BundleTask{
+			Title:       summary,
+			Description: field("description"),
+			Status:      b.status(field("status"), jiraCategories[strings.ToLower(field("status category"))]),
+			AssigneeID:  b.member(field("assignee"), ""),
+			CreatedAt:   jiraTime(field("created")),
+		}
+		if due := jiraTime(field("due date")); !due.IsZero() {
+			task.DueDate = &due
+		}
+		for _, raw := range fields("labels") {
+			for _, name := range strings.Fields(raw) {
+				task.Labels = append(task.Labels, b.label(name))
+			}
+		}
+		taskID := b.addTask(field("issue key"), task)
+
+		for _, raw := range fields("comment") {
+			// A comment cell is "date;author;text"; the text may hold more
+			// semicolons.
+			parts := strings.SplitN(raw, ";", 3)
+			if len(parts) < 3 {
+				b.addComment(model.BundleComment{TaskID: taskID, Author: "Jira", Text: raw})
+				continue
+			}
+			author := strings.TrimSpace(parts[1])
+			b.member(author, "")
+			b.addComment(model.BundleComment{TaskID: taskID, Author: author, Text: parts[2], CreatedAt: jiraTime(parts[0])})
+		}
+	}
+	if b == nil {
+		return model.ProjectBundle{}, ImportMapping{}, fmt.Errorf("%w: the Jira export has no issues", ErrBundleFormat)
+	}
+	bundle, mapping := b.done()
+	return bundle, mapping, nil
+}
+
+func jiraTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range jiraTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+)
+
+var ErrInvalidMilestone = errors.New("milestoneId must be a milestone of the task's project")
+
+type MilestoneInput struct {
+	Name        string
+	Description string
+	TargetDate  time.Time // a day; its time of day is dropped
+}
+
+type MilestoneService interface {
+	// List returns a page of the project's milestones, by target date
+	// unless params sort them otherwise.
+	List(ctx context.Context, projectID string, params httpx.ListParams) ([]model.Milestone, int64, error)
+	Create(ctx context.Context, projectID string, input MilestoneInput) (model.Milestone, error)
+	Get(ctx context.Context, id string) (model.Milestone, error)
+	Update(ctx context.Context, id string, input MilestoneInput) (model.Milestone, error)
+	// Delete deletes the milestone; its tasks keep no milestone.
+	Delete(ctx context.Context, id string) error
+}
+
+type MilestoneRepository interface {
+	// Project returns a project that is not in the trash.
+	Project(ctx context.Context, id string) (model.Project, error)
+	List(ctx context.Context, projectID uint, params httpx.ListParams) ([]model.Milestone, int64, error)
+	Create(ctx context.Context, milestone *model.Milestone) error
+	Get(ctx context.Context, id string) (model.Milestone, error)
+	Save(ctx context.Context, milestone *model.Milestone) error
+	Delete(ctx context.Context, milestone model.Milestone) error
+}
+
+type milestoneService struct{ repo MilestoneRepository }
+
+func NewMilestoneService(repo MilestoneRepository) MilestoneService {
+	return &milestoneService{repo: repo}
+}
+
+func (s *milestoneService) List(ctx context.Context, projectID string, params httpx.ListParams) ([]model.Milestone, int64, error) {
+	project, err := s.repo.Project(ctx, projectID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(ctx, project.ID, params)
+}
+
+func (s *milestoneService) Create(ctx context.Context, projectID string, input MilestoneInput) (model.Milestone, error) {
+	project, err := s.repo.Project(ctx, projectID)
+	if err != nil {
+		return model.Milestone{}, err
+	}
+	milestone := model.Milestone{ProjectID: project.ID}
+	setMilestone(&milestone, input)
+	return milestone, s.repo.Create(ctx, &milestone)
+}
+
+func (s *milestoneService) Get(ctx context.Context, id string) (model.Milestone, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *milestoneService) Update(ctx context.Context, id string, input MilestoneInput) (model.Milestone, error) {
+	milestone, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Milestone{}, err
+	}
+	setMilestone(&milestone, input)
+	return milestone, s.repo.Save(ctx, &milestone)
+}
+
+func setMilestone(milestone *model.Milestone, input MilestoneInput) {
+	milestone.Name = strings.TrimSpace(input.Name)
+	milestone.Description = input.Description
+	milestone.TargetDate = dateOf(input.TargetDate)
+}
+
+func (s *milestoneService) Delete(ctx context.Context, id string) error {
+	milestone, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, milestone)
+}
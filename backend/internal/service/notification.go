@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// NotificationListFilter narrows a user's notifications and pages them,
+// newest first unless Params sort them otherwise.
+type NotificationListFilter struct {
+	UserID uint
+	Unread bool
+	Params httpx.ListParams
+}
+
+type NotificationService interface {
+	// List returns a page of the user's notifications, their total and the
+	// number of unread ones.
+	List(ctx context.Context, filter NotificationListFilter) (items []model.Notification, total, unread int64, err error)
+	// Read marks a notification of the user read. Another user's
+	// notification is gorm.ErrRecordNotFound.
+	Read(ctx context.Context, id string, userID uint) (model.Notification, error)
+	ReadAll(ctx context.Context, userID uint) (int64, error)
+}
+
+type NotificationRepository interface {
+	List(ctx context.Context, filter NotificationListFilter) ([]model.Notification, int64, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	Get(ctx context.Context, id string) (model.Notification, error)
+	MarkRead(ctx context.Context, userID uint, ids []uint, at time.Time) (int64, error)
+}
+
+type notificationService struct{ repo NotificationRepository }
+
+func NewNotificationService(repo NotificationRepository) NotificationService {
+	return &notificationService{repo: repo}
+}
+
+func (s *notificationService) List(ctx context.Context, filter NotificationListFilter) ([]model.Notification, int64, int64, error) {
+	items, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	unread, err := s.repo.CountUnread(ctx, filter.UserID)
+	return items, total, unread, err
+}
+
+func (s *notificationService) Read(ctx context.Context, id string, userID uint) (model.Notification, error) {
+	n, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Notification{}, err
+	}
+	if n.UserID != userID {
+		return model.Notification{}, gorm.ErrRecordNotFound
+	}
+	if n.ReadAt == nil {
+		now := time.Now()
+		if _, err := s.repo.MarkRead(ctx, userID, []uint{n.ID}, now); err != nil {
+			return model.Notification{}, err
+		}
+		n.ReadAt = &now
+	}
+	return n, nil
+}
+
+func (s *notificationService) ReadAll(ctx context.Context, userID uint) (int64, error) {
+	return s.repo.MarkRead(ctx, userID, nil, time.Now())
+}
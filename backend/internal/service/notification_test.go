@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type stubNotificationRepo struct {
+	items map[string]model.Notification
+	read  []uint
+}
+
+func (s *stubNotificationRepo) List(ctx context.Context, filter NotificationListFilter) ([]model.Notification, int64, error) {
+	if filter.Params.PageSize != 50 {
+		return nil, 0, fmt.Errorf("pageSize = %d", filter.Params.PageSize)
+	}
+	return nil, 8, nil
+}
+func (s *stubNotificationRepo) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	return 3, nil
+}
+func (s *stubNotificationRepo) Get(ctx context.Context, id string) (model.Notification, error) {
+	n, ok := s.items[id]
+	if !ok {
+		return model.Notification{}, gorm.ErrRecordNotFound
+	}
+	return n, nil
+}
+func (s *stubNotificationRepo) MarkRead(ctx context.Context, userID uint, ids []uint, at time.Time) (int64, error) {
+	s.read = append(s.read, ids...)
+	return int64(len(ids)), nil
+}
+
+func TestNotificationService(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubNotificationRepo{items: map[string]model.Notification{"1": {ID: 1, UserID: 7}}}
+	s := NewNotificationService(repo)
+
+	if _, total, unread, err := s.List(ctx, NotificationListFilter{UserID: 7, Params: httpx.ListParams{Page: 1, PageSize: 50}}); err != nil || total != 8 || unread != 3 {
+		t.Fatalf("List = %d, %d, %v", total, unread, err)
+	}
+	if n, err := s.Read(ctx, "1", 7); err != nil || n.ReadAt == nil || !slices.Equal(repo.read, []uint{1}) {
+		t.Fatalf("Read = %+v, %v", n, err)
+	}
+	if _, err := s.Read(ctx, "1", 8); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Read of another user's notification = %v", err)
+	}
+}
@@ -2,35 +2,53 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"project-management/internal/httpx"
+	"project-management/internal/markdown"
 	"project-management/internal/model"
 )
 
+// ErrProjectArchived is returned for writes to an archived project or to its
+// tasks, comments, labels and attachments.
+var ErrProjectArchived = errors.New("the project is archived; unarchive it to make changes")
+
 type ProjectListFilter struct {
-	Params       httpx.ListParams
-	Query        string
-	Status       string
-	IncludeTasks bool
+	Params  httpx.ListParams
+	UserID  uint   // lists only the projects this user can see
+	Query   string // substring of the title or description
+	Status  string
+	Include []string // ?include= paths, checked against ProjectRelations
+
+	// IncludeArchived lists archived projects too; without it they are
+	// left out unless Status asks for them.
+	IncludeArchived bool
 }
 
+// ProjectRelations are the relations ?include= can load with a project.
+var ProjectRelations = httpx.Relations{"owner": nil, "tasks": TaskRelations}
+
 type ProjectCreateInput struct {
 	Title       string
 	Description string
 	Status      model.ProjectStatus
+	OwnerID     *uint
 }
 
 type ProjectUpdateInput struct {
 	Title       *string
 	Description *string
 	Status      *model.ProjectStatus
+	Version     *uint // version the caller read (If-Match); nil skips the check
+	UserID      uint  // who makes the change, recorded when it archives the project
 }
 
 type ProjectTaskListFilter struct {
 	Params     httpx.ListParams
 	Status     string
 	AssigneeID string
+	Include    []string // ?include= paths, checked against TaskRelations
 }
 
 type ProjectTaskCreateInput struct {
@@ -45,64 +63,176 @@ type ProjectTaskCreateInput struct {
 type ProjectService interface {
 	List(ctx context.Context, filter ProjectListFilter) ([]model.Project, int64, error)
 	Create(ctx context.Context, input ProjectCreateInput) (model.Project, error)
-	Get(ctx context.Context, id string, includeTasks bool) (model.Project, error)
+	Get(ctx context.Context, id string, include []string) (model.Project, error)
 	Update(ctx context.Context, id string, input ProjectUpdateInput) (model.Project, error)
-	Delete(ctx context.Context, id string) error
+	// Archive makes the project read-only, recording who archived it and
+	// when. Archiving an archived project changes nothing.
+	Archive(ctx context.Context, id string, userID uint, version *uint) (model.Project, error)
+	// Unarchive makes an archived project writable again.
+	Unarchive(ctx context.Context, id string, version *uint) (model.Project, error)
+	// Delete removes the project; a non-nil version must match the current one.
+	Delete(ctx context.Context, id string, version *uint) error
 	ListTasks(ctx context.Context, projectID uint, filter ProjectTaskListFilter) ([]model.Task, int64, error)
 	CreateTask(ctx context.Context, input ProjectTaskCreateInput) (model.Task, error)
+	// Clone copies the project, and what the options say of its tasks and
+	// labels, into a new active project.
+	Clone(ctx context.Context, id string, input ProjectCloneInput) (model.Project, error)
+	// ExportTasks passes the project's tasks matching filter to each, a
+	// batch at a time in ID order.
+	ExportTasks(ctx context.Context, projectID uint, filter ProjectTaskListFilter, each func([]model.Task) error) error
+	// ImportTasks creates the tasks in the project in one transaction, or
+	// with dryRun only returns what would be created.
+	ImportTasks(ctx context.Context, projectID uint, inputs []ProjectTaskCreateInput, dryRun bool) ([]model.Task, error)
 }
 
 type ProjectRepository interface {
 	List(ctx context.Context, filter ProjectListFilter) ([]model.Project, int64, error)
 	Create(ctx context.Context, project *model.Project) error
-	Get(ctx context.Context, id string, includeTasks bool) (model.Project, error)
+	Get(ctx context.Context, id string, include []string) (model.Project, error)
+	// Save writes the project if its version is unchanged in the database
+	// and increments it, or returns ErrVersionMismatch.
 	Save(ctx context.Context, project *model.Project) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, version *uint) error
 	ListTasks(ctx context.Context, projectID uint, filter ProjectTaskListFilter) ([]model.Task, int64, error)
 	CreateTask(ctx context.Context, task *model.Task) error
+	// GetTree returns the project with its tasks and their labels, and all
+	// labels of the project.
+	GetTree(ctx context.Context, id string) (model.Project, []model.Label, error)
+	// CreateTree inserts the project with the given labels and its Tasks in
+	// one transaction, creating the tasks' labels by name.
+	CreateTree(ctx context.Context, project *model.Project, labels []model.Label) error
+	EachTask(ctx context.Context, projectID uint, filter ProjectTaskListFilter, size int, each func([]model.Task) error) error
+	CreateTasks(ctx context.Context, projectID uint, tasks []model.Task) error
+	// LinkableTasks returns those of ids that are tasks of the project and
+	// not in the trash.
+	LinkableTasks(ctx context.Context, projectID uint, ids []uint) ([]uint, error)
 }
 
-type projectService struct{ repo ProjectRepository }
+type projectService struct {
+	repo  ProjectRepository
+	blobs BlobCleaner
+}
 
 func NewProjectService(repo ProjectRepository) ProjectService { return &projectService{repo: repo} }
 
+func NewProjectServiceWithDeps(repo ProjectRepository, blobs BlobCleaner) ProjectService {
+	return &projectService{repo: repo, blobs: blobs}
+}
+
 func (s *projectService) List(ctx context.Context, filter ProjectListFilter) ([]model.Project, int64, error) {
 	return s.repo.List(ctx, filter)
 }
 
 func (s *projectService) Create(ctx context.Context, input ProjectCreateInput) (model.Project, error) {
-	project := model.Project{Title: input.Title, Description: input.Description, Status: input.Status}
+	html, err := markdown.Render(input.Description)
+	if err != nil {
+		return model.Project{}, err
+	}
+	project := model.Project{Title: input.Title, Description: input.Description, DescriptionHTML: html, Status: input.Status, OwnerID: input.OwnerID}
+	if project.Status == model.ProjectArchived {
+		archive(&project, input.OwnerID)
+	}
 	return project, s.repo.Create(ctx, &project)
 }
 
-func (s *projectService) Get(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
-	return s.repo.Get(ctx, id, includeTasks)
+func (s *projectService) Get(ctx context.Context, id string, include []string) (model.Project, error) {
+	return s.repo.Get(ctx, id, include)
 }
 
 func (s *projectService) Update(ctx context.Context, id string, input ProjectUpdateInput) (model.Project, error) {
-	project, err := s.repo.Get(ctx, id, false)
+	project, err := s.repo.Get(ctx, id, nil)
 	if err != nil {
 		return model.Project{}, err
 	}
+	if err := checkVersion(input.Version, project.Version); err != nil {
+		return model.Project{}, err
+	}
+	if project.Status == model.ProjectArchived {
+		return model.Project{}, ErrProjectArchived
+	}
 	if input.Title != nil {
 		project.Title = *input.Title
 	}
 	if input.Description != nil {
+		html, err := markdown.RenderWithRefs(*input.Description, s.taskRefs(ctx, project.ID))
+		if err != nil {
+			return model.Project{}, err
+		}
 		project.Description = *input.Description
+		project.DescriptionHTML = html
 	}
-	if input.Status != nil {
-		project.Status = *input.Status
+	if input.Status != nil && *input.Status == model.ProjectArchived {
+		userID := input.UserID
+		archive(&project, &userID)
 	}
 	return project, s.repo.Save(ctx, &project)
 }
 
-func (s *projectService) Delete(ctx context.Context, id string) error { return s.repo.Delete(ctx, id) }
+func (s *projectService) Archive(ctx context.Context, id string, userID uint, version *uint) (model.Project, error) {
+	project, err := s.repo.Get(ctx, id, nil)
+	if err != nil {
+		return model.Project{}, err
+	}
+	if err := checkVersion(version, project.Version); err != nil {
+		return model.Project{}, err
+	}
+	if project.Status == model.ProjectArchived {
+		return project, nil
+	}
+	archive(&project, &userID)
+	return project, s.repo.Save(ctx, &project)
+}
+
+func (s *projectService) Unarchive(ctx context.Context, id string, version *uint) (model.Project, error) {
+	project, err := s.repo.Get(ctx, id, nil)
+	if err != nil {
+		return model.Project{}, err
+	}
+	if err := checkVersion(version, project.Version); err != nil {
+		return model.Project{}, err
+	}
+	if project.Status != model.ProjectArchived {
+		return project, nil
+	}
+	project.Status = model.ProjectActive
+	project.ArchivedAt = nil
+	project.ArchivedBy = nil
+	return project, s.repo.Save(ctx, &project)
+}
+
+// taskRefs links #123 references in the project's texts to its live tasks,
+// which everyone who can read those texts can see.
+func (s *projectService) taskRefs(ctx context.Context, projectID uint) markdown.TaskRefs {
+	return func(ids []uint) ([]uint, error) { return s.repo.LinkableTasks(ctx, projectID, ids) }
+}
+
+func archive(project *model.Project, userID *uint) {
+	now := time.Now()
+	project.Status = model.ProjectArchived
+	project.ArchivedAt = &now
+	if userID != nil && *userID != 0 {
+		by := *userID
+		project.ArchivedBy = &by
+	}
+}
+
+func (s *projectService) Delete(ctx context.Context, id string, version *uint) error {
+	if err := s.repo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	cleanupBlobs(ctx, s.blobs)
+	return nil
+}
 
 func (s *projectService) ListTasks(ctx context.Context, projectID uint, filter ProjectTaskListFilter) ([]model.Task, int64, error) {
 	return s.repo.ListTasks(ctx, projectID, filter)
 }
 
 func (s *projectService) CreateTask(ctx context.Context, input ProjectTaskCreateInput) (model.Task, error) {
-	task := model.Task{ProjectID: input.ProjectID, Title: input.Title, Description: input.Description, Status: input.Status, AssigneeID: input.AssigneeID, DueDate: input.DueDate}
+	html, err := markdown.RenderWithRefs(input.Description, s.taskRefs(ctx, input.ProjectID))
+	if err != nil {
+		return model.Task{}, err
+	}
+	task := model.Task{ProjectID: input.ProjectID, Title: input.Title, Description: input.Description, DescriptionHTML: html, Status: input.Status, AssigneeID: input.AssigneeID, DueDate: input.DueDate}
 	return task, s.repo.CreateTask(ctx, &task)
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,13 +12,18 @@ import (
 )
 
 type stubProjectRepo struct {
-	listFn       func(ctx context.Context, filter ProjectListFilter) ([]model.Project, int64, error)
-	createFn     func(ctx context.Context, project *model.Project) error
-	getFn        func(ctx context.Context, id string, includeTasks bool) (model.Project, error)
-	saveFn       func(ctx context.Context, project *model.Project) error
-	deleteFn     func(ctx context.Context, id string) error
-	listTasksFn  func(ctx context.Context, projectID uint, filter ProjectTaskListFilter) ([]model.Task, int64, error)
-	createTaskFn func(ctx context.Context, task *model.Task) error
+	listFn          func(ctx context.Context, filter ProjectListFilter) ([]model.Project, int64, error)
+	createFn        func(ctx context.Context, project *model.Project) error
+	getFn           func(ctx context.Context, id string, include []string) (model.Project, error)
+	saveFn          func(ctx context.Context, project *model.Project) error
+	deleteFn        func(ctx context.Context, id string, version *uint) error
+	listTasksFn     func(ctx context.Context, projectID uint, filter ProjectTaskListFilter) ([]model.Task, int64, error)
+	createTaskFn    func(ctx context.Context, task *model.Task) error
+	getTreeFn       func(ctx context.Context, id string) (model.Project, []model.Label, error)
+	createTreeFn    func(ctx context.Context, project *model.Project, labels []model.Label) error
+	eachTaskFn      func(ctx context.Context, projectID uint, filter ProjectTaskListFilter, size int, each func([]model.Task) error) error
+	createTasksFn   func(ctx context.Context, projectID uint, tasks []model.Task) error
+	linkableTasksFn func(ctx context.Context, projectID uint, ids []uint) ([]uint, error)
 }
 
 func (s stubProjectRepo) List(ctx context.Context, filter ProjectListFilter) ([]model.Project, int64, error) {
@@ -26,31 +32,48 @@ func (s stubProjectRepo) List(ctx context.Context, filter ProjectListFilter) ([]
 func (s stubProjectRepo) Create(ctx context.Context, project *model.Project) error {
 	return s.createFn(ctx, project)
 }
-func (s stubProjectRepo) Get(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
-	return s.getFn(ctx, id, includeTasks)
+func (s stubProjectRepo) Get(ctx context.Context, id string, include []string) (model.Project, error) {
+	return s.getFn(ctx, id, include)
 }
 func (s stubProjectRepo) Save(ctx context.Context, project *model.Project) error {
 	return s.saveFn(ctx, project)
 }
-func (s stubProjectRepo) Delete(ctx context.Context, id string) error { return s.deleteFn(ctx, id) }
+func (s stubProjectRepo) Delete(ctx context.Context, id string, version *uint) error {
+	return s.deleteFn(ctx, id, version)
+}
 func (s stubProjectRepo) ListTasks(ctx context.Context, projectID uint, filter ProjectTaskListFilter) ([]model.Task, int64, error) {
 	return s.listTasksFn(ctx, projectID, filter)
 }
 func (s stubProjectRepo) CreateTask(ctx context.Context, task *model.Task) error {
 	return s.createTaskFn(ctx, task)
 }
+func (s stubProjectRepo) GetTree(ctx context.Context, id string) (model.Project, []model.Label, error) {
+	return s.getTreeFn(ctx, id)
+}
+func (s stubProjectRepo) CreateTree(ctx context.Context, project *model.Project, labels []model.Label) error {
+	return s.createTreeFn(ctx, project, labels)
+}
+func (s stubProjectRepo) EachTask(ctx context.Context, projectID uint, filter ProjectTaskListFilter, size int, each func([]model.Task) error) error {
+	return s.eachTaskFn(ctx, projectID, filter, size, each)
+}
+func (s stubProjectRepo) CreateTasks(ctx context.Context, projectID uint, tasks []model.Task) error {
+	return s.createTasksFn(ctx, projectID, tasks)
+}
+func (s stubProjectRepo) LinkableTasks(ctx context.Context, projectID uint, ids []uint) ([]uint, error) {
+	return s.linkableTasksFn(ctx, projectID, ids)
+}
 
 func TestProjectService(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("list delegates", func(t *testing.T) {
 		svc := &projectService{repo: stubProjectRepo{listFn: func(ctx context.Context, filter ProjectListFilter) ([]model.Project, int64, error) {
-			if filter.Params.Page != 2 || !filter.IncludeTasks {
+			if filter.Params.Page != 2 || strings.Join(filter.Include, ",") != "tasks" {
 				t.Fatalf("filter = %+v", filter)
 			}
 			return []model.Project{{ID: 1}}, 1, nil
 		}}}
-		items, total, err := svc.List(ctx, ProjectListFilter{Params: httpx.ListParams{Page: 2}, IncludeTasks: true})
+		items, total, err := svc.List(ctx, ProjectListFilter{Params: httpx.ListParams{Page: 2}, Include: []string{"tasks"}})
 		if err != nil || total != 1 || len(items) != 1 {
 			t.Fatalf("items=%v total=%d err=%v", items, total, err)
 		}
@@ -58,7 +81,7 @@ func TestProjectService(t *testing.T) {
 
 	t.Run("create maps input", func(t *testing.T) {
 		svc := &projectService{repo: stubProjectRepo{createFn: func(ctx context.Context, project *model.Project) error {
-			if project.Title != "API" || project.Status != model.ProjectActive {
+			if project.Title != "API" || project.Status != model.ProjectActive || project.DescriptionHTML != "<p>desc</p>\n" {
 				t.Fatalf("project = %+v", project)
 			}
 			project.ID = 7
@@ -81,13 +104,13 @@ func TestProjectService(t *testing.T) {
 	})
 
 	t.Run("get delegates success", func(t *testing.T) {
-		svc := &projectService{repo: stubProjectRepo{getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
-			if id != "11" || !includeTasks {
-				t.Fatalf("id=%s includeTasks=%v", id, includeTasks)
+		svc := &projectService{repo: stubProjectRepo{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
+			if id != "11" || strings.Join(include, ",") != "tasks" {
+				t.Fatalf("id=%s include=%v", id, include)
 			}
 			return model.Project{ID: 11}, nil
 		}}}
-		project, err := svc.Get(ctx, "11", true)
+		project, err := svc.Get(ctx, "11", []string{"tasks"})
 		if err != nil || project.ID != 11 {
 			t.Fatalf("project=%+v err=%v", project, err)
 		}
@@ -96,7 +119,7 @@ func TestProjectService(t *testing.T) {
 	t.Run("update patches existing entity", func(t *testing.T) {
 		status := model.ProjectArchived
 		svc := &projectService{repo: stubProjectRepo{
-			getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+			getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
 				return model.Project{ID: 3, Title: "Old", Description: "Old desc", Status: model.ProjectActive}, nil
 			},
 			saveFn: func(ctx context.Context, project *model.Project) error {
@@ -114,7 +137,7 @@ func TestProjectService(t *testing.T) {
 
 	t.Run("update get error", func(t *testing.T) {
 		svc := &projectService{repo: stubProjectRepo{
-			getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+			getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
 				return model.Project{}, errors.New("boom")
 			},
 		}}
@@ -126,7 +149,7 @@ func TestProjectService(t *testing.T) {
 
 	t.Run("update save error", func(t *testing.T) {
 		svc := &projectService{repo: stubProjectRepo{
-			getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+			getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
 				return model.Project{ID: 1}, nil
 			},
 			saveFn: func(ctx context.Context, project *model.Project) error { return errors.New("save failed") },
@@ -138,13 +161,13 @@ func TestProjectService(t *testing.T) {
 	})
 
 	t.Run("delete delegates", func(t *testing.T) {
-		svc := &projectService{repo: stubProjectRepo{deleteFn: func(ctx context.Context, id string) error {
+		svc := &projectService{repo: stubProjectRepo{deleteFn: func(ctx context.Context, id string, version *uint) error {
 			if id != "9" {
 				t.Fatalf("id = %s", id)
 			}
 			return nil
 		}}}
-		if err := svc.Delete(ctx, "9"); err != nil {
+		if err := svc.Delete(ctx, "9", nil); err != nil {
 			t.Fatalf("Delete error = %v", err)
 		}
 	})
@@ -187,16 +210,71 @@ func TestProjectService(t *testing.T) {
 	})
 
 	t.Run("get error propagates", func(t *testing.T) {
-		svc := &projectService{repo: stubProjectRepo{getFn: func(ctx context.Context, id string, includeTasks bool) (model.Project, error) {
+		svc := &projectService{repo: stubProjectRepo{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
 			return model.Project{}, errors.New("boom")
 		}}}
-		_, err := svc.Get(ctx, "1", false)
+		_, err := svc.Get(ctx, "1", nil)
 		if err == nil || err.Error() != "boom" {
 			t.Fatalf("err = %v", err)
 		}
 	})
 }
 
+func TestProjectServiceArchive(t *testing.T) {
+	ctx := context.Background()
+	stored := model.Project{ID: 3, Title: "API", Status: model.ProjectActive, Version: 2}
+	var saved []model.Project
+	svc := &projectService{repo: stubProjectRepo{
+		getFn: func(ctx context.Context, id string, include []string) (model.Project, error) { return stored, nil },
+		saveFn: func(ctx context.Context, project *model.Project) error {
+			saved = append(saved, *project)
+			return nil
+		},
+	}}
+
+	if _, err := svc.Archive(ctx, "3", 7, ptr(uint(1))); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("stale archive err = %v", err)
+	}
+	project, err := svc.Archive(ctx, "3", 7, ptr(uint(2)))
+	if err != nil || project.Status != model.ProjectArchived || project.ArchivedAt == nil || project.ArchivedBy == nil || *project.ArchivedBy != 7 || len(saved) != 1 {
+		t.Fatalf("archive project = %+v, err = %v", project, err)
+	}
+
+	stored = project
+	if _, err := svc.Archive(ctx, "3", 8, nil); err != nil || len(saved) != 1 {
+		t.Fatalf("archiving again err = %v, saves = %d", err, len(saved))
+	}
+	if _, err := svc.Update(ctx, "3", ProjectUpdateInput{Title: ptr("New")}); !errors.Is(err, ErrProjectArchived) {
+		t.Fatalf("update of archived project err = %v", err)
+	}
+
+	project, err = svc.Unarchive(ctx, "3", nil)
+	if err != nil || project.Status != model.ProjectActive || project.ArchivedAt != nil || project.ArchivedBy != nil || len(saved) != 2 {
+		t.Fatalf("unarchive project = %+v, err = %v", project, err)
+	}
+}
+
+func TestProjectServiceArchiveOnWrite(t *testing.T) {
+	ctx := context.Background()
+	owner := uint(5)
+	svc := &projectService{repo: stubProjectRepo{
+		createFn: func(ctx context.Context, project *model.Project) error { return nil },
+		getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
+			return model.Project{ID: 3, Status: model.ProjectActive}, nil
+		},
+		saveFn: func(ctx context.Context, project *model.Project) error { return nil },
+	}}
+
+	project, err := svc.Create(ctx, ProjectCreateInput{Title: "Old", Status: model.ProjectArchived, OwnerID: &owner})
+	if err != nil || project.ArchivedAt == nil || project.ArchivedBy == nil || *project.ArchivedBy != owner {
+		t.Fatalf("create project = %+v, err = %v", project, err)
+	}
+	project, err = svc.Update(ctx, "3", ProjectUpdateInput{Status: ptr(model.ProjectArchived), UserID: 6})
+	if err != nil || project.ArchivedAt == nil || project.ArchivedBy == nil || *project.ArchivedBy != 6 {
+		t.Fatalf("update project = %+v, err = %v", project, err)
+	}
+}
+
 func TestNewProjectService(t *testing.T) {
 	if svc := NewProjectService(stubProjectRepo{}); svc == nil {
 		t.Fatal("NewProjectService returned nil")
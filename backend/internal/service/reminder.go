@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"project-management/internal/mail"
+	"project-management/internal/model"
+)
+
+var ErrReminderOffsets = errors.New("offsets must be at most 10 distinct minutes between -43200 and 43200")
+
+// DefaultReminderOffsets apply to users who have not chosen their own: a day
+// before the due date and at the due date.
+var DefaultReminderOffsets = []int{24 * 60, 0}
+
+const (
+	// maxReminderOffset bounds offsets, in minutes, to 30 days either way.
+	maxReminderOffset = 30 * 24 * 60
+	maxReminderCount  = 10
+	// reminderGrace is how late a reminder may still fire, for example
+	// after no instance ran for a while. Older reminders are skipped.
+	reminderGrace = 24 * time.Hour
+	// reminderLockKey is the Postgres advisory lock that lets one instance
+	// at a time send reminders.
+	reminderLockKey int64 = 0x72656d696e64 // "remind"
+)
+
+type ReminderPreferenceInput struct {
+	Offsets []int
+	Email   bool
+}
+
+type ReminderService interface {
+	// Preferences returns the user's settings, or the defaults.
+	Preferences(ctx context.Context, userID uint) (model.ReminderPreference, error)
+	SetPreferences(ctx context.Context, userID uint, input ReminderPreferenceInput) (model.ReminderPreference, error)
+	// Run sends the reminders that are due at now and returns how many it
+	// sent. It sends nothing while another instance runs.
+	Run(ctx context.Context, now time.Time) (int, error)
+}
+
+type ReminderRepository interface {
+	// Preferences returns the stored settings of the given users.
+	Preferences(ctx context.Context, userIDs []uint) ([]model.ReminderPreference, error)
+	SavePreference(ctx context.Context, pref *model.ReminderPreference) error
+	// Upcoming returns the open tasks with an assignee that are due between
+	// from and to, with the assignee, leaving out archived projects.
+	Upcoming(ctx context.Context, from, to time.Time) ([]model.Task, error)
+	// Fire records the reminder and, if notification is set, creates it,
+	// unless the reminder was recorded before. It reports whether it was new.
+	Fire(ctx context.Context, sent model.SentReminder, notification *model.Notification) (bool, error)
+	// TryLock runs fn while holding the advisory lock key. It returns false
+	// without running fn when another session holds the lock.
+	TryLock(ctx context.Context, key int64, fn func() error) (bool, error)
+}
+
+type reminderService struct {
+	repo   ReminderRepository
+	mailer mail.Mailer
+}
+
+func NewReminderService(repo ReminderRepository, mailer mail.Mailer) ReminderService {
+	return &reminderService{repo: repo, mailer: mailer}
+}
+
+func (s *reminderService) Preferences(ctx context.Context, userID uint) (model.ReminderPreference, error) {
+	prefs, err := s.repo.Preferences(ctx, []uint{userID})
+	if err != nil {
+		return model.ReminderPreference{}, err
+	}
+	if len(prefs) == 0 {
+		return defaultReminderPreference(userID), nil
+	}
+	return prefs[0], nil
+}
+
+func (s *reminderService) SetPreferences(ctx context.Context, userID uint, input ReminderPreferenceInput) (model.ReminderPreference, error) {
+	offsets := append([]int{}, input.Offsets...)
+	slices.Sort(offsets)
+	offsets = slices.Compact(offsets)
+	if len(offsets) > maxReminderCount {
+		return model.ReminderPreference{}, ErrReminderOffsets
+	}
+	for _, offset := range offsets {
+		if offset < -maxReminderOffset || offset > maxReminderOffset {
+			return model.ReminderPreference{}, ErrReminderOffsets
+		}
+	}
+	slices.Reverse(offsets)
+	pref := model.ReminderPreference{UserID: userID, Offsets: offsets, Email: input.Email}
+	return pref, s.repo.SavePreference(ctx, &pref)
+}
+
+func defaultReminderPreference(userID uint) model.ReminderPreference {
+	return model.ReminderPreference{UserID: userID, Offsets: slices.Clone(DefaultReminderOffsets), Email: true}
+}
+
+func (s *reminderService) Run(ctx context.Context, now time.Time) (int, error) {
+	var sent int
+	_, err := s.repo.TryLock(ctx, reminderLockKey, func() error {
+		var err error
+		sent, err = s.run(ctx, now)
+		return err
+	})
+	return sent, err
+}
+
+func (s *reminderService) run(ctx context.Context, now time.Time) (int, error) {
+	window := time.Duration(maxReminderOffset) * time.Minute
+	tasks, err := s.repo.Upcoming(ctx, now.Add(-window-reminderGrace), now.Add(window))
+	if err != nil || len(tasks) == 0 {
+		return 0, err
+	}
+	var userIDs []uint
+	for _, task := range tasks {
+		if !slices.Contains(userIDs, *task.AssigneeID) {
+			userIDs = append(userIDs, *task.AssigneeID)
+		}
+	}
+	stored, err := s.repo.Preferences(ctx, userIDs)
+	if err != nil {
+		return 0, err
+	}
+	prefs := make(map[uint]model.ReminderPreference, len(stored))
+	for _, pref := range stored {
+		prefs[pref.UserID] = pref
+	}
+
+	sent := 0
+	for _, task := range tasks {
+		pref, ok := prefs[*task.AssigneeID]
+		if !ok {
+			pref = defaultReminderPreference(*task.AssigneeID)
+		}
+		offsets := dueReminders(task, pref.Offsets, now)
+		if len(offsets) == 0 {
+			continue
+		}
+		// When several reminders are due at once only the latest is sent;
+		// the earlier ones are recorded as sent.
+		for i, offset := range offsets {
+			record := model.SentReminder{TaskID: task.ID, UserID: *task.AssigneeID, OffsetMinutes: offset, DueDate: *task.DueDate, SentAt: now}
+			var notification *model.Notification
+			if i == len(offsets)-1 {
+				title, text := reminderText(task, offset)
+				notification = &model.Notification{UserID: *task.AssigneeID, Kind: model.NotificationReminder, TaskID: &task.ID, Title: title, Text: text}
+			}
+			fired, err := s.repo.Fire(ctx, record, notification)
+			if err != nil {
+				return sent, err
+			}
+			if !fired || notification == nil {
+				continue
+			}
+			sent++
+			if pref.Email && task.Assignee != nil && task.Assignee.Email != "" && s.mailer != nil {
+				msg := mail.Message{To: task.Assignee.Email, Subject: notification.Title, Text: notification.Text}
+				if err := s.mailer.Send(ctx, msg); err != nil {
+					log.Printf("reminder mail for task %d failed: %v", task.ID, err)
+				}
+			}
+		}
+	}
+	return sent, nil
+}
+
+// dueReminders returns the offsets whose reminder time has come, in the
+// order they fall due. Reminders before the task was created, or more than
+// reminderGrace ago, are left out.
+func dueReminders(task model.Task, offsets []int, now time.Time) []int {
+	var due []int
+	for _, offset := range offsets {
+		at := task.DueDate.Add(-time.Duration(offset) * time.Minute)
+		if at.After(now) || at.Before(now.Add(-reminderGrace)) || at.Before(task.CreatedAt) {
+			continue
+		}
+		due = append(due, offset)
+	}
+	slices.SortFunc(due, func(a, b int) int { return b - a })
+	return due
+}
+
+func reminderText(task model.Task, offset int) (string, string) {
+	due := task.DueDate.UTC().Format("Mon, 02 Jan 2006 15:04 MST")
+	var when string
+	switch {
+	case offset > 0:
+		when = "is due in " + minutesText(offset)
+	case offset == 0:
+		when = "is due now"
+	default:
+		when = "is overdue by " + minutesText(-offset)
+	}
+	return fmt.Sprintf("%q %s", task.Title, when), fmt.Sprintf("Task #%d %q %s (%s).", task.ID, task.Title, when, due)
+}
+
+// minutesText writes a number of minutes in the largest whole unit.
+func minutesText(minutes int) string {
+	unit, n := "minute", minutes
+	switch {
+	case minutes%(24*60) == 0:
+		unit, n = "day", minutes/(24*60)
+	case minutes%60 == 0:
+		unit, n = "hour", minutes/60
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
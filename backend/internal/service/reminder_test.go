@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"project-management/internal/mail"
+	"project-management/internal/model"
+)
+
+type stubReminderRepo struct {
+	tasks  []model.Task
+	prefs  []model.ReminderPreference
+	sent   map[string]bool
+	notes  []model.Notification
+	locked bool
+}
+
+func (s *stubReminderRepo) Preferences(ctx context.Context, userIDs []uint) ([]model.ReminderPreference, error) {
+	var out []model.ReminderPreference
+	for _, pref := range s.prefs {
+		if slices.Contains(userIDs, pref.UserID) {
+			out = append(out, pref)
+		}
+	}
+	return out, nil
+}
+func (s *stubReminderRepo) SavePreference(ctx context.Context, pref *model.ReminderPreference) error {
+	s.prefs = append(s.prefs, *pref)
+	return nil
+}
+func (s *stubReminderRepo) Upcoming(ctx context.Context, from, to time.Time) ([]model.Task, error) {
+	var out []model.Task
+	for _, task := range s.tasks {
+		if !task.DueDate.Before(from) && !task.DueDate.After(to) {
+			out = append(out, task)
+		}
+	}
+	return out, nil
+}
+func (s *stubReminderRepo) Fire(ctx context.Context, sent model.SentReminder, notification *model.Notification) (bool, error) {
+	key := fmt.Sprintf("%d/%d/%d/%s", sent.TaskID, sent.UserID, sent.OffsetMinutes, sent.DueDate)
+	if s.sent[key] {
+		return false, nil
+	}
+	s.sent[key] = true
+	if notification != nil {
+		s.notes = append(s.notes, *notification)
+	}
+	return true, nil
+}
+func (s *stubReminderRepo) TryLock(ctx context.Context, key int64, fn func() error) (bool, error) {
+	if s.locked {
+		return false, nil
+	}
+	return true, fn()
+}
+
+type stubMailer struct{ sent []mail.Message }
+
+func (m *stubMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestReminderServiceRun(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	ann, bob := uint(1), uint(2)
+	task := func(id uint, assignee *uint, due, created time.Duration) model.Task {
+		d := now.Add(due)
+		return model.Task{ID: id, Title: fmt.Sprintf("Task %d", id), AssigneeID: assignee, DueDate: &d, CreatedAt: now.Add(created),
+			Assignee: &model.User{ID: *assignee, Email: fmt.Sprintf("user%d@example.com", *assignee)}}
+	}
+	repo := &stubReminderRepo{
+		tasks: []model.Task{
+			// The day-before reminder came an hour ago.
+			task(1, &ann, 23*time.Hour, -72*time.Hour),
+			// Both of Bob's reminders are due; only the later one is sent.
+			task(2, &bob, -time.Minute, -72*time.Hour),
+			// Created after its day-before reminder, which is skipped.
+			task(3, &ann, 2*time.Hour, -time.Hour),
+			// Overdue by more than the grace period.
+			task(4, &ann, -48*time.Hour, -96*time.Hour),
+		},
+		prefs: []model.ReminderPreference{{UserID: bob, Offsets: []int{60, 0}, Email: false}},
+		sent:  map[string]bool{},
+	}
+	mailer := &stubMailer{}
+	s := NewReminderService(repo, mailer)
+
+	n, err := s.Run(ctx, now)
+	if err != nil || n != 2 {
+		t.Fatalf("Run = %d, %v", n, err)
+	}
+	if len(repo.notes) != 2 || *repo.notes[0].TaskID != 1 || repo.notes[0].Title != `"Task 1" is due in 1 day` || repo.notes[0].UserID != ann ||
+		*repo.notes[1].TaskID != 2 || repo.notes[1].Title != `"Task 2" is due now` {
+		t.Fatalf("notifications = %+v", repo.notes)
+	}
+	if len(repo.sent) != 3 {
+		t.Fatalf("recorded = %v", repo.sent)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "user1@example.com" {
+		t.Fatalf("mails = %+v", mailer.sent)
+	}
+
+	if n, err := s.Run(ctx, now.Add(time.Minute)); err != nil || n != 0 {
+		t.Fatalf("second Run = %d, %v", n, err)
+	}
+	// Two hours later task 3 is due.
+	if n, _ := s.Run(ctx, now.Add(2*time.Hour)); n != 1 || *repo.notes[2].TaskID != 3 {
+		t.Fatalf("later Run = %d, %+v", n, repo.notes)
+	}
+
+	repo.locked = true
+	if n, err := s.Run(ctx, now.Add(24*time.Hour)); err != nil || n != 0 {
+		t.Fatalf("locked Run = %d, %v", n, err)
+	}
+}
+
+func TestReminderServicePreferences(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubReminderRepo{}
+	s := NewReminderService(repo, nil)
+
+	pref, err := s.Preferences(ctx, 5)
+	if err != nil || !slices.Equal(pref.Offsets, DefaultReminderOffsets) || !pref.Email {
+		t.Fatalf("default = %+v, %v", pref, err)
+	}
+	pref, err = s.SetPreferences(ctx, 5, ReminderPreferenceInput{Offsets: []int{0, 60, -1440, 60}})
+	if err != nil || !slices.Equal(pref.Offsets, []int{60, 0, -1440}) || pref.Email {
+		t.Fatalf("SetPreferences = %+v, %v", pref, err)
+	}
+	if pref, _ := s.Preferences(ctx, 5); !slices.Equal(pref.Offsets, []int{60, 0, -1440}) {
+		t.Fatalf("stored = %+v", pref)
+	}
+	if pref, _ := s.SetPreferences(ctx, 6, ReminderPreferenceInput{}); pref.Offsets == nil {
+		t.Fatal("no offsets were stored as null")
+	}
+	for _, offsets := range [][]int{{maxReminderOffset + 1}, {1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}} {
+		if _, err := s.SetPreferences(ctx, 5, ReminderPreferenceInput{Offsets: offsets}); !errors.Is(err, ErrReminderOffsets) {
+			t.Fatalf("SetPreferences(%v) = %v", offsets, err)
+		}
+	}
+}
+
+func TestMinutesText(t *testing.T) {
+	for minutes, want := range map[int]string{1: "1 minute", 90: "90 minutes", 120: "2 hours", 1440: "1 day", 2880: "2 days"} {
+		if got := minutesText(minutes); got != want {
+			t.Fatalf("minutesText(%d) = %q, want %q", minutes, got, want)
+		}
+	}
+}
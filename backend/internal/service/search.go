@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+)
+
+var (
+	ErrSearchQuery = errors.New("q must contain at least one word")
+	ErrSearchType  = errors.New("type must be a comma-separated list of project, task, comment")
+)
+
+const (
+	SearchProject = "project"
+	SearchTask    = "task"
+	SearchComment = "comment"
+)
+
+// Repositories wrap matched words in snippets with these markers; the service
+// escapes the text and turns them into <mark> tags.
+const (
+	SearchMarkStart = "\x01"
+	SearchMarkStop  = "\x02"
+)
+
+type SearchFilter struct {
+	Params httpx.ListParams
+	Query  string
+	Types  []string
+	UserID uint
+}
+
+type SearchService interface {
+	Search(ctx context.Context, filter SearchFilter) ([]model.SearchHit, int64, error)
+}
+
+type SearchRepository interface {
+	Search(ctx context.Context, filter SearchFilter) ([]model.SearchHit, int64, error)
+}
+
+type searchService struct{ repo SearchRepository }
+
+func NewSearchService(repo SearchRepository) SearchService { return &searchService{repo: repo} }
+
+func (s *searchService) Search(ctx context.Context, filter SearchFilter) ([]model.SearchHit, int64, error) {
+	if PrefixQuery(filter.Query) == "" {
+		return nil, 0, ErrSearchQuery
+	}
+	for _, t := range filter.Types {
+		if t != SearchProject && t != SearchTask && t != SearchComment {
+			return nil, 0, ErrSearchType
+		}
+	}
+	items, total, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range items {
+		items[i].Snippet = highlight(items[i].Snippet)
+	}
+	return items, total, nil
+}
+
+// PrefixQuery turns free text into a tsquery that matches every word as a
+// prefix ("api doc" -> "api:* & doc:*"). Anything but letters and digits is
+// dropped, so the result is always valid tsquery syntax; it is empty when
+// there is nothing to search for.
+func PrefixQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+func highlight(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, SearchMarkStart, "<mark>")
+	return strings.ReplaceAll(snippet, SearchMarkStop, "</mark>")
+}
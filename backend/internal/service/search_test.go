@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-management/internal/model"
+)
+
+type stubSearchRepo struct {
+	searchFn func(ctx context.Context, filter SearchFilter) ([]model.SearchHit, int64, error)
+}
+
+func (s stubSearchRepo) Search(ctx context.Context, filter SearchFilter) ([]model.SearchHit, int64, error) {
+	return s.searchFn(ctx, filter)
+}
+
+func TestPrefixQuery(t *testing.T) {
+	tests := map[string]string{
+		"API docs":          "api:* & docs:*",
+		"  фронт-енд ":      "фронт:* & енд:*",
+		"a & b | !c:* (d)'": "a:* & b:* & c:* & d:*",
+		"!!! ...":           "",
+	}
+	for in, want := range tests {
+		if got := PrefixQuery(in); got != want {
+			t.Fatalf("PrefixQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchService(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSearchService(stubSearchRepo{searchFn: func(ctx context.Context, filter SearchFilter) ([]model.SearchHit, int64, error) {
+		if filter.UserID != 4 || filter.Query != "deploy" {
+			t.Fatalf("filter = %+v", filter)
+		}
+		return []model.SearchHit{{Type: SearchTask, ID: 1, Snippet: "<b>" + SearchMarkStart + "deploy" + SearchMarkStop + "</b> now"}}, 1, nil
+	}})
+
+	items, total, err := svc.Search(ctx, SearchFilter{Query: "deploy", Types: []string{SearchTask}, UserID: 4})
+	if err != nil || total != 1 {
+		t.Fatalf("total=%d err=%v", total, err)
+	}
+	if items[0].Snippet != "&lt;b&gt;<mark>deploy</mark>&lt;/b&gt; now" {
+		t.Fatalf("snippet = %q", items[0].Snippet)
+	}
+
+	if _, _, err := svc.Search(ctx, SearchFilter{Query: " - "}); !errors.Is(err, ErrSearchQuery) {
+		t.Fatalf("err = %v, want ErrSearchQuery", err)
+	}
+	if _, _, err := svc.Search(ctx, SearchFilter{Query: "deploy", Types: []string{"user"}}); !errors.Is(err, ErrSearchType) {
+		t.Fatalf("err = %v, want ErrSearchType", err)
+	}
+}
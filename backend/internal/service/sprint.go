@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSprintDates    = errors.New("endDate must not be before startDate")
+	ErrSprintActive   = errors.New("the project already has an active sprint")
+	ErrSprintStart    = errors.New("only a planned sprint can start")
+	ErrSprintClose    = errors.New("only an active sprint can close")
+	ErrSprintClosed   = errors.New("a closed sprint cannot change")
+	ErrSprintState    = errors.New("state must be planned, active or closed")
+	ErrInvalidSprint  = errors.New("sprintId must be an open sprint of the task's project")
+	ErrSprintMoveBoth = errors.New("give either sprintId or backlog, not both")
+)
+
+// SprintInput names and dates a sprint. The dates are days; their time of
+// day is dropped.
+type SprintInput struct {
+	Name      string
+	Goal      string
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// SprintCloseInput says where the unfinished tasks of a closing sprint go:
+// to SprintID, a planned sprint of the project, or with Backlog to the
+// backlog. By default they go to the planned sprint that starts next, or to
+// the backlog when there is none.
+type SprintCloseInput struct {
+	SprintID *uint
+	Backlog  bool
+}
+
+type SprintService interface {
+	// List returns a page of the project's sprints, by start date unless
+	// params sort them otherwise, those in state only when it is set.
+	List(ctx context.Context, projectID string, state string, params httpx.ListParams) ([]model.Sprint, int64, error)
+	Create(ctx context.Context, projectID string, input SprintInput) (model.Sprint, error)
+	Get(ctx context.Context, id string) (model.Sprint, error)
+	Update(ctx context.Context, id string, input SprintInput) (model.Sprint, error)
+	// Delete deletes the sprint; its tasks go to the backlog.
+	Delete(ctx context.Context, id string) error
+	Start(ctx context.Context, id string) (model.Sprint, error)
+	// Close closes an active sprint, snapshots its scope and moves its
+	// unfinished tasks on.
+	Close(ctx context.Context, id string, input SprintCloseInput) (model.Sprint, error)
+}
+
+type SprintRepository interface {
+	// Project returns a project that is not in the trash.
+	Project(ctx context.Context, id string) (model.Project, error)
+	List(ctx context.Context, projectID uint, state model.SprintState, params httpx.ListParams) ([]model.Sprint, int64, error)
+	Create(ctx context.Context, sprint *model.Sprint) error
+	Get(ctx context.Context, id string) (model.Sprint, error)
+	Save(ctx context.Context, sprint *model.Sprint) error
+	Delete(ctx context.Context, sprint model.Sprint) error
+	// Active returns the project's active sprint, or gorm.ErrRecordNotFound.
+	Active(ctx context.Context, projectID uint) (model.Sprint, error)
+	// Next returns the project's planned sprint that starts first, or
+	// gorm.ErrRecordNotFound.
+	Next(ctx context.Context, projectID uint) (model.Sprint, error)
+	// Close locks the sprint's tasks, those in the trash too, stores
+	// snapshot(tasks) as the closed sprint's scope and moves the unfinished
+	// ones to moveTo, or to the backlog when it is nil, in one transaction.
+	// It returns ErrSprintClose when the sprint is no longer active.
+	Close(ctx context.Context, sprint *model.Sprint, moveTo *uint, snapshot func(tasks []model.Task) *model.SprintScope) error
+}
+
+type sprintService struct {
+	repo SprintRepository
+	now  func() time.Time
+}
+
+func NewSprintService(repo SprintRepository) SprintService {
+	return &sprintService{repo: repo, now: time.Now}
+}
+
+func (s *sprintService) List(ctx context.Context, projectID string, state string, params httpx.ListParams) ([]model.Sprint, int64, error) {
+	st := model.SprintState(strings.TrimSpace(state))
+	switch st {
+	case "", model.SprintPlanned, model.SprintActive, model.SprintClosed:
+	default:
+		return nil, 0, ErrSprintState
+	}
+	project, err := s.repo.Project(ctx, projectID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(ctx, project.ID, st, params)
+}
+
+func (s *sprintService) Create(ctx context.Context, projectID string, input SprintInput) (model.Sprint, error) {
+	project, err := s.repo.Project(ctx, projectID)
+	if err != nil {
+		return model.Sprint{}, err
+	}
+	sprint := model.Sprint{ProjectID: project.ID, State: model.SprintPlanned}
+	if err := setSprint(&sprint, input); err != nil {
+		return model.Sprint{}, err
+	}
+	return sprint, s.repo.Create(ctx, &sprint)
+}
+
+func (s *sprintService) Get(ctx context.Context, id string) (model.Sprint, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *sprintService) Update(ctx context.Context, id string, input SprintInput) (model.Sprint, error) {
+	sprint, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Sprint{}, err
+	}
+	if sprint.State == model.SprintClosed {
+		return model.Sprint{}, ErrSprintClosed
+	}
+	if err := setSprint(&sprint, input); err != nil {
+		return model.Sprint{}, err
+	}
+	return sprint, s.repo.Save(ctx, &sprint)
+}
+
+func setSprint(sprint *model.Sprint, input SprintInput) error {
+	start, end := dateOf(input.StartDate), dateOf(input.EndDate)
+	if end.Before(start) {
+		return ErrSprintDates
+	}
+	sprint.Name = strings.TrimSpace(input.Name)
+	sprint.Goal = input.Goal
+	sprint.StartDate, sprint.EndDate = start, end
+	return nil
+}
+
+// dateOf returns midnight UTC of t's day in UTC.
+func dateOf(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func (s *sprintService) Delete(ctx context.Context, id string) error {
+	sprint, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, sprint)
+}
+
+func (s *sprintService) Start(ctx context.Context, id string) (model.Sprint, error) {
+	sprint, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Sprint{}, err
+	}
+	if sprint.State != model.SprintPlanned {
+		return model.Sprint{}, ErrSprintStart
+	}
+	if _, err := s.repo.Active(ctx, sprint.ProjectID); err == nil {
+		return model.Sprint{}, ErrSprintActive
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Sprint{}, err
+	}
+	sprint.State = model.SprintActive
+	return sprint, s.repo.Save(ctx, &sprint)
+}
+
+func (s *sprintService) Close(ctx context.Context, id string, input SprintCloseInput) (model.Sprint, error) {
+	if input.SprintID != nil && input.Backlog {
+		return model.Sprint{}, ErrSprintMoveBoth
+	}
+	sprint, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Sprint{}, err
+	}
+	if sprint.State != model.SprintActive {
+		return model.Sprint{}, ErrSprintClose
+	}
+	moveTo, err := s.closeTarget(ctx, sprint, input)
+	if err != nil {
+		return model.Sprint{}, err
+	}
+	now := s.now()
+	sprint.State = model.SprintClosed
+	sprint.ClosedAt = &now
+	snapshot := func(tasks []model.Task) *model.SprintScope {
+		scope := model.SprintScope{Total: len(tasks), MovedTo: moveTo, Tasks: make([]model.SprintScopeTask, len(tasks))}
+		for i, task := range tasks {
+			scope.Tasks[i] = model.SprintScopeTask{ID: task.ID, Title: task.Title, Status: task.Status, AssigneeID: task.AssigneeID}
+			if task.Status == model.TaskDone {
+				scope.Done++
+			} else {
+				scope.Moved++
+			}
+		}
+		return &scope
+	}
+	if err := s.repo.Close(ctx, &sprint, moveTo, snapshot); err != nil {
+		return model.Sprint{}, err
+	}
+	return sprint, nil
+}
+
+// closeTarget returns the sprint that takes the unfinished tasks of sprint,
+// nil for the backlog.
+func (s *sprintService) closeTarget(ctx context.Context, sprint model.Sprint, input SprintCloseInput) (*uint, error) {
+	switch {
+	case input.Backlog:
+		return nil, nil
+	case input.SprintID != nil:
+		next, err := s.repo.Get(ctx, strconv.FormatUint(uint64(*input.SprintID), 10))
+		if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && (next.ProjectID != sprint.ProjectID || next.State != model.SprintPlanned) {
+			return nil, ErrInvalidSprint
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &next.ID, nil
+	}
+	next, err := s.repo.Next(ctx, sprint.ProjectID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &next.ID, nil
+}
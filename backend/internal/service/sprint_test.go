@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type stubSprintRepo struct {
+	sprints []model.Sprint
+	tasks   []model.Task
+}
+
+func (s *stubSprintRepo) Project(ctx context.Context, id string) (model.Project, error) {
+	if id != "2" {
+		return model.Project{}, gorm.ErrRecordNotFound
+	}
+	return model.Project{ID: 2}, nil
+}
+func (s *stubSprintRepo) List(ctx context.Context, projectID uint, state model.SprintState, params httpx.ListParams) ([]model.Sprint, int64, error) {
+	var out []model.Sprint
+	for _, sprint := range s.sprints {
+		if sprint.ProjectID == projectID && (state == "" || sprint.State == state) {
+			out = append(out, sprint)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+func (s *stubSprintRepo) Create(ctx context.Context, sprint *model.Sprint) error {
+	sprint.ID = uint(len(s.sprints) + 1)
+	s.sprints = append(s.sprints, *sprint)
+	return nil
+}
+func (s *stubSprintRepo) Get(ctx context.Context, id string) (model.Sprint, error) {
+	for _, sprint := range s.sprints {
+		if strconv.Itoa(int(sprint.ID)) == id {
+			return sprint, nil
+		}
+	}
+	return model.Sprint{}, gorm.ErrRecordNotFound
+}
+func (s *stubSprintRepo) Save(ctx context.Context, sprint *model.Sprint) error {
+	s.sprints[sprint.ID-1] = *sprint
+	return nil
+}
+func (s *stubSprintRepo) Delete(ctx context.Context, sprint model.Sprint) error { panic("not used") }
+func (s *stubSprintRepo) Active(ctx context.Context, projectID uint) (model.Sprint, error) {
+	for _, sprint := range s.sprints {
+		if sprint.ProjectID == projectID && sprint.State == model.SprintActive {
+			return sprint, nil
+		}
+	}
+	return model.Sprint{}, gorm.ErrRecordNotFound
+}
+func (s *stubSprintRepo) Next(ctx context.Context, projectID uint) (model.Sprint, error) {
+	var next *model.Sprint
+	for i, sprint := range s.sprints {
+		if sprint.ProjectID == projectID && sprint.State == model.SprintPlanned && (next == nil || sprint.StartDate.Before(next.StartDate)) {
+			next = &s.sprints[i]
+		}
+	}
+	if next == nil {
+		return model.Sprint{}, gorm.ErrRecordNotFound
+	}
+	return *next, nil
+}
+func (s *stubSprintRepo) Close(ctx context.Context, sprint *model.Sprint, moveTo *uint, snapshot func(tasks []model.Task) *model.SprintScope) error {
+	if s.sprints[sprint.ID-1].State != model.SprintActive {
+		return ErrSprintClose
+	}
+	var tasks []model.Task
+	for _, task := range s.tasks {
+		if task.SprintID != nil && *task.SprintID == sprint.ID {
+			tasks = append(tasks, task)
+		}
+	}
+	sprint.Scope = snapshot(tasks)
+	s.sprints[sprint.ID-1] = *sprint
+	for i, task := range s.tasks {
+		if task.SprintID != nil && *task.SprintID == sprint.ID && task.Status != model.TaskDone {
+			s.tasks[i].SprintID = moveTo
+		}
+	}
+	return nil
+}
+
+func TestSprintServiceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubSprintRepo{}
+	s := NewSprintService(repo)
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 15, 30, 0, 0, time.UTC) }
+
+	one, err := s.Create(ctx, "2", SprintInput{Name: " Sprint 1 ", StartDate: day(5), EndDate: day(16)})
+	if err != nil || one.Name != "Sprint 1" || one.State != model.SprintPlanned || !one.StartDate.Equal(time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Create = %+v, %v", one, err)
+	}
+	if _, err := s.Create(ctx, "2", SprintInput{Name: "Backwards", StartDate: day(16), EndDate: day(5)}); !errors.Is(err, ErrSprintDates) {
+		t.Fatalf("reversed dates = %v", err)
+	}
+	if _, err := s.Create(ctx, "9", SprintInput{Name: "Nowhere", StartDate: day(5), EndDate: day(5)}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("unknown project = %v", err)
+	}
+	// Sprint 3 is planned to start before sprint 2, so it is next.
+	two, _ := s.Create(ctx, "2", SprintInput{Name: "Sprint 2", StartDate: day(26), EndDate: day(30)})
+	three, _ := s.Create(ctx, "2", SprintInput{Name: "Sprint 3", StartDate: day(19), EndDate: day(23)})
+
+	if _, err := s.Start(ctx, toID(one.ID)); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := s.Start(ctx, toID(two.ID)); !errors.Is(err, ErrSprintActive) {
+		t.Fatalf("second active sprint = %v", err)
+	}
+	if _, err := s.Start(ctx, toID(one.ID)); !errors.Is(err, ErrSprintStart) {
+		t.Fatalf("restart = %v", err)
+	}
+	if active, total, _ := s.List(ctx, "2", "active", httpx.ListParams{Page: 1, PageSize: 20}); len(active) != 1 || total != 1 || active[0].ID != one.ID {
+		t.Fatalf("active sprints = %+v", active)
+	}
+	if _, _, err := s.List(ctx, "2", "done", httpx.ListParams{}); !errors.Is(err, ErrSprintState) {
+		t.Fatalf("List with bad state = %v", err)
+	}
+
+	repo.tasks = []model.Task{
+		{ID: 1, Title: "Done", Status: model.TaskDone, SprintID: &one.ID},
+		{ID: 2, Title: "Doing", Status: model.TaskInProgress, SprintID: &one.ID},
+		{ID: 3, Title: "Todo", Status: model.TaskTodo, SprintID: &one.ID},
+		{ID: 4, Title: "Elsewhere", Status: model.TaskTodo, SprintID: &two.ID},
+	}
+	if _, err := s.Close(ctx, toID(two.ID), SprintCloseInput{}); !errors.Is(err, ErrSprintClose) {
+		t.Fatalf("close planned sprint = %v", err)
+	}
+	if _, err := s.Close(ctx, toID(one.ID), SprintCloseInput{SprintID: &one.ID}); !errors.Is(err, ErrInvalidSprint) {
+		t.Fatalf("move to itself = %v", err)
+	}
+	if _, err := s.Close(ctx, toID(one.ID), SprintCloseInput{SprintID: &two.ID, Backlog: true}); !errors.Is(err, ErrSprintMoveBoth) {
+		t.Fatalf("move to both = %v", err)
+	}
+	closed, err := s.Close(ctx, toID(one.ID), SprintCloseInput{})
+	if err != nil || closed.State != model.SprintClosed || closed.ClosedAt == nil {
+		t.Fatalf("Close = %+v, %v", closed, err)
+	}
+	scope := closed.Scope
+	if scope.Total != 3 || scope.Done != 1 || scope.Moved != 2 || *scope.MovedTo != three.ID || scope.Tasks[1].Status != model.TaskInProgress {
+		t.Fatalf("scope = %+v", scope)
+	}
+	var moved []uint
+	for _, task := range repo.tasks {
+		if task.SprintID != nil && *task.SprintID == three.ID {
+			moved = append(moved, task.ID)
+		}
+	}
+	if !slices.Equal(moved, []uint{2, 3}) || *repo.tasks[0].SprintID != one.ID {
+		t.Fatalf("tasks = %+v", repo.tasks)
+	}
+	if _, err := s.Update(ctx, toID(one.ID), SprintInput{Name: "Renamed", StartDate: day(5), EndDate: day(16)}); !errors.Is(err, ErrSprintClosed) {
+		t.Fatalf("update closed sprint = %v", err)
+	}
+
+	// Without a planned sprint left, unfinished tasks go to the backlog.
+	s.Start(ctx, toID(three.ID))
+	if closed, err := s.Close(ctx, toID(three.ID), SprintCloseInput{Backlog: true}); err != nil || closed.Scope.MovedTo != nil {
+		t.Fatalf("Close to backlog = %+v, %v", closed, err)
+	}
+	if repo.tasks[1].SprintID != nil {
+		t.Fatalf("task 2 = %+v", repo.tasks[1])
+	}
+}
+
+type stubMilestoneRepo struct{ milestones []model.Milestone }
+
+func (s *stubMilestoneRepo) Project(ctx context.Context, id string) (model.Project, error) {
+	return model.Project{ID: 2}, nil
+}
+func (s *stubMilestoneRepo) List(ctx context.Context, projectID uint, params httpx.ListParams) ([]model.Milestone, int64, error) {
+	return s.milestones, int64(len(s.milestones)), nil
+}
+func (s *stubMilestoneRepo) Create(ctx context.Context, milestone *model.Milestone) error {
+	milestone.ID = uint(len(s.milestones) + 1)
+	s.milestones = append(s.milestones, *milestone)
+	return nil
+}
+func (s *stubMilestoneRepo) Get(ctx context.Context, id string) (model.Milestone, error) {
+	if id != "1" {
+		return model.Milestone{}, gorm.ErrRecordNotFound
+	}
+	return s.milestones[0], nil
+}
+func (s *stubMilestoneRepo) Save(ctx context.Context, milestone *model.Milestone) error {
+	s.milestones[milestone.ID-1] = *milestone
+	return nil
+}
+func (s *stubMilestoneRepo) Delete(ctx context.Context, milestone model.Milestone) error {
+	panic("not used")
+}
+
+func TestMilestoneServiceDropsTimeOfDay(t *testing.T) {
+	ctx := context.Background()
+	s := NewMilestoneService(&stubMilestoneRepo{})
+	target := time.Date(2026, 12, 1, 18, 0, 0, 0, time.FixedZone("", -5*3600))
+
+	milestone, err := s.Create(ctx, "2", MilestoneInput{Name: " Beta ", TargetDate: target})
+	if err != nil || milestone.Name != "Beta" || milestone.ProjectID != 2 || !milestone.TargetDate.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Create = %+v, %v", milestone, err)
+	}
+	updated, err := s.Update(ctx, "1", MilestoneInput{Name: "GA", TargetDate: target.AddDate(0, 1, 0)})
+	if err != nil || updated.Name != "GA" || updated.TargetDate.Month() != time.January {
+		t.Fatalf("Update = %+v, %v", updated, err)
+	}
+	if _, err := s.Update(ctx, "2", MilestoneInput{Name: "GA"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Update unknown = %v", err)
+	}
+}
+
+func toID(id uint) string { return strconv.FormatUint(uint64(id), 10) }
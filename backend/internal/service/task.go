@@ -2,20 +2,74 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"project-management/internal/filter"
 	"project-management/internal/httpx"
+	"project-management/internal/markdown"
 	"project-management/internal/model"
+	"project-management/internal/recurrence"
+)
+
+var (
+	ErrInvalidLabel      = errors.New("labels must be 1 to 64 characters")
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+	ErrRecurrenceDueDate = errors.New("a recurring task needs a dueDate")
 )
 
 type TaskListFilter struct {
-	Params          httpx.ListParams
-	ProjectID       string
-	Status          string
-	AssigneeID      string
-	DueFrom         string
-	DueTo           string
-	IncludeComments bool
+	Params     httpx.ListParams
+	ProjectID  string
+	Status     string
+	AssigneeID string
+	DueFrom    string
+	DueTo      string
+	Include    []string // ?include= paths, checked against TaskRelations
+
+	// SprintID and MilestoneID narrow the list to a sprint or milestone;
+	// TaskUnplanned selects the tasks without one, such as the backlog.
+	SprintID    string
+	MilestoneID string
+
+	// IncludeArchived keeps the tasks of archived projects, which are left
+	// out unless ProjectID names one.
+	IncludeArchived bool
+
+	// Filter is a ?filter= expression; "me" in it refers to UserID. The
+	// service compiles it into Where for the repository.
+	Filter string
+	UserID uint
+	Where  *filter.Condition
+}
+
+// TaskUnplanned as TaskListFilter.SprintID or MilestoneID selects the tasks
+// without a sprint or milestone.
+const TaskUnplanned = "none"
+
+// TaskRelations are the relations ?include= can load with a task.
+var TaskRelations = httpx.Relations{"comments": nil, "assignee": nil, "labels": nil}
+
+// TaskFilterFields is the allowlist for ?filter= on task lists.
+var TaskFilterFields = filter.Fields{
+	"project":  {Type: filter.Int, Column: "tasks.project_id"},
+	"status":   {Type: filter.Enum, Column: "tasks.status", Values: []string{string(model.TaskTodo), string(model.TaskInProgress), string(model.TaskDone)}},
+	"assignee": {Type: filter.Int, Column: "tasks.assignee_id", Nullable: true, Me: true},
+	"due":      {Type: filter.Date, Column: "tasks.due_date", Nullable: true},
+	"created":  {Type: filter.Date, Column: "tasks.created_at"},
+	"title":    {Type: filter.String, Column: "tasks.title"},
+	"series":   {Type: filter.Int, Column: "tasks.series_id", Nullable: true},
+	"label": {
+		Type:     filter.String,
+		Column:   "l.name",
+		Exists:   "SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id WHERE tl.task_id = tasks.id",
+		Nullable: true,
+	},
+	"sprint":    {Type: filter.Int, Column: "tasks.sprint_id", Nullable: true},
+	"milestone": {Type: filter.Int, Column: "tasks.milestone_id", Nullable: true},
 }
 
 type TaskCreateInput struct {
@@ -25,6 +79,10 @@ type TaskCreateInput struct {
 	Status      model.TaskStatus
 	AssigneeID  *uint
 	DueDate     *time.Time
+	Labels      []string
+	Recurrence  string
+	SprintID    *uint
+	MilestoneID *uint
 }
 
 type TaskUpdateInput struct {
@@ -33,6 +91,11 @@ type TaskUpdateInput struct {
 	Status      *model.TaskStatus
 	AssigneeID  **uint
 	DueDate     **time.Time
+	Labels      *[]string
+	SprintID    **uint
+	MilestoneID **uint
+	Recurrence  *string // "" stops the series
+	Version     *uint   // version the caller read (If-Match); nil skips the check
 }
 
 type TaskCommentListFilter struct {
@@ -49,46 +112,124 @@ type TaskCommentCreateInput struct {
 type TaskService interface {
 	List(ctx context.Context, filter TaskListFilter) ([]model.Task, int64, error)
 	Create(ctx context.Context, input TaskCreateInput) (model.Task, error)
-	Get(ctx context.Context, id string, includeComments bool) (model.Task, error)
+	Get(ctx context.Context, id string, include []string) (model.Task, error)
 	Update(ctx context.Context, id string, input TaskUpdateInput) (model.Task, error)
-	Delete(ctx context.Context, id string) error
+	// Delete removes the task; a non-nil version must match the current one.
+	Delete(ctx context.Context, id string, version *uint) error
 	ListComments(ctx context.Context, taskID string, filter TaskCommentListFilter) ([]model.Comment, int64, error)
 	CreateComment(ctx context.Context, input TaskCommentCreateInput) (model.Comment, error)
+	Bulk(ctx context.Context, input TaskBulkInput) (TaskBulkReport, error)
+	// Watch subscribes the user to the task; Unwatch ends it. Both are
+	// idempotent.
+	Watch(ctx context.Context, id string, userID uint) error
+	Unwatch(ctx context.Context, id string, userID uint) error
 }
 
 type TaskRepository interface {
 	List(ctx context.Context, filter TaskListFilter) ([]model.Task, int64, error)
 	Create(ctx context.Context, task *model.Task) error
-	Get(ctx context.Context, id string, includeComments bool) (model.Task, error)
+	Get(ctx context.Context, id string, include []string) (model.Task, error)
+	// Save writes the task and its labels if its version is unchanged in the
+	// database and increments it, or returns ErrVersionMismatch.
 	Save(ctx context.Context, task *model.Task) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, version *uint) error
 	ListComments(ctx context.Context, taskID string, filter TaskCommentListFilter) ([]model.Comment, int64, error)
 	CreateComment(ctx context.Context, comment *model.Comment) error
+	// Select returns up to limit tasks of sel with their labels, by ID.
+	Select(ctx context.Context, sel TaskSelection, limit int) ([]model.Task, error)
+	ProjectExists(ctx context.Context, projectID uint) (bool, error)
+	// ApplyBulk saves and deletes the given tasks in one transaction, each
+	// only at the version it was read with, and creates the next occurrences
+	// of completed recurring tasks, keyed by the completed task's ID, like
+	// Complete. A failure rolls back everything and is returned as a
+	// *TaskBulkError.
+	ApplyBulk(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error
+	// Complete saves the task like Save and creates next, its next
+	// occurrence, with the task's watchers in the same transaction.
+	Complete(ctx context.Context, task *model.Task, next *model.Task) error
+	Watch(ctx context.Context, taskID, userID uint) error
+	Unwatch(ctx context.Context, taskID, userID uint) error
+	// LinkableTasks returns those of ids that are tasks of the project and
+	// not in the trash.
+	LinkableTasks(ctx context.Context, projectID uint, ids []uint) ([]uint, error)
 }
 
-type taskService struct{ repo TaskRepository }
+type taskService struct {
+	repo  TaskRepository
+	blobs BlobCleaner
+}
 
 func NewTaskService(repo TaskRepository) TaskService { return &taskService{repo: repo} }
-func (s *taskService) List(ctx context.Context, filter TaskListFilter) ([]model.Task, int64, error) {
-	return s.repo.List(ctx, filter)
+
+func NewTaskServiceWithDeps(repo TaskRepository, blobs BlobCleaner) TaskService {
+	return &taskService{repo: repo, blobs: blobs}
+}
+func (s *taskService) List(ctx context.Context, listFilter TaskListFilter) ([]model.Task, int64, error) {
+	if err := compileTaskFilter(&listFilter); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(ctx, listFilter)
+}
+
+// compileTaskFilter compiles the ?filter= expression of f into f.Where.
+// taskRefs links #123 references in texts of the project to its live tasks,
+// which everyone who can read those texts can see.
+func (s *taskService) taskRefs(ctx context.Context, projectID uint) markdown.TaskRefs {
+	return func(ids []uint) ([]uint, error) { return s.repo.LinkableTasks(ctx, projectID, ids) }
+}
+
+func compileTaskFilter(f *TaskListFilter) error {
+	if f.Filter == "" {
+		return nil
+	}
+	node, err := filter.Parse(f.Filter)
+	if err != nil {
+		return err
+	}
+	cond, err := TaskFilterFields.Compile(node, filter.Env{UserID: f.UserID, Now: time.Now()})
+	if err != nil {
+		return err
+	}
+	f.Where = &cond
+	return nil
 }
 func (s *taskService) Create(ctx context.Context, input TaskCreateInput) (model.Task, error) {
-	task := model.Task{ProjectID: input.ProjectID, Title: input.Title, Description: input.Description, Status: input.Status, AssigneeID: input.AssigneeID, DueDate: input.DueDate}
+	html, err := markdown.RenderWithRefs(input.Description, s.taskRefs(ctx, input.ProjectID))
+	if err != nil {
+		return model.Task{}, err
+	}
+	labels, err := taskLabels(input.ProjectID, input.Labels)
+	if err != nil {
+		return model.Task{}, err
+	}
+	task := model.Task{ProjectID: input.ProjectID, Title: input.Title, Description: input.Description, DescriptionHTML: html, Status: input.Status, AssigneeID: input.AssigneeID, DueDate: input.DueDate, Labels: labels, SprintID: input.SprintID, MilestoneID: input.MilestoneID}
+	if err := setRecurrence(&task, input.Recurrence); err != nil {
+		return model.Task{}, err
+	}
 	return task, s.repo.Create(ctx, &task)
 }
-func (s *taskService) Get(ctx context.Context, id string, includeComments bool) (model.Task, error) {
-	return s.repo.Get(ctx, id, includeComments)
+func (s *taskService) Get(ctx context.Context, id string, include []string) (model.Task, error) {
+	return s.repo.Get(ctx, id, include)
 }
 func (s *taskService) Update(ctx context.Context, id string, input TaskUpdateInput) (model.Task, error) {
-	task, err := s.repo.Get(ctx, id, false)
+	task, err := s.repo.Get(ctx, id, nil)
 	if err != nil {
 		return model.Task{}, err
 	}
+	if err := checkVersion(input.Version, task.Version); err != nil {
+		return model.Task{}, err
+	}
+	completed := input.Status != nil && *input.Status == model.TaskDone && task.Status != model.TaskDone
 	if input.Title != nil {
 		task.Title = *input.Title
 	}
 	if input.Description != nil {
+		html, err := markdown.RenderWithRefs(*input.Description, s.taskRefs(ctx, task.ProjectID))
+		if err != nil {
+			return model.Task{}, err
+		}
 		task.Description = *input.Description
+		task.DescriptionHTML = html
 	}
 	if input.Status != nil {
 		task.Status = *input.Status
@@ -99,13 +240,156 @@ func (s *taskService) Update(ctx context.Context, id string, input TaskUpdateInp
 	if input.DueDate != nil {
 		task.DueDate = *input.DueDate
 	}
+	if input.SprintID != nil {
+		task.SprintID = *input.SprintID
+	}
+	if input.MilestoneID != nil {
+		task.MilestoneID = *input.MilestoneID
+	}
+	if input.Labels != nil {
+		labels, err := taskLabels(task.ProjectID, *input.Labels)
+		if err != nil {
+			return model.Task{}, err
+		}
+		task.Labels = labels
+	}
+	if input.Recurrence != nil {
+		if err := setRecurrence(&task, *input.Recurrence); err != nil {
+			return model.Task{}, err
+		}
+	} else if task.Recurrence != "" && task.DueDate == nil {
+		return model.Task{}, ErrRecurrenceDueDate
+	}
+	if completed && task.Recurrence != "" {
+		if next, ok := nextOccurrence(&task); ok {
+			if err := s.repo.Complete(ctx, &task, &next); err != nil {
+				return model.Task{}, err
+			}
+			task.NextOccurrence = &next
+			return task, nil
+		}
+	}
 	return task, s.repo.Save(ctx, &task)
 }
-func (s *taskService) Delete(ctx context.Context, id string) error { return s.repo.Delete(ctx, id) }
+
+// setRecurrence validates and sets the task's rule in canonical form, or
+// stops the series when rule is empty. A new series starts at the task.
+func setRecurrence(task *model.Task, rule string) error {
+	if strings.TrimSpace(rule) == "" {
+		task.Recurrence = ""
+		return nil
+	}
+	parsed, err := recurrence.Parse(rule)
+	var perr *recurrence.Error
+	if errors.As(err, &perr) {
+		return fmt.Errorf("%w: %s", ErrInvalidRecurrence, perr.Msg)
+	}
+	if err != nil {
+		return err
+	}
+	if task.DueDate == nil {
+		return ErrRecurrenceDueDate
+	}
+	task.Recurrence = parsed.String()
+	if task.SeriesID == nil && task.ID != 0 {
+		task.SeriesID = &task.ID
+	}
+	task.Occurrence = max(task.Occurrence, 1)
+	return nil
+}
+
+// nextOccurrence moves the rule of a completed task to a copy of it due at
+// the next date of the series. It returns false, and stops the series, when
+// the series has ended.
+func nextOccurrence(task *model.Task) (model.Task, bool) {
+	rule, err := recurrence.Parse(task.Recurrence)
+	if task.SeriesID == nil {
+		task.SeriesID = &task.ID
+	}
+	task.Occurrence = max(task.Occurrence, 1)
+	recurring := task.Recurrence
+	task.Recurrence = ""
+	if err != nil {
+		return model.Task{}, false
+	}
+	due, ok := rule.Next(task.DueDate.UTC(), int(task.Occurrence))
+	if !ok {
+		return model.Task{}, false
+	}
+	labels := make([]model.Label, len(task.Labels))
+	for i, label := range task.Labels {
+		labels[i] = model.Label{ProjectID: label.ProjectID, Name: label.Name}
+	}
+	return model.Task{
+		ProjectID:       task.ProjectID,
+		Title:           task.Title,
+		Description:     task.Description,
+		DescriptionHTML: task.DescriptionHTML,
+		Status:          model.TaskTodo,
+		AssigneeID:      task.AssigneeID,
+		DueDate:         &due,
+		Labels:          labels,
+		Recurrence:      recurring,
+		SeriesID:        task.SeriesID,
+		Occurrence:      task.Occurrence + 1,
+	}, true
+}
+func (s *taskService) Delete(ctx context.Context, id string, version *uint) error {
+	if err := s.repo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	cleanupBlobs(ctx, s.blobs)
+	return nil
+}
 func (s *taskService) ListComments(ctx context.Context, taskID string, filter TaskCommentListFilter) ([]model.Comment, int64, error) {
 	return s.repo.ListComments(ctx, taskID, filter)
 }
 func (s *taskService) CreateComment(ctx context.Context, input TaskCommentCreateInput) (model.Comment, error) {
-	comment := model.Comment{TaskID: input.TaskID, Author: input.Author, Text: input.Text}
+	html, err := markdown.RenderWithRefs(input.Text, func(ids []uint) ([]uint, error) {
+		task, err := s.repo.Get(ctx, strconv.FormatUint(uint64(input.TaskID), 10), nil)
+		if err != nil {
+			return nil, err
+		}
+		return s.repo.LinkableTasks(ctx, task.ProjectID, ids)
+	})
+	if err != nil {
+		return model.Comment{}, err
+	}
+	comment := model.Comment{TaskID: input.TaskID, Author: input.Author, Text: input.Text, HTML: html}
 	return comment, s.repo.CreateComment(ctx, &comment)
 }
+
+// taskLabels turns label names into labels of the project, dropping
+// duplicates. The repository resolves them to existing rows or creates them.
+func taskLabels(projectID uint, names []string) ([]model.Label, error) {
+	labels := []model.Label{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || len(name) > 64 {
+			return nil, ErrInvalidLabel
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		labels = append(labels, model.Label{ProjectID: projectID, Name: name})
+	}
+	return labels, nil
+}
+
+func (s *taskService) Watch(ctx context.Context, id string, userID uint) error {
+	task, err := s.repo.Get(ctx, id, nil)
+	if err != nil {
+		return err
+	}
+	return s.repo.Watch(ctx, task.ID, userID)
+}
+
+func (s *taskService) Unwatch(ctx context.Context, id string, userID uint) error {
+	task, err := s.repo.Get(ctx, id, nil)
+	if err != nil {
+		return err
+	}
+	return s.repo.Unwatch(ctx, task.ID, userID)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"project-management/internal/markdown"
+	"project-management/internal/model"
+)
+
+// MaxImportTasks caps the number of tasks one import may create.
+const MaxImportTasks = 5000
+
+// exportBatch is the number of tasks ExportTasks loads at a time.
+const exportBatch = 500
+
+func (s *projectService) ExportTasks(ctx context.Context, projectID uint, filter ProjectTaskListFilter, each func([]model.Task) error) error {
+	if _, err := s.repo.Get(ctx, strconv.FormatUint(uint64(projectID), 10), nil); err != nil {
+		return err
+	}
+	return s.repo.EachTask(ctx, projectID, filter, exportBatch, each)
+}
+
+func (s *projectService) ImportTasks(ctx context.Context, projectID uint, inputs []ProjectTaskCreateInput, dryRun bool) ([]model.Task, error) {
+	project, err := s.repo.Get(ctx, strconv.FormatUint(uint64(projectID), 10), nil)
+	if err != nil {
+		return nil, err
+	}
+	if project.Status == model.ProjectArchived {
+		return nil, ErrProjectArchived
+	}
+	tasks := make([]model.Task, len(inputs))
+	for i, input := range inputs {
+		html, err := markdown.RenderWithRefs(input.Description, s.taskRefs(ctx, projectID))
+		if err != nil {
+			return nil, err
+		}
+		tasks[i] = model.Task{ProjectID: projectID, Title: input.Title, Description: input.Description, DescriptionHTML: html, Status: input.Status, AssigneeID: input.AssigneeID, DueDate: input.DueDate}
+	}
+	if dryRun || len(tasks) == 0 {
+		return tasks, nil
+	}
+	return tasks, s.repo.CreateTasks(ctx, projectID, tasks)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func TestProjectServiceImportTasks(t *testing.T) {
+	ctx := context.Background()
+	active := func(ctx context.Context, id string, include []string) (model.Project, error) {
+		return model.Project{ID: 1, Status: model.ProjectActive}, nil
+	}
+	inputs := []ProjectTaskCreateInput{{Title: "A", Description: "**a**", Status: model.TaskTodo}, {Title: "B", Status: model.TaskDone}}
+
+	t.Run("creates the tasks", func(t *testing.T) {
+		var created []model.Task
+		svc := &projectService{repo: stubProjectRepo{getFn: active, createTasksFn: func(ctx context.Context, projectID uint, tasks []model.Task) error {
+			if projectID != 1 {
+				t.Fatalf("projectID = %d", projectID)
+			}
+			created = tasks
+			return nil
+		}}}
+		tasks, err := svc.ImportTasks(ctx, 1, inputs, false)
+		if err != nil || len(tasks) != 2 || len(created) != 2 {
+			t.Fatalf("tasks=%+v created=%+v err=%v", tasks, created, err)
+		}
+		if tasks[0].DescriptionHTML != "<p><strong>a</strong></p>\n" || tasks[0].ProjectID != 1 || tasks[1].Status != model.TaskDone {
+			t.Fatalf("tasks = %+v", tasks)
+		}
+	})
+
+	t.Run("dry run and empty imports write nothing", func(t *testing.T) {
+		svc := &projectService{repo: stubProjectRepo{getFn: active}}
+		if tasks, err := svc.ImportTasks(ctx, 1, inputs, true); err != nil || len(tasks) != 2 {
+			t.Fatalf("tasks=%+v err=%v", tasks, err)
+		}
+		if tasks, err := svc.ImportTasks(ctx, 1, nil, false); err != nil || len(tasks) != 0 {
+			t.Fatalf("tasks=%+v err=%v", tasks, err)
+		}
+	})
+
+	t.Run("archived project", func(t *testing.T) {
+		svc := &projectService{repo: stubProjectRepo{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
+			return model.Project{ID: 1, Status: model.ProjectArchived}, nil
+		}}}
+		if _, err := svc.ImportTasks(ctx, 1, inputs, true); !errors.Is(err, ErrProjectArchived) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("missing project", func(t *testing.T) {
+		svc := &projectService{repo: stubProjectRepo{getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
+			return model.Project{}, gorm.ErrRecordNotFound
+		}}}
+		if _, err := svc.ImportTasks(ctx, 9, inputs, false); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestProjectServiceExportTasks(t *testing.T) {
+	ctx := context.Background()
+	svc := &projectService{repo: stubProjectRepo{
+		getFn: func(ctx context.Context, id string, include []string) (model.Project, error) {
+			if id != "1" {
+				return model.Project{}, gorm.ErrRecordNotFound
+			}
+			return model.Project{ID: 1}, nil
+		},
+		eachTaskFn: func(ctx context.Context, projectID uint, filter ProjectTaskListFilter, size int, each func([]model.Task) error) error {
+			if size != exportBatch || filter.Status != "todo" {
+				t.Fatalf("size=%d filter=%+v", size, filter)
+			}
+			return each([]model.Task{{ID: 1}, {ID: 2}})
+		},
+	}}
+	var got int
+	err := svc.ExportTasks(ctx, 1, ProjectTaskListFilter{Status: "todo"}, func(tasks []model.Task) error {
+		got += len(tasks)
+		return nil
+	})
+	if err != nil || got != 2 {
+		t.Fatalf("got=%d err=%v", got, err)
+	}
+	if err := svc.ExportTasks(ctx, 2, ProjectTaskListFilter{}, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -3,9 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"project-management/internal/filter"
 	"project-management/internal/httpx"
 	"project-management/internal/model"
 )
@@ -13,11 +15,18 @@ import (
 type stubTaskRepo struct {
 	listFn          func(ctx context.Context, filter TaskListFilter) ([]model.Task, int64, error)
 	createFn        func(ctx context.Context, task *model.Task) error
-	getFn           func(ctx context.Context, id string, includeComments bool) (model.Task, error)
+	getFn           func(ctx context.Context, id string, include []string) (model.Task, error)
 	saveFn          func(ctx context.Context, task *model.Task) error
-	deleteFn        func(ctx context.Context, id string) error
+	deleteFn        func(ctx context.Context, id string, version *uint) error
 	listCommentsFn  func(ctx context.Context, taskID string, filter TaskCommentListFilter) ([]model.Comment, int64, error)
 	createCommentFn func(ctx context.Context, comment *model.Comment) error
+	selectFn        func(ctx context.Context, sel TaskSelection, limit int) ([]model.Task, error)
+	projectExistsFn func(ctx context.Context, projectID uint) (bool, error)
+	applyBulkFn     func(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error
+	completeFn      func(ctx context.Context, task *model.Task, next *model.Task) error
+	watchFn         func(ctx context.Context, taskID, userID uint) error
+	unwatchFn       func(ctx context.Context, taskID, userID uint) error
+	linkableTasksFn func(ctx context.Context, projectID uint, ids []uint) ([]uint, error)
 }
 
 func (s stubTaskRepo) List(ctx context.Context, filter TaskListFilter) ([]model.Task, int64, error) {
@@ -26,42 +35,74 @@ func (s stubTaskRepo) List(ctx context.Context, filter TaskListFilter) ([]model.
 func (s stubTaskRepo) Create(ctx context.Context, task *model.Task) error {
 	return s.createFn(ctx, task)
 }
-func (s stubTaskRepo) Get(ctx context.Context, id string, includeComments bool) (model.Task, error) {
-	return s.getFn(ctx, id, includeComments)
+func (s stubTaskRepo) Get(ctx context.Context, id string, include []string) (model.Task, error) {
+	return s.getFn(ctx, id, include)
 }
 func (s stubTaskRepo) Save(ctx context.Context, task *model.Task) error { return s.saveFn(ctx, task) }
-func (s stubTaskRepo) Delete(ctx context.Context, id string) error      { return s.deleteFn(ctx, id) }
+func (s stubTaskRepo) Delete(ctx context.Context, id string, version *uint) error {
+	return s.deleteFn(ctx, id, version)
+}
 func (s stubTaskRepo) ListComments(ctx context.Context, taskID string, filter TaskCommentListFilter) ([]model.Comment, int64, error) {
 	return s.listCommentsFn(ctx, taskID, filter)
 }
 func (s stubTaskRepo) CreateComment(ctx context.Context, comment *model.Comment) error {
 	return s.createCommentFn(ctx, comment)
 }
+func (s stubTaskRepo) Select(ctx context.Context, sel TaskSelection, limit int) ([]model.Task, error) {
+	return s.selectFn(ctx, sel, limit)
+}
+func (s stubTaskRepo) ProjectExists(ctx context.Context, projectID uint) (bool, error) {
+	return s.projectExistsFn(ctx, projectID)
+}
+func (s stubTaskRepo) ApplyBulk(ctx context.Context, save []model.Task, next map[uint]*model.Task, remove []model.Task) error {
+	return s.applyBulkFn(ctx, save, next, remove)
+}
+func (s stubTaskRepo) Complete(ctx context.Context, task *model.Task, next *model.Task) error {
+	return s.completeFn(ctx, task, next)
+}
+func (s stubTaskRepo) Watch(ctx context.Context, taskID, userID uint) error {
+	return s.watchFn(ctx, taskID, userID)
+}
+func (s stubTaskRepo) Unwatch(ctx context.Context, taskID, userID uint) error {
+	return s.unwatchFn(ctx, taskID, userID)
+}
+func (s stubTaskRepo) LinkableTasks(ctx context.Context, projectID uint, ids []uint) ([]uint, error) {
+	return s.linkableTasksFn(ctx, projectID, ids)
+}
 
 func TestTaskService(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("list delegates", func(t *testing.T) {
 		svc := &taskService{repo: stubTaskRepo{listFn: func(ctx context.Context, filter TaskListFilter) ([]model.Task, int64, error) {
-			if filter.Params.PageSize != 5 || !filter.IncludeComments {
+			if filter.Params.PageSize != 5 || strings.Join(filter.Include, ",") != "comments" {
 				t.Fatalf("filter = %+v", filter)
 			}
 			return []model.Task{{ID: 1}}, 1, nil
 		}}}
-		items, total, err := svc.List(ctx, TaskListFilter{Params: httpx.ListParams{PageSize: 5}, IncludeComments: true})
+		items, total, err := svc.List(ctx, TaskListFilter{Params: httpx.ListParams{PageSize: 5}, Include: []string{"comments"}})
 		if err != nil || total != 1 || len(items) != 1 {
 			t.Fatalf("items=%v total=%d err=%v", items, total, err)
 		}
 	})
 
 	t.Run("create maps input", func(t *testing.T) {
-		svc := &taskService{repo: stubTaskRepo{createFn: func(ctx context.Context, task *model.Task) error {
-			if task.ProjectID != 2 || task.Title != "Build" {
-				t.Fatalf("task = %+v", task)
-			}
-			return nil
-		}}}
-		_, err := svc.Create(ctx, TaskCreateInput{ProjectID: 2, Title: "Build", Status: model.TaskTodo})
+		svc := &taskService{repo: stubTaskRepo{
+			createFn: func(ctx context.Context, task *model.Task) error {
+				if task.ProjectID != 2 || task.Title != "Build" || task.DescriptionHTML != "<p>Fixes <a href=\"/tasks/3\" class=\"task-ref\" rel=\"nofollow\">#3</a>, not #4</p>\n" {
+					t.Fatalf("task = %+v", task)
+				}
+				return nil
+			},
+			// #4 is missing, in the trash or in another project.
+			linkableTasksFn: func(ctx context.Context, projectID uint, ids []uint) ([]uint, error) {
+				if projectID != 2 || len(ids) != 2 {
+					t.Fatalf("LinkableTasks(%d, %v)", projectID, ids)
+				}
+				return []uint{3}, nil
+			},
+		}}
+		_, err := svc.Create(ctx, TaskCreateInput{ProjectID: 2, Title: "Build", Description: "Fixes #3, not #4", Status: model.TaskTodo})
 		if err != nil {
 			t.Fatalf("Create error = %v", err)
 		}
@@ -78,13 +119,13 @@ func TestTaskService(t *testing.T) {
 	})
 
 	t.Run("get delegates success", func(t *testing.T) {
-		svc := &taskService{repo: stubTaskRepo{getFn: func(ctx context.Context, id string, includeComments bool) (model.Task, error) {
-			if id != "7" || !includeComments {
-				t.Fatalf("id=%s includeComments=%v", id, includeComments)
+		svc := &taskService{repo: stubTaskRepo{getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
+			if id != "7" || strings.Join(include, ",") != "comments" {
+				t.Fatalf("id=%s include=%v", id, include)
 			}
 			return model.Task{ID: 7}, nil
 		}}}
-		task, err := svc.Get(ctx, "7", true)
+		task, err := svc.Get(ctx, "7", []string{"comments"})
 		if err != nil || task.ID != 7 {
 			t.Fatalf("task=%+v err=%v", task, err)
 		}
@@ -95,7 +136,7 @@ func TestTaskService(t *testing.T) {
 		assignee := uint(7)
 		due := time.Now()
 		svc := &taskService{repo: stubTaskRepo{
-			getFn: func(ctx context.Context, id string, includeComments bool) (model.Task, error) {
+			getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
 				return model.Task{ID: 1, Title: "Old", Status: model.TaskTodo}, nil
 			},
 			saveFn: func(ctx context.Context, task *model.Task) error {
@@ -111,8 +152,77 @@ func TestTaskService(t *testing.T) {
 		}
 	})
 
+	t.Run("update checks the expected version", func(t *testing.T) {
+		svc := &taskService{repo: stubTaskRepo{
+			getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
+				return model.Task{ID: 1, Title: "Old", Status: model.TaskTodo, Version: 3}, nil
+			},
+			saveFn: func(ctx context.Context, task *model.Task) error {
+				t.Fatal("stale update was saved")
+				return nil
+			},
+		}}
+		stale := uint(2)
+		if _, err := svc.Update(ctx, "1", TaskUpdateInput{Title: ptr("New"), Version: &stale}); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("Update error = %v, want ErrVersionMismatch", err)
+		}
+	})
+
+	t.Run("list compiles filter", func(t *testing.T) {
+		svc := &taskService{repo: stubTaskRepo{listFn: func(ctx context.Context, filter TaskListFilter) ([]model.Task, int64, error) {
+			if filter.Where == nil || filter.Where.SQL != "(tasks.assignee_id = ? AND EXISTS (SELECT 1 FROM task_labels tl JOIN labels l ON l.id = tl.label_id WHERE tl.task_id = tasks.id AND l.name = ?))" {
+				t.Fatalf("where = %+v", filter.Where)
+			}
+			if filter.Where.Args[0] != uint(4) || filter.Where.Args[1] != "bug" {
+				t.Fatalf("args = %v", filter.Where.Args)
+			}
+			return nil, 0, nil
+		}}}
+		if _, _, err := svc.List(ctx, TaskListFilter{Filter: "assignee = me and label = bug", UserID: 4}); err != nil {
+			t.Fatalf("List error = %v", err)
+		}
+	})
+
+	t.Run("list rejects invalid filter", func(t *testing.T) {
+		svc := &taskService{repo: stubTaskRepo{}}
+		_, _, err := svc.List(ctx, TaskListFilter{Filter: "priority = high"})
+		var ferr *filter.Error
+		if !errors.As(err, &ferr) || ferr.Pos != 1 {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("labels are trimmed and deduplicated", func(t *testing.T) {
+		svc := &taskService{repo: stubTaskRepo{
+			createFn: func(ctx context.Context, task *model.Task) error {
+				if len(task.Labels) != 2 || task.Labels[0] != (model.Label{ProjectID: 2, Name: "bug"}) || task.Labels[1].Name != "ui" {
+					t.Fatalf("labels = %+v", task.Labels)
+				}
+				return nil
+			},
+			getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
+				return model.Task{ID: 1, ProjectID: 2, Labels: []model.Label{{ID: 5, ProjectID: 2, Name: "bug"}}}, nil
+			},
+			saveFn: func(ctx context.Context, task *model.Task) error {
+				if len(task.Labels) != 0 {
+					t.Fatalf("labels = %+v", task.Labels)
+				}
+				return nil
+			},
+		}}
+		if _, err := svc.Create(ctx, TaskCreateInput{ProjectID: 2, Title: "A", Labels: []string{" bug", "ui", "bug "}}); err != nil {
+			t.Fatalf("Create error = %v", err)
+		}
+		if _, err := svc.Update(ctx, "1", TaskUpdateInput{Labels: ptr([]string{})}); err != nil {
+			t.Fatalf("Update error = %v", err)
+		}
+		if _, err := svc.Create(ctx, TaskCreateInput{ProjectID: 2, Title: "A", Labels: []string{"  "}}); !errors.Is(err, ErrInvalidLabel) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
 	t.Run("update get error", func(t *testing.T) {
-		svc := &taskService{repo: stubTaskRepo{getFn: func(ctx context.Context, id string, includeComments bool) (model.Task, error) {
+		svc := &taskService{repo: stubTaskRepo{getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
 			return model.Task{}, errors.New("boom")
 		}}}
 		_, err := svc.Update(ctx, "1", TaskUpdateInput{})
@@ -123,7 +233,7 @@ func TestTaskService(t *testing.T) {
 
 	t.Run("update save error", func(t *testing.T) {
 		svc := &taskService{repo: stubTaskRepo{
-			getFn: func(ctx context.Context, id string, includeComments bool) (model.Task, error) {
+			getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
 				return model.Task{ID: 1}, nil
 			},
 			saveFn: func(ctx context.Context, task *model.Task) error { return errors.New("save failed") },
@@ -171,8 +281,8 @@ func TestTaskService(t *testing.T) {
 	})
 
 	t.Run("delete error propagates", func(t *testing.T) {
-		svc := &taskService{repo: stubTaskRepo{deleteFn: func(ctx context.Context, id string) error { return errors.New("boom") }}}
-		if err := svc.Delete(ctx, "9"); err == nil || err.Error() != "boom" {
+		svc := &taskService{repo: stubTaskRepo{deleteFn: func(ctx context.Context, id string, version *uint) error { return errors.New("boom") }}}
+		if err := svc.Delete(ctx, "9", nil); err == nil || err.Error() != "boom" {
 			t.Fatalf("err = %v", err)
 		}
 	})
@@ -183,3 +293,99 @@ func TestNewTaskService(t *testing.T) {
 		t.Fatal("NewTaskService returned nil")
 	}
 }
+
+func TestTaskServiceRecurrence(t *testing.T) {
+	ctx := context.Background()
+	due := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	done := model.TaskDone
+
+	t.Run("create validates the rule", func(t *testing.T) {
+		var created model.Task
+		svc := &taskService{repo: stubTaskRepo{createFn: func(ctx context.Context, task *model.Task) error {
+			created = *task
+			return nil
+		}}}
+		if _, err := svc.Create(ctx, TaskCreateInput{Title: "Standup", Recurrence: "FREQ=DAILY"}); !errors.Is(err, ErrRecurrenceDueDate) {
+			t.Fatalf("Create without dueDate = %v", err)
+		}
+		if _, err := svc.Create(ctx, TaskCreateInput{Title: "Standup", DueDate: &due, Recurrence: "FREQ=HOURLY"}); !errors.Is(err, ErrInvalidRecurrence) {
+			t.Fatalf("Create with an hourly rule = %v", err)
+		}
+		if _, err := svc.Create(ctx, TaskCreateInput{Title: "Standup", DueDate: &due, Recurrence: "rrule:freq=weekly;byday=mo,we"}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if created.Recurrence != "FREQ=WEEKLY;BYDAY=MO,WE" || created.Occurrence != 1 {
+			t.Fatalf("created = %+v", created)
+		}
+	})
+
+	t.Run("completing creates the next occurrence", func(t *testing.T) {
+		assignee := uint(7)
+		svc := &taskService{repo: stubTaskRepo{
+			getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
+				return model.Task{ID: 4, ProjectID: 2, Title: "Report", Description: "Weekly", Status: model.TaskInProgress, AssigneeID: &assignee, DueDate: &due,
+					Labels: []model.Label{{ID: 3, ProjectID: 2, Name: "ops"}}, Recurrence: "FREQ=WEEKLY;INTERVAL=2", Occurrence: 1, Version: 5}, nil
+			},
+			completeFn: func(ctx context.Context, task *model.Task, next *model.Task) error {
+				if task.Status != model.TaskDone || task.Recurrence != "" || task.SeriesID == nil || *task.SeriesID != 4 {
+					t.Fatalf("task = %+v", task)
+				}
+				if next.Title != "Report" || next.Description != "Weekly" || next.Status != model.TaskTodo || next.AssigneeID == nil || *next.AssigneeID != 7 ||
+					!next.DueDate.Equal(due.AddDate(0, 0, 14)) || next.Recurrence != "FREQ=WEEKLY;INTERVAL=2" || *next.SeriesID != 4 || next.Occurrence != 2 ||
+					len(next.Labels) != 1 || next.Labels[0].Name != "ops" || next.Labels[0].ID != 0 {
+					t.Fatalf("next = %+v", next)
+				}
+				next.ID = 5
+				return nil
+			},
+		}}
+		task, err := svc.Update(ctx, "4", TaskUpdateInput{Status: &done})
+		if err != nil || task.NextOccurrence == nil || task.NextOccurrence.ID != 5 {
+			t.Fatalf("Update = %+v, %v", task, err)
+		}
+	})
+
+	t.Run("the last occurrence ends the series", func(t *testing.T) {
+		series := uint(1)
+		svc := &taskService{repo: stubTaskRepo{
+			getFn: func(ctx context.Context, id string, include []string) (model.Task, error) {
+				return model.Task{ID: 3, Status: model.TaskTodo, DueDate: &due, Recurrence: "FREQ=DAILY;COUNT=3", SeriesID: &series, Occurrence: 3}, nil
+			},
+			saveFn: func(ctx context.Context, task *model.Task) error {
+				if task.Recurrence != "" || task.Status != model.TaskDone {
+					t.Fatalf("task = %+v", task)
+				}
+				return nil
+			},
+		}}
+		if task, err := svc.Update(ctx, "3", TaskUpdateInput{Status: &done}); err != nil || task.NextOccurrence != nil {
+			t.Fatalf("Update = %+v, %v", task, err)
+		}
+	})
+
+	t.Run("editing and stopping the series", func(t *testing.T) {
+		var saved model.Task
+		stored := model.Task{ID: 8, Status: model.TaskDone, DueDate: &due}
+		svc := &taskService{repo: stubTaskRepo{
+			getFn: func(ctx context.Context, id string, include []string) (model.Task, error) { return stored, nil },
+			saveFn: func(ctx context.Context, task *model.Task) error {
+				saved = *task
+				return nil
+			},
+		}}
+		// Completed already, so saving it done again starts nothing.
+		if _, err := svc.Update(ctx, "8", TaskUpdateInput{Status: &done, Recurrence: ptr("FREQ=MONTHLY")}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if saved.Recurrence != "FREQ=MONTHLY" || saved.SeriesID == nil || *saved.SeriesID != 8 || saved.Occurrence != 1 {
+			t.Fatalf("saved = %+v", saved)
+		}
+		stored = saved
+		if _, err := svc.Update(ctx, "8", TaskUpdateInput{DueDate: ptr[*time.Time](nil)}); !errors.Is(err, ErrRecurrenceDueDate) {
+			t.Fatalf("Update without dueDate = %v", err)
+		}
+		if _, err := svc.Update(ctx, "8", TaskUpdateInput{Recurrence: ptr("")}); err != nil || saved.Recurrence != "" {
+			t.Fatalf("stop = %+v, %v", saved, err)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/markdown"
+	"project-management/internal/model"
+)
+
+var ErrTemplateForbidden = errors.New("only the creator can delete a template")
+
+type TemplateCreateInput struct {
+	ProjectID   uint
+	Name        string
+	Description string
+	CreatedBy   uint
+	Options     CopyOptions
+}
+
+type TemplateUseInput struct {
+	Title   string // empty takes the template's name
+	OwnerID *uint
+	// StartDate is the day the template's due dates count from; nil means
+	// today.
+	StartDate *time.Time
+}
+
+type TemplateService interface {
+	List(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error)
+	// Create snapshots the project as it is now into a new template.
+	Create(ctx context.Context, input TemplateCreateInput) (model.ProjectTemplate, error)
+	Get(ctx context.Context, id string) (model.ProjectTemplate, error)
+	Delete(ctx context.Context, id string, userID uint) error
+	// Use creates an active project from the template.
+	Use(ctx context.Context, id string, input TemplateUseInput) (model.Project, error)
+}
+
+type TemplateRepository interface {
+	List(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error)
+	Create(ctx context.Context, template *model.ProjectTemplate) error
+	Get(ctx context.Context, id string) (model.ProjectTemplate, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type templateService struct {
+	repo     TemplateRepository
+	projects ProjectRepository
+	now      func() time.Time
+}
+
+func NewTemplateService(repo TemplateRepository, projects ProjectRepository) TemplateService {
+	return &templateService{repo: repo, projects: projects, now: time.Now}
+}
+
+func (s *templateService) List(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error) {
+	return s.repo.List(ctx, params)
+}
+
+func (s *templateService) Create(ctx context.Context, input TemplateCreateInput) (model.ProjectTemplate, error) {
+	if input.Options.Subtasks {
+		return model.ProjectTemplate{}, ErrSubtasksUnsupported
+	}
+	source, labels, err := s.projects.GetTree(ctx, strconv.FormatUint(uint64(input.ProjectID), 10))
+	if err != nil {
+		return model.ProjectTemplate{}, err
+	}
+	opts := input.Options
+	template := model.ProjectTemplate{
+		Name:            input.Name,
+		Description:     input.Description,
+		SourceProjectID: &source.ID,
+		CreatedBy:       input.CreatedBy,
+		Labels:          []string{},
+		Tasks:           []model.TemplateTask{},
+	}
+	if opts.Descriptions {
+		template.ProjectDescription = source.Description
+	}
+	if opts.Labels {
+		template.Labels = labelNames(labels)
+	}
+	if opts.Tasks {
+		start := startOfDay(source.CreatedAt)
+		for _, task := range source.Tasks {
+			tt := model.TemplateTask{Title: task.Title, Status: task.Status, AssigneeID: task.AssigneeID}
+			if opts.Descriptions {
+				tt.Description = task.Description
+			}
+			if opts.ResetStatuses {
+				tt.Status = model.TaskTodo
+			}
+			if task.DueDate != nil {
+				days := int(startOfDay(*task.DueDate).Sub(start).Hours() / 24)
+				tt.DueInDays = &days
+			}
+			if opts.Labels {
+				tt.Labels = labelNames(task.Labels)
+			}
+			template.Tasks = append(template.Tasks, tt)
+		}
+	}
+	return template, s.repo.Create(ctx, &template)
+}
+
+func (s *templateService) Get(ctx context.Context, id string) (model.ProjectTemplate, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *templateService) Delete(ctx context.Context, id string, userID uint) error {
+	template, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if template.CreatedBy != userID {
+		return ErrTemplateForbidden
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *templateService) Use(ctx context.Context, id string, input TemplateUseInput) (model.Project, error) {
+	template, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.Project{}, err
+	}
+	start := startOfDay(s.now())
+	if input.StartDate != nil {
+		start = startOfDay(*input.StartDate)
+	}
+	project := model.Project{Title: input.Title, Status: model.ProjectActive, OwnerID: input.OwnerID, Description: template.ProjectDescription}
+	if project.Title == "" {
+		project.Title = template.Name
+	}
+	if project.DescriptionHTML, err = markdown.Render(project.Description); err != nil {
+		return model.Project{}, err
+	}
+	labels, err := taskLabels(0, template.Labels)
+	if err != nil {
+		return model.Project{}, err
+	}
+	for _, tt := range template.Tasks {
+		task := model.Task{Title: tt.Title, Description: tt.Description, Status: tt.Status, AssigneeID: tt.AssigneeID}
+		if task.Status == "" {
+			task.Status = model.TaskTodo
+		}
+		if task.DescriptionHTML, err = markdown.Render(task.Description); err != nil {
+			return model.Project{}, err
+		}
+		if tt.DueInDays != nil {
+			due := start.AddDate(0, 0, *tt.DueInDays)
+			task.DueDate = &due
+		}
+		if task.Labels, err = taskLabels(0, tt.Labels); err != nil {
+			return model.Project{}, err
+		}
+		project.Tasks = append(project.Tasks, task)
+	}
+	return project, s.projects.CreateTree(ctx, &project, labels)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type stubTemplateRepo struct {
+	templates map[string]model.ProjectTemplate
+	deleted   []string
+}
+
+func (s *stubTemplateRepo) List(ctx context.Context, params httpx.ListParams) ([]model.ProjectTemplate, int64, error) {
+	panic("not used")
+}
+func (s *stubTemplateRepo) Create(ctx context.Context, template *model.ProjectTemplate) error {
+	template.ID = uint(len(s.templates) + 1)
+	s.templates[strconv.FormatUint(uint64(template.ID), 10)] = *template
+	return nil
+}
+func (s *stubTemplateRepo) Get(ctx context.Context, id string) (model.ProjectTemplate, error) {
+	template, ok := s.templates[id]
+	if !ok {
+		return model.ProjectTemplate{}, gorm.ErrRecordNotFound
+	}
+	return template, nil
+}
+func (s *stubTemplateRepo) Delete(ctx context.Context, id string) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func TestTemplateService(t *testing.T) {
+	ctx := context.Background()
+	due := time.Date(2026, 1, 8, 17, 0, 0, 0, time.UTC)
+	source := model.Project{
+		ID: 1, Title: "Onboarding", Description: "Welcome **client**", CreatedAt: time.Date(2026, 1, 5, 23, 0, 0, 0, time.UTC),
+		Tasks: []model.Task{
+			{Title: "Kickoff", Description: "Call", Status: model.TaskDone, DueDate: &due, Labels: []model.Label{{Name: "client"}}},
+			{Title: "Invoice", Status: model.TaskInProgress},
+		},
+	}
+	var created model.Project
+	var createdLabels []model.Label
+	repo := &stubTemplateRepo{templates: map[string]model.ProjectTemplate{}}
+	svc := &templateService{
+		repo: repo,
+		projects: stubProjectRepo{
+			getTreeFn: func(ctx context.Context, id string) (model.Project, []model.Label, error) {
+				if id != "1" {
+					return model.Project{}, nil, gorm.ErrRecordNotFound
+				}
+				return source, []model.Label{{Name: "client"}, {Name: "internal"}}, nil
+			},
+			createTreeFn: func(ctx context.Context, project *model.Project, labels []model.Label) error {
+				created, createdLabels = *project, labels
+				return nil
+			},
+		},
+		now: func() time.Time { return time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC) },
+	}
+
+	template, err := svc.Create(ctx, TemplateCreateInput{ProjectID: 1, Name: "Client onboarding", CreatedBy: 5, Options: CopyOptions{Tasks: true, Labels: true, Descriptions: true, ResetStatuses: true}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if *template.SourceProjectID != 1 || template.CreatedBy != 5 || template.ProjectDescription != "Welcome **client**" || len(template.Labels) != 2 || len(template.Tasks) != 2 {
+		t.Fatalf("template = %+v", template)
+	}
+	kickoff := template.Tasks[0]
+	if kickoff.Status != model.TaskTodo || kickoff.Description != "Call" || kickoff.DueInDays == nil || *kickoff.DueInDays != 3 || kickoff.Labels[0] != "client" {
+		t.Fatalf("kickoff = %+v", kickoff)
+	}
+	if template.Tasks[1].DueInDays != nil {
+		t.Fatalf("invoice = %+v", template.Tasks[1])
+	}
+	if _, err := svc.Create(ctx, TemplateCreateInput{ProjectID: 2, Name: "x"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Create from missing project err = %v", err)
+	}
+
+	owner := uint(6)
+	if _, err := svc.Use(ctx, "1", TemplateUseInput{OwnerID: &owner}); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	if created.Title != "Client onboarding" || *created.OwnerID != 6 || created.Status != model.ProjectActive || created.DescriptionHTML == "" || len(createdLabels) != 2 {
+		t.Fatalf("project = %+v, labels = %+v", created, createdLabels)
+	}
+	if d := created.Tasks[0].DueDate; d == nil || !d.Equal(time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("due from today = %v", d)
+	}
+	start := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := svc.Use(ctx, "1", TemplateUseInput{Title: "ACME", StartDate: &start}); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	if created.Title != "ACME" || !created.Tasks[0].DueDate.Equal(time.Date(2026, 11, 5, 0, 0, 0, 0, time.UTC)) || created.Tasks[0].Labels[0].Name != "client" {
+		t.Fatalf("project = %+v", created)
+	}
+	if _, err := svc.Use(ctx, "9", TemplateUseInput{}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Use of missing template err = %v", err)
+	}
+
+	if _, err := svc.Create(ctx, TemplateCreateInput{ProjectID: 1, Name: "Onboarding", Options: CopyOptions{Tasks: true, Subtasks: true}}); !errors.Is(err, ErrSubtasksUnsupported) {
+		t.Fatalf("Create with subtasks err = %v", err)
+	}
+
+	if err := svc.Delete(ctx, "1", 6); !errors.Is(err, ErrTemplateForbidden) {
+		t.Fatalf("Delete by other user err = %v", err)
+	}
+	if err := svc.Delete(ctx, "1", 5); err != nil || len(repo.deleted) != 1 {
+		t.Fatalf("Delete err = %v, deleted = %v", err, repo.deleted)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	thumbnailSize      = 256
+	thumbnailMaxPixels = 40_000_000
+)
+
+// makeThumbnail scales an image so its longer side is at most thumbnailSize
+// and encodes it as JPEG. Non-images and images it cannot decode yield false.
+func makeThumbnail(r io.ReadSeeker, mimeType string) ([]byte, bool) {
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		return nil, false
+	}
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 || cfg.Width*cfg.Height > thumbnailMaxPixels {
+		return nil, false
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, false
+	}
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, false
+	}
+
+	w, h := cfg.Width, cfg.Height
+	if w > thumbnailSize || h > thumbnailSize {
+		if w >= h {
+			w, h = thumbnailSize, max(1, h*thumbnailSize/w)
+		} else {
+			w, h = max(1, w*thumbnailSize/h), thumbnailSize
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTimeEntryDuration  = errors.New("give either endedAt or minutes")
+	ErrTimeEntryRange     = errors.New("a time entry must end after it starts and last at most 24 hours")
+	ErrTimeEntryForbidden = errors.New("only its author may delete a time entry")
+	ErrTimesheetRange     = errors.New("from must not be after to, and they may be at most 366 days apart")
+)
+
+const (
+	// maxTimeEntry bounds entries logged by hand. A timer records however
+	// long it ran.
+	maxTimeEntry       = 24 * time.Hour
+	maxTimesheetDays   = 366
+	timesheetDayFormat = "2006-01-02"
+)
+
+// TimeEntryInput logs time by hand. It ends at EndedAt or after Minutes;
+// exactly one of them must be set.
+type TimeEntryInput struct {
+	UserID    uint
+	StartedAt time.Time
+	EndedAt   *time.Time
+	Minutes   *int
+	Note      string
+}
+
+// TimesheetFilter selects the time logged from From to To, both dates in
+// UTC and inclusive, by UserID or, when it is nil, by everyone.
+type TimesheetFilter struct {
+	From   time.Time
+	To     time.Time
+	UserID *uint
+}
+
+// TimesheetRow is the time logged on a project on one day.
+type TimesheetRow struct {
+	Day       time.Time
+	ProjectID uint
+	Title     string
+	Seconds   int64
+}
+
+type TimeService interface {
+	// List returns the task's entries and the seconds logged on it, leaving
+	// out a running timer.
+	List(ctx context.Context, taskID string, params httpx.ListParams) ([]model.TimeEntry, int64, int64, error)
+	Create(ctx context.Context, taskID string, input TimeEntryInput) (model.TimeEntry, error)
+	// Delete deletes an entry of the user; another user's entry is
+	// ErrTimeEntryForbidden.
+	Delete(ctx context.Context, id string, userID uint) error
+	// Running returns the user's running timer, or gorm.ErrRecordNotFound.
+	Running(ctx context.Context, userID uint) (model.TimeEntry, error)
+	// Start starts a timer on the task, stopping the user's running one.
+	Start(ctx context.Context, taskID string, userID uint, note string) (model.TimeEntry, error)
+	// Stop stops the user's running timer, or returns gorm.ErrRecordNotFound.
+	Stop(ctx context.Context, userID uint) (model.TimeEntry, error)
+	ProjectTime(ctx context.Context, projectID string) (model.ProjectTime, error)
+	Timesheet(ctx context.Context, filter TimesheetFilter) (model.Timesheet, error)
+}
+
+type TimeRepository interface {
+	// Task returns a task that is not in the trash.
+	Task(ctx context.Context, id string) (model.Task, error)
+	List(ctx context.Context, taskID uint, params httpx.ListParams) ([]model.TimeEntry, int64, error)
+	TaskSeconds(ctx context.Context, taskID uint) (int64, error)
+	Create(ctx context.Context, entry *model.TimeEntry) error
+	Get(ctx context.Context, id string) (model.TimeEntry, error)
+	Delete(ctx context.Context, entry model.TimeEntry) error
+	Running(ctx context.Context, userID uint) (model.TimeEntry, error)
+	// Start saves stop, the user's running timer if there is one, and
+	// creates entry in one transaction.
+	Start(ctx context.Context, stop *model.TimeEntry, entry *model.TimeEntry) error
+	// Stop saves the end of a running entry, or returns
+	// gorm.ErrRecordNotFound when it is no longer running.
+	Stop(ctx context.Context, entry *model.TimeEntry) error
+	// ProjectTime returns the project's ID and the seconds logged on each of
+	// its tasks that has any, or gorm.ErrRecordNotFound for an unknown project.
+	ProjectTime(ctx context.Context, projectID string) (model.ProjectTime, error)
+	// Timesheet sums the finished entries that started in [from, to) by UTC
+	// day and project, in that order.
+	Timesheet(ctx context.Context, from, to time.Time, userID *uint) ([]TimesheetRow, error)
+}
+
+type timeService struct {
+	repo TimeRepository
+	now  func() time.Time
+}
+
+func NewTimeService(repo TimeRepository) TimeService {
+	return &timeService{repo: repo, now: time.Now}
+}
+
+func (s *timeService) List(ctx context.Context, taskID string, params httpx.ListParams) ([]model.TimeEntry, int64, int64, error) {
+	task, err := s.repo.Task(ctx, taskID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	items, total, err := s.repo.List(ctx, task.ID, params)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	seconds, err := s.repo.TaskSeconds(ctx, task.ID)
+	return items, total, seconds, err
+}
+
+func (s *timeService) Create(ctx context.Context, taskID string, input TimeEntryInput) (model.TimeEntry, error) {
+	if (input.EndedAt == nil) == (input.Minutes == nil) {
+		return model.TimeEntry{}, ErrTimeEntryDuration
+	}
+	start := input.StartedAt.UTC().Truncate(time.Second)
+	var end time.Time
+	if input.EndedAt != nil {
+		end = input.EndedAt.UTC().Truncate(time.Second)
+	} else {
+		end = start.Add(time.Duration(*input.Minutes) * time.Minute)
+	}
+	if !end.After(start) || end.Sub(start) > maxTimeEntry {
+		return model.TimeEntry{}, ErrTimeEntryRange
+	}
+	task, err := s.repo.Task(ctx, taskID)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	entry := model.TimeEntry{TaskID: task.ID, UserID: input.UserID, Note: input.Note}
+	finish(&entry, start, end)
+	return entry, s.repo.Create(ctx, &entry)
+}
+
+func (s *timeService) Delete(ctx context.Context, id string, userID uint) error {
+	entry, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if entry.UserID != userID {
+		return ErrTimeEntryForbidden
+	}
+	return s.repo.Delete(ctx, entry)
+}
+
+func (s *timeService) Running(ctx context.Context, userID uint) (model.TimeEntry, error) {
+	return s.repo.Running(ctx, userID)
+}
+
+func (s *timeService) Start(ctx context.Context, taskID string, userID uint, note string) (model.TimeEntry, error) {
+	task, err := s.repo.Task(ctx, taskID)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	now := s.now().UTC().Truncate(time.Second)
+	var stop *model.TimeEntry
+	running, err := s.repo.Running(ctx, userID)
+	switch {
+	case err == nil:
+		finish(&running, running.StartedAt, now)
+		stop = &running
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return model.TimeEntry{}, err
+	}
+	entry := model.TimeEntry{TaskID: task.ID, UserID: userID, StartedAt: now, Note: note}
+	return entry, s.repo.Start(ctx, stop, &entry)
+}
+
+func (s *timeService) Stop(ctx context.Context, userID uint) (model.TimeEntry, error) {
+	entry, err := s.repo.Running(ctx, userID)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	finish(&entry, entry.StartedAt, s.now().UTC().Truncate(time.Second))
+	return entry, s.repo.Stop(ctx, &entry)
+}
+
+// finish sets the start and end of entry and the whole seconds between them.
+func finish(entry *model.TimeEntry, start, end time.Time) {
+	if end.Before(start) {
+		end = start
+	}
+	entry.StartedAt = start
+	entry.EndedAt = &end
+	entry.Seconds = int64(end.Sub(start) / time.Second)
+}
+
+func (s *timeService) ProjectTime(ctx context.Context, projectID string) (model.ProjectTime, error) {
+	out, err := s.repo.ProjectTime(ctx, projectID)
+	if err != nil {
+		return model.ProjectTime{}, err
+	}
+	if out.Tasks == nil {
+		out.Tasks = []model.TaskTime{}
+	}
+	for _, task := range out.Tasks {
+		out.Seconds += task.Seconds
+	}
+	return out, nil
+}
+
+func (s *timeService) Timesheet(ctx context.Context, filter TimesheetFilter) (model.Timesheet, error) {
+	from := filter.From.UTC().Truncate(24 * time.Hour)
+	to := filter.To.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if !to.After(from) || to.Sub(from) > maxTimesheetDays*24*time.Hour {
+		return model.Timesheet{}, ErrTimesheetRange
+	}
+	rows, err := s.repo.Timesheet(ctx, from, to, filter.UserID)
+	if err != nil {
+		return model.Timesheet{}, err
+	}
+	sheet := model.Timesheet{
+		From:   from.Format(timesheetDayFormat),
+		To:     to.AddDate(0, 0, -1).Format(timesheetDayFormat),
+		UserID: filter.UserID,
+		Days:   []model.TimesheetDay{},
+	}
+	for _, row := range rows {
+		date := row.Day.UTC().Format(timesheetDayFormat)
+		if n := len(sheet.Days); n == 0 || sheet.Days[n-1].Date != date {
+			sheet.Days = append(sheet.Days, model.TimesheetDay{Date: date})
+		}
+		day := &sheet.Days[len(sheet.Days)-1]
+		day.Projects = append(day.Projects, model.TimesheetProject{ProjectID: row.ProjectID, Title: row.Title, Seconds: row.Seconds})
+		day.Seconds += row.Seconds
+		sheet.Seconds += row.Seconds
+	}
+	return sheet, nil
+}
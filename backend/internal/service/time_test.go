@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type stubTimeRepo struct {
+	entries []model.TimeEntry
+	rows    []TimesheetRow
+	from    time.Time
+	to      time.Time
+}
+
+func (s *stubTimeRepo) Task(ctx context.Context, id string) (model.Task, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n > 10 {
+		return model.Task{}, gorm.ErrRecordNotFound
+	}
+	return model.Task{ID: uint(n)}, nil
+}
+func (s *stubTimeRepo) List(ctx context.Context, taskID uint, params httpx.ListParams) ([]model.TimeEntry, int64, error) {
+	panic("not used")
+}
+func (s *stubTimeRepo) TaskSeconds(ctx context.Context, taskID uint) (int64, error) {
+	panic("not used")
+}
+func (s *stubTimeRepo) Create(ctx context.Context, entry *model.TimeEntry) error {
+	entry.ID = uint(len(s.entries) + 1)
+	s.entries = append(s.entries, *entry)
+	return nil
+}
+func (s *stubTimeRepo) Get(ctx context.Context, id string) (model.TimeEntry, error) {
+	for _, e := range s.entries {
+		if strconv.Itoa(int(e.ID)) == id {
+			return e, nil
+		}
+	}
+	return model.TimeEntry{}, gorm.ErrRecordNotFound
+}
+func (s *stubTimeRepo) Delete(ctx context.Context, entry model.TimeEntry) error {
+	for i, e := range s.entries {
+		if e.ID == entry.ID {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+		}
+	}
+	return nil
+}
+func (s *stubTimeRepo) Running(ctx context.Context, userID uint) (model.TimeEntry, error) {
+	for _, e := range s.entries {
+		if e.UserID == userID && e.EndedAt == nil {
+			return e, nil
+		}
+	}
+	return model.TimeEntry{}, gorm.ErrRecordNotFound
+}
+func (s *stubTimeRepo) Start(ctx context.Context, stop *model.TimeEntry, entry *model.TimeEntry) error {
+	if stop != nil {
+		if err := s.Stop(ctx, stop); err != nil {
+			return err
+		}
+	}
+	return s.Create(ctx, entry)
+}
+func (s *stubTimeRepo) Stop(ctx context.Context, entry *model.TimeEntry) error {
+	for i, e := range s.entries {
+		if e.ID == entry.ID && e.EndedAt == nil {
+			s.entries[i] = *entry
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+func (s *stubTimeRepo) ProjectTime(ctx context.Context, projectID string) (model.ProjectTime, error) {
+	return model.ProjectTime{ProjectID: 3, Tasks: []model.TaskTime{{TaskID: 1, Seconds: 60}, {TaskID: 2, Seconds: 30}}}, nil
+}
+func (s *stubTimeRepo) Timesheet(ctx context.Context, from, to time.Time, userID *uint) ([]TimesheetRow, error) {
+	s.from, s.to = from, to
+	return s.rows, nil
+}
+
+func TestTimeServiceCreate(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubTimeRepo{}
+	s := NewTimeService(repo)
+	start := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	entry, err := s.Create(ctx, "1", TimeEntryInput{UserID: 9, StartedAt: start, Minutes: ptr(45), Note: "review"})
+	if err != nil || entry.Seconds != 45*60 || !entry.EndedAt.Equal(start.Add(45*time.Minute)) || entry.TaskID != 1 {
+		t.Fatalf("Create = %+v, %v", entry, err)
+	}
+	end := start.Add(90 * time.Second)
+	if entry, err := s.Create(ctx, "1", TimeEntryInput{UserID: 9, StartedAt: start, EndedAt: &end}); err != nil || entry.Seconds != 90 {
+		t.Fatalf("Create with endedAt = %+v, %v", entry, err)
+	}
+
+	tests := []struct {
+		name  string
+		input TimeEntryInput
+		want  error
+	}{
+		{"neither end", TimeEntryInput{StartedAt: start}, ErrTimeEntryDuration},
+		{"both ends", TimeEntryInput{StartedAt: start, EndedAt: &end, Minutes: ptr(5)}, ErrTimeEntryDuration},
+		{"negative", TimeEntryInput{StartedAt: start, Minutes: ptr(-5)}, ErrTimeEntryRange},
+		{"over a day", TimeEntryInput{StartedAt: start, Minutes: ptr(24*60 + 1)}, ErrTimeEntryRange},
+	}
+	for _, tt := range tests {
+		if _, err := s.Create(ctx, "1", tt.input); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if _, err := s.Create(ctx, "99", TimeEntryInput{StartedAt: start, Minutes: ptr(5)}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("unknown task: err = %v", err)
+	}
+
+	if err := s.Delete(ctx, "1", 8); !errors.Is(err, ErrTimeEntryForbidden) {
+		t.Fatalf("Delete by another user = %v", err)
+	}
+	if err := s.Delete(ctx, "1", 9); err != nil || len(repo.entries) != 1 {
+		t.Fatalf("Delete = %v, entries %+v", err, repo.entries)
+	}
+}
+
+func TestTimeServiceTimer(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubTimeRepo{}
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	s := &timeService{repo: repo, now: func() time.Time { return now }}
+
+	if _, err := s.Stop(ctx, 9); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Stop without timer = %v", err)
+	}
+	first, err := s.Start(ctx, "1", 9, "")
+	if err != nil || first.EndedAt != nil || !first.StartedAt.Equal(now) {
+		t.Fatalf("Start = %+v, %v", first, err)
+	}
+
+	// Starting on another task stops the running timer.
+	now = now.Add(20*time.Minute + 500*time.Millisecond)
+	second, err := s.Start(ctx, "2", 9, "pairing")
+	if err != nil || second.TaskID != 2 {
+		t.Fatalf("second Start = %+v, %v", second, err)
+	}
+	if stopped, _ := repo.Get(ctx, "1"); stopped.EndedAt == nil || stopped.Seconds != 20*60 {
+		t.Fatalf("first timer = %+v", stopped)
+	}
+	if running, err := s.Running(ctx, 9); err != nil || running.ID != second.ID {
+		t.Fatalf("Running = %+v, %v", running, err)
+	}
+
+	now = now.Add(time.Hour)
+	stopped, err := s.Stop(ctx, 9)
+	if err != nil || stopped.Seconds != 3600 || stopped.Note != "pairing" {
+		t.Fatalf("Stop = %+v, %v", stopped, err)
+	}
+	if _, err := s.Running(ctx, 9); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Running after Stop = %v", err)
+	}
+	if _, err := s.Start(ctx, "99", 9, ""); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Start on unknown task = %v", err)
+	}
+}
+
+func TestTimeServiceReports(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	repo := &stubTimeRepo{rows: []TimesheetRow{
+		{Day: day(1), ProjectID: 3, Title: "Ops", Seconds: 600},
+		{Day: day(1), ProjectID: 4, Title: "Web", Seconds: 300},
+		{Day: day(3), ProjectID: 3, Title: "Ops", Seconds: 60},
+	}}
+	s := NewTimeService(repo)
+
+	sheet, err := s.Timesheet(ctx, TimesheetFilter{From: day(1), To: day(7)})
+	if err != nil || sheet.From != "2026-10-01" || sheet.To != "2026-10-07" || sheet.Seconds != 960 || len(sheet.Days) != 2 {
+		t.Fatalf("Timesheet = %+v, %v", sheet, err)
+	}
+	if d := sheet.Days[0]; d.Date != "2026-10-01" || d.Seconds != 900 || len(d.Projects) != 2 || sheet.Days[1].Projects[0].Seconds != 60 {
+		t.Fatalf("days = %+v", sheet.Days)
+	}
+	if !repo.from.Equal(day(1)) || !repo.to.Equal(day(8)) {
+		t.Fatalf("range = %v to %v", repo.from, repo.to)
+	}
+	if _, err := s.Timesheet(ctx, TimesheetFilter{From: day(7), To: day(1)}); !errors.Is(err, ErrTimesheetRange) {
+		t.Fatalf("reversed range = %v", err)
+	}
+	if _, err := s.Timesheet(ctx, TimesheetFilter{From: day(1), To: day(1).AddDate(1, 1, 0)}); !errors.Is(err, ErrTimesheetRange) {
+		t.Fatalf("long range = %v", err)
+	}
+	repo.rows = nil
+	if sheet, _ := s.Timesheet(ctx, TimesheetFilter{From: day(1), To: day(1)}); sheet.Days == nil {
+		t.Fatal("empty timesheet has null days")
+	}
+
+	total, err := s.ProjectTime(ctx, "3")
+	if err != nil || total.Seconds != 90 || len(total.Tasks) != 2 {
+		t.Fatalf("ProjectTime = %+v, %v", total, err)
+	}
+}
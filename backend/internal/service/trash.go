@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"project-management/internal/config"
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+)
+
+var (
+	ErrTrashType          = errors.New("type must be a comma-separated list of project, task, comment")
+	ErrTrashParentDeleted = errors.New("the project or task this belongs to is in the trash; restore it first")
+)
+
+// Kinds of trashed items, as in /trash/:type/:id.
+const (
+	TrashProject = "project"
+	TrashTask    = "task"
+	TrashComment = "comment"
+)
+
+const defaultTrashRetentionDays = 30
+
+type TrashFilter struct {
+	Params httpx.ListParams
+	Types  []string
+	UserID uint
+}
+
+type TrashService interface {
+	// List returns what the user can restore: deleted projects they own and
+	// deleted tasks and comments in such projects. Tasks and comments deleted
+	// with their project or task are restored with it and not listed.
+	List(ctx context.Context, filter TrashFilter) ([]model.TrashItem, int64, error)
+	// Restore takes an item out of the trash together with everything that
+	// was deleted with it.
+	Restore(ctx context.Context, kind, id string, userID uint) error
+	// Purge deletes for good what has been in the trash longer than the
+	// retention period and reports how many items that were.
+	Purge(ctx context.Context) (int64, error)
+}
+
+type TrashRepository interface {
+	List(ctx context.Context, filter TrashFilter) ([]model.TrashItem, int64, error)
+	// Restore returns gorm.ErrRecordNotFound for an item that is not in the
+	// trash or not in a project the user can see, and ErrProjectArchived for
+	// a task or comment of an archived project.
+	Restore(ctx context.Context, kind, id string, userID uint) error
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type trashService struct {
+	repo      TrashRepository
+	blobs     BlobCleaner
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewTrashService keeps deleted items for TRASH_RETENTION_DAYS, 30 by
+// default.
+func NewTrashService(repo TrashRepository, blobs BlobCleaner) TrashService {
+	days := config.GetEnvInt("TRASH_RETENTION_DAYS", defaultTrashRetentionDays)
+	return &trashService{repo: repo, blobs: blobs, retention: time.Duration(days) * 24 * time.Hour, now: time.Now}
+}
+
+func (s *trashService) List(ctx context.Context, filter TrashFilter) ([]model.TrashItem, int64, error) {
+	for _, t := range filter.Types {
+		if !validTrashType(t) {
+			return nil, 0, ErrTrashType
+		}
+	}
+	items, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(s.retention)
+	}
+	return items, total, nil
+}
+
+func (s *trashService) Restore(ctx context.Context, kind, id string, userID uint) error {
+	if !validTrashType(kind) {
+		return ErrTrashType
+	}
+	return s.repo.Restore(ctx, kind, id, userID)
+}
+
+func (s *trashService) Purge(ctx context.Context) (int64, error) {
+	n, err := s.repo.Purge(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		cleanupBlobs(ctx, s.blobs)
+	}
+	return n, nil
+}
+
+func validTrashType(t string) bool {
+	return t == TrashProject || t == TrashTask || t == TrashComment
+}
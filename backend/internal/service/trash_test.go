@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"project-management/internal/model"
+)
+
+type stubTrashRepo struct {
+	listFn    func(ctx context.Context, filter TrashFilter) ([]model.TrashItem, int64, error)
+	restoreFn func(ctx context.Context, kind, id string, userID uint) error
+	purgeFn   func(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+func (s stubTrashRepo) List(ctx context.Context, filter TrashFilter) ([]model.TrashItem, int64, error) {
+	return s.listFn(ctx, filter)
+}
+func (s stubTrashRepo) Restore(ctx context.Context, kind, id string, userID uint) error {
+	return s.restoreFn(ctx, kind, id, userID)
+}
+func (s stubTrashRepo) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	return s.purgeFn(ctx, cutoff)
+}
+
+type countingCleaner struct{ calls int }
+
+func (c *countingCleaner) CleanupOrphans(ctx context.Context) error {
+	c.calls++
+	return nil
+}
+
+func TestTrashService(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TRASH_RETENTION_DAYS", "7")
+	now := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	deleted := now.Add(-48 * time.Hour)
+	blobs := &countingCleaner{}
+	svc := NewTrashService(stubTrashRepo{
+		listFn: func(ctx context.Context, filter TrashFilter) ([]model.TrashItem, int64, error) {
+			return []model.TrashItem{{Type: TrashTask, ID: 3, DeletedAt: deleted}}, 1, nil
+		},
+		restoreFn: func(ctx context.Context, kind, id string, userID uint) error {
+			if kind != TrashProject || id != "5" || userID != 2 {
+				t.Fatalf("Restore(%s, %s, %d)", kind, id, userID)
+			}
+			return nil
+		},
+		purgeFn: func(ctx context.Context, cutoff time.Time) (int64, error) {
+			if !cutoff.Equal(now.Add(-7 * 24 * time.Hour)) {
+				t.Fatalf("cutoff = %v", cutoff)
+			}
+			return 2, nil
+		},
+	}, blobs).(*trashService)
+	svc.now = func() time.Time { return now }
+
+	items, total, err := svc.List(ctx, TrashFilter{Types: []string{TrashTask}, UserID: 2})
+	if err != nil || total != 1 || !items[0].PurgeAt.Equal(deleted.Add(7*24*time.Hour)) {
+		t.Fatalf("items = %+v total = %d err = %v", items, total, err)
+	}
+	if _, _, err := svc.List(ctx, TrashFilter{Types: []string{"user"}}); !errors.Is(err, ErrTrashType) {
+		t.Fatalf("err = %v, want ErrTrashType", err)
+	}
+	if err := svc.Restore(ctx, TrashProject, "5", 2); err != nil {
+		t.Fatalf("Restore error = %v", err)
+	}
+	if err := svc.Restore(ctx, "label", "5", 2); !errors.Is(err, ErrTrashType) {
+		t.Fatalf("err = %v, want ErrTrashType", err)
+	}
+	if n, err := svc.Purge(ctx); n != 2 || err != nil || blobs.calls != 1 {
+		t.Fatalf("Purge = %d, %v; cleanups = %d", n, err, blobs.calls)
+	}
+}
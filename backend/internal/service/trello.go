@@ -0,0 +1,164 @@
+package service
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"project-management/internal/model"
+)
+
+// trelloBoard is the part of a Trello board JSON export the import reads.
+// Trello exports hold no member emails; ExternalImportOptions.Members maps
+// usernames to them.
+type trelloBoard struct {
+	Name  string `json:"name"`
+	Desc  string `json:"desc"`
+	Lists []struct {
+		ID     string  `json:"id"`
+		Name   string  `json:"name"`
+		Closed bool    `json:"closed"`
+		Pos    float64 `json:"pos"`
+	} `json:"lists"`
+	Cards []struct {
+		ID        string   `json:"id"`
+		ShortLink string   `json:"shortLink"`
+		Name      string   `json:"name"`
+		Desc      string   `json:"desc"`
+		IDList    string   `json:"idList"`
+		Closed    bool     `json:"closed"`
+		Pos       float64  `json:"pos"`
+		Due       *string  `json:"due"`
+		IDMembers []string `json:"idMembers"`
+		Labels    []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Members []struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		FullName string `json:"fullName"`
+	} `json:"members"`
+	Actions []struct {
+		Type            string    `json:"type"`
+		Date            time.Time `json:"date"`
+		IDMemberCreator string    `json:"idMemberCreator"`
+		Data            struct {
+			Text string `json:"text"`
+			Card struct {
+				ID string `json:"id"`
+			} `json:"card"`
+		} `json:"data"`
+		MemberCreator struct {
+			Username string `json:"username"`
+			FullName string `json:"fullName"`
+		} `json:"memberCreator"`
+	} `json:"actions"`
+}
+
+// ReadTrello turns a Trello board JSON export into a project bundle. Lists
+// become statuses, cards tasks in list order, comments comments; the first
+// member of a card is its assignee.
+func ReadTrello(r io.Reader, opts ExternalImportOptions) (model.ProjectBundle, ImportMapping, error) {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return model.ProjectBundle{}, ImportMapping{}, fmt.Errorf("%w: not a Trello board export: %v", ErrBundleFormat, err)
+	}
+	if board.Name == "" && len(board.Lists) == 0 {
+		return model.ProjectBundle{}, ImportMapping{}, fmt.Errorf("%w: not a Trello board export", ErrBundleFormat)
+	}
+	b := newExternalBundle(board.Name, board.Desc, opts)
+
+	type list struct {
+		name   string
+		closed bool
+		pos    float64
+	}
+	lists := make(map[string]list, len(board.Lists))
+	for _, l := range board.Lists {
+		lists[l.ID] = list{name: l.Name, closed: l.Closed, pos: l.Pos}
+	}
+	members := make(map[string]int, len(board.Members))
+	for i, m := range board.Members {
+		members[m.ID] = i
+	}
+	member := func(id, username, fullName string) *uint {
+		if i, ok := members[id]; ok {
+			username, fullName = board.Members[i].Username, board.Members[i].FullName
+		}
+		return b.member(username, fullName)
+	}
+
+	cards := board.Cards
+	sort.SliceStable(cards, func(i, j int) bool {
+		li, lj := lists[cards[i].IDList], lists[cards[j].IDList]
+		if li.pos != lj.pos {
+			return li.pos < lj.pos
+		}
+		return cards[i].Pos < cards[j].Pos
+	})
+	tasks := map[string]uint{}
+	for _, card := range cards {
+		l := lists[card.IDList]
+		if !opts.IncludeArchived && (card.Closed || l.closed) {
+			b.mapping.Skipped++
+			continue
+		}
+		task := model.BundleTask{Title: card.Name, Description: card.Desc, Status: b.status(l.name, ""), CreatedAt: trelloCreated(card.ID)}
+		if card.Due != nil {
+			if due, err := time.Parse(time.RFC3339, *card.Due); err == nil {
+				task.DueDate = &due
+			}
+		}
+		for _, id := range card.IDMembers {
+			if assignee := member(id, "", ""); assignee != nil && task.AssigneeID == nil {
+				task.AssigneeID = assignee
+			}
+		}
+		for _, label := range card.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color
+			}
+			if name = b.label(name); name != "" {
+				task.Labels = append(task.Labels, name)
+			}
+		}
+		key := card.ShortLink
+		if key == "" {
+			key = card.ID
+		}
+		tasks[card.ID] = b.addTask(key, task)
+	}
+
+	actions := board.Actions
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Date.Before(actions[j].Date) })
+	for _, action := range actions {
+		taskID, ok := tasks[action.Data.Card.ID]
+		if action.Type != "commentCard" || !ok {
+			continue
+		}
+		author := action.MemberCreator.FullName
+		if author == "" {
+			author = action.MemberCreator.Username
+		}
+		member(action.IDMemberCreator, action.MemberCreator.Username, action.MemberCreator.FullName)
+		b.addComment(model.BundleComment{TaskID: taskID, Author: author, Text: action.Data.Text, CreatedAt: action.Date})
+	}
+	bundle, mapping := b.done()
+	return bundle, mapping, nil
+}
+
+// trelloCreated reads the creation time from the first four bytes of a
+// Trello ID, a Unix timestamp.
+func trelloCreated(id string) time.Time {
+	raw, err := hex.DecodeString(id[:min(len(id), 8)])
+	if err != nil || len(raw) != 4 {
+		return time.Time{}
+	}
+	return time.Unix(int64(raw[0])<<24|int64(raw[1])<<16|int64(raw[2])<<8|int64(raw[3]), 0).UTC()
+}
@@ -0,0 +1,17 @@
+package service
+
+import "errors"
+
+// ErrVersionMismatch means a project, task or comment changed after the
+// caller read it: the If-Match version is stale, or another write was saved
+// between reading and saving the row.
+var ErrVersionMismatch = errors.New("the resource was modified; reload it and try again")
+
+// checkVersion compares the version the caller expects, if any, with the
+// current one.
+func checkVersion(want *uint, current uint) error {
+	if want != nil && *want != current {
+		return ErrVersionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-management/internal/filter"
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrViewForbidden = errors.New("only the owner can change a view")
+	ErrViewGroupBy   = errors.New("groupBy must be one of status, assignee, project")
+	ErrViewDate      = errors.New("dueFrom and dueTo must be a date like 2026-11-01 or today+7d")
+	ErrViewAssignee  = errors.New("assigneeId must be a user ID or me")
+	ErrViewStatus    = errors.New("status must be one of todo, in_progress, done")
+)
+
+// viewGroupSort maps a groupBy value to the task sort key that keeps groups
+// together.
+var viewGroupSort = map[string]string{"status": "status", "assignee": "assigneeId", "project": "projectId"}
+
+type ViewInput struct {
+	Name       string
+	ProjectID  *uint
+	Filter     string
+	Status     string
+	AssigneeID string
+	DueFrom    string
+	DueTo      string
+	Sort       string
+	PageSize   int
+	GroupBy    string
+}
+
+type ViewTaskGroup struct {
+	Key   string       `json:"key"`
+	Items []model.Task `json:"items"`
+}
+
+type ViewService interface {
+	Create(ctx context.Context, ownerID uint, input ViewInput) (model.SavedView, error)
+	// List returns a page of the views userID can use, by name unless
+	// params sort them otherwise.
+	List(ctx context.Context, userID uint, params httpx.ListParams) ([]model.SavedView, int64, error)
+	Get(ctx context.Context, id string, userID uint) (model.SavedView, error)
+	Update(ctx context.Context, id string, userID uint, input ViewInput) (model.SavedView, error)
+	Delete(ctx context.Context, id string, userID uint) error
+	// Tasks runs the view for userID. query holds the request's pagination
+	// (page and pageSize, or cursor and limit), which falls back to the stored
+	// page size; the returned params are the ones applied.
+	Tasks(ctx context.Context, id string, userID uint, query url.Values) ([]model.Task, int64, httpx.ListParams, model.SavedView, error)
+}
+
+type ViewRepository interface {
+	Create(ctx context.Context, view *model.SavedView) error
+	Get(ctx context.Context, id string) (model.SavedView, error)
+	ListForUser(ctx context.Context, userID uint, params httpx.ListParams) ([]model.SavedView, int64, error)
+	Save(ctx context.Context, view *model.SavedView) error
+	Delete(ctx context.Context, id string) error
+	ProjectVisible(ctx context.Context, projectID, userID uint) (bool, error)
+}
+
+type viewService struct {
+	repo  ViewRepository
+	tasks TaskService
+}
+
+func NewViewService(repo ViewRepository, tasks TaskService) ViewService {
+	return &viewService{repo: repo, tasks: tasks}
+}
+
+func (s *viewService) Create(ctx context.Context, ownerID uint, input ViewInput) (model.SavedView, error) {
+	view := model.SavedView{OwnerID: ownerID}
+	if err := s.apply(ctx, &view, ownerID, input); err != nil {
+		return model.SavedView{}, err
+	}
+	return view, s.repo.Create(ctx, &view)
+}
+
+func (s *viewService) List(ctx context.Context, userID uint, params httpx.ListParams) ([]model.SavedView, int64, error) {
+	return s.repo.ListForUser(ctx, userID, params)
+}
+
+func (s *viewService) Get(ctx context.Context, id string, userID uint) (model.SavedView, error) {
+	view, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return model.SavedView{}, err
+	}
+	if view.OwnerID == userID {
+		return view, nil
+	}
+	if view.ProjectID != nil {
+		visible, err := s.repo.ProjectVisible(ctx, *view.ProjectID, userID)
+		if err != nil {
+			return model.SavedView{}, err
+		}
+		if visible {
+			return view, nil
+		}
+	}
+	// Views the caller cannot see are reported as missing.
+	return model.SavedView{}, gorm.ErrRecordNotFound
+}
+
+func (s *viewService) Update(ctx context.Context, id string, userID uint, input ViewInput) (model.SavedView, error) {
+	view, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return model.SavedView{}, err
+	}
+	if view.OwnerID != userID {
+		return model.SavedView{}, ErrViewForbidden
+	}
+	if err := s.apply(ctx, &view, userID, input); err != nil {
+		return model.SavedView{}, err
+	}
+	return view, s.repo.Save(ctx, &view)
+}
+
+func (s *viewService) Delete(ctx context.Context, id string, userID uint) error {
+	view, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if view.OwnerID != userID {
+		return ErrViewForbidden
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *viewService) Tasks(ctx context.Context, id string, userID uint, query url.Values) ([]model.Task, int64, httpx.ListParams, model.SavedView, error) {
+	view, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return nil, 0, httpx.ListParams{}, model.SavedView{}, err
+	}
+	listFilter, err := resolveView(view, userID, time.Now())
+	if err != nil {
+		return nil, 0, httpx.ListParams{}, model.SavedView{}, err
+	}
+	q := url.Values{}
+	for _, key := range []string{"page", "pageSize", "cursor", "limit", "count"} {
+		if query.Has(key) {
+			q.Set(key, query.Get(key))
+		}
+	}
+	if view.PageSize > 0 && q.Get("pageSize") == "" && q.Get("limit") == "" {
+		if q.Has("cursor") || q.Has("limit") {
+			q.Set("limit", strconv.Itoa(view.PageSize))
+		} else {
+			q.Set("pageSize", strconv.Itoa(view.PageSize))
+		}
+	}
+	sort := view.Sort
+	if key, ok := viewGroupSort[view.GroupBy]; ok {
+		sort = strings.TrimSuffix(key+","+sort, ",")
+	}
+	q.Set("sort", sort)
+	if listFilter.Params, err = httpx.ParseListQuery(q); err != nil {
+		return nil, 0, httpx.ListParams{}, model.SavedView{}, err
+	}
+	items, total, err := s.tasks.List(ctx, listFilter)
+	return items, total, listFilter.Params, view, err
+}
+
+// GroupTasks splits a page of tasks by the view's groupBy field, keeping the
+// order of the page. It returns nil when the view is not grouped.
+func GroupTasks(view model.SavedView, items []model.Task) []ViewTaskGroup {
+	if _, ok := viewGroupSort[view.GroupBy]; !ok {
+		return nil
+	}
+	groups := []ViewTaskGroup{}
+	for _, task := range items {
+		key := ""
+		switch view.GroupBy {
+		case "status":
+			key = string(task.Status)
+		case "assignee":
+			if task.AssigneeID != nil {
+				key = strconv.FormatUint(uint64(*task.AssigneeID), 10)
+			}
+		case "project":
+			key = strconv.FormatUint(uint64(task.ProjectID), 10)
+		}
+		if n := len(groups); n > 0 && groups[n-1].Key == key {
+			groups[n-1].Items = append(groups[n-1].Items, task)
+			continue
+		}
+		groups = append(groups, ViewTaskGroup{Key: key, Items: []model.Task{task}})
+	}
+	return groups
+}
+
+// apply validates input and copies it onto view. Relative values are checked
+// but stored unresolved.
+func (s *viewService) apply(ctx context.Context, view *model.SavedView, userID uint, input ViewInput) error {
+	if input.ProjectID != nil {
+		visible, err := s.repo.ProjectVisible(ctx, *input.ProjectID, userID)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	if input.GroupBy != "" {
+		if _, ok := viewGroupSort[input.GroupBy]; !ok {
+			return ErrViewGroupBy
+		}
+	}
+	view.Name = strings.TrimSpace(input.Name)
+	view.ProjectID = input.ProjectID
+	view.Filter = strings.TrimSpace(input.Filter)
+	view.Status = strings.TrimSpace(input.Status)
+	view.AssigneeID = strings.TrimSpace(input.AssigneeID)
+	view.DueFrom = strings.TrimSpace(input.DueFrom)
+	view.DueTo = strings.TrimSpace(input.DueTo)
+	view.Sort = strings.TrimSpace(input.Sort)
+	view.PageSize = input.PageSize
+	view.GroupBy = input.GroupBy
+
+	if _, err := resolveView(*view, userID, time.Now()); err != nil {
+		return err
+	}
+	if view.Filter != "" {
+		node, err := filter.Parse(view.Filter)
+		if err != nil {
+			return err
+		}
+		if _, err := TaskFilterFields.Compile(node, filter.Env{UserID: userID, Now: time.Now()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveView turns a stored view into the task list filter it stands for
+// when run by userID at now.
+func resolveView(view model.SavedView, userID uint, now time.Time) (TaskListFilter, error) {
+	f := TaskListFilter{Filter: view.Filter, UserID: userID, Status: view.Status}
+	if f.Status != "" && f.Status != string(model.TaskTodo) && f.Status != string(model.TaskInProgress) && f.Status != string(model.TaskDone) {
+		return TaskListFilter{}, ErrViewStatus
+	}
+	if view.ProjectID != nil {
+		f.ProjectID = strconv.FormatUint(uint64(*view.ProjectID), 10)
+	}
+	switch {
+	case view.AssigneeID == "":
+	case strings.EqualFold(view.AssigneeID, "me"):
+		f.AssigneeID = strconv.FormatUint(uint64(userID), 10)
+	default:
+		if _, err := strconv.ParseUint(view.AssigneeID, 10, 32); err != nil {
+			return TaskListFilter{}, ErrViewAssignee
+		}
+		f.AssigneeID = view.AssigneeID
+	}
+	for _, bound := range []struct {
+		raw string
+		dst *string
+	}{{view.DueFrom, &f.DueFrom}, {view.DueTo, &f.DueTo}} {
+		if bound.raw == "" {
+			continue
+		}
+		day, ok := filter.ResolveDate(bound.raw, now)
+		if !ok {
+			return TaskListFilter{}, ErrViewDate
+		}
+		*bound.dst = day.Format("2006-01-02")
+	}
+	return f, nil
+}
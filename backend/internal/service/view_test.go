@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"project-management/internal/filter"
+	"project-management/internal/httpx"
+	"project-management/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type stubViewRepo struct {
+	views   map[string]model.SavedView
+	visible map[uint]bool // project ID → visible to the caller
+	saved   *model.SavedView
+}
+
+func (s *stubViewRepo) Create(ctx context.Context, view *model.SavedView) error {
+	view.ID = 1
+	s.saved = view
+	return nil
+}
+func (s *stubViewRepo) Get(ctx context.Context, id string) (model.SavedView, error) {
+	view, ok := s.views[id]
+	if !ok {
+		return model.SavedView{}, gorm.ErrRecordNotFound
+	}
+	return view, nil
+}
+func (s *stubViewRepo) ListForUser(ctx context.Context, userID uint, params httpx.ListParams) ([]model.SavedView, int64, error) {
+	return nil, 0, nil
+}
+func (s *stubViewRepo) Save(ctx context.Context, view *model.SavedView) error {
+	s.saved = view
+	return nil
+}
+func (s *stubViewRepo) Delete(ctx context.Context, id string) error { return nil }
+func (s *stubViewRepo) ProjectVisible(ctx context.Context, projectID, userID uint) (bool, error) {
+	return s.visible[projectID], nil
+}
+
+func TestResolveView(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+	project := uint(3)
+	got, err := resolveView(model.SavedView{ProjectID: &project, Status: "todo", AssigneeID: "me", DueFrom: "today", DueTo: "today+1w", Filter: "label = bug"}, 7, now)
+	if err != nil {
+		t.Fatalf("resolveView: %v", err)
+	}
+	if got.ProjectID != "3" || got.AssigneeID != "7" || got.DueFrom != "2026-10-18" || got.DueTo != "2026-10-25" || got.Filter != "label = bug" || got.UserID != 7 {
+		t.Fatalf("filter = %+v", got)
+	}
+
+	for view, want := range map[*model.SavedView]error{
+		{AssigneeID: "someone"}: ErrViewAssignee,
+		{DueTo: "next week"}:    ErrViewDate,
+		{Status: "blocked"}:     ErrViewStatus,
+	} {
+		if _, err := resolveView(*view, 7, now); !errors.Is(err, want) {
+			t.Fatalf("resolveView(%+v) err = %v, want %v", *view, err, want)
+		}
+	}
+}
+
+func TestViewService(t *testing.T) {
+	ctx := context.Background()
+	shared := uint(3)
+	private := uint(4)
+	repo := &stubViewRepo{
+		views: map[string]model.SavedView{
+			"1": {ID: 1, OwnerID: 7, Name: "Mine", AssigneeID: "me", GroupBy: "status", Sort: "-dueDate", PageSize: 10},
+			"2": {ID: 2, OwnerID: 8, Name: "Shared", ProjectID: &shared},
+			"3": {ID: 3, OwnerID: 8, Name: "Hidden", ProjectID: &private},
+			"4": {ID: 4, OwnerID: 8, Name: "Personal"},
+		},
+		visible: map[uint]bool{shared: true},
+	}
+	var listed TaskListFilter
+	tasks := NewTaskService(stubTaskRepo{listFn: func(ctx context.Context, filter TaskListFilter) ([]model.Task, int64, error) {
+		listed = filter
+		return []model.Task{{ID: 1, Status: model.TaskTodo}, {ID: 2, Status: model.TaskTodo}, {ID: 3, Status: model.TaskDone}}, 3, nil
+	}})
+	svc := NewViewService(repo, tasks)
+
+	t.Run("create validates and stores relative values", func(t *testing.T) {
+		view, err := svc.Create(ctx, 7, ViewInput{Name: " Due soon ", AssigneeID: "me", DueTo: "today+7d", GroupBy: "assignee"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if view.OwnerID != 7 || view.Name != "Due soon" || view.AssigneeID != "me" || view.DueTo != "today+7d" {
+			t.Fatalf("view = %+v", view)
+		}
+		if _, err := svc.Create(ctx, 7, ViewInput{Name: "x", GroupBy: "label"}); !errors.Is(err, ErrViewGroupBy) {
+			t.Fatalf("err = %v, want ErrViewGroupBy", err)
+		}
+		if _, err := svc.Create(ctx, 7, ViewInput{Name: "x", ProjectID: &private}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("err = %v, want not found", err)
+		}
+		var ferr *filter.Error
+		if _, err := svc.Create(ctx, 7, ViewInput{Name: "x", Filter: "owner = me"}); !errors.As(err, &ferr) {
+			t.Fatalf("err = %v, want *filter.Error", err)
+		}
+	})
+
+	t.Run("get hides views the caller cannot see", func(t *testing.T) {
+		if _, err := svc.Get(ctx, "2", 7); err != nil {
+			t.Fatalf("shared view: %v", err)
+		}
+		for _, id := range []string{"3", "4"} {
+			if _, err := svc.Get(ctx, id, 7); !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("view %s err = %v, want not found", id, err)
+			}
+		}
+	})
+
+	t.Run("only the owner can change a view", func(t *testing.T) {
+		if _, err := svc.Update(ctx, "2", 7, ViewInput{Name: "Mine now"}); !errors.Is(err, ErrViewForbidden) {
+			t.Fatalf("update err = %v, want ErrViewForbidden", err)
+		}
+		if err := svc.Delete(ctx, "2", 7); !errors.Is(err, ErrViewForbidden) {
+			t.Fatalf("delete err = %v, want ErrViewForbidden", err)
+		}
+		if err := svc.Delete(ctx, "1", 7); err != nil {
+			t.Fatalf("delete own view: %v", err)
+		}
+	})
+
+	t.Run("tasks runs the view for the caller", func(t *testing.T) {
+		items, total, params, view, err := svc.Tasks(ctx, "1", 7, url.Values{})
+		if err != nil || total != 3 {
+			t.Fatalf("total=%d err=%v", total, err)
+		}
+		if listed.AssigneeID != "7" || params.PageSize != 10 || len(params.Sort) != 2 || params.Sort[0].Field != "status" || params.Sort[1].Field != "dueDate" {
+			t.Fatalf("filter = %+v params = %+v", listed, params)
+		}
+		groups := GroupTasks(view, items)
+		if len(groups) != 2 || groups[0].Key != "todo" || len(groups[0].Items) != 2 || groups[1].Key != "done" {
+			t.Fatalf("groups = %+v", groups)
+		}
+		if GroupTasks(model.SavedView{}, items) != nil {
+			t.Fatal("ungrouped view returned groups")
+		}
+	})
+}
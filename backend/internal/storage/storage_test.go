@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func exerciseStore(t *testing.T, store BlobStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if ok, err := store.Exists(ctx, "ab/abcdef"); err != nil || ok {
+		t.Fatalf("Exists before Put = %v, %v", ok, err)
+	}
+	if _, err := store.Get(ctx, "ab/abcdef"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get missing err = %v", err)
+	}
+
+	content := []byte("hello blob")
+	if err := store.Put(ctx, "ab/abcdef", bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if ok, err := store.Exists(ctx, "ab/abcdef"); err != nil || !ok {
+		t.Fatalf("Exists after Put = %v, %v", ok, err)
+	}
+	rc, err := store.Get(ctx, "ab/abcdef")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(got, content) {
+		t.Fatalf("Get = %q", got)
+	}
+
+	if err := store.Delete(ctx, "ab/abcdef"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "ab/abcdef"); err != nil {
+		t.Fatalf("Delete missing: %v", err)
+	}
+	if ok, _ := store.Exists(ctx, "ab/abcdef"); ok {
+		t.Fatal("expected blob to be deleted")
+	}
+
+	for _, key := range []string{"", "../escape", "/abs", "a//b", `a\b`} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("Put(%q) err = %v", key, err)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	exerciseStore(t, store)
+}
+
+// fakeS3 is a minimal in-memory stand-in for an S3-compatible endpoint.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	t       *testing.T
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !VerifyV4(r, "eu-test-1", "AKID", "SECRET") {
+		f.t.Errorf("bad signature for %s %s: %s", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/bucket/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	srv := httptest.NewServer(&fakeS3{objects: map[string][]byte{}, t: t})
+	defer srv.Close()
+
+	store, err := NewS3Store(S3Config{Endpoint: srv.URL + "/", Region: "eu-test-1", Bucket: "bucket", AccessKey: "AKID", SecretKey: "SECRET"})
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	exerciseStore(t, store)
+}
+
+func TestNewS3StoreRequiresConfig(t *testing.T) {
+	if _, err := NewS3Store(S3Config{Endpoint: "http://localhost:9000"}); err == nil {
+		t.Fatal("expected config error")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("BLOB_STORE", "local")
+	t.Setenv("BLOB_DIR", t.TempDir())
+	if store, err := FromEnv(); err != nil {
+		t.Fatalf("FromEnv local: %v", err)
+	} else if _, ok := store.(*LocalStore); !ok {
+		t.Fatalf("store = %T", store)
+	}
+
+	t.Setenv("BLOB_STORE", "ftp")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("expected unknown store error")
+	}
+}
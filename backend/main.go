@@ -5,16 +5,21 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	_ "project-management/docs"
+	"project-management/internal/config"
 	"project-management/internal/db"
 	"project-management/internal/handler"
+	"project-management/internal/mail"
 	"project-management/internal/middleware"
 	"project-management/internal/repository"
 	"project-management/internal/service"
+	"project-management/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -29,6 +34,9 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
 	database := db.MustOpen()
 
 	r := gin.New()
@@ -43,12 +51,51 @@ func main() {
 	authHandler.Register(api)
 
 	protected := api.Group("/")
-	protected.Use(middleware.JWTAuth())
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(database))
+	protected.Use(middleware.JWTAuth(), middleware.Idempotency(idempotencyService))
 	authHandler.RegisterProtected(protected)
+	blobStore, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("blob store init failed: %v", err)
+	}
+	attachmentService := service.NewAttachmentService(repository.NewAttachmentRepository(database), blobStore)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+	attachmentHandler.RegisterPublic(api)
+	attachmentHandler.Register(protected)
+
 	handler.NewUserHandler(service.NewUserService(repository.NewUserRepository(database))).Register(protected)
-	handler.NewProjectHandler(service.NewProjectService(repository.NewProjectRepository(database))).Register(protected)
-	handler.NewTaskHandler(service.NewTaskService(repository.NewTaskRepository(database))).Register(protected)
-	handler.NewCommentHandler(service.NewCommentService(repository.NewCommentRepository(database))).Register(protected)
+	preconditions := handler.Preconditions{RequireIfMatch: config.GetEnvBool("REQUIRE_IF_MATCH", false)}
+	projectRepository := repository.NewProjectRepository(database)
+	handler.NewProjectHandlerWithPreconditions(service.NewProjectServiceWithDeps(projectRepository, attachmentService), preconditions).Register(protected)
+	handler.NewBundleHandler(service.NewBundleService(repository.NewBundleRepository(database), projectRepository, blobStore)).Register(protected)
+	handler.NewTemplateHandler(service.NewTemplateService(repository.NewTemplateRepository(database), projectRepository)).Register(protected)
+	taskService := service.NewTaskServiceWithDeps(repository.NewTaskRepository(database), attachmentService)
+	handler.NewTaskHandlerWithPreconditions(taskService, preconditions).Register(protected)
+	handler.NewViewHandler(service.NewViewService(repository.NewViewRepository(database), taskService)).Register(protected)
+	calendarHandler := handler.NewCalendarHandler(service.NewCalendarService(repository.NewCalendarRepository(database)))
+	calendarHandler.RegisterPublic(api)
+	calendarHandler.Register(protected)
+	commentHandler := handler.NewCommentHandlerWithPreconditions(service.NewCommentServiceWithDeps(repository.NewCommentRepository(database), attachmentService), preconditions)
+	commentHandler.Register(protected)
+	handler.NewSearchHandler(service.NewSearchService(repository.NewSearchRepository(database))).Register(protected)
+	handler.NewTimeHandler(service.NewTimeService(repository.NewTimeRepository(database))).Register(protected)
+	handler.NewSprintHandler(service.NewSprintService(repository.NewSprintRepository(database))).Register(protected)
+	handler.NewMilestoneHandler(service.NewMilestoneService(repository.NewMilestoneRepository(database))).Register(protected)
+	trashService := service.NewTrashService(repository.NewTrashRepository(database), attachmentService)
+	handler.NewTrashHandler(trashService).Register(protected)
+	go purgeExpired(idempotencyService, trashService)
+	mailer, err := mail.FromEnv()
+	if err != nil {
+		log.Fatalf("mailer init failed: %v", err)
+	}
+	reminderService := service.NewReminderService(repository.NewReminderRepository(database), mailer)
+	handler.NewNotificationHandler(service.NewNotificationService(repository.NewNotificationRepository(database)), reminderService).Register(protected)
+	go sendReminders(reminderService)
+
+	admin := protected.Group("/admin")
+	// Administrators are listed by email in ADMIN_EMAILS, comma-separated.
+	admin.Use(middleware.RequireAdmin(config.GetEnvList("ADMIN_EMAILS")))
+	commentHandler.RegisterAdmin(admin)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -71,8 +118,9 @@ func cors() gin.HandlerFunc {
 		}
 
 		c.Header("Access-Control-Allow-Origin", origin)
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "ETag, Link, X-Total-Count, Idempotent-Replayed, Retry-After")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -83,3 +131,35 @@ func cors() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// purgeExpired deletes expired idempotency keys and trash once an hour.
+func purgeExpired(idempotency service.IdempotencyService, trash service.TrashService) {
+	for range time.Tick(time.Hour) {
+		ctx := context.Background()
+		if _, err := idempotency.Purge(ctx); err != nil {
+			log.Printf("idempotency key purge failed: %v", err)
+		}
+		if n, err := trash.Purge(ctx); err != nil {
+			log.Printf("trash purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("purged %d items from the trash", n)
+		}
+	}
+}
+
+// sendReminders sends due-date reminders every REMINDER_INTERVAL_SECONDS, 60
+// by default; 0 turns them off for this instance. Instances take turns
+// through an advisory lock, so any number of them may run this.
+func sendReminders(reminders service.ReminderService) {
+	interval := config.GetEnvInt("REMINDER_INTERVAL_SECONDS", 60)
+	if interval <= 0 {
+		return
+	}
+	for now := range time.Tick(time.Duration(interval) * time.Second) {
+		if n, err := reminders.Run(context.Background(), now); err != nil {
+			log.Printf("reminders failed: %v", err)
+		} else if n > 0 {
+			log.Printf("sent %d reminders", n)
+		}
+	}
+}
@@ -3,16 +3,21 @@
 package integration_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"project-management/internal/httpx"
+	"project-management/internal/mail"
 	"project-management/internal/model"
 	"project-management/internal/repository"
 	"project-management/internal/service"
+	"project-management/internal/storage"
 
 	"gorm.io/gorm"
 )
@@ -78,10 +83,10 @@ func TestProjectRepositoryIntegration(t *testing.T) {
 	}
 
 	items, total, err := repo.List(ctx, service.ProjectListFilter{
-		Params:       httpx.ListParams{Page: 1, PageSize: 10, Sort: []httpx.SortField{{Field: "title"}}},
-		Query:        "API",
-		Status:       string(model.ProjectActive),
-		IncludeTasks: true,
+		Params:  httpx.ListParams{Page: 1, PageSize: 10, Sort: []httpx.SortField{{Field: "title"}}},
+		Query:   "API",
+		Status:  string(model.ProjectActive),
+		Include: []string{"tasks"},
 	})
 	if err != nil {
 		t.Fatalf("List: %v", err)
@@ -89,8 +94,12 @@ func TestProjectRepositoryIntegration(t *testing.T) {
 	if total != 1 || len(items) != 1 || len(items[0].Tasks) != 2 {
 		t.Fatalf("unexpected list result: total=%d items=%+v", total, items)
 	}
+	// q matches anywhere in the title, not only word prefixes.
+	if _, total, err := repo.List(ctx, service.ProjectListFilter{Params: httpx.ListParams{Page: 1, PageSize: 10}, Query: "ckend"}); err != nil || total != 1 {
+		t.Fatalf("substring List = %d, %v", total, err)
+	}
 
-	got, err := repo.Get(ctx, toStringID(projectA.ID), true)
+	got, err := repo.Get(ctx, toStringID(projectA.ID), []string{"tasks"})
 	if err != nil {
 		t.Fatalf("Get: %v", err)
 	}
@@ -103,7 +112,7 @@ func TestProjectRepositoryIntegration(t *testing.T) {
 		t.Fatalf("Save: %v", err)
 	}
 
-	updated, err := repo.Get(ctx, toStringID(projectA.ID), false)
+	updated, err := repo.Get(ctx, toStringID(projectA.ID), nil)
 	if err != nil {
 		t.Fatalf("Get updated: %v", err)
 	}
@@ -123,10 +132,10 @@ func TestProjectRepositoryIntegration(t *testing.T) {
 		t.Fatalf("unexpected tasks result: total=%d tasks=%+v", taskTotal, tasks)
 	}
 
-	if err := repo.Delete(ctx, toStringID(projectB.ID)); err != nil {
+	if err := repo.Delete(ctx, toStringID(projectB.ID), nil); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
-	_, err = repo.Get(ctx, toStringID(projectB.ID), false)
+	_, err = repo.Get(ctx, toStringID(projectB.ID), nil)
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		t.Fatalf("expected deleted project to be missing, got %v", err)
 	}
@@ -184,13 +193,13 @@ func TestTaskRepositoryIntegration(t *testing.T) {
 	}
 
 	items, total, err := repo.List(ctx, service.TaskListFilter{
-		Params:          httpx.ListParams{Page: 1, PageSize: 10, Sort: []httpx.SortField{{Field: "dueDate"}}},
-		ProjectID:       toStringID(project.ID),
-		Status:          string(model.TaskTodo),
-		AssigneeID:      "8",
-		DueFrom:         "2026-04-01",
-		DueTo:           "2026-04-10",
-		IncludeComments: true,
+		Params:     httpx.ListParams{Page: 1, PageSize: 10, Sort: []httpx.SortField{{Field: "dueDate"}}},
+		ProjectID:  toStringID(project.ID),
+		Status:     string(model.TaskTodo),
+		AssigneeID: "8",
+		DueFrom:    "2026-04-01",
+		DueTo:      "2026-04-10",
+		Include:    []string{"comments"},
 	})
 	if err != nil {
 		t.Fatalf("List: %v", err)
@@ -199,7 +208,7 @@ func TestTaskRepositoryIntegration(t *testing.T) {
 		t.Fatalf("unexpected list result: total=%d items=%+v", total, items)
 	}
 
-	got, err := repo.Get(ctx, toStringID(taskA.ID), true)
+	got, err := repo.Get(ctx, toStringID(taskA.ID), []string{"comments"})
 	if err != nil {
 		t.Fatalf("Get: %v", err)
 	}
@@ -223,10 +232,10 @@ func TestTaskRepositoryIntegration(t *testing.T) {
 		t.Fatalf("unexpected comments result: total=%d comments=%+v", commentTotal, comments)
 	}
 
-	if err := repo.Delete(ctx, toStringID(taskB.ID)); err != nil {
+	if err := repo.Delete(ctx, toStringID(taskB.ID), nil); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
-	_, err = repo.Get(ctx, toStringID(taskB.ID), false)
+	_, err = repo.Get(ctx, toStringID(taskB.ID), nil)
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
 		t.Fatalf("expected deleted task to be missing, got %v", err)
 	}
@@ -345,6 +354,1445 @@ func TestCommentRepositoryCountError(t *testing.T) {
 	}
 }
 
+func TestTaskCursorPaginationIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	repo := repository.NewTaskRepository(db)
+	ctx := context.Background()
+
+	project := &model.Project{Title: "Paging", Status: model.ProjectActive}
+	if err := db.Create(project).Error; err != nil {
+		t.Fatalf("seed project: %v", err)
+	}
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	var want []uint
+	for i := 0; i < 7; i++ {
+		task := &model.Task{ProjectID: project.ID, Title: fmt.Sprintf("Task %d", i), Status: model.TaskTodo}
+		if i%3 != 0 {
+			d := due.AddDate(0, 0, i%2) // repeated due dates exercise the id tiebreak
+			task.DueDate = &d
+		}
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("seed task: %v", err)
+		}
+	}
+	var all []model.Task
+	if err := db.Order("due_date DESC, id DESC").Find(&all).Error; err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	for _, task := range all {
+		want = append(want, task.ID)
+	}
+
+	list := func(q url.Values) ([]uint, *httpx.CursorPage) {
+		p, err := httpx.ParseListQuery(q)
+		if err != nil {
+			t.Fatalf("ParseListQuery: %v", err)
+		}
+		items, _, err := repo.List(ctx, service.TaskListFilter{Params: p})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		ids := make([]uint, len(items))
+		for i, task := range items {
+			ids[i] = task.ID
+		}
+		return ids, p.Cursor
+	}
+
+	var got []uint
+	var pages []*httpx.CursorPage
+	q := url.Values{"limit": {"3"}, "sort": {"-dueDate"}}
+	for {
+		ids, page := list(q)
+		got = append(got, ids...)
+		pages = append(pages, page)
+		if page.Next == "" {
+			break
+		}
+		q = url.Values{"cursor": {page.Next}, "limit": {"3"}}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) || len(pages) != 3 {
+		t.Fatalf("paged ids = %v over %d pages, want %v", got, len(pages), want)
+	}
+
+	// A task created while paging does not shift the remaining pages.
+	if err := db.Create(&model.Task{ProjectID: project.ID, Title: "Late", Status: model.TaskTodo}).Error; err != nil {
+		t.Fatalf("seed task: %v", err)
+	}
+	ids, _ := list(url.Values{"cursor": {pages[0].Next}, "limit": {"3"}})
+	if fmt.Sprint(ids) != fmt.Sprint(want[3:6]) {
+		t.Fatalf("second page after insert = %v, want %v", ids, want[3:6])
+	}
+
+	ids, back := list(url.Values{"cursor": {pages[2].Prev}, "limit": {"3"}})
+	if fmt.Sprint(ids) != fmt.Sprint(want[3:6]) || back.Next == "" || back.Prev == "" {
+		t.Fatalf("previous page = %v (%+v), want %v", ids, back, want[3:6])
+	}
+}
+
+func TestTaskFilterAndLabelsIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	repo := repository.NewTaskRepository(db)
+	svc := service.NewTaskService(repo)
+	ctx := context.Background()
+
+	project := &model.Project{Title: "Filters", Status: model.ProjectActive}
+	if err := db.Create(project).Error; err != nil {
+		t.Fatalf("seed project: %v", err)
+	}
+	me := uint(5)
+	soon := time.Now().UTC().AddDate(0, 0, 2)
+	bug, err := svc.Create(ctx, service.TaskCreateInput{ProjectID: project.ID, Title: "Crash", Status: model.TaskTodo, Labels: []string{"bug", "ui"}})
+	if err != nil {
+		t.Fatalf("Create bug: %v", err)
+	}
+	mine, err := svc.Create(ctx, service.TaskCreateInput{ProjectID: project.ID, Title: "Mine", Status: model.TaskInProgress, AssigneeID: &me, Labels: []string{"bug"}})
+	if err != nil {
+		t.Fatalf("Create mine: %v", err)
+	}
+	if _, err := svc.Create(ctx, service.TaskCreateInput{ProjectID: project.ID, Title: "Soon", Status: model.TaskDone, DueDate: &soon}); err != nil {
+		t.Fatalf("Create soon: %v", err)
+	}
+
+	var labelCount int64
+	db.Model(&model.Label{}).Count(&labelCount)
+	if labelCount != 2 || mine.Labels[0].ID != bug.Labels[0].ID {
+		t.Fatalf("labels not shared: count=%d bug=%+v mine=%+v", labelCount, bug.Labels, mine.Labels)
+	}
+
+	list := func(expr string) []string {
+		items, _, err := svc.List(ctx, service.TaskListFilter{Params: httpx.ListParams{Page: 1, PageSize: 10, Sort: []httpx.SortField{{Field: "title"}}}, Filter: expr, UserID: me})
+		if err != nil {
+			t.Fatalf("List(%q): %v", expr, err)
+		}
+		titles := make([]string, len(items))
+		for i, item := range items {
+			titles[i] = item.Title
+		}
+		return titles
+	}
+	tests := map[string]string{
+		"status in (todo, in_progress) and label = bug":    "Crash,Mine",
+		"assignee = me or due <= today+7d":                 "Mine,Soon",
+		"assignee != me and not label = null":              "Crash",
+		`label not in (ui) and title ~ "i"`:                "Mine",
+		"label = ui or (status = done and due > today+1w)": "Crash",
+	}
+	for expr, want := range tests {
+		if got := strings.Join(list(expr), ","); got != want {
+			t.Fatalf("filter %q = %q, want %q", expr, got, want)
+		}
+	}
+
+	updated, err := svc.Update(ctx, toStringID(bug.ID), service.TaskUpdateInput{Labels: &[]string{"ui", "regression"}})
+	if err != nil {
+		t.Fatalf("Update labels: %v", err)
+	}
+	got, err := repo.Get(ctx, toStringID(updated.ID), nil)
+	if err != nil || len(got.Labels) != 2 {
+		t.Fatalf("labels after update = %+v err=%v", got.Labels, err)
+	}
+	if titles := list("label = bug"); len(titles) != 1 || titles[0] != "Mine" {
+		t.Fatalf("label = bug after update: %v", titles)
+	}
+}
+
+func TestSearchRepositoryIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	repo := repository.NewSearchRepository(db)
+	ctx := context.Background()
+
+	owner, stranger, assignee := uint(1), uint(2), uint(3)
+	theirs := &model.Project{Title: "Deployment pipeline", Description: "Release tooling", Status: model.ProjectActive, OwnerID: &stranger}
+	private := &model.Project{Title: "Secret deploys", Status: model.ProjectActive, OwnerID: &owner}
+	unowned := &model.Project{Title: "Legacy deploys", Status: model.ProjectActive}
+	for _, p := range []*model.Project{theirs, private, unowned} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("seed project: %v", err)
+		}
+	}
+	task := &model.Task{ProjectID: private.ID, Title: "Rotate keys", Description: "Before the next deployment", Status: model.TaskTodo, AssigneeID: &assignee}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatalf("seed task: %v", err)
+	}
+	deletedAt := time.Now()
+	comments := []*model.Comment{
+		{TaskID: task.ID, Author: "Ann", Text: "deploy <script> after review"},
+		{TaskID: task.ID, Author: "Ann", Text: "deploy later", DeletedAt: &deletedAt},
+	}
+	for _, c := range comments {
+		if err := db.Create(c).Error; err != nil {
+			t.Fatalf("seed comment: %v", err)
+		}
+	}
+
+	search := func(userID uint, types ...string) []model.SearchHit {
+		items, total, err := repo.Search(ctx, service.SearchFilter{Params: httpx.ListParams{Page: 1, PageSize: 10}, Query: "depl", Types: types, UserID: userID})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if int(total) != len(items) {
+			t.Fatalf("total = %d, items = %d", total, len(items))
+		}
+		return items
+	}
+
+	// Another user's project is hidden; a project without an owner is
+	// everyone's, as in the project list.
+	if items := search(stranger); len(items) != 2 || items[0].Type != service.SearchProject || items[1].Type != service.SearchProject {
+		t.Fatalf("stranger hits = %+v", items)
+	}
+	if items := search(owner); len(items) != 4 {
+		t.Fatalf("owner hits = %+v", items)
+	}
+	for _, hit := range search(owner) {
+		if hit.ProjectID == theirs.ID {
+			t.Fatalf("owner sees another user's project: %+v", hit)
+		}
+	}
+	listed, total, err := repository.NewProjectRepository(db).List(ctx, service.ProjectListFilter{Params: httpx.ListParams{Page: 1, PageSize: 10}, UserID: stranger})
+	if err != nil || total != 2 || len(listed) != 2 || listed[0].ID == private.ID || listed[1].ID == private.ID {
+		t.Fatalf("stranger's projects = %+v, %d, %v", listed, total, err)
+	}
+	items := search(assignee, service.SearchComment)
+	if len(items) != 1 || items[0].ID != comments[0].ID || items[0].TaskID == nil || *items[0].TaskID != task.ID || items[0].ProjectID != private.ID {
+		t.Fatalf("comment hits = %+v", items)
+	}
+	if want := service.SearchMarkStart + "deploy" + service.SearchMarkStop; !strings.Contains(items[0].Snippet, want) {
+		t.Fatalf("snippet = %q", items[0].Snippet)
+	}
+}
+
+func TestViewRepositoryIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	repo := repository.NewViewRepository(db)
+	ctx := context.Background()
+
+	ann := &model.User{Email: "ann@example.com", Name: "Ann", PasswordHash: "hash-a"}
+	bob := &model.User{Email: "bob@example.com", Name: "Bob", PasswordHash: "hash-b"}
+	for _, u := range []*model.User{ann, bob} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("seed user: %v", err)
+		}
+	}
+	shared := &model.Project{Title: "Shared", Status: model.ProjectActive}
+	private := &model.Project{Title: "Private", Status: model.ProjectActive, OwnerID: &ann.ID}
+	for _, p := range []*model.Project{shared, private} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("seed project: %v", err)
+		}
+	}
+	views := []*model.SavedView{
+		{OwnerID: ann.ID, Name: "Mine", AssigneeID: "me"},
+		{OwnerID: ann.ID, Name: "Team", ProjectID: &shared.ID, GroupBy: "status"},
+		{OwnerID: ann.ID, Name: "Secret", ProjectID: &private.ID},
+	}
+	for _, v := range views {
+		if err := repo.Create(ctx, v); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	list := func(userID uint) []string {
+		items, _, err := repo.ListForUser(ctx, userID, httpx.ListParams{Page: 1, PageSize: 20})
+		if err != nil {
+			t.Fatalf("ListForUser: %v", err)
+		}
+		names := make([]string, len(items))
+		for i, v := range items {
+			names[i] = v.Name
+		}
+		return names
+	}
+	if got := list(ann.ID); len(got) != 3 || got[0] != "Mine" || got[2] != "Team" {
+		t.Fatalf("ann views = %v", got)
+	}
+	if got := list(bob.ID); len(got) != 1 || got[0] != "Team" {
+		t.Fatalf("bob views = %v", got)
+	}
+	// Views page like the other lists, here in cursor mode.
+	params, _ := httpx.ParseListQuery(url.Values{"limit": {"2"}})
+	first, total, err := repo.ListForUser(ctx, ann.ID, params)
+	if err != nil || total != 3 || len(first) != 2 || params.Cursor.Next == "" {
+		t.Fatalf("first page = %+v, %d, %v", first, total, err)
+	}
+	params, _ = httpx.ParseListQuery(url.Values{"cursor": {params.Cursor.Next}})
+	if rest, _, err := repo.ListForUser(ctx, ann.ID, params); err != nil || len(rest) != 1 || rest[0].Name != "Team" || params.Cursor.Next != "" {
+		t.Fatalf("second page = %+v, %v", rest, err)
+	}
+
+	if visible, err := repo.ProjectVisible(ctx, private.ID, bob.ID); err != nil || visible {
+		t.Fatalf("private visible to bob = %v, err = %v", visible, err)
+	}
+	if err := db.Create(&model.Task{ProjectID: private.ID, Title: "Help", Status: model.TaskTodo, AssigneeID: &bob.ID}).Error; err != nil {
+		t.Fatalf("seed task: %v", err)
+	}
+	if visible, err := repo.ProjectVisible(ctx, private.ID, bob.ID); err != nil || !visible {
+		t.Fatalf("private visible to assignee = %v, err = %v", visible, err)
+	}
+
+	if err := db.Unscoped().Delete(shared).Error; err != nil {
+		t.Fatalf("delete project: %v", err)
+	}
+	if _, err := repo.Get(ctx, toStringID(views[1].ID)); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("view of deleted project err = %v, want not found", err)
+	}
+}
+
+func TestProjectNestedIncludeIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	repo := repository.NewProjectRepository(db)
+
+	owner := &model.User{Email: "owner@example.com", Name: "Owner", PasswordHash: "x"}
+	if err := db.Create(owner).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	project := &model.Project{Title: "Board", Status: model.ProjectActive, OwnerID: &owner.ID}
+	if err := repo.Create(ctx, project); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	task := &model.Task{ProjectID: project.ID, Title: "Card", Status: model.TaskTodo, AssigneeID: &owner.ID}
+	if err := repo.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if err := db.Create(&model.Comment{TaskID: task.ID, Author: "owner", Text: "hi"}).Error; err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+
+	got, err := repo.Get(ctx, toStringID(project.ID), []string{"owner", "tasks", "tasks.comments", "tasks.assignee"})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Owner == nil || got.Owner.ID != owner.ID || len(got.Tasks) != 1 {
+		t.Fatalf("unexpected project: %+v", got)
+	}
+	if tk := got.Tasks[0]; tk.Assignee == nil || tk.Assignee.Email != owner.Email || len(tk.Comments) != 1 {
+		t.Fatalf("unexpected task: %+v", tk)
+	}
+
+	got.Title = "Board v2"
+	if err := repo.Save(ctx, &got); err != nil {
+		t.Fatalf("Save with loaded relations: %v", err)
+	}
+}
+
+func TestTaskVersionIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projects := repository.NewProjectRepository(db)
+	repo := repository.NewTaskRepository(db)
+
+	project := &model.Project{Title: "Versions", Status: model.ProjectActive}
+	if err := projects.Create(ctx, project); err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	task := &model.Task{ProjectID: project.ID, Title: "Edit me", Status: model.TaskTodo}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if task.Version != 1 {
+		t.Fatalf("version after create = %d, want 1", task.Version)
+	}
+
+	mine, err := repo.Get(ctx, toStringID(task.ID), nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	theirs := mine
+	mine.Title = "Mine"
+	if err := repo.Save(ctx, &mine); err != nil || mine.Version != 2 {
+		t.Fatalf("Save = %v, version %d", err, mine.Version)
+	}
+	theirs.Title = "Theirs"
+	if err := repo.Save(ctx, &theirs); !errors.Is(err, service.ErrVersionMismatch) || theirs.Version != 1 {
+		t.Fatalf("stale Save = %v, version %d; want ErrVersionMismatch", err, theirs.Version)
+	}
+	got, err := repo.Get(ctx, toStringID(task.ID), nil)
+	if err != nil || got.Title != "Mine" || got.Version != 2 {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+
+	stale, current := uint(1), uint(2)
+	if err := repo.Delete(ctx, toStringID(task.ID), &stale); !errors.Is(err, service.ErrVersionMismatch) {
+		t.Fatalf("stale Delete = %v, want ErrVersionMismatch", err)
+	}
+	if err := repo.Delete(ctx, toStringID(task.ID), &current); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, toStringID(task.ID), &current); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Delete missing = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestTaskBulkIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projects := repository.NewProjectRepository(db)
+	repo := repository.NewTaskRepository(db)
+	svc := service.NewTaskService(repo)
+
+	from := &model.Project{Title: "From", Status: model.ProjectActive}
+	to := &model.Project{Title: "To", Status: model.ProjectActive}
+	for _, p := range []*model.Project{from, to} {
+		if err := projects.Create(ctx, p); err != nil {
+			t.Fatalf("Create project: %v", err)
+		}
+	}
+	var ids []uint
+	for _, title := range []string{"A", "B", "C"} {
+		task := &model.Task{ProjectID: from.ID, Title: title, Status: model.TaskDone, Labels: []model.Label{{ProjectID: from.ID, Name: "bug"}}}
+		if err := repo.Create(ctx, task); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, task.ID)
+	}
+
+	move := service.TaskBulkInput{Selection: service.TaskSelection{Filter: &service.TaskListFilter{ProjectID: toStringID(from.ID), Filter: "status = done"}}, Op: service.BulkMove, ProjectID: to.ID}
+	report, err := svc.Bulk(ctx, service.TaskBulkInput{Selection: move.Selection, Op: move.Op, ProjectID: move.ProjectID, DryRun: true})
+	if err != nil || report.Applied || report.Changed != 3 {
+		t.Fatalf("dry run = %+v, %v", report, err)
+	}
+	if got, _ := repo.Get(ctx, toStringID(ids[0]), nil); got.ProjectID != from.ID {
+		t.Fatalf("dry run moved the task: %+v", got)
+	}
+
+	// A task changed since it was selected rolls back the whole operation.
+	stale := []model.Task{}
+	for _, id := range ids {
+		task, _ := repo.Get(ctx, toStringID(id), nil)
+		task.ProjectID = to.ID
+		stale = append(stale, task)
+	}
+	if err := db.Model(&model.Task{}).Where("id = ?", ids[2]).Update("version", 5).Error; err != nil {
+		t.Fatalf("bump version: %v", err)
+	}
+	var itemErr *service.TaskBulkError
+	if err := repo.ApplyBulk(ctx, stale, nil, nil); !errors.As(err, &itemErr) || itemErr.TaskID != ids[2] {
+		t.Fatalf("ApplyBulk = %v, want a TaskBulkError for task %d", err, ids[2])
+	}
+	if got, _ := repo.Get(ctx, toStringID(ids[0]), nil); got.ProjectID != from.ID {
+		t.Fatalf("rolled back task moved: %+v", got)
+	}
+
+	report, err = svc.Bulk(ctx, move)
+	if err != nil || !report.Applied || report.Changed != 3 {
+		t.Fatalf("move = %+v, %v", report, err)
+	}
+	moved, err := repo.Get(ctx, toStringID(ids[0]), nil)
+	if err != nil || moved.ProjectID != to.ID || len(moved.Labels) != 1 || moved.Labels[0].ProjectID != to.ID {
+		t.Fatalf("moved = %+v, %v", moved, err)
+	}
+
+	report, err = svc.Bulk(ctx, service.TaskBulkInput{Selection: service.TaskSelection{IDs: ids[:2]}, Op: service.BulkDelete})
+	if err != nil || !report.Applied {
+		t.Fatalf("delete = %+v, %v", report, err)
+	}
+	if _, err := repo.Get(ctx, toStringID(ids[1]), nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Get deleted = %v", err)
+	}
+}
+
+func TestIdempotencyRepositoryIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	repo := repository.NewIdempotencyRepository(db)
+	now := time.Now()
+	lease := now.Add(time.Minute)
+
+	first := &model.IdempotencyKey{UserID: 1, Key: "k", Fingerprint: "f", CreatedAt: now, LockedUntil: &lease, ExpiresAt: now.Add(time.Hour)}
+	if ok, err := repo.Reserve(ctx, first); err != nil || !ok {
+		t.Fatalf("Reserve = %v, %v", ok, err)
+	}
+	again := &model.IdempotencyKey{UserID: 1, Key: "k", Fingerprint: "f", CreatedAt: now, LockedUntil: &lease, ExpiresAt: now.Add(time.Hour)}
+	if ok, err := repo.Reserve(ctx, again); err != nil || ok {
+		t.Fatalf("second Reserve = %v, %v; want the key taken", ok, err)
+	}
+
+	// A reservation whose request never finished is free once its lease ends.
+	lapsed := now.Add(2 * time.Minute)
+	takeover := &model.IdempotencyKey{UserID: 1, Key: "k", Fingerprint: "f", CreatedAt: lapsed, LockedUntil: &lease, ExpiresAt: now.Add(time.Hour)}
+	if ok, err := repo.Reserve(ctx, takeover); err != nil || !ok {
+		t.Fatalf("Reserve over a lapsed lease = %v, %v", ok, err)
+	}
+	first = takeover
+	other := &model.IdempotencyKey{UserID: 2, Key: "k", Fingerprint: "f", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if ok, err := repo.Reserve(ctx, other); err != nil || !ok {
+		t.Fatalf("Reserve for another user = %v, %v", ok, err)
+	}
+
+	first.Status, first.Header, first.Body = 201, `{"Content-Type":"application/json"}`, []byte(`{"id":1}`)
+	if err := repo.Complete(ctx, *first); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	got, err := repo.Find(ctx, 1, "k")
+	if err != nil || got.Status != 201 || string(got.Body) != `{"id":1}` || got.LockedUntil != nil {
+		t.Fatalf("Find = %+v, %v", got, err)
+	}
+	if ok, err := repo.Reserve(ctx, &model.IdempotencyKey{UserID: 1, Key: "k", Fingerprint: "f", CreatedAt: lapsed, ExpiresAt: now.Add(time.Hour)}); err != nil || ok {
+		t.Fatalf("Reserve over a completed key = %v, %v; want it kept", ok, err)
+	}
+
+	later := now.Add(2 * time.Hour)
+	expired := &model.IdempotencyKey{UserID: 1, Key: "k", Fingerprint: "g", CreatedAt: later, ExpiresAt: later.Add(time.Hour)}
+	if ok, err := repo.Reserve(ctx, expired); err != nil || !ok {
+		t.Fatalf("Reserve over an expired key = %v, %v", ok, err)
+	}
+	if n, err := repo.DeleteExpired(ctx, later); err != nil || n != 1 {
+		t.Fatalf("DeleteExpired = %d, %v; want the other user's key", n, err)
+	}
+}
+
+func TestTrashIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	users := repository.NewAuthRepository(db)
+	projects := repository.NewProjectRepository(db)
+	tasks := repository.NewTaskRepository(db)
+	comments := service.NewCommentService(repository.NewCommentRepository(db))
+	trash := repository.NewTrashRepository(db)
+
+	owner := &model.User{Email: "owner@example.com", Name: "Owner", PasswordHash: "x"}
+	other := &model.User{Email: "other@example.com", Name: "Other", PasswordHash: "x"}
+	for _, u := range []*model.User{owner, other} {
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatalf("Create user: %v", err)
+		}
+	}
+	project := &model.Project{Title: "Trash", Status: model.ProjectActive, OwnerID: &owner.ID}
+	if err := projects.Create(ctx, project); err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	early := &model.Task{ProjectID: project.ID, Title: "Deleted first", Status: model.TaskTodo}
+	kept := &model.Task{ProjectID: project.ID, Title: "Deleted with project", Status: model.TaskTodo}
+	for _, task := range []*model.Task{early, kept} {
+		if err := tasks.Create(ctx, task); err != nil {
+			t.Fatalf("Create task: %v", err)
+		}
+	}
+	comment, err := comments.Create(ctx, service.CommentCreateInput{TaskID: kept.ID, Author: "Owner", Text: "first"})
+	if err != nil {
+		t.Fatalf("Create comment: %v", err)
+	}
+	text := "keep me"
+	if _, err := comments.Update(ctx, toStringID(comment.ID), service.CommentUpdateInput{Text: &text}); err != nil {
+		t.Fatalf("Update comment: %v", err)
+	}
+	if _, err := comments.AddReaction(ctx, toStringID(comment.ID), owner.ID, "👍"); err != nil {
+		t.Fatalf("AddReaction: %v", err)
+	}
+	threads := func() (model.Comment, int64) {
+		got, err := comments.Get(ctx, toStringID(comment.ID))
+		if err != nil {
+			t.Fatalf("Get comment: %v", err)
+		}
+		_, revisions, err := comments.ListRevisions(ctx, toStringID(comment.ID), httpx.ListParams{Page: 1, PageSize: 10})
+		if err != nil {
+			t.Fatalf("ListRevisions: %v", err)
+		}
+		return got, revisions
+	}
+
+	if err := comments.Delete(ctx, toStringID(comment.ID), nil); err != nil {
+		t.Fatalf("Delete comment: %v", err)
+	}
+	// The tombstone hides the history and reactions but keeps them.
+	if tombstone, revisions := threads(); len(tombstone.Reactions) != 0 || revisions != 0 {
+		t.Fatalf("tombstone = %+v with %d revisions", tombstone, revisions)
+	}
+	if err := tasks.Delete(ctx, toStringID(early.ID), nil); err != nil {
+		t.Fatalf("Delete task: %v", err)
+	}
+	items, total, err := trash.List(ctx, service.TrashFilter{Params: httpx.ListParams{Page: 1, PageSize: 10}, UserID: owner.ID})
+	if err != nil || total != 2 {
+		t.Fatalf("List = %+v total %d, %v", items, total, err)
+	}
+	if _, total, _ := trash.List(ctx, service.TrashFilter{Params: httpx.ListParams{Page: 1, PageSize: 10}, UserID: other.ID}); total != 0 {
+		t.Fatalf("other user sees %d trashed items", total)
+	}
+
+	if err := trash.Restore(ctx, service.TrashComment, toStringID(comment.ID), owner.ID); err != nil {
+		t.Fatalf("Restore comment: %v", err)
+	}
+	restored, err := comments.Get(ctx, toStringID(comment.ID))
+	if err != nil || restored.Text != "keep me" || restored.DeletedAt != nil {
+		t.Fatalf("restored comment = %+v, %v", restored, err)
+	}
+	if restored, revisions := threads(); len(restored.Reactions) != 1 || revisions != 1 {
+		t.Fatalf("restored comment = %+v with %d revisions", restored, revisions)
+	}
+
+	if err := projects.Delete(ctx, toStringID(project.ID), nil); err != nil {
+		t.Fatalf("Delete project: %v", err)
+	}
+	if _, err := tasks.Get(ctx, toStringID(kept.ID), nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("task of deleted project = %v, want not found", err)
+	}
+	if _, err := comments.Get(ctx, toStringID(comment.ID)); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("comment of deleted project = %v, want not found", err)
+	}
+	items, _, _ = trash.List(ctx, service.TrashFilter{Params: httpx.ListParams{Page: 1, PageSize: 10}, UserID: owner.ID})
+	if len(items) != 1 || items[0].Type != service.TrashProject {
+		t.Fatalf("trash after project delete = %+v", items)
+	}
+	if err := trash.Restore(ctx, service.TrashTask, toStringID(early.ID), owner.ID); !errors.Is(err, service.ErrTrashParentDeleted) {
+		t.Fatalf("Restore task of deleted project = %v, want ErrTrashParentDeleted", err)
+	}
+	if err := trash.Restore(ctx, service.TrashProject, toStringID(project.ID), other.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Restore by other user = %v, want not found", err)
+	}
+	if err := trash.Restore(ctx, service.TrashProject, toStringID(project.ID), owner.ID); err != nil {
+		t.Fatalf("Restore project: %v", err)
+	}
+	if _, err := tasks.Get(ctx, toStringID(kept.ID), nil); err != nil {
+		t.Fatalf("task deleted with the project not restored: %v", err)
+	}
+	if _, err := tasks.Get(ctx, toStringID(early.ID), nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("task deleted before the project was restored too: %v", err)
+	}
+	if err := db.Model(&model.Project{}).Where("id = ?", project.ID).Update("status", model.ProjectArchived).Error; err != nil {
+		t.Fatalf("archive project: %v", err)
+	}
+	if err := trash.Restore(ctx, service.TrashTask, toStringID(early.ID), owner.ID); !errors.Is(err, service.ErrProjectArchived) {
+		t.Fatalf("Restore task of archived project = %v, want ErrProjectArchived", err)
+	}
+
+	n, err := trash.Purge(ctx, time.Now().Add(time.Minute))
+	if err != nil || n != 1 {
+		t.Fatalf("Purge = %d, %v", n, err)
+	}
+	var count int64
+	db.Unscoped().Model(&model.Task{}).Where("id = ?", early.ID).Count(&count)
+	if count != 0 {
+		t.Fatal("purged task still stored")
+	}
+}
+
+func TestArchivedProjectIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projectRepo := repository.NewProjectRepository(db)
+	projects := service.NewProjectService(projectRepo)
+	tasks := repository.NewTaskRepository(db)
+	comments := repository.NewCommentRepository(db)
+	users := repository.NewAuthRepository(db)
+
+	user := &model.User{Email: "archiver@example.com", Name: "Archiver", PasswordHash: "x"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	project, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Old", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	other, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Current", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	task := &model.Task{ProjectID: project.ID, Title: "Done long ago", Status: model.TaskDone}
+	if err := tasks.Create(ctx, task); err != nil {
+		t.Fatalf("Create task: %v", err)
+	}
+	comment := &model.Comment{TaskID: task.ID, Author: "Archiver", Text: "shipped"}
+	if err := comments.Create(ctx, comment); err != nil {
+		t.Fatalf("Create comment: %v", err)
+	}
+
+	archived, err := projects.Archive(ctx, toStringID(project.ID), user.ID, nil)
+	if err != nil || archived.ArchivedBy == nil || *archived.ArchivedBy != user.ID || archived.ArchivedAt == nil {
+		t.Fatalf("Archive = %+v, %v", archived, err)
+	}
+
+	page := httpx.ListParams{Page: 1, PageSize: 10}
+	if items, total, err := projectRepo.List(ctx, service.ProjectListFilter{Params: page}); err != nil || total != 1 || items[0].ID != other.ID {
+		t.Fatalf("default List = %+v total %d, %v", items, total, err)
+	}
+	if _, total, _ := projectRepo.List(ctx, service.ProjectListFilter{Params: page, IncludeArchived: true}); total != 2 {
+		t.Fatalf("List with archived total = %d, want 2", total)
+	}
+	if _, total, _ := projectRepo.List(ctx, service.ProjectListFilter{Params: page, Status: string(model.ProjectArchived)}); total != 1 {
+		t.Fatalf("List of archived total = %d, want 1", total)
+	}
+	if _, total, _ := tasks.List(ctx, service.TaskListFilter{Params: page}); total != 0 {
+		t.Fatalf("default task List total = %d, want 0", total)
+	}
+	if _, total, _ := tasks.List(ctx, service.TaskListFilter{Params: page, ProjectID: toStringID(project.ID)}); total != 1 {
+		t.Fatalf("task List of archived project total = %d, want 1", total)
+	}
+
+	task.Title = "Renamed"
+	writes := map[string]error{
+		"create task":    tasks.Create(ctx, &model.Task{ProjectID: project.ID, Title: "New", Status: model.TaskTodo}),
+		"save task":      tasks.Save(ctx, task),
+		"delete task":    tasks.Delete(ctx, toStringID(task.ID), nil),
+		"project task":   projectRepo.CreateTask(ctx, &model.Task{ProjectID: project.ID, Title: "New", Status: model.TaskTodo}),
+		"create comment": comments.Create(ctx, &model.Comment{TaskID: task.ID, Author: "A", Text: "late"}),
+		"save comment":   comments.Save(ctx, comment),
+		"react":          comments.AddReaction(ctx, &model.CommentReaction{CommentID: comment.ID, UserID: user.ID, Emoji: "👍"}),
+	}
+	for name, err := range writes {
+		if !errors.Is(err, service.ErrProjectArchived) {
+			t.Fatalf("%s err = %v, want ErrProjectArchived", name, err)
+		}
+	}
+	moved := model.Task{ID: task.ID, ProjectID: other.ID, Title: "Moved", Status: model.TaskDone, Version: task.Version}
+	if err := tasks.Save(ctx, &moved); !errors.Is(err, service.ErrProjectArchived) {
+		t.Fatalf("moving out of archived project err = %v", err)
+	}
+
+	if _, err := projects.Unarchive(ctx, toStringID(project.ID), nil); err != nil {
+		t.Fatalf("Unarchive: %v", err)
+	}
+	if err := tasks.Save(ctx, task); err != nil {
+		t.Fatalf("Save after unarchive: %v", err)
+	}
+}
+
+func TestProjectCloneAndTemplateIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projectRepo := repository.NewProjectRepository(db)
+	projects := service.NewProjectService(projectRepo)
+	templates := service.NewTemplateService(repository.NewTemplateRepository(db), projectRepo)
+	tasks := service.NewTaskService(repository.NewTaskRepository(db))
+
+	source, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Onboarding", Description: "Steps", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	due := time.Now().UTC().Add(72 * time.Hour)
+	for _, input := range []service.TaskCreateInput{
+		{ProjectID: source.ID, Title: "Kickoff", Status: model.TaskDone, DueDate: &due, Labels: []string{"client"}},
+		{ProjectID: source.ID, Title: "Invoice", Status: model.TaskTodo, Labels: []string{"billing", "client"}},
+	} {
+		if _, err := tasks.Create(ctx, input); err != nil {
+			t.Fatalf("Create task: %v", err)
+		}
+	}
+
+	all := service.CopyOptions{Tasks: true, Labels: true, Descriptions: true, ResetStatuses: true}
+	clone, err := projects.Clone(ctx, toStringID(source.ID), service.ProjectCloneInput{Options: all})
+	if err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	tree, labels, err := projectRepo.GetTree(ctx, toStringID(clone.ID))
+	if err != nil {
+		t.Fatalf("GetTree: %v", err)
+	}
+	if tree.Title != "Onboarding (copy)" || tree.Description != "Steps" || len(tree.Tasks) != 2 || len(labels) != 2 {
+		t.Fatalf("clone = %+v, labels = %+v", tree, labels)
+	}
+	kickoff := tree.Tasks[0]
+	if kickoff.Status != model.TaskTodo || kickoff.DueDate == nil || !kickoff.DueDate.Equal(due.Truncate(time.Microsecond)) || len(kickoff.Labels) != 1 || kickoff.Labels[0].ProjectID != clone.ID {
+		t.Fatalf("cloned kickoff = %+v", kickoff)
+	}
+
+	template, err := templates.Create(ctx, service.TemplateCreateInput{ProjectID: source.ID, Name: "Client onboarding", CreatedBy: 1, Options: all})
+	if err != nil {
+		t.Fatalf("Create template: %v", err)
+	}
+	stored, err := templates.Get(ctx, toStringID(template.ID))
+	if err != nil || len(stored.Tasks) != 2 || stored.Tasks[0].DueInDays == nil || *stored.Tasks[0].DueInDays != 3 || len(stored.Labels) != 2 {
+		t.Fatalf("stored template = %+v, %v", stored, err)
+	}
+	start := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	created, err := templates.Use(ctx, toStringID(template.ID), service.TemplateUseInput{Title: "ACME", StartDate: &start})
+	if err != nil {
+		t.Fatalf("Use template: %v", err)
+	}
+	tree, labels, err = projectRepo.GetTree(ctx, toStringID(created.ID))
+	if err != nil || tree.Title != "ACME" || len(tree.Tasks) != 2 || len(labels) != 2 {
+		t.Fatalf("project from template = %+v, labels = %+v, %v", tree, labels, err)
+	}
+	if d := tree.Tasks[0].DueDate; d == nil || !d.Equal(start.AddDate(0, 0, 3)) {
+		t.Fatalf("due = %v", d)
+	}
+}
+
+func TestProjectTaskImportExportIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projects := service.NewProjectService(repository.NewProjectRepository(db))
+
+	project, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Import", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	inputs := make([]service.ProjectTaskCreateInput, 7)
+	for i := range inputs {
+		status := model.TaskTodo
+		if i%2 == 1 {
+			status = model.TaskDone
+		}
+		inputs[i] = service.ProjectTaskCreateInput{Title: fmt.Sprintf("Task %d", i), Status: status}
+	}
+	if _, err := projects.ImportTasks(ctx, project.ID, inputs, true); err != nil {
+		t.Fatalf("ImportTasks dry run: %v", err)
+	}
+	if _, total, _ := projects.ListTasks(ctx, project.ID, service.ProjectTaskListFilter{}); total != 0 {
+		t.Fatalf("dry run created %d tasks", total)
+	}
+	created, err := projects.ImportTasks(ctx, project.ID, inputs, false)
+	if err != nil || len(created) != 7 || created[6].ID == 0 {
+		t.Fatalf("ImportTasks = %+v, %v", created, err)
+	}
+
+	var batches, exported int
+	err = projects.ExportTasks(ctx, project.ID, service.ProjectTaskListFilter{Status: string(model.TaskTodo)}, func(tasks []model.Task) error {
+		batches++
+		exported += len(tasks)
+		return nil
+	})
+	if err != nil || batches != 1 || exported != 4 {
+		t.Fatalf("ExportTasks batches=%d exported=%d err=%v", batches, exported, err)
+	}
+
+	if _, err := projects.Archive(ctx, toStringID(project.ID), 1, nil); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if _, err := projects.ImportTasks(ctx, project.ID, inputs, false); !errors.Is(err, service.ErrProjectArchived) {
+		t.Fatalf("import into archived project err = %v", err)
+	}
+}
+
+func TestProjectBundleIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	users := repository.NewAuthRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	tasks := service.NewTaskService(repository.NewTaskRepository(db))
+	comments := service.NewCommentService(repository.NewCommentRepository(db))
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	attachments := service.NewAttachmentService(repository.NewAttachmentRepository(db), store)
+	bundles := service.NewBundleService(repository.NewBundleRepository(db), projectRepo, store)
+
+	owner := &model.User{Email: "owner@example.com", Name: "Owner", PasswordHash: "x"}
+	dev := &model.User{Email: "dev@example.com", Name: "Dev", PasswordHash: "x"}
+	for _, u := range []*model.User{owner, dev} {
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatalf("Create user: %v", err)
+		}
+	}
+	project := &model.Project{Title: "Launch", Status: model.ProjectActive, OwnerID: &owner.ID}
+	if err := projectRepo.Create(ctx, project); err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	task, err := tasks.Create(ctx, service.TaskCreateInput{ProjectID: project.ID, Title: "Deploy", Status: model.TaskTodo, AssigneeID: &dev.ID, Labels: []string{"ops"}})
+	if err != nil {
+		t.Fatalf("Create task: %v", err)
+	}
+	root, err := comments.Create(ctx, service.CommentCreateInput{TaskID: task.ID, Author: "Owner", Text: "Ready?"})
+	if err != nil {
+		t.Fatalf("Create comment: %v", err)
+	}
+	if _, err := comments.Create(ctx, service.CommentCreateInput{TaskID: task.ID, ParentID: &root.ID, Author: "Dev", Text: "Yes"}); err != nil {
+		t.Fatalf("Create reply: %v", err)
+	}
+	if _, err := comments.AddReaction(ctx, toStringID(root.ID), dev.ID, "👍"); err != nil {
+		t.Fatalf("AddReaction: %v", err)
+	}
+	if _, err := attachments.Upload(ctx, service.AttachmentUploadInput{TaskID: toStringID(task.ID), UploadedBy: dev.ID, FileName: "notes.txt", Content: strings.NewReader("release notes")}); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	bundle, err := bundles.Export(ctx, toStringID(project.ID))
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := bundles.WriteZip(ctx, &buf, bundle); err != nil {
+		t.Fatalf("WriteZip: %v", err)
+	}
+	read, files, err := service.ReadBundle(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("ReadBundle: %v, %d files", err, len(files))
+	}
+	report, err := bundles.Import(ctx, service.BundleImportInput{Bundle: read, Files: files, Title: "Launch (restored)"})
+	if err != nil || !report.Applied || len(report.Conflicts) != 0 {
+		t.Fatalf("Import = %+v, %v", report, err)
+	}
+	if report.Created != (model.BundleCounts{Labels: 1, Tasks: 1, Comments: 2, Reactions: 1, Attachments: 1}) {
+		t.Fatalf("created = %+v", report.Created)
+	}
+
+	tree, labels, err := projectRepo.GetTree(ctx, toStringID(report.Project.ID))
+	if err != nil || tree.Title != "Launch (restored)" || *tree.OwnerID != owner.ID || len(tree.Tasks) != 1 || len(labels) != 1 {
+		t.Fatalf("imported project = %+v, labels = %+v, %v", tree, labels, err)
+	}
+	imported := tree.Tasks[0]
+	if imported.ID != report.TaskIDs[task.ID] || imported.ID == task.ID || *imported.AssigneeID != dev.ID || len(imported.Labels) != 1 || imported.Labels[0].ProjectID != tree.ID {
+		t.Fatalf("imported task = %+v", imported)
+	}
+	var replies int64
+	db.Model(&model.Comment{}).Where("parent_id = ?", report.CommentIDs[root.ID]).Count(&replies)
+	var reactions int64
+	db.Model(&model.CommentReaction{}).Where("comment_id = ? AND user_id = ?", report.CommentIDs[root.ID], dev.ID).Count(&reactions)
+	stored, _, err := attachments.ListForTask(ctx, toStringID(imported.ID), httpx.ListParams{Page: 1, PageSize: 20})
+	if replies != 1 || reactions != 1 || err != nil || len(stored) != 1 || stored[0].UploadedBy != dev.ID {
+		t.Fatalf("replies = %d, reactions = %d, attachments = %+v, %v", replies, reactions, stored, err)
+	}
+}
+
+func TestExternalImportIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	dev := &model.User{Email: "dev@example.com", Name: "Dev", PasswordHash: "x"}
+	if err := repository.NewAuthRepository(db).Create(ctx, dev); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	projectRepo := repository.NewProjectRepository(db)
+	bundles := service.NewBundleService(repository.NewBundleRepository(db), projectRepo, nil)
+
+	export := "Summary,Issue key,Status,Assignee,Labels,Labels,Comment\n" +
+		"Login,WEB-1,In Progress,DEV@example.com,frontend,auth,02/Oct/26 10:00 AM;dev;Looks good\n" +
+		"Signup,WEB-2,Done,Pat,frontend,,\n"
+	bundle, mapping, err := service.ReadJira(strings.NewReader(export), "Website", service.ExternalImportOptions{})
+	if err != nil {
+		t.Fatalf("ReadJira: %v", err)
+	}
+	report, err := bundles.Import(ctx, service.BundleImportInput{Bundle: bundle, OwnerID: &dev.ID})
+	if err != nil || !report.Applied || report.Created.Tasks != 2 || report.Created.Comments != 1 || report.Created.Labels != 2 {
+		t.Fatalf("Import = %+v, %v", report, err)
+	}
+	tree, _, err := projectRepo.GetTree(ctx, toStringID(report.Project.ID))
+	if err != nil || tree.Title != "Website" || len(tree.Tasks) != 2 {
+		t.Fatalf("project = %+v, %v", tree, err)
+	}
+	login := tree.Tasks[0]
+	if login.Status != model.TaskInProgress || login.AssigneeID == nil || *login.AssigneeID != dev.ID || len(login.Labels) != 2 || tree.Tasks[1].AssigneeID != nil {
+		t.Fatalf("tasks = %+v", tree.Tasks)
+	}
+	if len(mapping.Members) != 3 || mapping.Members[1].Email != "" {
+		t.Fatalf("members = %+v", mapping.Members)
+	}
+}
+
+func TestCalendarFeedIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projects := service.NewProjectService(repository.NewProjectRepository(db))
+	tasks := service.NewTaskService(repository.NewTaskRepository(db))
+	calendar := service.NewCalendarService(repository.NewCalendarRepository(db))
+	users := repository.NewAuthRepository(db)
+
+	user := &model.User{Email: "calendar@example.com", Name: "Calendar", PasswordHash: "x"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	project, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Feed", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	other, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Other", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	due := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	later := due.AddDate(0, 0, 7)
+	create := func(projectID uint, title string, assignee *uint, due *time.Time) model.Task {
+		task, err := tasks.Create(ctx, service.TaskCreateInput{ProjectID: projectID, Title: title, Status: model.TaskTodo, AssigneeID: assignee, DueDate: due, Labels: []string{"feed"}})
+		if err != nil {
+			t.Fatalf("Create task: %v", err)
+		}
+		return task
+	}
+	assigned := create(project.ID, "Assigned", &user.ID, &later)
+	watched := create(other.ID, "Watched", nil, &due)
+	create(project.ID, "No due date", &user.ID, nil)
+	create(project.ID, "Someone else's", nil, &due)
+	if err := tasks.Watch(ctx, toStringID(watched.ID), user.ID); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if err := tasks.Watch(ctx, toStringID(watched.ID), user.ID); err != nil {
+		t.Fatalf("Watch again: %v", err)
+	}
+
+	token, err := calendar.Rotate(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	feed, err := calendar.Feed(ctx, token, nil)
+	if err != nil || len(feed) != 2 || feed[0].ID != watched.ID || feed[1].ID != assigned.ID || len(feed[1].Labels) != 1 {
+		t.Fatalf("Feed = %+v, %v", feed, err)
+	}
+	if feed, _ := calendar.Feed(ctx, token, &project.ID); len(feed) != 1 || feed[0].ID != assigned.ID {
+		t.Fatalf("Feed of one project = %+v", feed)
+	}
+
+	if err := tasks.Unwatch(ctx, toStringID(watched.ID), user.ID); err != nil {
+		t.Fatalf("Unwatch: %v", err)
+	}
+	if _, err := projects.Archive(ctx, toStringID(project.ID), user.ID, nil); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if feed, _ := calendar.Feed(ctx, token, nil); len(feed) != 0 {
+		t.Fatalf("Feed after unwatch and archive = %+v", feed)
+	}
+	if err := tasks.Watch(ctx, "999999", user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Watch of a missing task = %v", err)
+	}
+
+	rotated, err := calendar.Rotate(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := calendar.Feed(ctx, token, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Feed with the old token = %v", err)
+	}
+	if err := calendar.Revoke(ctx, user.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := calendar.Feed(ctx, rotated, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Feed with a revoked token = %v", err)
+	}
+}
+
+func TestRecurringTaskIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projects := service.NewProjectService(repository.NewProjectRepository(db))
+	tasks := service.NewTaskService(repository.NewTaskRepository(db))
+	users := repository.NewAuthRepository(db)
+
+	user := &model.User{Email: "recurring@example.com", Name: "Recurring", PasswordHash: "x"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	project, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Chores", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	due := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	first, err := tasks.Create(ctx, service.TaskCreateInput{ProjectID: project.ID, Title: "Water plants", Status: model.TaskTodo, AssigneeID: &user.ID, DueDate: &due, Labels: []string{"home"}, Recurrence: "FREQ=WEEKLY;COUNT=2"})
+	if err != nil || first.SeriesID == nil || *first.SeriesID != first.ID || first.Occurrence != 1 {
+		t.Fatalf("Create = %+v, %v", first, err)
+	}
+	if err := tasks.Watch(ctx, toStringID(first.ID), user.ID); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	done := model.TaskDone
+	completed, err := tasks.Update(ctx, toStringID(first.ID), service.TaskUpdateInput{Status: &done})
+	if err != nil || completed.Recurrence != "" || completed.NextOccurrence == nil {
+		t.Fatalf("Update = %+v, %v", completed, err)
+	}
+	next, err := tasks.Get(ctx, toStringID(completed.NextOccurrence.ID), []string{"labels"})
+	if err != nil || next.Status != model.TaskTodo || !next.DueDate.Equal(due.AddDate(0, 0, 7)) || next.Occurrence != 2 || *next.SeriesID != first.ID ||
+		next.Recurrence != "FREQ=WEEKLY;COUNT=2" || len(next.Labels) != 1 || next.Labels[0].Name != "home" || *next.AssigneeID != user.ID {
+		t.Fatalf("next = %+v, %v", next, err)
+	}
+	var watchers int64
+	if err := db.Model(&model.TaskWatcher{}).Where("task_id = ? AND user_id = ?", next.ID, user.ID).Count(&watchers).Error; err != nil || watchers != 1 {
+		t.Fatalf("watchers of the next occurrence = %d, %v", watchers, err)
+	}
+
+	// Reopening and completing again does not repeat the occurrence.
+	todo := model.TaskTodo
+	if _, err := tasks.Update(ctx, toStringID(first.ID), service.TaskUpdateInput{Status: &todo}); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if again, err := tasks.Update(ctx, toStringID(first.ID), service.TaskUpdateInput{Status: &done}); err != nil || again.NextOccurrence != nil {
+		t.Fatalf("complete again = %+v, %v", again, err)
+	}
+	// The second occurrence is the last one.
+	if last, err := tasks.Update(ctx, toStringID(next.ID), service.TaskUpdateInput{Status: &done}); err != nil || last.NextOccurrence != nil || last.Recurrence != "" {
+		t.Fatalf("complete last = %+v, %v", last, err)
+	}
+
+	series, total, err := tasks.List(ctx, service.TaskListFilter{Params: httpx.ListParams{Page: 1, PageSize: 10}, Filter: fmt.Sprintf("series = %d", first.ID)})
+	if err != nil || total != 2 || len(series) != 2 {
+		t.Fatalf("List of the series = %+v total %d, %v", series, total, err)
+	}
+
+	// A bulk completion continues the series too.
+	daily, err := tasks.Create(ctx, service.TaskCreateInput{ProjectID: project.ID, Title: "Feed cat", Status: model.TaskTodo, DueDate: &due, Recurrence: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatalf("Create daily: %v", err)
+	}
+	if err := tasks.Watch(ctx, toStringID(daily.ID), user.ID); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	report, err := tasks.Bulk(ctx, service.TaskBulkInput{Selection: service.TaskSelection{IDs: []uint{daily.ID}}, Op: service.BulkSetStatus, Status: model.TaskDone})
+	if err != nil || !report.Applied || report.Results[0].NextOccurrenceID == nil {
+		t.Fatalf("Bulk = %+v, %v", report, err)
+	}
+	tomorrow, err := tasks.Get(ctx, toStringID(*report.Results[0].NextOccurrenceID), nil)
+	if err != nil || !tomorrow.DueDate.Equal(due.AddDate(0, 0, 1)) || tomorrow.Recurrence != "FREQ=DAILY" || *tomorrow.SeriesID != daily.ID {
+		t.Fatalf("next after bulk = %+v, %v", tomorrow, err)
+	}
+	if err := db.Model(&model.TaskWatcher{}).Where("task_id = ? AND user_id = ?", tomorrow.ID, user.ID).Count(&watchers).Error; err != nil || watchers != 1 {
+		t.Fatalf("watchers after bulk = %d, %v", watchers, err)
+	}
+}
+
+type recordingMailer struct{ sent []mail.Message }
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestReminderIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projects := service.NewProjectService(repository.NewProjectRepository(db))
+	tasks := repository.NewTaskRepository(db)
+	users := repository.NewAuthRepository(db)
+	reminderRepo := repository.NewReminderRepository(db)
+	mailer := &recordingMailer{}
+	reminders := service.NewReminderService(reminderRepo, mailer)
+	notifications := service.NewNotificationService(repository.NewNotificationRepository(db))
+
+	ann := &model.User{Email: "ann@example.com", Name: "Ann", PasswordHash: "x"}
+	bob := &model.User{Email: "bob@example.com", Name: "Bob", PasswordHash: "x"}
+	for _, u := range []*model.User{ann, bob} {
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatalf("Create user: %v", err)
+		}
+	}
+	if _, err := reminders.SetPreferences(ctx, bob.ID, service.ReminderPreferenceInput{Offsets: []int{-60}, Email: false}); err != nil {
+		t.Fatalf("SetPreferences: %v", err)
+	}
+	project, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Reminders", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	soon, late, done := now.Add(23*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour)
+	for _, task := range []*model.Task{
+		{ProjectID: project.ID, Title: "Soon", Status: model.TaskTodo, AssigneeID: &ann.ID, DueDate: &soon},
+		{ProjectID: project.ID, Title: "Late", Status: model.TaskInProgress, AssigneeID: &bob.ID, DueDate: &late},
+		{ProjectID: project.ID, Title: "Done", Status: model.TaskDone, AssigneeID: &ann.ID, DueDate: &done},
+		{ProjectID: project.ID, Title: "Nobody's", Status: model.TaskTodo, DueDate: &late},
+	} {
+		task.CreatedAt = now.Add(-72 * time.Hour)
+		if err := tasks.Create(ctx, task); err != nil {
+			t.Fatalf("Create task: %v", err)
+		}
+	}
+
+	if n, err := reminders.Run(ctx, now); err != nil || n != 2 {
+		t.Fatalf("Run = %d, %v", n, err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].To != ann.Email {
+		t.Fatalf("mails = %+v", mailer.sent)
+	}
+	if n, err := reminders.Run(ctx, now); err != nil || n != 0 {
+		t.Fatalf("second Run = %d, %v", n, err)
+	}
+
+	page := httpx.ListParams{Page: 1, PageSize: 20}
+	items, total, unread, err := notifications.List(ctx, service.NotificationListFilter{UserID: bob.ID, Params: page})
+	if err != nil || total != 1 || unread != 1 || len(items) != 1 || items[0].Title != `"Late" is overdue by 1 hour` {
+		t.Fatalf("List = %+v total %d unread %d, %v", items, total, unread, err)
+	}
+	if _, err := notifications.Read(ctx, toStringID(items[0].ID), ann.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Read by another user = %v", err)
+	}
+	if n, err := notifications.Read(ctx, toStringID(items[0].ID), bob.ID); err != nil || n.ReadAt == nil {
+		t.Fatalf("Read = %+v, %v", n, err)
+	}
+	if unreadItems, _, unread, _ := notifications.List(ctx, service.NotificationListFilter{UserID: bob.ID, Unread: true, Params: page}); unread != 0 || len(unreadItems) != 0 {
+		t.Fatalf("unread after Read = %d, %+v", unread, unreadItems)
+	}
+
+	// A second instance cannot run while the lock is held.
+	locked, err := reminderRepo.TryLock(ctx, 42, func() error {
+		inner, err := reminderRepo.TryLock(ctx, 42, func() error { return nil })
+		if err != nil || inner {
+			t.Fatalf("inner TryLock = %v, %v", inner, err)
+		}
+		return nil
+	})
+	if err != nil || !locked {
+		t.Fatalf("TryLock = %v, %v", locked, err)
+	}
+	if again, err := reminderRepo.TryLock(ctx, 42, func() error { return nil }); err != nil || !again {
+		t.Fatalf("TryLock after unlock = %v, %v", again, err)
+	}
+}
+
+func TestTimeTrackingIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projects := service.NewProjectService(repository.NewProjectRepository(db))
+	tasks := repository.NewTaskRepository(db)
+	users := repository.NewAuthRepository(db)
+	times := service.NewTimeService(repository.NewTimeRepository(db))
+
+	ann := &model.User{Email: "ann@example.com", Name: "Ann", PasswordHash: "x"}
+	bob := &model.User{Email: "bob@example.com", Name: "Bob", PasswordHash: "x"}
+	for _, u := range []*model.User{ann, bob} {
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatalf("Create user: %v", err)
+		}
+	}
+	ops, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Ops", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	web, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Web", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	deploy := &model.Task{ProjectID: ops.ID, Title: "Deploy", Status: model.TaskTodo}
+	backup := &model.Task{ProjectID: ops.ID, Title: "Backup", Status: model.TaskTodo}
+	page := &model.Task{ProjectID: web.ID, Title: "Landing page", Status: model.TaskTodo}
+	for _, task := range []*model.Task{deploy, backup, page} {
+		if err := tasks.Create(ctx, task); err != nil {
+			t.Fatalf("Create task: %v", err)
+		}
+	}
+
+	oct1 := time.Date(2026, 10, 1, 23, 30, 0, 0, time.UTC)
+	for _, e := range []struct {
+		task    *model.Task
+		user    uint
+		start   time.Time
+		minutes int
+	}{
+		{deploy, ann.ID, oct1, 60},
+		{backup, ann.ID, oct1.Add(-2 * time.Hour), 30},
+		{page, ann.ID, oct1.Add(-3 * time.Hour), 15},
+		{deploy, bob.ID, oct1.Add(24 * time.Hour), 45},
+	} {
+		if _, err := times.Create(ctx, toStringID(e.task.ID), service.TimeEntryInput{UserID: e.user, StartedAt: e.start, Minutes: &e.minutes}); err != nil {
+			t.Fatalf("Create entry: %v", err)
+		}
+	}
+
+	if _, err := times.Start(ctx, toStringID(deploy.ID), ann.ID, "hotfix"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	running, err := times.Start(ctx, toStringID(page.ID), ann.ID, "")
+	if err != nil {
+		t.Fatalf("second Start: %v", err)
+	}
+	// The index allows one running timer per user.
+	if err := db.Create(&model.TimeEntry{TaskID: page.ID, UserID: ann.ID, StartedAt: time.Now()}).Error; err == nil {
+		t.Fatal("a second running timer was stored")
+	}
+	if got, err := times.Running(ctx, ann.ID); err != nil || got.ID != running.ID {
+		t.Fatalf("Running = %+v, %v", got, err)
+	}
+	if stopped, err := times.Stop(ctx, ann.ID); err != nil || stopped.EndedAt == nil {
+		t.Fatalf("Stop = %+v, %v", stopped, err)
+	}
+	if _, err := times.Stop(ctx, ann.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("second Stop = %v", err)
+	}
+
+	items, total, seconds, err := times.List(ctx, toStringID(deploy.ID), httpx.ListParams{Page: 1, PageSize: 10})
+	if err != nil || total != 3 || len(items) != 3 || seconds != 105*60 {
+		t.Fatalf("List = %d items, total %d, %d seconds, %v", len(items), total, seconds, err)
+	}
+	sum, err := times.ProjectTime(ctx, toStringID(ops.ID))
+	if err != nil || sum.Seconds != 135*60 || len(sum.Tasks) != 2 || sum.Tasks[0].TaskID != deploy.ID {
+		t.Fatalf("ProjectTime = %+v, %v", sum, err)
+	}
+
+	sheet, err := times.Timesheet(ctx, service.TimesheetFilter{From: oct1, To: oct1, UserID: &ann.ID})
+	if err != nil || sheet.Seconds != 105*60 || len(sheet.Days) != 1 || len(sheet.Days[0].Projects) != 2 {
+		t.Fatalf("Timesheet = %+v, %v", sheet, err)
+	}
+	if p := sheet.Days[0].Projects[0]; p.ProjectID != ops.ID || p.Seconds != 90*60 {
+		t.Fatalf("Ops on Oct 1 = %+v", p)
+	}
+	everyone, err := times.Timesheet(ctx, service.TimesheetFilter{From: oct1, To: oct1.AddDate(0, 0, 1)})
+	if err != nil || len(everyone.Days) != 2 || everyone.Days[1].Seconds != 45*60 {
+		t.Fatalf("everyone's Timesheet = %+v, %v", everyone, err)
+	}
+
+	if _, err := projects.Archive(ctx, toStringID(ops.ID), ann.ID, nil); err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	minutes := 5
+	if _, err := times.Create(ctx, toStringID(deploy.ID), service.TimeEntryInput{UserID: ann.ID, StartedAt: oct1, Minutes: &minutes}); !errors.Is(err, service.ErrProjectArchived) {
+		t.Fatalf("Create in archived project = %v", err)
+	}
+}
+
+func TestSprintIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	projects := service.NewProjectService(repository.NewProjectRepository(db))
+	tasks := service.NewTaskService(repository.NewTaskRepository(db))
+	sprints := service.NewSprintService(repository.NewSprintRepository(db))
+	milestones := service.NewMilestoneService(repository.NewMilestoneRepository(db))
+
+	app, err := projects.Create(ctx, service.ProjectCreateInput{Title: "App", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	other, err := projects.Create(ctx, service.ProjectCreateInput{Title: "Other", Status: model.ProjectActive})
+	if err != nil {
+		t.Fatalf("Create project: %v", err)
+	}
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	one, err := sprints.Create(ctx, toStringID(app.ID), service.SprintInput{Name: "Sprint 1", StartDate: day(5), EndDate: day(16)})
+	if err != nil {
+		t.Fatalf("Create sprint: %v", err)
+	}
+	two, _ := sprints.Create(ctx, toStringID(app.ID), service.SprintInput{Name: "Sprint 2", StartDate: day(19), EndDate: day(30)})
+	foreign, _ := sprints.Create(ctx, toStringID(other.ID), service.SprintInput{Name: "Theirs", StartDate: day(5), EndDate: day(16)})
+	beta, err := milestones.Create(ctx, toStringID(app.ID), service.MilestoneInput{Name: "Beta", TargetDate: day(31)})
+	if err != nil {
+		t.Fatalf("Create milestone: %v", err)
+	}
+
+	if _, err := sprints.Start(ctx, toStringID(one.ID)); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	// The index allows one active sprint per project.
+	if err := db.Model(&model.Sprint{}).Where("id = ?", two.ID).Update("state", model.SprintActive).Error; err == nil {
+		t.Fatal("a second active sprint was stored")
+	}
+
+	create := func(title string, status model.TaskStatus, sprintID *uint) model.Task {
+		task, err := tasks.Create(ctx, service.TaskCreateInput{ProjectID: app.ID, Title: title, Status: status, SprintID: sprintID, MilestoneID: &beta.ID})
+		if err != nil {
+			t.Fatalf("Create %s: %v", title, err)
+		}
+		return task
+	}
+	create("Done", model.TaskDone, &one.ID)
+	doing := create("Doing", model.TaskInProgress, &one.ID)
+	idea := create("Idea", model.TaskTodo, nil)
+	dropped := create("Dropped", model.TaskTodo, &one.ID)
+	if err := tasks.Delete(ctx, toStringID(dropped.ID), nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := tasks.Create(ctx, service.TaskCreateInput{ProjectID: app.ID, Title: "Stray", Status: model.TaskTodo, SprintID: &foreign.ID}); !errors.Is(err, service.ErrInvalidSprint) {
+		t.Fatalf("other project's sprint = %v", err)
+	}
+
+	list := func(sprintID, milestoneID string) []string {
+		items, _, err := tasks.List(ctx, service.TaskListFilter{Params: httpx.ListParams{Page: 1, PageSize: 10, Sort: []httpx.SortField{{Field: "title"}}}, SprintID: sprintID, MilestoneID: milestoneID})
+		if err != nil {
+			t.Fatalf("List(%q, %q): %v", sprintID, milestoneID, err)
+		}
+		titles := make([]string, len(items))
+		for i, item := range items {
+			titles[i] = item.Title
+		}
+		return titles
+	}
+	if got := list(toStringID(one.ID), ""); strings.Join(got, ",") != "Doing,Done" {
+		t.Fatalf("sprint tasks = %v", got)
+	}
+	if got := list(service.TaskUnplanned, toStringID(beta.ID)); strings.Join(got, ",") != "Idea" {
+		t.Fatalf("backlog tasks = %v", got)
+	}
+
+	closed, err := sprints.Close(ctx, toStringID(one.ID), service.SprintCloseInput{})
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	// The trashed task is snapshotted and moved like the others.
+	stored, err := sprints.Get(ctx, toStringID(one.ID))
+	if err != nil || stored.State != model.SprintClosed || stored.Scope == nil || stored.Scope.Total != 3 || stored.Scope.Done != 1 || stored.Scope.Moved != 2 || *stored.Scope.MovedTo != two.ID {
+		t.Fatalf("closed sprint = %+v (%+v), %v", stored, closed.Scope, err)
+	}
+	var droppedSprint *uint
+	if err := db.Unscoped().Model(&model.Task{}).Where("id = ?", dropped.ID).Pluck("sprint_id", &droppedSprint).Error; err != nil || droppedSprint == nil || *droppedSprint != two.ID {
+		t.Fatalf("trashed task sprint = %v, %v", droppedSprint, err)
+	}
+	// A close that loses the race to another one changes nothing.
+	again := one
+	again.State = model.SprintClosed
+	if err := repository.NewSprintRepository(db).Close(ctx, &again, nil, func(tasks []model.Task) *model.SprintScope { return &model.SprintScope{} }); !errors.Is(err, service.ErrSprintClose) {
+		t.Fatalf("second close = %v", err)
+	}
+	if got := list(toStringID(two.ID), ""); strings.Join(got, ",") != "Doing" {
+		t.Fatalf("tasks after second close = %v", got)
+	}
+	if got := list(toStringID(two.ID), ""); strings.Join(got, ",") != "Doing" {
+		t.Fatalf("next sprint tasks = %v", got)
+	}
+	moved, _ := tasks.Get(ctx, toStringID(doing.ID), nil)
+	if moved.Version != doing.Version+1 {
+		t.Fatalf("moved task version = %d, want %d", moved.Version, doing.Version+1)
+	}
+	if _, err := tasks.Update(ctx, toStringID(idea.ID), service.TaskUpdateInput{SprintID: ptrTo(&one.ID)}); !errors.Is(err, service.ErrInvalidSprint) {
+		t.Fatalf("assign to closed sprint = %v", err)
+	}
+
+	// Deleting a milestone leaves its tasks without one.
+	if err := milestones.Delete(ctx, toStringID(beta.ID)); err != nil {
+		t.Fatalf("Delete milestone: %v", err)
+	}
+	if got := list("", service.TaskUnplanned); len(got) != 3 {
+		t.Fatalf("tasks without milestone = %v", got)
+	}
+}
+
+func ptrTo[T any](v T) *T { return &v }
+
 func toStringID(id uint) string {
 	return fmt.Sprintf("%d", id)
 }
+
+func TestTaskReferencesIntegration(t *testing.T) {
+	db := openTestDB(t)
+	resetTestDB(t, db)
+	ctx := context.Background()
+	tasks := service.NewTaskService(repository.NewTaskRepository(db))
+	comments := service.NewCommentService(repository.NewCommentRepository(db))
+
+	home := &model.Project{Title: "Home", Status: model.ProjectActive}
+	away := &model.Project{Title: "Away", Status: model.ProjectActive}
+	for _, p := range []*model.Project{home, away} {
+		if err := db.Create(p).Error; err != nil {
+			t.Fatalf("seed project: %v", err)
+		}
+	}
+	live := &model.Task{ProjectID: home.ID, Title: "Live", Status: model.TaskTodo}
+	trashed := &model.Task{ProjectID: home.ID, Title: "Trashed", Status: model.TaskTodo}
+	other := &model.Task{ProjectID: away.ID, Title: "Elsewhere", Status: model.TaskTodo}
+	for _, task := range []*model.Task{live, trashed, other} {
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("seed task: %v", err)
+		}
+	}
+	if err := db.Delete(trashed).Error; err != nil {
+		t.Fatalf("trash task: %v", err)
+	}
+
+	// Only live tasks of the same project are linked.
+	text := fmt.Sprintf("#%d #%d #%d #999999", live.ID, trashed.ID, other.ID)
+	task, err := tasks.Create(ctx, service.TaskCreateInput{ProjectID: home.ID, Title: "Refs", Description: text, Status: model.TaskTodo})
+	if err != nil {
+		t.Fatalf("Create task: %v", err)
+	}
+	comment, err := comments.Create(ctx, service.CommentCreateInput{TaskID: task.ID, Author: "Ann", Text: text})
+	if err != nil {
+		t.Fatalf("Create comment: %v", err)
+	}
+	for _, html := range []string{task.DescriptionHTML, comment.HTML} {
+		if strings.Count(html, `class="task-ref"`) != 1 || !strings.Contains(html, fmt.Sprintf(`href="/tasks/%d"`, live.ID)) {
+			t.Fatalf("html = %q", html)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	appdb "project-management/internal/db"
 	"project-management/internal/model"
 
 	"gorm.io/driver/postgres"
@@ -38,9 +39,12 @@ func openTestDB(t *testing.T) *gorm.DB {
 		t.Skipf("integration database ping failed: %v", err)
 	}
 
-	if err := db.AutoMigrate(&model.User{}, &model.Project{}, &model.Task{}, &model.Comment{}); err != nil {
+	if err := db.AutoMigrate(&model.User{}, &model.Project{}, &model.Task{}, &model.Label{}, &model.Comment{}, &model.CommentReaction{}, &model.CommentRevision{}, &model.Blob{}, &model.Attachment{}, &model.SavedView{}, &model.IdempotencyKey{}, &model.ProjectTemplate{}, &model.TaskWatcher{}, &model.CalendarToken{}, &model.ReminderPreference{}, &model.SentReminder{}, &model.Notification{}, &model.TimeEntry{}, &model.Sprint{}, &model.Milestone{}); err != nil {
 		t.Fatalf("automigrate: %v", err)
 	}
+	if err := appdb.MigrateSearch(db); err != nil {
+		t.Fatalf("migrate search: %v", err)
+	}
 
 	t.Cleanup(func() { _ = sqlDB.Close() })
 	return db
@@ -48,7 +52,7 @@ func openTestDB(t *testing.T) *gorm.DB {
 
 func resetTestDB(t *testing.T, db *gorm.DB) {
 	t.Helper()
-	if err := db.Exec("TRUNCATE TABLE comments, tasks, projects, users RESTART IDENTITY CASCADE").Error; err != nil {
+	if err := db.Exec("TRUNCATE TABLE milestones, sprints, time_entries, notifications, sent_reminders, reminder_preferences, calendar_tokens, task_watchers, project_templates, idempotency_keys, saved_views, task_labels, labels, attachments, blobs, comment_revisions, comment_reactions, comments, tasks, projects, users RESTART IDENTITY CASCADE").Error; err != nil {
 		t.Fatalf("truncate tables: %v", err)
 	}
 }